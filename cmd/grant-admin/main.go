@@ -0,0 +1,47 @@
+// grant-admin 为已注册的用户授予管理员权限
+//
+//	go run ./cmd/grant-admin alice bob
+//
+// 只处理执行时已存在的账户；不存在的用户名会列出并以非零状态退出，需在用户注册后重新执行
+package main
+
+import (
+	"codefolio/internal/config"
+	"codefolio/internal/util"
+	"flag"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	logger := util.InitLogger()
+	defer logger.Sync()
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "用法: %s <用户名>...\n", os.Args[0])
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.LoadConfig()
+	db, err := gorm.Open(postgres.Open(cfg.GetDSN()), &gorm.Config{})
+	if err != nil {
+		logger.Fatal("数据库连接失败", zap.Error(err))
+	}
+
+	missing, err := util.GrantAdmins(db, flag.Args())
+	if err != nil {
+		logger.Fatal("授予管理员权限失败", zap.Error(err))
+	}
+	if len(missing) > 0 {
+		logger.Error("用户不存在，未授予管理员权限", zap.Strings("usernames", missing))
+		os.Exit(1)
+	}
+}
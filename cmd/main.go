@@ -41,23 +41,49 @@ func main() {
 	}
 
 	// 配置文件上传参数
-	util.SetUploadConfig(
+	if err := util.SetUploadConfig(
 		cfg.Upload.StoragePath,
 		cfg.Upload.MaxFileSize,
 		cfg.Upload.AllowedTypes,
-	)
+	); err != nil {
+		logger.Fatal("允许上传的文件类型配置无效", zap.Error(err))
+	}
+	util.SetDocumentConverter(util.NewLibreOfficeConverter(cfg.Upload.LibreOfficePath))
+	util.SetConversionLimits(util.ConversionLimits{
+		MaxPages:    cfg.Upload.MaxPages,
+		MaxPageSize: float64(cfg.Upload.MaxPageSize),
+		Timeout:     cfg.Upload.ConvertTimeout,
+		CPUTime:     cfg.Upload.ConvertCPULimit,
+		Memory:      cfg.Upload.ConvertMemoryLimit,
+	})
+	if err := util.SetRenderProfile(util.RenderProfile{
+		Formats:  cfg.Upload.ImageFormats,
+		Quality:  cfg.Upload.ImageQuality,
+		DPI:      cfg.Upload.ImageDPI,
+		MaxWidth: cfg.Upload.ImageMaxWidth,
+	}); err != nil {
+		logger.Fatal("简历图片格式配置无效", zap.Error(err))
+	}
+
+	// 探测可用的PDF渲染器，没有外部工具时使用纯Go渲染器
+	util.ProbePDFRenderers()
 
 	// 创建路由
 	r := gin.New()
 
+	// 只信任配置的反向代理转发的客户端IP，否则登录保护和限流可被伪造的X-Forwarded-For绕过
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Fatal("信任的代理配置无效", zap.Error(err))
+	}
+
 	// 使用中间件
 	r.Use(handler.LoggerMiddleware())
 	r.Use(handler.Recovery())
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Last-Event-ID", "X-Upload-ID", "X-Part-Checksum"},
+		ExposeHeaders:    []string{"Content-Length", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -65,9 +91,10 @@ func main() {
 	// 健康检查接口
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
-			"status":      "online",
-			"version":     cfg.Server.Version,
-			"environment": cfg.Server.Mode,
+			"status":       "online",
+			"version":      cfg.Server.Version,
+			"environment":  cfg.Server.Mode,
+			"pdf_renderer": util.PDFRenderers(),
 		})
 	})
 
@@ -96,53 +123,224 @@ func main() {
 		&domain.User{},
 		&domain.Resume{},
 		&domain.University{},
+		&domain.RateLimitBucket{},
+		&domain.UserIdentity{},
+		&domain.ResumeVersion{},
+		&domain.Favorite{},
+		&domain.Comment{},
+		&domain.CommentVote{},
+		&domain.Consultation{},
+		&domain.ConsultationMessage{},
+		&domain.Notification{},
+		&domain.NotificationPreference{},
+		&domain.Webhook{},
+		&domain.WebhookDelivery{},
+		&domain.Blob{},
+		&domain.UploadSession{},
+		&domain.UploadPart{},
 	)
 	if err != nil {
 		logger.Fatal("数据库迁移失败", zap.Error(err))
 	}
 
-	// 初始化存储目录
-	if err := os.MkdirAll(util.UploadDir, 0755); err != nil {
-		logger.Fatal("创建上传目录失败", zap.Error(err))
+	// 初始化文件存储
+	util.SetStorage(loadStorage(cfg), cfg.Storage.URLExpiry)
+	urlSecret := cfg.Storage.URLSecret
+	if urlSecret == "" {
+		urlSecret = cfg.JWT.Secret
 	}
+	util.SetFileURLSigning(urlSecret, cfg.Storage.BindViewer)
+
+	// 简历文件按内容寻址保存，由数据库记录引用计数
+	util.SetBlobIndex(repository.NewBlobRepository(db))
+
+	// 将旧数据中带上传目录前缀的文件路径统一为存储键
+	util.MigrateStorageKeys(db)
+
+	// 相似简历检测按感知哈希分段索引筛选候选
+	util.MigratePHashIndexes(db)
+
+	// 定期检查存储中无引用的文件和文件缺失的记录
+	util.StartStorageGC(db, cfg.Storage.GCInterval, util.StorageGCOptions{
+		Repair:      cfg.Storage.GCRepair,
+		GracePeriod: cfg.Storage.GCGracePeriod,
+	})
 
 	// 初始化种子数据
 	util.SeedUniversities(db)
 
 	// 创建仓库
 	userRepo := repository.NewUserRepository(db)
+	identityRepo := repository.NewUserIdentityRepository(db)
 	resumeRepo := repository.NewResumeRepository(db)
+	resumeVersionRepo := repository.NewResumeVersionRepository(db)
+	uploadSessionRepo := repository.NewUploadSessionRepository(db)
+	favoriteRepo := repository.NewFavoriteRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	consultationRepo := repository.NewConsultationRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
 	universityRepo := repository.NewUniversityRepository(db)
 
+	// 创建邮件发送器
+	mailer := util.NewMailer(
+		cfg.Email.SMTPHost,
+		cfg.Email.SMTPPort,
+		cfg.Email.Username,
+		cfg.Email.Password,
+		cfg.Email.From,
+	)
+
+	// 创建事件总线，站内通知、实时推送和Webhook订阅全部业务事件
+	eventBus := service.NewEventBus()
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, mailer, cfg.Notification.DigestInterval)
+	eventBus.Subscribe(notificationService.HandleEvent)
+	eventHub := service.NewEventHub(cfg.Stream.HistorySize, cfg.Stream.HistoryTTL)
+	eventBus.Subscribe(eventHub.HandleEvent)
+	webhookService := service.NewWebhookService(
+		webhookRepo,
+		cfg.Webhook.Timeout,
+		cfg.Webhook.MaxAttempts,
+		cfg.Webhook.RetryBackoff,
+		cfg.Webhook.MaxBackoff,
+		cfg.Webhook.PollInterval,
+	)
+	eventBus.Subscribe(webhookService.HandleEvent)
+
 	// 创建服务
-	userService := service.NewUserService(userRepo, cfg.JWT.Secret, cfg.JWT.ExpireHours)
+	userService := service.NewUserService(userRepo, mailer, cfg.JWT.Secret, cfg.JWT.ExpireHours, service.LoginPolicy{
+		MaxFailedAttempts:  cfg.Login.MaxFailedAttempts,
+		LockoutDuration:    cfg.Login.LockoutDuration,
+		MaxLockoutDuration: cfg.Login.MaxLockoutDuration,
+		IPMaxFailures:      cfg.Login.IPMaxFailures,
+		IPWindow:           cfg.Login.IPWindow,
+	})
 	resumeService := service.NewResumeService(
 		resumeRepo,
+		resumeVersionRepo,
 		userRepo,
+		uploadSessionRepo,
+		eventBus,
+		service.DuplicatePolicy{
+			ExactAction:     cfg.Upload.DuplicateAction,
+			SimilarDistance: cfg.Upload.SimilarDistance,
+		},
+		service.UploadPolicy{
+			ChunkSize:   cfg.Upload.ChunkSize,
+			MaxFileSize: cfg.Upload.ChunkedMaxSize,
+			SessionTTL:  cfg.Upload.SessionTTL,
+			MaxSessions: cfg.Upload.MaxSessions,
+		},
 		cfg.Upload.AnonymousView,
 		cfg.Upload.UserView,
+		cfg.Account.TrashRetention,
 	)
 	universityService := service.NewUniversityService(universityRepo)
+	favoriteService := service.NewFavoriteService(favoriteRepo, resumeRepo)
+	commentService := service.NewCommentService(commentRepo, resumeRepo, userRepo, eventBus)
+	consultationService := service.NewConsultationService(
+		consultationRepo,
+		resumeRepo,
+		loadPaymentProvider(cfg),
+		eventBus,
+		cfg.Payment.Currency,
+		cfg.Payment.MaxPrice,
+	)
+	accountService := service.NewAccountService(userRepo, resumeRepo, identityRepo, mailer, cfg.Account.DeletionGracePeriod, cfg.Account.TrashRetention, resumeService, favoriteService, commentService, consultationService, notificationService)
+	oauthService := service.NewOAuthService(identityRepo, userRepo, userService, cfg.JWT.Secret, loadOAuthProviders(cfg)...)
 
 	// 创建处理器
-	userHandler := handler.NewUserHandler(userService)
+	userHandler := handler.NewUserHandler(userService, resumeService)
 	faqHandler := handler.NewFAQHandler()
-	resumeHandler := handler.NewResumeHandler(resumeService)
+	resumeHandler := handler.NewResumeHandler(resumeService, favoriteService)
+	favoriteHandler := handler.NewFavoriteHandler(favoriteService)
+	commentHandler := handler.NewCommentHandler(commentService)
+	consultationHandler := handler.NewConsultationHandler(consultationService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	streamHandler := handler.NewStreamHandler(eventHub, cfg.Stream.HeartbeatInterval)
 	universityHandler := handler.NewUniversityHandler(universityService)
+	adminHandler := handler.NewAdminHandler(userService, accountService, commentService, resumeService)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	oauthHandler := handler.NewOAuthHandler(oauthService, cfg.OAuth.SuccessRedirect)
+	accountHandler := handler.NewAccountHandler(accountService)
+
+	// 创建限流器
+	var rateLimitStore util.RateLimitStore
+	switch cfg.RateLimit.Backend {
+	case "postgres":
+		rateLimitStore = repository.NewRateLimitRepository(db)
+	default:
+		rateLimitStore = util.NewMemoryRateLimitStore()
+	}
+	uploadLimiter := handler.RateLimitMiddleware(rateLimitStore, util.RateLimitPolicy{
+		Name:   "upload",
+		Limit:  cfg.RateLimit.Upload.Limit,
+		Period: cfg.RateLimit.Upload.Period,
+	})
+	registerLimiter := handler.RateLimitMiddleware(rateLimitStore, util.RateLimitPolicy{
+		Name:   "register",
+		Limit:  cfg.RateLimit.Register.Limit,
+		Period: cfg.RateLimit.Register.Period,
+	})
+	listingLimiter := handler.RateLimitMiddleware(rateLimitStore, util.RateLimitPolicy{
+		Name:   "listing",
+		Limit:  cfg.RateLimit.Listing.Limit,
+		Period: cfg.RateLimit.Listing.Period,
+	})
+	loginLimiter := handler.RateLimitMiddleware(rateLimitStore, util.RateLimitPolicy{
+		Name:   "login",
+		Limit:  cfg.RateLimit.Login.Limit,
+		Period: cfg.RateLimit.Login.Period,
+	})
+	diffLimiter := handler.RateLimitMiddleware(rateLimitStore, util.RateLimitPolicy{
+		Name:   "diff",
+		Limit:  cfg.RateLimit.Diff.Limit,
+		Period: cfg.RateLimit.Diff.Period,
+	})
 
 	// 创建API分组
 	api := r.Group("/api/v1")
 
-	// 静态文件服务 - 使用原生方式添加静态文件支持
-	r.StaticFS("/uploads", http.Dir(util.UploadDir))
-
-	// 原有的文件服务路由保留
-	api.GET("/files/*path", resumeHandler.ServeResumeFile)
+	// 文件服务，仅提供带有效签名的文件URL，绑定访问者的URL可通过access_token参数认证
+	api.GET("/files/*path", handler.QueryTokenAuth(), handler.OptionalAuth(cfg.JWT.Secret, userService), resumeHandler.ServeResumeFile)
+	api.HEAD("/files/*path", handler.QueryTokenAuth(), handler.OptionalAuth(cfg.JWT.Secret, userService), resumeHandler.ServeResumeFile)
 
 	// 用户相关路由
-	api.POST("/register", userHandler.Register)
-	api.POST("/login", userHandler.Login)
-	api.GET("/me", handler.AuthMiddleware(cfg.JWT.Secret), userHandler.GetMe)
+	api.POST("/register", registerLimiter, userHandler.Register)
+	api.POST("/login", loginLimiter, userHandler.Login)
+
+	// 当前用户相关路由
+	// 注销冷静期内的账户只能查看账户信息和撤销注销申请
+	pendingAuth := handler.PendingDeletionAuth(cfg.JWT.Secret, userService)
+	api.GET("/me", pendingAuth, userHandler.GetMe)
+	api.POST("/me/deletion/cancel", pendingAuth, accountHandler.CancelDeletion)
+
+	meGroup := api.Group("/me", handler.AuthMiddleware(cfg.JWT.Secret, userService))
+	{
+		meGroup.PUT("", userHandler.UpdateMe)
+		meGroup.DELETE("", accountHandler.DeleteMe)
+		meGroup.PUT("/avatar", userHandler.UpdateAvatar)
+		meGroup.GET("/export", accountHandler.ExportMe)
+		meGroup.GET("/favorites", favoriteHandler.GetMyFavorites)
+		meGroup.GET("/consultations", consultationHandler.GetMyConsultations)
+		meGroup.GET("/notifications", notificationHandler.GetNotifications)
+		meGroup.GET("/notifications/unread-count", notificationHandler.GetUnreadCount)
+		meGroup.POST("/notifications/:id/read", notificationHandler.MarkRead)
+		meGroup.POST("/notifications/read-all", notificationHandler.MarkAllRead)
+		meGroup.GET("/notifications/preferences", notificationHandler.GetPreferences)
+		meGroup.PUT("/notifications/preferences", notificationHandler.UpdatePreferences)
+		meGroup.GET("/identities", oauthHandler.GetIdentities)
+		meGroup.POST("/identities/:provider", oauthHandler.LinkIdentity)
+		meGroup.DELETE("/identities/:provider", oauthHandler.UnlinkIdentity)
+	}
+
+	// 用户公开主页
+	api.GET("/users/:username", userHandler.GetPublicProfile)
+
+	// 第三方登录路由
+	api.GET("/oauth/providers", oauthHandler.GetProviders)
+	api.GET("/oauth/:provider/login", oauthHandler.Login)
+	api.GET("/oauth/:provider/callback", oauthHandler.Callback)
 
 	// FAQ相关路由
 	api.GET("/faqs", faqHandler.GetFAQs)
@@ -152,28 +350,93 @@ func main() {
 
 	// 简历相关路由
 	resumeGroup := api.Group("/resumes")
+
+	// 公开路由：可匿名访问，携带有效令牌时识别当前用户
+	publicResumes := resumeGroup.Group("", handler.OptionalAuth(cfg.JWT.Secret, userService))
 	{
-		// 公开路由
-		resumeGroup.GET("", resumeHandler.GetResumes)
-		resumeGroup.GET("/:id", resumeHandler.GetResume)
-		resumeGroup.GET("/:id/download", resumeHandler.DownloadResume)
-		resumeGroup.POST("/upload-pdf", resumeHandler.UploadPDF)
-		resumeGroup.POST("/create", resumeHandler.CreateResume)
-
-		// 需要认证的路由
-		auth := resumeGroup.Use(handler.AuthMiddleware(cfg.JWT.Secret))
-		{
-			// 新的两步上传流程
-			// auth.POST("/upload-pdf", resumeHandler.UploadPDF)
-			// auth.POST("/create", resumeHandler.CreateResume)
-
-			// 兼容旧接口
-			auth.POST("", resumeHandler.UploadResume)
-			auth.PUT("/:id", resumeHandler.UpdateResume)
-			auth.PUT("/:id/file", resumeHandler.UpdateResumeFile)
-			auth.DELETE("/:id", resumeHandler.DeleteResume)
-			auth.GET("/user/list", resumeHandler.GetUserResumes)
-		}
+		publicResumes.GET("", listingLimiter, resumeHandler.GetResumes)
+		publicResumes.GET("/:id", resumeHandler.GetResume)
+		publicResumes.GET("/:id/download", resumeHandler.DownloadResume)
+		publicResumes.GET("/:id/versions", resumeHandler.GetResumeVersions)
+		publicResumes.GET("/:id/versions/:version", resumeHandler.GetResumeVersion)
+		publicResumes.GET("/:id/comments", commentHandler.GetComments)
+	}
+
+	// 需要认证的路由
+	authResumes := resumeGroup.Group("", handler.AuthMiddleware(cfg.JWT.Secret, userService))
+	{
+		// 生成比较结果开销较大，需登录并单独限流
+		authResumes.GET("/:id/diff", diffLimiter, resumeHandler.DiffResumeVersions)
+
+		// 两步上传流程
+		authResumes.POST("/upload-pdf", uploadLimiter, resumeHandler.UploadPDF)
+		authResumes.POST("/create", resumeHandler.CreateResume)
+
+		// 分片上传，合并后与上传简历文件接口一样返回文件标识
+		authResumes.POST("/uploads", resumeHandler.InitUpload)
+		authResumes.GET("/uploads/:upload_id", resumeHandler.GetUpload)
+		authResumes.PUT("/uploads/:upload_id/parts/:part", resumeHandler.UploadPart)
+		authResumes.POST("/uploads/:upload_id/complete", uploadLimiter, resumeHandler.CompleteUpload)
+		authResumes.DELETE("/uploads/:upload_id", resumeHandler.AbortUpload)
+
+		// 兼容旧接口
+		authResumes.POST("", uploadLimiter, resumeHandler.UploadResume)
+		authResumes.PUT("/:id", resumeHandler.UpdateResume)
+		authResumes.PUT("/:id/file", uploadLimiter, resumeHandler.UpdateResumeFile)
+		authResumes.DELETE("/:id", resumeHandler.DeleteResume)
+		authResumes.GET("/trash", resumeHandler.GetDeletedResumes)
+		authResumes.POST("/:id/restore", resumeHandler.RestoreResume)
+		authResumes.POST("/:id/versions/:version/rollback", resumeHandler.RollbackResume)
+		authResumes.POST("/:id/favorite", favoriteHandler.AddFavorite)
+		authResumes.DELETE("/:id/favorite", favoriteHandler.RemoveFavorite)
+		authResumes.POST("/:id/comments", commentHandler.CreateComment)
+		authResumes.POST("/:id/consultations", consultationHandler.CreateConsultation)
+		authResumes.GET("/user/list", resumeHandler.GetUserResumes)
+	}
+
+	// 评论相关路由
+	commentGroup := api.Group("/comments", handler.AuthMiddleware(cfg.JWT.Secret, userService))
+	{
+		commentGroup.PUT("/:id", commentHandler.UpdateComment)
+		commentGroup.DELETE("/:id", commentHandler.DeleteComment)
+		commentGroup.POST("/:id/upvote", commentHandler.Upvote)
+		commentGroup.DELETE("/:id/upvote", commentHandler.RemoveUpvote)
+	}
+
+	// 实时事件推送，EventSource无法设置请求头，允许通过查询参数传递令牌
+	api.GET("/events", handler.QueryTokenAuth(), handler.AuthMiddleware(cfg.JWT.Secret, userService), streamHandler.Stream)
+
+	// 付费咨询相关路由
+	consultationGroup := api.Group("/consultations", handler.AuthMiddleware(cfg.JWT.Secret, userService))
+	{
+		consultationGroup.GET("/:id", consultationHandler.GetConsultation)
+		consultationGroup.POST("/:id/accept", consultationHandler.AcceptConsultation)
+		consultationGroup.POST("/:id/decline", consultationHandler.DeclineConsultation)
+		consultationGroup.POST("/:id/cancel", consultationHandler.CancelConsultation)
+		consultationGroup.POST("/:id/pay", consultationHandler.PayConsultation)
+		consultationGroup.POST("/:id/messages", consultationHandler.PostMessage)
+		consultationGroup.POST("/:id/close", consultationHandler.CloseConsultation)
+		consultationGroup.POST("/:id/refund", consultationHandler.RefundConsultation)
+	}
+
+	// 管理员路由
+	adminGroup := api.Group("/admin", handler.AuthMiddleware(cfg.JWT.Secret, userService), handler.AdminMiddleware(userService))
+	{
+		adminGroup.POST("/users/:id/unlock", adminHandler.UnlockUser)
+		adminGroup.POST("/users/:id/restore", adminHandler.RestoreUser)
+		adminGroup.GET("/resumes", adminHandler.GetResumes)
+		adminGroup.PUT("/resumes/:id/status", adminHandler.ReviewResume)
+		adminGroup.GET("/comments", adminHandler.GetComments)
+		adminGroup.PUT("/comments/:id/status", adminHandler.ModerateComment)
+		adminGroup.DELETE("/comments/:id", adminHandler.RemoveComment)
+		adminGroup.GET("/webhooks", webhookHandler.GetWebhooks)
+		adminGroup.POST("/webhooks", webhookHandler.CreateWebhook)
+		adminGroup.GET("/webhooks/:id", webhookHandler.GetWebhook)
+		adminGroup.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+		adminGroup.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		adminGroup.POST("/webhooks/:id/ping", webhookHandler.PingWebhook)
+		adminGroup.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+		adminGroup.POST("/webhooks/:id/deliveries/:deliveryId/replay", webhookHandler.ReplayDelivery)
 	}
 
 	// 启动服务器
@@ -206,3 +469,72 @@ func main() {
 
 	logger.Info("服务器已关闭")
 }
+
+// loadOAuthProviders 根据配置创建已启用的第三方登录提供方
+func loadOAuthProviders(cfg *config.Config) []service.OAuthProvider {
+	var providers []service.OAuthProvider
+
+	if gh := cfg.OAuth.GitHub; gh.ClientID != "" {
+		providers = append(providers, service.NewGitHubProvider(service.OAuthClientConfig{
+			ClientID:     gh.ClientID,
+			ClientSecret: gh.ClientSecret,
+			RedirectURL:  gh.RedirectURL,
+			Scopes:       gh.Scopes,
+		}))
+	}
+
+	if oidc := cfg.OAuth.OIDC; oidc.ClientID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		provider, err := service.NewOIDCProvider(ctx, service.OIDCProviderConfig{
+			OAuthClientConfig: service.OAuthClientConfig{
+				ClientID:     oidc.ClientID,
+				ClientSecret: oidc.ClientSecret,
+				RedirectURL:  oidc.RedirectURL,
+				Scopes:       oidc.Scopes,
+			},
+			Name:        oidc.Name,
+			Issuer:      oidc.Issuer,
+			AuthURL:     oidc.AuthURL,
+			TokenURL:    oidc.TokenURL,
+			UserInfoURL: oidc.UserInfoURL,
+		})
+		if err != nil {
+			util.GetLogger().Error("初始化OIDC登录失败", zap.Error(err))
+		} else {
+			providers = append(providers, provider)
+		}
+	}
+
+	return providers
+}
+
+// loadPaymentProvider 根据配置创建付费咨询使用的支付渠道
+func loadPaymentProvider(cfg *config.Config) service.PaymentProvider {
+	switch cfg.Payment.Provider {
+	case "fake":
+		util.GetLogger().Warn("付费咨询使用本地模拟支付，不会产生真实扣款")
+		return service.NewFakePaymentProvider(cfg.Payment.FakeBalance)
+	default:
+		util.GetLogger().Fatal("不支持的支付渠道", zap.String("provider", cfg.Payment.Provider))
+		return nil
+	}
+}
+
+// loadStorage 根据配置创建文件存储
+func loadStorage(cfg *config.Config) util.Storage {
+	storage, err := util.OpenStorage(cfg.Storage.Driver, util.S3Config{
+		Endpoint:  cfg.Storage.S3Endpoint,
+		Region:    cfg.Storage.S3Region,
+		Bucket:    cfg.Storage.S3Bucket,
+		AccessKey: cfg.Storage.S3AccessKey,
+		SecretKey: cfg.Storage.S3SecretKey,
+		PathStyle: cfg.Storage.S3PathStyle,
+		Timeout:   cfg.Storage.S3Timeout,
+	})
+	if err != nil {
+		util.GetLogger().Fatal("初始化文件存储失败", zap.Error(err), zap.String("driver", cfg.Storage.Driver))
+	}
+	return storage
+}
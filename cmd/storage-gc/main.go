@@ -0,0 +1,120 @@
+// storage-gc 检查文件存储与数据库记录的一致性
+//
+// 默认只输出报告，不修改任何数据：
+//
+//	go run ./cmd/storage-gc
+//	go run ./cmd/storage-gc -repair -grace 24h
+//
+// 报告无引用的文件、文件缺失的记录、错误的引用计数和残留的本地临时目录；
+// -repair 删除无引用的文件和临时目录并修正引用计数，文件缺失的记录需人工处理
+package main
+
+import (
+	"codefolio/internal/config"
+	"codefolio/internal/util"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func main() {
+	logger := util.InitLogger()
+	defer logger.Sync()
+
+	cfg := config.LoadConfig()
+
+	repair := flag.Bool("repair", false, "删除无引用的文件和残留的临时目录并修正引用计数，默认只输出报告")
+	grace := flag.Duration("grace", cfg.Storage.GCGracePeriod, "只处理早于该时长的文件")
+	asJSON := flag.Bool("json", false, "以JSON格式输出报告")
+	flag.Parse()
+
+	// 上传目录决定本地存储的根目录和旧格式路径的前缀
+	if err := util.SetUploadConfig(cfg.Upload.StoragePath, cfg.Upload.MaxFileSize, cfg.Upload.AllowedTypes); err != nil {
+		logger.Fatal("允许上传的文件类型配置无效", zap.Error(err))
+	}
+	storage, err := util.OpenStorage(cfg.Storage.Driver, util.S3Config{
+		Endpoint:  cfg.Storage.S3Endpoint,
+		Region:    cfg.Storage.S3Region,
+		Bucket:    cfg.Storage.S3Bucket,
+		AccessKey: cfg.Storage.S3AccessKey,
+		SecretKey: cfg.Storage.S3SecretKey,
+		PathStyle: cfg.Storage.S3PathStyle,
+		Timeout:   cfg.Storage.S3Timeout,
+	})
+	if err != nil {
+		logger.Fatal("初始化文件存储失败", zap.Error(err), zap.String("driver", cfg.Storage.Driver))
+	}
+	util.SetStorage(storage, cfg.Storage.URLExpiry)
+
+	db, err := gorm.Open(postgres.Open(cfg.GetDSN()), &gorm.Config{})
+	if err != nil {
+		logger.Fatal("数据库连接失败", zap.Error(err))
+	}
+
+	report, err := util.RunStorageGC(context.Background(), db, util.StorageGCOptions{
+		Repair:      *repair,
+		GracePeriod: *grace,
+	})
+	if err != nil {
+		logger.Fatal("存储一致性检查失败", zap.Error(err))
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			logger.Fatal("输出报告失败", zap.Error(err))
+		}
+	} else {
+		printReport(report)
+	}
+
+	if len(report.Errors) > 0 {
+		os.Exit(1)
+	}
+}
+
+// printReport 以文本格式输出报告
+func printReport(r *util.StorageGCReport) {
+	mode := "仅报告（使用 -repair 执行清理）"
+	if r.Repair {
+		mode = "修复"
+	}
+	fmt.Printf("模式: %s\n", mode)
+	fmt.Printf("扫描文件: %d，耗时 %s\n", r.ScannedFiles, r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond))
+
+	fmt.Printf("\n无引用的文件: %d（%d 字节）\n", len(r.Orphans), r.OrphanBytes)
+	for _, o := range r.Orphans {
+		fmt.Printf("  %s\t%d\t%s\n", o.Key, o.Size, o.ModTime.Format("2006-01-02 15:04:05"))
+	}
+
+	fmt.Printf("\n文件缺失的记录: %d\n", len(r.Missing))
+	for _, m := range r.Missing {
+		fmt.Printf("  %s#%d.%s\t%s\n", m.Table, m.ID, m.Column, m.Key)
+	}
+
+	fmt.Printf("\n引用计数不一致: %d\n", len(r.BlobMismatches))
+	for _, m := range r.BlobMismatches {
+		fmt.Printf("  %s\t%d -> %d\n", m.Key, m.RefCount, m.Expected)
+	}
+
+	fmt.Printf("\n残留的临时目录: %d\n", len(r.StaleTempDirs))
+	for _, dir := range r.StaleTempDirs {
+		fmt.Printf("  %s\n", dir)
+	}
+
+	if r.Repair {
+		fmt.Printf("\n已删除文件: %d（%d 字节），已修正引用计数: %d，已删除临时目录: %d\n",
+			r.DeletedFiles, r.FreedBytes, r.FixedBlobs, r.RemovedTempDirs)
+	}
+	for _, e := range r.Errors {
+		fmt.Printf("错误: %s\n", e)
+	}
+}
@@ -23,23 +23,24 @@ const (
 	CodeRequestFailed    = 1010 // 请求失败
 
 	// 用户相关错误代码 (2000-2999)
-	CodeUserNotFound          = 2000 // 用户不存在
-	CodeUserAlreadyExists     = 2001 // 用户已存在
-	CodeInvalidCredentials    = 2002 // 无效的凭据
-	CodePasswordMismatch      = 2003 // 密码不匹配
-	CodeInvalidToken          = 2004 // 无效的令牌
-	CodeTokenExpired          = 2005 // 令牌已过期
-	CodeEmailNotVerified      = 2006 // 邮箱未验证
-	CodeInvalidEmail          = 2007 // 无效的邮箱
-	CodeInvalidPassword       = 2008 // 无效的密码
-	CodePasswordTooWeak       = 2009 // 密码强度不足
-	CodeTooManyRequests       = 2010 // 请求次数过多
-	CodeAccountLocked         = 2011 // 账户已锁定
-	CodeSessionExpired        = 2012 // 会话已过期
-	CodeLoginRequired         = 2013 // 需要登录
-	CodePermissionDenied      = 2014 // 权限不足
-	CodeUserDisabled          = 2015 // 用户已禁用
-	CodeUserProfileIncomplete = 2016 // 用户资料不完整
+	CodeUserNotFound           = 2000 // 用户不存在
+	CodeUserAlreadyExists      = 2001 // 用户已存在
+	CodeInvalidCredentials     = 2002 // 无效的凭据
+	CodePasswordMismatch       = 2003 // 密码不匹配
+	CodeInvalidToken           = 2004 // 无效的令牌
+	CodeTokenExpired           = 2005 // 令牌已过期
+	CodeEmailNotVerified       = 2006 // 邮箱未验证
+	CodeInvalidEmail           = 2007 // 无效的邮箱
+	CodeInvalidPassword        = 2008 // 无效的密码
+	CodePasswordTooWeak        = 2009 // 密码强度不足
+	CodeTooManyRequests        = 2010 // 请求次数过多
+	CodeAccountLocked          = 2011 // 账户已锁定
+	CodeSessionExpired         = 2012 // 会话已过期
+	CodeLoginRequired          = 2013 // 需要登录
+	CodePermissionDenied       = 2014 // 权限不足
+	CodeUserDisabled           = 2015 // 用户已禁用
+	CodeUserProfileIncomplete  = 2016 // 用户资料不完整
+	CodeAccountPendingDeletion = 2017 // 账户注销中
 
 	// 数据相关错误代码 (3000-3999)
 	CodeDataNotFound         = 3000 // 数据不存在
@@ -59,6 +60,26 @@ const (
 	CodeDataLockFailed       = 3014 // 数据锁定失败
 	CodeDataRelationInvalid  = 3015 // 数据关系无效
 
+	// 上传文件校验和转换错误代码
+	CodeFileTooLarge           = 3016 // 文件太大
+	CodeFileTypeNotAllowed     = 3017 // 文件类型不允许
+	CodePDFMalformed           = 3018 // PDF文件已损坏
+	CodePDFEncrypted           = 3019 // PDF文件已加密
+	CodePDFTooManyPages        = 3020 // PDF页数超过限制
+	CodePDFPageTooLarge        = 3021 // PDF页面尺寸超过限制
+	CodeConversionTimeout      = 3022 // 文件转换超时
+	CodeConversionLimitReached = 3023 // 文件转换超出资源限制
+	CodeDocumentConvertFailed  = 3024 // 文档转换为PDF失败
+	CodeConverterUnavailable   = 3025 // 文档转换工具不可用
+	CodeImageTooLarge          = 3026 // 图片尺寸超过限制
+
+	// 分片上传错误代码
+	CodeChecksumMismatch  = 3027 // 校验和不匹配
+	CodeUploadIncomplete  = 3028 // 分片未全部上传
+	CodeUploadNotActive   = 3029 // 上传会话已完成或正在处理
+	CodeInvalidUploadPart = 3030 // 分片序号或大小无效
+	CodeTooManyUploads    = 3031 // 未完成的上传会话过多
+
 	// 业务逻辑错误代码 (4000-4999)
 	CodeOperationFailed     = 4000 // 操作失败
 	CodeOperationNotAllowed = 4001 // 操作不允许
@@ -110,23 +131,24 @@ var codeMessages = map[int]string{
 	CodeRequestFailed:    "请求失败",
 
 	// 用户相关错误代码 (2000-2999)
-	CodeUserNotFound:          "用户不存在",
-	CodeUserAlreadyExists:     "用户已存在",
-	CodeInvalidCredentials:    "无效的凭据",
-	CodePasswordMismatch:      "密码不匹配",
-	CodeInvalidToken:          "无效的令牌",
-	CodeTokenExpired:          "令牌已过期",
-	CodeEmailNotVerified:      "邮箱未验证",
-	CodeInvalidEmail:          "无效的邮箱",
-	CodeInvalidPassword:       "无效的密码",
-	CodePasswordTooWeak:       "密码强度不足",
-	CodeTooManyRequests:       "请求次数过多",
-	CodeAccountLocked:         "账户已锁定",
-	CodeSessionExpired:        "会话已过期",
-	CodeLoginRequired:         "需要登录",
-	CodePermissionDenied:      "权限不足",
-	CodeUserDisabled:          "用户已禁用",
-	CodeUserProfileIncomplete: "用户资料不完整",
+	CodeUserNotFound:           "用户不存在",
+	CodeUserAlreadyExists:      "用户已存在",
+	CodeInvalidCredentials:     "无效的凭据",
+	CodePasswordMismatch:       "密码不匹配",
+	CodeInvalidToken:           "无效的令牌",
+	CodeTokenExpired:           "令牌已过期",
+	CodeEmailNotVerified:       "邮箱未验证",
+	CodeInvalidEmail:           "无效的邮箱",
+	CodeInvalidPassword:        "无效的密码",
+	CodePasswordTooWeak:        "密码强度不足",
+	CodeTooManyRequests:        "请求次数过多",
+	CodeAccountLocked:          "账户已锁定",
+	CodeSessionExpired:         "会话已过期",
+	CodeLoginRequired:          "需要登录",
+	CodePermissionDenied:       "权限不足",
+	CodeUserDisabled:           "用户已禁用",
+	CodeUserProfileIncomplete:  "用户资料不完整",
+	CodeAccountPendingDeletion: "账户注销中，请先撤销注销申请",
 
 	// 数据相关错误代码 (3000-3999)
 	CodeDataNotFound:         "数据不存在",
@@ -146,6 +168,25 @@ var codeMessages = map[int]string{
 	CodeDataLockFailed:       "数据锁定失败",
 	CodeDataRelationInvalid:  "数据关系无效",
 
+	// 上传文件校验和转换错误代码
+	CodeFileTooLarge:           "文件太大",
+	CodeFileTypeNotAllowed:     "文件类型不允许",
+	CodePDFMalformed:           "PDF文件已损坏或格式不正确",
+	CodePDFEncrypted:           "不支持加密的PDF文件",
+	CodePDFTooManyPages:        "PDF页数超过限制",
+	CodePDFPageTooLarge:        "PDF页面尺寸超过限制",
+	CodeConversionTimeout:      "文件转换超时",
+	CodeConversionLimitReached: "文件转换超出资源限制",
+	CodeDocumentConvertFailed:  "文档转换为PDF失败",
+	CodeConverterUnavailable:   "服务器暂不支持该文档格式",
+	CodeImageTooLarge:          "图片尺寸超过限制",
+
+	CodeChecksumMismatch:  "文件校验和不匹配",
+	CodeUploadIncomplete:  "分片尚未全部上传",
+	CodeUploadNotActive:   "上传会话已完成或正在处理",
+	CodeInvalidUploadPart: "分片序号或大小无效",
+	CodeTooManyUploads:    "未完成的上传过多，请先完成或取消之前的上传",
+
 	// 业务逻辑错误代码 (4000-4999)
 	CodeOperationFailed:     "操作失败",
 	CodeOperationNotAllowed: "操作不允许",
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -12,18 +13,28 @@ import (
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Email    EmailConfig
-	Upload   UploadConfig
+	Server       ServerConfig
+	Database     DatabaseConfig
+	JWT          JWTConfig
+	Email        EmailConfig
+	Upload       UploadConfig
+	Storage      StorageConfig
+	Login        LoginConfig
+	RateLimit    RateLimitConfig
+	OAuth        OAuthConfig
+	Account      AccountConfig
+	Payment      PaymentConfig
+	Notification NotificationConfig
+	Stream       StreamConfig
+	Webhook      WebhookConfig
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port    int
-	Mode    string
-	Version string
+	Port           int
+	Mode           string
+	Version        string
+	TrustedProxies []string // 信任的反向代理地址或网段，只有来自这些地址的X-Forwarded-For才用于识别客户端IP
 }
 
 // DatabaseConfig 数据库配置
@@ -54,10 +65,136 @@ type EmailConfig struct {
 // UploadConfig 文件上传配置
 type UploadConfig struct {
 	MaxFileSize   int64  // 最大文件大小（字节）
-	AllowedTypes  string // 允许的文件类型
+	AllowedTypes  string // 允许上传的简历文件类型，逗号分隔：.pdf、.docx、.png、.jpg
 	StoragePath   string // 存储路径
 	AnonymousView int    // 匿名用户查看限制
 	UserView      int    // 注册用户查看限制
+
+	DuplicateAction string // 其他用户已上传完全相同的简历时的处理方式：reject拒绝，flag进入审核
+	SimilarDistance int    // 第一页感知哈希的汉明距离不超过该值时视为相似简历并进入审核，0表示不检测
+
+	MaxPages           int           // PDF最大页数
+	MaxPageSize        int           // PDF页面宽高上限（pt，1pt=1/72英寸）
+	ConvertTimeout     time.Duration // 单次外部转换命令的超时时间
+	ConvertCPULimit    time.Duration // 单次外部转换命令的CPU时间上限，0表示不限制
+	ConvertMemoryLimit int64         // 单次外部转换命令的内存上限（字节），0表示不限制
+
+	ImageFormats  []string // 简历图片输出格式（jpg、png、webp），第一个为主格式，其余按Accept协商返回
+	ImageQuality  int      // 有损格式的图片质量 (1-100)
+	ImageDPI      int      // PDF渲染分辨率
+	ImageMaxWidth int      // 简历图片最大宽度（像素），0表示不限制
+
+	LibreOfficePath string // 将DOCX转换为PDF使用的LibreOffice命令
+
+	ChunkSize      int64         // 分片上传的分片大小（字节）
+	ChunkedMaxSize int64         // 分片上传允许的最大文件大小（字节）
+	SessionTTL     time.Duration // 分片上传会话的有效期
+	MaxSessions    int           // 每个用户同时存在的未完成上传会话数量上限
+}
+
+// StorageConfig 文件存储配置
+type StorageConfig struct {
+	Driver      string        // 存储类型: local 或 s3
+	URLExpiry   time.Duration // 文件URL有效期，签名URL和预签名URL共用
+	URLSecret   string        // 文件URL签名密钥，为空时使用JWT密钥
+	BindViewer  bool          // 签发给登录用户的文件URL是否只允许该用户访问
+	S3Endpoint  string        // S3兼容服务地址
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3PathStyle bool          // 以路径形式访问存储桶，MinIO需要开启
+	S3Timeout   time.Duration // 单次S3请求的超时时间，包括读取响应内容
+
+	// 存储一致性检查
+	GCInterval    time.Duration // 检查间隔，0表示不定期检查，可使用storage-gc命令手动执行
+	GCRepair      bool          // 是否删除无引用的文件并修正引用计数，关闭时只记录日志
+	GCGracePeriod time.Duration // 早于该时长的文件才会被清理，应大于上传到创建简历的最长间隔
+}
+
+// LoginConfig 登录防暴力破解配置
+type LoginConfig struct {
+	MaxFailedAttempts  int           // 单个账户连续失败多少次后锁定
+	LockoutDuration    time.Duration // 首次锁定时长，之后每次锁定时长翻倍
+	MaxLockoutDuration time.Duration // 锁定时长上限
+	IPMaxFailures      int           // 单个IP在统计窗口内允许的最大失败次数
+	IPWindow           time.Duration // IP失败次数统计窗口
+}
+
+// RateLimitConfig 请求限流配置
+type RateLimitConfig struct {
+	Backend  string        // 存储后端：memory（单实例）或 postgres（多实例共享）
+	Upload   RateLimitRule // 上传简历（触发PDF转换）
+	Register RateLimitRule // 用户注册
+	Listing  RateLimitRule // 简历列表
+	Login    RateLimitRule // 密码登录，与失败次数限制互补，限制每个IP的总尝试次数
+	Diff     RateLimitRule // 简历版本对比（触发PDF渲染）
+}
+
+// RateLimitRule 单条限流规则，Limit为0表示不限流
+type RateLimitRule struct {
+	Limit  int           // 周期内允许的请求数
+	Period time.Duration // 统计周期
+}
+
+// OAuthConfig 第三方登录配置，ClientID为空的提供方不启用
+type OAuthConfig struct {
+	SuccessRedirect string // 登录成功后跳转的前端地址，令牌通过URL片段传递；为空时回调直接返回JSON
+	GitHub          OAuthClientConfig
+	OIDC            OIDCConfig
+}
+
+// OAuthClientConfig OAuth客户端配置
+type OAuthClientConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// OIDCConfig 通用OIDC提供方配置
+type OIDCConfig struct {
+	OAuthClientConfig
+	Name        string // 提供方名称，用于登录路由
+	Issuer      string // 签发者地址，用于自动发现端点和校验ID令牌
+	AuthURL     string // 以下端点可选，留空时通过发现文档获取
+	TokenURL    string
+	UserInfoURL string
+}
+
+// AccountConfig 账户管理配置
+type AccountConfig struct {
+	DeletionGracePeriod time.Duration // 注销冷静期，期满后删除账户并移入回收站
+	TrashRetention      time.Duration // 已删除的简历和账户在回收站中的保留时间，期满后永久删除
+}
+
+// PaymentConfig 付费咨询支付配置
+type PaymentConfig struct {
+	Provider    string // 支付渠道，目前仅支持fake（本地模拟支付）
+	Currency    string // 结算币种
+	FakeBalance int64  // 模拟支付中每个用户的余额（分），0表示不限额
+	MaxPrice    int64  // 咨询报价上限（分），0表示不限
+}
+
+// NotificationConfig 站内通知配置
+type NotificationConfig struct {
+	DigestInterval time.Duration // 未读通知邮件摘要的发送间隔，0表示不发送
+}
+
+// StreamConfig 实时事件推送配置
+type StreamConfig struct {
+	HeartbeatInterval time.Duration // 心跳间隔，应小于代理的空闲超时
+	HistorySize       int           // 每个用户保留的最近事件数，用于断线重连补发
+	HistoryTTL        time.Duration // 事件保留时长
+}
+
+// WebhookConfig Webhook推送配置
+type WebhookConfig struct {
+	Timeout      time.Duration // 单次推送的超时时间
+	MaxAttempts  int           // 最大推送次数，用尽后标记为失败
+	RetryBackoff time.Duration // 首次重试的等待时间，之后每次翻倍
+	MaxBackoff   time.Duration // 重试等待时间上限
+	PollInterval time.Duration // 检查待推送记录的间隔
 }
 
 // LoadConfig 加载配置
@@ -72,6 +209,8 @@ func LoadConfig() *Config {
 			Port:    getEnvAsInt("SERVER_PORT", 8080),
 			Mode:    getEnv("SERVER_MODE", "development"),
 			Version: getEnv("SERVER_VERSION", "0.1.0"),
+
+			TrustedProxies: getEnvAsSlice("SERVER_TRUSTED_PROXIES", nil),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -98,6 +237,106 @@ func LoadConfig() *Config {
 			StoragePath:   getEnv("UPLOAD_STORAGE_PATH", "./uploads"),
 			AnonymousView: getEnvAsInt("UPLOAD_ANONYMOUS_VIEW_LIMIT", 5), // 匿名用户每天可查看5份简历
 			UserView:      getEnvAsInt("UPLOAD_USER_VIEW_LIMIT", 20),     // 注册用户每天可查看20份简历
+
+			DuplicateAction: getEnv("UPLOAD_DUPLICATE_ACTION", "reject"),
+			SimilarDistance: getEnvAsInt("UPLOAD_SIMILAR_DISTANCE", 6),
+
+			MaxPages:           getEnvAsInt("UPLOAD_MAX_PAGES", 10),
+			MaxPageSize:        getEnvAsInt("UPLOAD_MAX_PAGE_SIZE", 2000), // 约70cm
+			ConvertTimeout:     getEnvAsDuration("UPLOAD_CONVERT_TIMEOUT", time.Minute),
+			ConvertCPULimit:    getEnvAsDuration("UPLOAD_CONVERT_CPU_LIMIT", 30*time.Second),
+			ConvertMemoryLimit: getEnvAsInt64("UPLOAD_CONVERT_MEMORY_LIMIT", 1024*1024*1024), // 默认1GB
+
+			ImageFormats:  getEnvAsSlice("UPLOAD_IMAGE_FORMATS", []string{"jpg"}),
+			ImageQuality:  getEnvAsInt("UPLOAD_IMAGE_QUALITY", 90),
+			ImageDPI:      getEnvAsInt("UPLOAD_IMAGE_DPI", 150),
+			ImageMaxWidth: getEnvAsInt("UPLOAD_IMAGE_MAX_WIDTH", 2000),
+
+			LibreOfficePath: getEnv("UPLOAD_LIBREOFFICE_PATH", "soffice"),
+
+			ChunkSize:      getEnvAsInt64("UPLOAD_CHUNK_SIZE", 1024*1024),          // 默认1MB
+			ChunkedMaxSize: getEnvAsInt64("UPLOAD_CHUNKED_MAX_SIZE", 50*1024*1024), // 默认50MB
+			SessionTTL:     getEnvAsDuration("UPLOAD_SESSION_TTL", 24*time.Hour),
+			MaxSessions:    getEnvAsInt("UPLOAD_MAX_SESSIONS", 5),
+		},
+		Storage: StorageConfig{
+			Driver:      getEnv("STORAGE_DRIVER", "local"),
+			URLExpiry:   getEnvAsDuration("STORAGE_URL_EXPIRY", 15*time.Minute),
+			URLSecret:   getEnv("STORAGE_URL_SECRET", ""),
+			BindViewer:  getEnvAsBool("STORAGE_URL_BIND_VIEWER", false),
+			S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+			S3Region:    getEnv("S3_REGION", "us-east-1"),
+			S3Bucket:    getEnv("S3_BUCKET", ""),
+			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			S3PathStyle: getEnvAsBool("S3_PATH_STYLE", true),
+			S3Timeout:   getEnvAsDuration("S3_TIMEOUT", time.Minute),
+
+			GCInterval:    getEnvAsDuration("STORAGE_GC_INTERVAL", 24*time.Hour),
+			GCRepair:      getEnvAsBool("STORAGE_GC_REPAIR", false),
+			GCGracePeriod: getEnvAsDuration("STORAGE_GC_GRACE_PERIOD", 24*time.Hour),
+		},
+		Login: LoginConfig{
+			MaxFailedAttempts:  getEnvAsInt("LOGIN_MAX_FAILED_ATTEMPTS", 5),
+			LockoutDuration:    getEnvAsDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			MaxLockoutDuration: getEnvAsDuration("LOGIN_MAX_LOCKOUT_DURATION", 24*time.Hour),
+			IPMaxFailures:      getEnvAsInt("LOGIN_IP_MAX_FAILURES", 20),
+			IPWindow:           getEnvAsDuration("LOGIN_IP_WINDOW", 15*time.Minute),
+		},
+		RateLimit: RateLimitConfig{
+			Backend:  getEnv("RATE_LIMIT_BACKEND", "memory"),
+			Upload:   getEnvAsRateLimitRule("RATE_LIMIT_UPLOAD", RateLimitRule{Limit: 10, Period: time.Minute}),
+			Register: getEnvAsRateLimitRule("RATE_LIMIT_REGISTER", RateLimitRule{Limit: 5, Period: time.Hour}),
+			Listing:  getEnvAsRateLimitRule("RATE_LIMIT_LISTING", RateLimitRule{Limit: 120, Period: time.Minute}),
+			Login:    getEnvAsRateLimitRule("RATE_LIMIT_LOGIN", RateLimitRule{Limit: 20, Period: time.Minute}),
+			Diff:     getEnvAsRateLimitRule("RATE_LIMIT_DIFF", RateLimitRule{Limit: 10, Period: time.Minute}),
+		},
+		OAuth: OAuthConfig{
+			SuccessRedirect: getEnv("OAUTH_SUCCESS_REDIRECT", ""),
+			GitHub: OAuthClientConfig{
+				ClientID:     getEnv("OAUTH_GITHUB_CLIENT_ID", ""),
+				ClientSecret: getEnv("OAUTH_GITHUB_CLIENT_SECRET", ""),
+				RedirectURL:  getEnv("OAUTH_GITHUB_REDIRECT_URL", ""),
+				Scopes:       getEnvAsSlice("OAUTH_GITHUB_SCOPES", nil),
+			},
+			OIDC: OIDCConfig{
+				OAuthClientConfig: OAuthClientConfig{
+					ClientID:     getEnv("OAUTH_OIDC_CLIENT_ID", ""),
+					ClientSecret: getEnv("OAUTH_OIDC_CLIENT_SECRET", ""),
+					RedirectURL:  getEnv("OAUTH_OIDC_REDIRECT_URL", ""),
+					Scopes:       getEnvAsSlice("OAUTH_OIDC_SCOPES", nil),
+				},
+				Name:        getEnv("OAUTH_OIDC_NAME", "oidc"),
+				Issuer:      getEnv("OAUTH_OIDC_ISSUER", ""),
+				AuthURL:     getEnv("OAUTH_OIDC_AUTH_URL", ""),
+				TokenURL:    getEnv("OAUTH_OIDC_TOKEN_URL", ""),
+				UserInfoURL: getEnv("OAUTH_OIDC_USERINFO_URL", ""),
+			},
+		},
+		Account: AccountConfig{
+			DeletionGracePeriod: getEnvAsDuration("ACCOUNT_DELETION_GRACE_PERIOD", 7*24*time.Hour),
+			TrashRetention:      getEnvAsDuration("TRASH_RETENTION_PERIOD", 30*24*time.Hour),
+		},
+		Payment: PaymentConfig{
+			Provider:    getEnv("PAYMENT_PROVIDER", "fake"),
+			Currency:    getEnv("PAYMENT_CURRENCY", "CNY"),
+			FakeBalance: getEnvAsInt64("PAYMENT_FAKE_BALANCE", 0),
+			MaxPrice:    getEnvAsInt64("CONSULTATION_MAX_PRICE", 100000), // 默认最高1000元
+		},
+		Notification: NotificationConfig{
+			DigestInterval: getEnvAsDuration("NOTIFICATION_DIGEST_INTERVAL", 24*time.Hour),
+		},
+		Stream: StreamConfig{
+			HeartbeatInterval: getEnvAsDuration("SSE_HEARTBEAT_INTERVAL", 25*time.Second),
+			HistorySize:       getEnvAsInt("SSE_HISTORY_SIZE", 100),
+			HistoryTTL:        getEnvAsDuration("SSE_HISTORY_TTL", 10*time.Minute),
+		},
+		Webhook: WebhookConfig{
+			Timeout:      getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			MaxAttempts:  getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			RetryBackoff: getEnvAsDuration("WEBHOOK_RETRY_BACKOFF", 30*time.Second),
+			MaxBackoff:   getEnvAsDuration("WEBHOOK_MAX_BACKOFF", 6*time.Hour),
+			PollInterval: getEnvAsDuration("WEBHOOK_POLL_INTERVAL", 10*time.Second),
 		},
 	}
 }
@@ -161,3 +400,47 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+// getEnvAsSlice 获取以逗号分隔的环境变量并转换为字符串切片
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvAsRateLimitRule 获取形如"10/1m"的限流规则环境变量，"0"表示不限流
+func getEnvAsRateLimitRule(key string, defaultValue RateLimitRule) RateLimitRule {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "0" || value == "" {
+		return RateLimitRule{}
+	}
+
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return defaultValue
+	}
+	limit, err := strconv.Atoi(parts[0])
+	if err != nil || limit < 0 {
+		return defaultValue
+	}
+	period, err := time.ParseDuration(parts[1])
+	if err != nil || period <= 0 {
+		return defaultValue
+	}
+
+	return RateLimitRule{Limit: limit, Period: period}
+}
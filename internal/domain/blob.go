@@ -0,0 +1,12 @@
+package domain
+
+import "time"
+
+// Blob 内容寻址文件的引用计数
+// 相同内容的文件只保存一份，存储键由文件内容的SHA-256决定，引用归零时删除文件
+type Blob struct {
+	Key       string    `gorm:"primaryKey;size:255"`
+	RefCount  int       `gorm:"not null;default:0"` // 引用该文件的简历版本数
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
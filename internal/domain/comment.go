@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 评论状态
+const (
+	CommentStatusVisible = "visible" // 正常显示
+	CommentStatusHidden  = "hidden"  // 被管理员隐藏
+)
+
+// Comment 简历评论，ParentID非空时为对其他评论的回复
+type Comment struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	ResumeID    uint           `json:"resume_id" gorm:"not null;index"`
+	UserID      uint           `json:"user_id" gorm:"not null;index"`
+	ParentID    *uint          `json:"parent_id" gorm:"index"`
+	Content     string         `json:"content" gorm:"type:text;not null"`
+	Page        int            `json:"page"`                                                 // 锚定的页码，0表示针对整份简历
+	Region      *CommentRegion `json:"region" gorm:"serializer:json"`                        // 锚定的图片区域，为空表示针对整页
+	UpvoteCount int            `json:"upvote_count" gorm:"not null;default:0"`               // 点赞数
+	Status      string         `json:"status" gorm:"size:20;not null;default:visible;index"` // 审核状态
+	EditedAt    *time.Time     `json:"edited_at"`                                            // 最后编辑时间
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+
+	// 软删除，有回复的评论删除后保留占位
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// CommentRegion 评论锚定的区域，坐标为渲染后简历图片上的像素位置
+type CommentRegion struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// CommentVote 评论点赞记录
+type CommentVote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CommentID uint      `json:"comment_id" gorm:"not null;uniqueIndex:idx_comment_vote_user"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_comment_vote_user;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
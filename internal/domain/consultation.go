@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// 咨询状态
+const (
+	ConsultationStatusRequested = "requested" // 已提问，等待简历所有者报价
+	ConsultationStatusAccepted  = "accepted"  // 已接受并报价，等待提问者付款
+	ConsultationStatusPaying    = "paying"    // 付款处理中，防止并发重复扣款
+	ConsultationStatusPaid      = "paid"      // 已付款，等待简历所有者回答
+	ConsultationStatusAnswered  = "answered"  // 简历所有者已回答
+	ConsultationStatusClosed    = "closed"    // 已结束（完成、拒绝或取消）
+	ConsultationStatusRefunded  = "refunded"  // 已退款
+)
+
+// Consultation 针对简历的付费咨询
+type Consultation struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	ResumeID      uint       `json:"resume_id" gorm:"not null;index"`
+	AskerID       uint       `json:"asker_id" gorm:"not null;index"` // 提问者
+	OwnerID       uint       `json:"owner_id" gorm:"not null;index"` // 简历所有者
+	Question      string     `json:"question" gorm:"type:text;not null"`
+	Price         int64      `json:"price"` // 报价，单位为分
+	Currency      string     `json:"currency" gorm:"size:8"`
+	Status        string     `json:"status" gorm:"size:20;not null;default:requested;index"`
+	CloseReason   string     `json:"close_reason" gorm:"size:255"`  // 拒绝或取消的原因
+	PaymentID     string     `json:"-" gorm:"size:128"`             // 支付渠道的交易号
+	PaymentMethod string     `json:"payment_method" gorm:"size:32"` // 支付渠道名称
+	AcceptedAt    *time.Time `json:"accepted_at"`
+	PaidAt        *time.Time `json:"paid_at"`
+	AnsweredAt    *time.Time `json:"answered_at"`
+	ClosedAt      *time.Time `json:"closed_at"`
+	RefundedAt    *time.Time `json:"refunded_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ConsultationMessage 咨询中的留言
+type ConsultationMessage struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ConsultationID uint      `json:"consultation_id" gorm:"not null;index"`
+	SenderID       uint      `json:"sender_id" gorm:"not null"`
+	Content        string    `json:"content" gorm:"type:text;not null"`
+	CreatedAt      time.Time `json:"created_at"`
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// Favorite 简历收藏
+type Favorite struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_favorite_user_resume"`
+	ResumeID  uint      `json:"resume_id" gorm:"not null;uniqueIndex:idx_favorite_user_resume;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
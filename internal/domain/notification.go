@@ -0,0 +1,64 @@
+package domain
+
+import "time"
+
+// 通知类型
+const (
+	NotificationResumeApproved        = "resume_approved"        // 简历审核通过
+	NotificationResumeRejected        = "resume_rejected"        // 简历审核未通过
+	NotificationCommentCreated        = "comment_created"        // 简历收到评论
+	NotificationCommentReplied        = "comment_replied"        // 评论收到回复
+	NotificationConsultationRequested = "consultation_requested" // 收到咨询
+	NotificationConsultationAccepted  = "consultation_accepted"  // 咨询已报价
+	NotificationConsultationDeclined  = "consultation_declined"  // 咨询被拒绝
+	NotificationConsultationPaid      = "consultation_paid"      // 咨询已付款
+	NotificationConsultationMessage   = "consultation_message"   // 咨询有新留言
+	NotificationConsultationRefunded  = "consultation_refunded"  // 咨询已退款
+)
+
+// NotificationTypes 全部通知类型，用于展示偏好设置
+var NotificationTypes = []string{
+	NotificationResumeApproved,
+	NotificationResumeRejected,
+	NotificationCommentCreated,
+	NotificationCommentReplied,
+	NotificationConsultationRequested,
+	NotificationConsultationAccepted,
+	NotificationConsultationDeclined,
+	NotificationConsultationPaid,
+	NotificationConsultationMessage,
+	NotificationConsultationRefunded,
+}
+
+// IsNotificationType 判断是否为已知的通知类型
+func IsNotificationType(t string) bool {
+	for _, nt := range NotificationTypes {
+		if nt == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification 站内通知
+type Notification struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index:idx_notification_user_read"`
+	Type      string     `json:"type" gorm:"size:50;not null"`
+	ActorID   uint       `json:"actor_id"` // 触发通知的用户，系统通知为0
+	Title     string     `json:"title" gorm:"size:255;not null"`
+	Content   string     `json:"content" gorm:"type:text"`
+	Link      string     `json:"link" gorm:"size:255"` // 前端跳转路径
+	ReadAt    *time.Time `json:"read_at" gorm:"index:idx_notification_user_read"`
+	EmailedAt *time.Time `json:"-"` // 已通过邮件摘要发送的时间
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationPreference 用户对某类通知的偏好，未设置时使用默认值：站内通知开启、邮件摘要关闭
+type NotificationPreference struct {
+	ID     uint   `json:"-" gorm:"primaryKey"`
+	UserID uint   `json:"-" gorm:"not null;uniqueIndex:idx_notification_pref_user_type"`
+	Type   string `json:"type" gorm:"size:50;not null;uniqueIndex:idx_notification_pref_user_type"`
+	InApp  bool   `json:"in_app" gorm:"not null"` // 是否接收站内通知
+	Email  bool   `json:"email" gorm:"not null"`  // 是否包含在邮件摘要中
+}
@@ -0,0 +1,10 @@
+package domain
+
+import "time"
+
+// RateLimitBucket 限流令牌桶状态，用于多实例共享限流
+type RateLimitBucket struct {
+	Key        string    `gorm:"primaryKey;size:255"`
+	Tokens     float64   `gorm:"not null"`
+	LastRefill time.Time `gorm:"not null;index"`
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 //const (
@@ -34,15 +36,52 @@ import (
 //	Xiaohongshu                    // 13
 //)
 
+// 简历审核状态
+const (
+	ResumeStatusPending  = "pending"  // 待审核
+	ResumeStatusApproved = "approved" // 审核通过，公开展示
+	ResumeStatusRejected = "rejected" // 审核未通过
+)
+
+// 简历重复类型
+const (
+	ResumeDuplicateExact   = "exact"   // 文件内容完全相同
+	ResumeDuplicateSimilar = "similar" // 第一页的感知哈希相近
+)
+
+// 简历列表排序方式
+const (
+	ResumeSortLatest    = "latest"    // 按创建时间倒序
+	ResumeSortFavorites = "favorites" // 按收藏次数倒序
+)
+
 // Resume 简历信息
 type Resume struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	UserID      uint      `json:"user_id"`
-	ImageURL    string    `json:"image_url"`    // 简历图片URL, 由前端上传的PDF文件转换为图片后存储在服务器上的URL
-	Role        int       `json:"role"`         // 应聘职位
-	Level       int       `json:"level"`        // 经历等级：实习生/应届生/社招
-	University  int       `json:"university"`   // 毕业院校
-	PassCompany []int     `json:"pass_company"` // 面试通过的公司
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        uint      `json:"user_id"`
+	ImageURL      string    `json:"image_url"`                                             // 简历图片的存储键，由上传的PDF文件转换得到，对外返回时转换为签名URL
+	SourceURL     string    `json:"-"`                                                     // 用户上传的原始文件的存储键，仅用于所有者导出
+	Role          int       `json:"role"`                                                  // 应聘职位
+	Level         int       `json:"level"`                                                 // 经历等级：实习生/应届生/社招
+	University    int       `json:"university"`                                            // 毕业院校
+	PassCompany   []int     `json:"pass_company" gorm:"serializer:json"`                   // 面试通过的公司
+	ViewCount     int       `json:"view_count" gorm:"not null;default:0"`                  // 查看次数（不含所有者）
+	DownloadCount int       `json:"download_count" gorm:"not null;default:0"`              // 下载次数（不含所有者）
+	FavoriteCount int       `json:"favorite_count" gorm:"not null;default:0;index"`        // 收藏次数
+	Status        string    `json:"status" gorm:"size:20;not null;default:approved;index"` // 审核状态
+	Version       int       `json:"version" gorm:"not null;default:1"`                     // 当前版本号，对应最新的修订记录
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// 文件内容哈希，用于检测重复上传和相似简历
+	ContentHash string `json:"-" gorm:"size:64;index"` // 原始文件的SHA-256
+	PHash       string `json:"-" gorm:"size:16"`       // 第一页的感知哈希
+
+	// 上传时检测到与其他用户的简历重复或相似，简历进入审核队列并记录原简历
+	DuplicateOfID     *uint  `json:"duplicate_of_id,omitempty" gorm:"index"`
+	DuplicateKind     string `json:"duplicate_kind,omitempty" gorm:"size:20"` // 重复类型：exact/similar
+	DuplicateDistance int    `json:"duplicate_distance,omitempty"`            // 与原简历第一页感知哈希的汉明距离
+
+	// 软删除，删除后保留一段时间供所有者恢复
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 }
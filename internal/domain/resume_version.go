@@ -0,0 +1,62 @@
+package domain
+
+import "time"
+
+// 简历修订类型
+const (
+	ResumeChangeCreate   = "create"   // 创建简历
+	ResumeChangeFile     = "file"     // 替换简历文件
+	ResumeChangeMetadata = "metadata" // 修改职位、院校、offer等信息
+	ResumeChangeRollback = "rollback" // 回滚到历史版本
+)
+
+// ResumeVersion 简历修订记录，每次修改简历都会保存一份文件和元数据快照
+type ResumeVersion struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	ResumeID       uint      `json:"resume_id" gorm:"not null;uniqueIndex:idx_resume_version"`
+	UserID         uint      `json:"user_id" gorm:"not null;index"`
+	Version        int       `json:"version" gorm:"not null;uniqueIndex:idx_resume_version"` // 版本号，从1开始递增
+	ChangeType     string    `json:"change_type" gorm:"size:20;not null"`                    // 修订类型
+	RolledBackFrom int       `json:"rolled_back_from,omitempty"`                             // 回滚时恢复的版本号
+	ImageURL       string    `json:"image_url"`
+	SourceURL      string    `json:"-"`
+	ContentHash    string    `json:"-" gorm:"size:64"` // 原始文件的SHA-256
+	PHash          string    `json:"-" gorm:"size:16"` // 第一页的感知哈希
+	Role           int       `json:"role"`
+	Level          int       `json:"level"`
+	University     int       `json:"university"`
+	PassCompany    []int     `json:"pass_company" gorm:"serializer:json"`
+	Status         string    `json:"-" gorm:"size:20;index"` // 该版本作为当前版本期间简历的审核状态，只有审核通过的版本对他人可见
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// IsPublic 该版本是否曾在审核通过期间公开展示
+func (v *ResumeVersion) IsPublic() bool {
+	return v.Status == ResumeStatusApproved
+}
+
+// OwnsFiles 该版本是否持有文件引用
+// 创建和替换文件时上传的文件由对应版本持有，回滚和修改信息的版本只是引用已有文件
+func (v *ResumeVersion) OwnsFiles() bool {
+	return v.ChangeType == ResumeChangeCreate || v.ChangeType == ResumeChangeFile
+}
+
+// NewOffers 返回相比上一版本新增的offer，即该版本期间拿到的offer
+func (v *ResumeVersion) NewOffers(prev *ResumeVersion) []int {
+	if prev == nil {
+		return v.PassCompany
+	}
+
+	seen := make(map[int]bool, len(prev.PassCompany))
+	for _, company := range prev.PassCompany {
+		seen[company] = true
+	}
+
+	offers := []int{}
+	for _, company := range v.PassCompany {
+		if !seen[company] {
+			offers = append(offers, company)
+		}
+	}
+	return offers
+}
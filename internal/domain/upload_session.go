@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// 分片上传会话状态
+const (
+	UploadSessionUploading  = "uploading"  // 正在接收分片
+	UploadSessionCompleting = "completing" // 正在合并和转换，不再接收分片
+	UploadSessionCompleted  = "completed"  // 已合并并转换完成，转换结果可用于创建简历
+	UploadSessionClaimed    = "claimed"    // 转换结果已被创建简历取用或已释放
+)
+
+// UploadSession 分片上传会话
+// 客户端先声明文件大小和SHA-256，按服务端给出的分片大小逐片上传，中断后可查询已接收的分片继续上传
+type UploadSession struct {
+	ID         string    `json:"upload_id" gorm:"primaryKey;size:36"`
+	UserID     uint      `json:"-" gorm:"not null;index"`
+	FileName   string    `json:"file_name" gorm:"size:255;not null"`
+	FileSize   int64     `json:"file_size" gorm:"not null"`
+	ChunkSize  int64     `json:"chunk_size" gorm:"not null"`
+	TotalParts int       `json:"total_parts" gorm:"not null"`
+	Checksum   string    `json:"checksum" gorm:"size:64;not null"` // 完整文件的SHA-256
+	Status     string    `json:"status" gorm:"size:20;not null;index"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// 合并转换的结果，保存在会话记录上，任一实例都能用上传ID作为文件标识创建简历
+	ImageKey    string `json:"-" gorm:"size:1024"` // 转换后图片的存储键
+	SourceKey   string `json:"-" gorm:"size:1024"` // 原始文件的存储键
+	ContentHash string `json:"-" gorm:"size:64"`   // 原始文件的SHA-256
+	PHash       string `json:"-" gorm:"size:16"`   // 第一页的感知哈希
+}
+
+// PartSize 第partNumber个分片（从1开始）的大小，最后一个分片为剩余部分
+func (s *UploadSession) PartSize(partNumber int) int64 {
+	if partNumber < s.TotalParts {
+		return s.ChunkSize
+	}
+	return s.FileSize - s.ChunkSize*int64(s.TotalParts-1)
+}
+
+// UploadPart 已接收的分片
+type UploadPart struct {
+	SessionID  string    `json:"-" gorm:"primaryKey;size:36"`
+	PartNumber int       `json:"part_number" gorm:"primaryKey;autoIncrement:false"`
+	Size       int64     `json:"size" gorm:"not null"`
+	Checksum   string    `json:"checksum" gorm:"size:64;not null"` // 分片的SHA-256
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -3,17 +3,50 @@ package domain
 import (
 	"time"
 
-	_ "gorm.io/gorm"
+	"gorm.io/gorm"
 )
 
 // User 用户模型
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Username  string    `json:"username" gorm:"size:50;not null;unique"`
-	Email     string    `json:"email" gorm:"size:100;not null;unique"`
-	Password  string    `json:"-" gorm:"size:100;not null"`
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Username string `json:"username" gorm:"size:50;not null;unique"`
+	Email    string `json:"email" gorm:"size:100;not null;unique"`
+	Password string `json:"-" gorm:"size:100;not null"`
+	IsAdmin  bool   `json:"is_admin" gorm:"not null;default:false"`
+
+	// 个人资料
+	DisplayName    string        `json:"display_name" gorm:"size:50"`
+	AvatarURL      string        `json:"avatar_url" gorm:"size:255"`
+	Bio            string        `json:"bio" gorm:"size:500"`
+	Company        string        `json:"company" gorm:"size:100"`      // 当前就职公司
+	GraduationYear int           `json:"graduation_year"`              // 毕业年份，0表示未填写
+	Links          []ProfileLink `json:"links" gorm:"serializer:json"` // 个人链接，如GitHub、博客
+
+	// 登录保护
+	FailedLoginCount int        `json:"-" gorm:"not null;default:0"` // 连续登录失败次数
+	LockCount        int        `json:"-" gorm:"not null;default:0"` // 连续锁定次数，用于计算锁定时长
+	LockedUntil      *time.Time `json:"-"`                           // 锁定截止时间
+
+	// 账户注销
+	DeletionRequestedAt *time.Time `json:"-"`              // 申请注销时间
+	DeletionScheduledAt *time.Time `json:"-" gorm:"index"` // 计划删除时间，冷静期内可撤销
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// 软删除，保留期内可由管理员恢复
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+// ProfileLink 个人资料中的外部链接
+type ProfileLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// IsLocked 判断账户当前是否处于锁定状态
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
 }
 
 type UserRepository interface {
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// UserIdentity 第三方登录身份，将OAuth/OIDC提供方账户关联到本站用户
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"user_id" gorm:"not null;index"`
+	Provider  string    `json:"provider" gorm:"size:32;not null;uniqueIndex:idx_identity_provider_subject"` // 提供方名称，如github
+	Subject   string    `json:"-" gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject"`       // 提供方内的唯一用户标识
+	Login     string    `json:"login" gorm:"size:100"`                                                      // 提供方用户名
+	Email     string    `json:"email" gorm:"size:100"`                                                      // 提供方邮箱
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
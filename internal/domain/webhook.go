@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// Webhook事件类型
+const (
+	WebhookEventResumeCreated  = "resume.created"  // 简历创建
+	WebhookEventResumeApproved = "resume.approved" // 简历审核通过
+	WebhookEventResumeRejected = "resume.rejected" // 简历审核未通过
+	WebhookEventResumeDeleted  = "resume.deleted"  // 简历删除
+	WebhookEventPing           = "webhook.ping"    // 测试推送，仅在管理员手动测试时发送
+)
+
+// WebhookEventTypes 可订阅的Webhook事件类型
+var WebhookEventTypes = []string{
+	WebhookEventResumeCreated,
+	WebhookEventResumeApproved,
+	WebhookEventResumeRejected,
+	WebhookEventResumeDeleted,
+}
+
+// IsWebhookEventType 判断是否为可订阅的Webhook事件类型
+func IsWebhookEventType(t string) bool {
+	for _, et := range WebhookEventTypes {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// 推送记录状态
+const (
+	WebhookDeliveryPending   = "pending"   // 等待推送或重试
+	WebhookDeliverySucceeded = "succeeded" // 推送成功
+	WebhookDeliveryFailed    = "failed"    // 重试次数用尽
+)
+
+// Webhook 外部系统的事件订阅
+type Webhook struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	URL         string    `json:"url" gorm:"size:500;not null"`
+	Secret      string    `json:"-" gorm:"size:128;not null"` // 签名密钥
+	Events      []string  `json:"events" gorm:"serializer:json"`
+	Description string    `json:"description" gorm:"size:255"`
+	Active      bool      `json:"active" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subscribes 判断是否订阅了指定事件
+func (w *Webhook) Subscribes(eventType string) bool {
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery Webhook推送记录
+type WebhookDelivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	WebhookID      uint       `json:"webhook_id" gorm:"not null;index"`
+	EventID        string     `json:"event_id" gorm:"size:64;not null;index"` // 事件ID，重放时保持不变，供接收方去重
+	EventType      string     `json:"event_type" gorm:"size:50;not null"`
+	Payload        string     `json:"payload" gorm:"type:text;not null"`
+	Status         string     `json:"status" gorm:"size:20;not null;index:idx_webhook_delivery_due"`
+	Attempts       int        `json:"attempts" gorm:"not null"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body" gorm:"type:text"` // 截断保存的响应内容
+	Error          string     `json:"error" gorm:"size:500"`
+	ReplayOf       *uint      `json:"replay_of"` // 重放时指向原推送记录
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
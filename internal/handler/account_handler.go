@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AccountHandler 账户注销与数据导出处理器
+type AccountHandler struct {
+	accountService service.AccountService
+}
+
+// NewAccountHandler 创建账户处理器
+func NewAccountHandler(accountService service.AccountService) *AccountHandler {
+	return &AccountHandler{
+		accountService: accountService,
+	}
+}
+
+// DeleteAccountRequest 注销账户请求
+type DeleteAccountRequest struct {
+	Password        string `json:"password"`         // 设置了密码的账户必填
+	ConfirmUsername string `json:"confirm_username"` // 第三方登录账户需输入用户名确认
+}
+
+// DeletionStatusResponse 注销状态响应
+type DeletionStatusResponse struct {
+	ScheduledAt string `json:"scheduled_at,omitempty"` // 计划删除时间，为空表示未申请注销
+}
+
+// DeleteMe 申请注销当前账户
+// @Summary 注销账户
+// @Description 验证密码后申请注销，冷静期结束后永久删除账户、简历及文件，冷静期内可撤销
+// @Description 申请后除查看账户信息和撤销注销外的接口均返回403，账户删除后令牌失效
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body DeleteAccountRequest true "注销确认"
+// @Success 200 {object} common.Response{data=DeletionStatusResponse}
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/me [delete]
+// @Security BearerAuth
+func (h *AccountHandler) DeleteMe(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := util.BindAndValidate(c, &req); err != nil {
+		return // 错误已在BindAndValidate中处理
+	}
+
+	user, err := h.accountService.RequestDeletion(userID, req.Password, req.ConfirmUsername)
+	if err != nil {
+		switch err {
+		case service.ErrDeletionConfirmFailed:
+			common.ResponseWithCustomError(c, common.CodePasswordMismatch, err.Error())
+		case service.ErrUserNotFound:
+			common.ResponseWithError(c, common.CodeUserNotFound)
+		default:
+			util.GetLogger().Error("申请注销账户失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.ResponseWithData(c, DeletionStatusResponse{
+		ScheduledAt: user.DeletionScheduledAt.Format("2006-01-02 15:04:05"),
+	})
+}
+
+// CancelDeletion 撤销注销申请
+// @Summary 撤销注销申请
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} common.Response
+// @Failure 401,500 {object} common.Response
+// @Router /api/v1/me/deletion/cancel [post]
+// @Security BearerAuth
+func (h *AccountHandler) CancelDeletion(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	if _, err := h.accountService.CancelDeletion(userID); err != nil {
+		switch err {
+		case service.ErrDeletionNotRequested:
+			common.ResponseWithCustomError(c, common.CodeInvalidState, err.Error())
+		case service.ErrUserNotFound:
+			common.ResponseWithError(c, common.CodeUserNotFound)
+		default:
+			util.GetLogger().Error("撤销注销申请失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.ResponseSuccess(c)
+}
+
+// ExportMe 导出个人数据
+// @Summary 导出个人数据
+// @Description 下载包含个人资料、简历元数据和原始文件的ZIP压缩包
+// @Tags 用户
+// @Produce application/zip
+// @Success 200 {file} file "数据压缩包"
+// @Failure 401 {object} common.Response
+// @Router /api/v1/me/export [get]
+// @Security BearerAuth
+func (h *AccountHandler) ExportMe(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	filename := fmt.Sprintf("codefolio-export-%d-%s.zip", userID, time.Now().Format("20060102"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	// 压缩包以流式写出，开始写入后无法再返回错误响应
+	if err := h.accountService.ExportUserData(userID, c.Writer); err != nil {
+		util.GetLogger().Error("导出个人数据失败", zap.Error(err), zap.Uint("userID", userID))
+		_ = c.Error(err)
+	}
+}
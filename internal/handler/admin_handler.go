@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AdminHandler 管理员接口处理器
+type AdminHandler struct {
+	userService    service.UserService
+	accountService service.AccountService
+	commentService service.CommentService
+	resumeService  service.ResumeService
+}
+
+// NewAdminHandler 创建管理员处理器
+func NewAdminHandler(userService service.UserService, accountService service.AccountService, commentService service.CommentService, resumeService service.ResumeService) *AdminHandler {
+	return &AdminHandler{
+		userService:    userService,
+		accountService: accountService,
+		commentService: commentService,
+		resumeService:  resumeService,
+	}
+}
+
+// UnlockUser 解除账户锁定
+// @Summary 解除账户锁定
+// @Description 清除用户的登录失败次数和锁定状态
+// @Tags 管理
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} common.Response
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/users/{id}/unlock [post]
+// @Security BearerAuth
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	// 获取用户ID
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	if err := h.userService.UnlockUser(uint(id)); err != nil {
+		switch err {
+		case service.ErrUserNotFound:
+			common.ResponseWithError(c, common.CodeUserNotFound)
+		default:
+			util.GetLogger().Error("解除账户锁定失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	util.GetLogger().Info("管理员解除账户锁定",
+		zap.Uint("adminID", getCurrentUserID(c)),
+		zap.Uint64("userID", id))
+
+	common.ResponseSuccess(c)
+}
+
+// RestoreUser 恢复已删除的账户
+// @Summary 恢复已删除的账户
+// @Description 恢复回收站中的账户及随账户一起删除的简历，超过保留期的账户无法恢复
+// @Tags 管理
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} common.Response{data=UserResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/users/{id}/restore [post]
+// @Security BearerAuth
+func (h *AdminHandler) RestoreUser(c *gin.Context) {
+	// 获取用户ID
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	user, err := h.accountService.RestoreUser(uint(id))
+	if err != nil {
+		switch err {
+		case service.ErrDeletedUserNotFound:
+			common.ResponseWithError(c, common.CodeUserNotFound)
+		case service.ErrRestoreExpired:
+			common.ResponseWithCustomError(c, common.CodeOperationNotAllowed, err.Error())
+		default:
+			util.GetLogger().Error("恢复账户失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	util.GetLogger().Info("管理员恢复已删除账户",
+		zap.Uint("adminID", getCurrentUserID(c)),
+		zap.Uint64("userID", id))
+
+	common.ResponseWithData(c, toUserResponse(c, user))
+}
+
+// ReviewResumeRequest 简历审核请求
+type ReviewResumeRequest struct {
+	Status string `json:"status" binding:"required,oneof=pending approved rejected"`
+	Reason string `json:"reason" binding:"max=500"` // 审核意见，未通过时发送给简历所有者
+}
+
+// AdminResumeResponse 管理员审核时的简历响应，包含疑似重复的原简历
+type AdminResumeResponse struct {
+	ResumeResponse
+	DuplicateOfID     *uint  `json:"duplicate_of_id,omitempty"`    // 疑似重复的原简历ID
+	DuplicateOfLink   string `json:"duplicate_of_link,omitempty"`  // 原简历链接，供管理员比对
+	DuplicateKind     string `json:"duplicate_kind,omitempty"`     // 重复类型：exact文件完全相同，similar第一页相似
+	DuplicateDistance int    `json:"duplicate_distance,omitempty"` // 与原简历第一页感知哈希的汉明距离
+}
+
+// toAdminResumeResponse 转换为管理员审核时的简历响应
+func toAdminResumeResponse(c *gin.Context, resume *domain.Resume) AdminResumeResponse {
+	resp := AdminResumeResponse{
+		ResumeResponse:    toResumeResponse(c, resume),
+		DuplicateOfID:     resume.DuplicateOfID,
+		DuplicateKind:     resume.DuplicateKind,
+		DuplicateDistance: resume.DuplicateDistance,
+	}
+	if resume.DuplicateOfID != nil {
+		resp.DuplicateOfLink = fmt.Sprintf("/resumes/%d", *resume.DuplicateOfID)
+	}
+	return resp
+}
+
+// GetResumes 按审核状态分页获取简历
+// @Summary 按审核状态获取简历
+// @Description 按审核状态筛选简历，按提交时间正序，供管理员审核；疑似重复的简历附带原简历链接
+// @Tags 管理
+// @Produce json
+// @Param status query string false "审核状态(pending/approved/rejected)"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} common.Response
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/resumes [get]
+// @Security BearerAuth
+func (h *AdminHandler) GetResumes(c *gin.Context) {
+	page, size := GetPagingParams(c)
+
+	resumes, total, err := h.resumeService.GetResumesByStatus(c.Query("status"), page, size)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidResumeStatus:
+			common.ResponseWithCustomError(c, common.CodeInvalidParams, err.Error())
+		default:
+			util.GetLogger().Error("获取待审核简历失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	items := make([]AdminResumeResponse, 0, len(resumes))
+	for i := range resumes {
+		items = append(items, toAdminResumeResponse(c, &resumes[i]))
+	}
+
+	common.ResponseWithData(c, gin.H{
+		"items": items,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+// ReviewResume 审核简历
+// @Summary 审核简历
+// @Description 修改简历审核状态，审核通过或未通过时通知简历所有者
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "简历ID"
+// @Param data body ReviewResumeRequest true "审核结果"
+// @Success 200 {object} common.Response{data=AdminResumeResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/resumes/{id}/status [put]
+// @Security BearerAuth
+func (h *AdminHandler) ReviewResume(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	var req ReviewResumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	resume, err := h.resumeService.ReviewResume(uint(id), req.Status, req.Reason, getCurrentUserID(c))
+	if err != nil {
+		switch err {
+		case service.ErrResumeNotFound:
+			common.ResponseWithError(c, common.CodeDataNotFound)
+		case service.ErrInvalidResumeStatus:
+			common.ResponseWithCustomError(c, common.CodeInvalidParams, err.Error())
+		default:
+			util.GetLogger().Error("审核简历失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.ResponseWithData(c, toAdminResumeResponse(c, resume))
+}
+
+// ModerateCommentRequest 评论审核请求
+type ModerateCommentRequest struct {
+	Status string `json:"status" binding:"required,oneof=visible hidden"`
+}
+
+// GetComments 分页获取评论
+// @Summary 分页获取评论
+// @Description 按状态筛选评论，供管理员审核
+// @Tags 管理
+// @Produce json
+// @Param status query string false "评论状态(visible/hidden)"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} common.Response
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/comments [get]
+// @Security BearerAuth
+func (h *AdminHandler) GetComments(c *gin.Context) {
+	page, size := GetPagingParams(c)
+
+	comments, total, err := h.commentService.ListComments(page, size, c.Query("status"))
+	if err != nil {
+		switch err {
+		case service.ErrInvalidCommentStatus:
+			common.ResponseWithCustomError(c, common.CodeInvalidParams, err.Error())
+		default:
+			util.GetLogger().Error("获取评论列表失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	items := make([]CommentResponse, 0, len(comments))
+	for i := range comments {
+		items = append(items, toCommentResponse(&comments[i]))
+	}
+
+	common.ResponseWithData(c, gin.H{
+		"items": items,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+// ModerateComment 修改评论审核状态
+// @Summary 修改评论审核状态
+// @Description 隐藏或恢复评论，隐藏的评论内容仅作者本人可见
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "评论ID"
+// @Param data body ModerateCommentRequest true "审核状态"
+// @Success 200 {object} common.Response
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/comments/{id}/status [put]
+// @Security BearerAuth
+func (h *AdminHandler) ModerateComment(c *gin.Context) {
+	id, ok := parseCommentID(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	var req ModerateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	if err := h.commentService.ModerateComment(id, req.Status); err != nil {
+		h.handleCommentError(c, err)
+		return
+	}
+
+	util.GetLogger().Info("管理员审核评论",
+		zap.Uint("adminID", getCurrentUserID(c)),
+		zap.Uint("commentID", id),
+		zap.String("status", req.Status))
+
+	common.ResponseSuccess(c)
+}
+
+// RemoveComment 删除评论
+// @Summary 删除评论
+// @Tags 管理
+// @Produce json
+// @Param id path int true "评论ID"
+// @Success 200 {object} common.Response
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/comments/{id} [delete]
+// @Security BearerAuth
+func (h *AdminHandler) RemoveComment(c *gin.Context) {
+	id, ok := parseCommentID(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	if err := h.commentService.RemoveComment(id); err != nil {
+		h.handleCommentError(c, err)
+		return
+	}
+
+	util.GetLogger().Info("管理员删除评论",
+		zap.Uint("adminID", getCurrentUserID(c)),
+		zap.Uint("commentID", id))
+
+	common.ResponseSuccess(c)
+}
+
+// handleCommentError 将评论审核错误转换为响应
+func (h *AdminHandler) handleCommentError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrCommentNotFound:
+		common.ResponseWithError(c, common.CodeDataNotFound)
+	case service.ErrInvalidCommentStatus:
+		common.ResponseWithCustomError(c, common.CodeInvalidParams, err.Error())
+	default:
+		util.GetLogger().Error("评论审核失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+	}
+}
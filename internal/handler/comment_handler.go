@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CommentHandler 简历评论处理器
+type CommentHandler struct {
+	commentService service.CommentService
+}
+
+// NewCommentHandler 创建简历评论处理器
+func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// CreateCommentRequest 发表评论请求
+type CreateCommentRequest struct {
+	Content  string                `json:"content" binding:"required,max=2000"`
+	ParentID *uint                 `json:"parent_id"` // 回复的评论ID
+	Page     int                   `json:"page"`      // 锚定的页码，0表示针对整份简历
+	Region   *domain.CommentRegion `json:"region"`    // 锚定的图片区域
+}
+
+// UpdateCommentRequest 编辑评论请求
+type UpdateCommentRequest struct {
+	Content string `json:"content" binding:"required,max=2000"`
+}
+
+// CommentAuthorResponse 评论作者信息
+type CommentAuthorResponse struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	AvatarURL   string `json:"avatar_url"`
+}
+
+// CommentResponse 评论响应
+type CommentResponse struct {
+	ID            uint                   `json:"id"`
+	ResumeID      uint                   `json:"resume_id"`
+	ParentID      *uint                  `json:"parent_id"`
+	Author        *CommentAuthorResponse `json:"author"`          // 作者已注销或评论已删除时为空
+	IsResumeOwner bool                   `json:"is_resume_owner"` // 是否为简历所有者的回复
+	Content       string                 `json:"content"`
+	Page          int                    `json:"page"`
+	Region        *domain.CommentRegion  `json:"region"`
+	UpvoteCount   int                    `json:"upvote_count"`
+	Upvoted       bool                   `json:"upvoted"`
+	Hidden        bool                   `json:"hidden"`  // 被管理员隐藏
+	Deleted       bool                   `json:"deleted"` // 已删除，仅作为回复的占位
+	Edited        bool                   `json:"edited"`
+	CreatedAt     string                 `json:"created_at"`
+	Replies       []CommentResponse      `json:"replies"`
+}
+
+// toCommentResponse 转换为评论响应
+func toCommentResponse(comment *domain.Comment) CommentResponse {
+	return CommentResponse{
+		ID:          comment.ID,
+		ResumeID:    comment.ResumeID,
+		ParentID:    comment.ParentID,
+		Content:     comment.Content,
+		Page:        comment.Page,
+		Region:      comment.Region,
+		UpvoteCount: comment.UpvoteCount,
+		Hidden:      comment.Status == domain.CommentStatusHidden,
+		Edited:      comment.EditedAt != nil,
+		CreatedAt:   comment.CreatedAt.Format("2006-01-02 15:04:05"),
+		Replies:     []CommentResponse{},
+	}
+}
+
+// toCommentThreadResponse 转换评论楼层，隐藏和已删除的评论不返回内容
+func toCommentThreadResponse(c *gin.Context, thread *service.CommentThread, viewerID uint) CommentResponse {
+	resp := toCommentResponse(&thread.Comment)
+	resp.IsResumeOwner = thread.IsResumeOwner
+	resp.Upvoted = thread.Upvoted
+	resp.Deleted = thread.Deleted
+
+	if thread.Author != nil {
+		resp.Author = &CommentAuthorResponse{
+			Username:    thread.Author.Username,
+			DisplayName: thread.Author.DisplayName,
+			AvatarURL:   util.GetFileURL(c, thread.Author.AvatarURL, 0),
+		}
+	}
+	if thread.Deleted || (resp.Hidden && thread.Comment.UserID != viewerID) {
+		resp.Content = ""
+		resp.Region = nil
+	}
+	if thread.Deleted {
+		resp.Author = nil
+	}
+
+	for _, reply := range thread.Replies {
+		resp.Replies = append(resp.Replies, toCommentThreadResponse(c, reply, viewerID))
+	}
+	return resp
+}
+
+// parseCommentID 解析评论ID路径参数
+func parseCommentID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetComments 获取简历评论
+// @Summary 获取简历评论
+// @Description 按楼层返回简历的评论和回复
+// @Tags 评论
+// @Produce json
+// @Param id path int true "简历ID"
+// @Success 200 {object} common.Response{data=[]CommentResponse}
+// @Failure 400,500 {object} common.Response
+// @Router /api/v1/resumes/{id}/comments [get]
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	resumeID, ok := parseCommentID(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+
+	threads, err := h.commentService.GetResumeComments(resumeID, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	respList := make([]CommentResponse, 0, len(threads))
+	for _, thread := range threads {
+		respList = append(respList, toCommentThreadResponse(c, thread, userID))
+	}
+
+	common.ResponseWithData(c, respList)
+}
+
+// CreateComment 发表评论
+// @Summary 发表评论
+// @Description 评论简历或回复其他评论，可锚定到某一页的某个区域
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Param id path int true "简历ID"
+// @Param data body CreateCommentRequest true "评论内容"
+// @Success 200 {object} common.Response{data=CommentResponse}
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/resumes/{id}/comments [post]
+// @Security BearerAuth
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	resumeID, ok := parseCommentID(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	var req CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	comment, err := h.commentService.CreateComment(resumeID, userID, service.CommentInput{
+		Content:  req.Content,
+		ParentID: req.ParentID,
+		Page:     req.Page,
+		Region:   req.Region,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, toCommentResponse(comment))
+}
+
+// UpdateComment 编辑评论
+// @Summary 编辑评论
+// @Tags 评论
+// @Accept json
+// @Produce json
+// @Param id path int true "评论ID"
+// @Param data body UpdateCommentRequest true "评论内容"
+// @Success 200 {object} common.Response{data=CommentResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/comments/{id} [put]
+// @Security BearerAuth
+func (h *CommentHandler) UpdateComment(c *gin.Context) {
+	commentID, ok := parseCommentID(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	var req UpdateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	comment, err := h.commentService.UpdateComment(commentID, userID, req.Content)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, toCommentResponse(comment))
+}
+
+// DeleteComment 删除评论
+// @Summary 删除评论
+// @Tags 评论
+// @Produce json
+// @Param id path int true "评论ID"
+// @Success 200 {object} common.Response
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/comments/{id} [delete]
+// @Security BearerAuth
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	commentID, ok := parseCommentID(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.commentService.DeleteComment(commentID, userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c)
+}
+
+// Upvote 点赞评论
+// @Summary 点赞评论
+// @Tags 评论
+// @Produce json
+// @Param id path int true "评论ID"
+// @Success 200 {object} common.Response
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/comments/{id}/upvote [post]
+// @Security BearerAuth
+func (h *CommentHandler) Upvote(c *gin.Context) {
+	commentID, ok := parseCommentID(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.commentService.Upvote(commentID, userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c)
+}
+
+// RemoveUpvote 取消点赞
+// @Summary 取消点赞评论
+// @Tags 评论
+// @Produce json
+// @Param id path int true "评论ID"
+// @Success 200 {object} common.Response
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/comments/{id}/upvote [delete]
+// @Security BearerAuth
+func (h *CommentHandler) RemoveUpvote(c *gin.Context) {
+	commentID, ok := parseCommentID(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.commentService.RemoveUpvote(commentID, userID); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c)
+}
+
+// handleError 将评论相关错误转换为响应
+func (h *CommentHandler) handleError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrResumeNotFound, service.ErrCommentNotFound:
+		common.ResponseWithError(c, common.CodeDataNotFound)
+	case service.ErrNotCommentAuthor:
+		common.ResponseWithError(c, common.CodeForbidden, http.StatusForbidden)
+	case service.ErrInvalidCommentParent, service.ErrInvalidCommentAnchor:
+		common.ResponseWithCustomError(c, common.CodeInvalidParams, err.Error())
+	default:
+		util.GetLogger().Error("评论操作失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+	}
+}
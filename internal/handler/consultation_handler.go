@@ -0,0 +1,479 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ConsultationHandler 付费咨询处理器
+type ConsultationHandler struct {
+	consultationService service.ConsultationService
+}
+
+// NewConsultationHandler 创建付费咨询处理器
+func NewConsultationHandler(consultationService service.ConsultationService) *ConsultationHandler {
+	return &ConsultationHandler{
+		consultationService: consultationService,
+	}
+}
+
+// CreateConsultationRequest 发起咨询请求
+type CreateConsultationRequest struct {
+	Question string `json:"question" binding:"required,max=2000"`
+}
+
+// AcceptConsultationRequest 接受咨询请求
+type AcceptConsultationRequest struct {
+	Price int64 `json:"price" binding:"required,gt=0"` // 报价，单位为分
+}
+
+// CloseConsultationRequest 拒绝或取消咨询请求
+type CloseConsultationRequest struct {
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// ConsultationMessageRequest 咨询留言请求
+type ConsultationMessageRequest struct {
+	Content string `json:"content" binding:"required,max=5000"`
+}
+
+// ConsultationResponse 咨询响应
+type ConsultationResponse struct {
+	ID            uint                          `json:"id"`
+	ResumeID      uint                          `json:"resume_id"`
+	AskerID       uint                          `json:"asker_id"`
+	OwnerID       uint                          `json:"owner_id"`
+	Question      string                        `json:"question"`
+	Price         int64                         `json:"price"` // 单位为分
+	Currency      string                        `json:"currency"`
+	Status        string                        `json:"status"`
+	CloseReason   string                        `json:"close_reason,omitempty"`
+	PaymentMethod string                        `json:"payment_method,omitempty"`
+	AcceptedAt    *time.Time                    `json:"accepted_at,omitempty"`
+	PaidAt        *time.Time                    `json:"paid_at,omitempty"`
+	AnsweredAt    *time.Time                    `json:"answered_at,omitempty"`
+	ClosedAt      *time.Time                    `json:"closed_at,omitempty"`
+	RefundedAt    *time.Time                    `json:"refunded_at,omitempty"`
+	CreatedAt     string                        `json:"created_at"`
+	UpdatedAt     string                        `json:"updated_at"`
+	Messages      []ConsultationMessageResponse `json:"messages,omitempty"`
+}
+
+// ConsultationMessageResponse 咨询留言响应
+type ConsultationMessageResponse struct {
+	ID        uint   `json:"id"`
+	SenderID  uint   `json:"sender_id"`
+	Content   string `json:"content"`
+	CreatedAt string `json:"created_at"`
+}
+
+// toConsultationResponse 转换为咨询响应
+func toConsultationResponse(consultation *domain.Consultation) ConsultationResponse {
+	return ConsultationResponse{
+		ID:            consultation.ID,
+		ResumeID:      consultation.ResumeID,
+		AskerID:       consultation.AskerID,
+		OwnerID:       consultation.OwnerID,
+		Question:      consultation.Question,
+		Price:         consultation.Price,
+		Currency:      consultation.Currency,
+		Status:        consultation.Status,
+		CloseReason:   consultation.CloseReason,
+		PaymentMethod: consultation.PaymentMethod,
+		AcceptedAt:    consultation.AcceptedAt,
+		PaidAt:        consultation.PaidAt,
+		AnsweredAt:    consultation.AnsweredAt,
+		ClosedAt:      consultation.ClosedAt,
+		RefundedAt:    consultation.RefundedAt,
+		CreatedAt:     consultation.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     consultation.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// toConsultationMessageResponse 转换为咨询留言响应
+func toConsultationMessageResponse(message *domain.ConsultationMessage) ConsultationMessageResponse {
+	return ConsultationMessageResponse{
+		ID:        message.ID,
+		SenderID:  message.SenderID,
+		Content:   message.Content,
+		CreatedAt: message.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// parseConsultationRequest 解析路径中的ID并获取当前用户，失败时已写入响应
+func parseConsultationRequest(c *gin.Context) (uint, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return 0, 0, false
+	}
+
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	return uint(id), userID, true
+}
+
+// CreateConsultation 发起咨询
+// @Summary 发起付费咨询
+// @Description 向公开简历的所有者提问，等待所有者报价
+// @Tags 咨询
+// @Accept json
+// @Produce json
+// @Param id path int true "简历ID"
+// @Param data body CreateConsultationRequest true "咨询问题"
+// @Success 200 {object} common.Response{data=ConsultationResponse}
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/resumes/{id}/consultations [post]
+// @Security BearerAuth
+func (h *ConsultationHandler) CreateConsultation(c *gin.Context) {
+	resumeID, userID, ok := parseConsultationRequest(c)
+	if !ok {
+		return
+	}
+
+	var req CreateConsultationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	consultation, err := h.consultationService.CreateConsultation(resumeID, userID, req.Question)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, toConsultationResponse(consultation))
+}
+
+// GetMyConsultations 获取我的咨询
+// @Summary 获取我的咨询
+// @Description 分页获取当前用户发起或收到的咨询
+// @Tags 咨询
+// @Produce json
+// @Param role query string false "asker（我发起的）或 owner（我收到的），为空时返回全部"
+// @Param status query string false "咨询状态"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} common.Response
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/me/consultations [get]
+// @Security BearerAuth
+func (h *ConsultationHandler) GetMyConsultations(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	role := c.Query("role")
+	if role != "" && role != repository.ConsultationRoleAsker && role != repository.ConsultationRoleOwner {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	page, size := GetPagingParams(c)
+
+	consultations, total, err := h.consultationService.GetUserConsultations(userID, role, c.Query("status"), page, size)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	items := make([]ConsultationResponse, 0, len(consultations))
+	for i := range consultations {
+		items = append(items, toConsultationResponse(&consultations[i]))
+	}
+
+	common.ResponseWithData(c, gin.H{
+		"items": items,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+// GetConsultation 获取咨询详情
+// @Summary 获取咨询详情
+// @Description 获取咨询及全部留言，仅咨询双方可见
+// @Tags 咨询
+// @Produce json
+// @Param id path int true "咨询ID"
+// @Success 200 {object} common.Response{data=ConsultationResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/consultations/{id} [get]
+// @Security BearerAuth
+func (h *ConsultationHandler) GetConsultation(c *gin.Context) {
+	id, userID, ok := parseConsultationRequest(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.consultationService.GetConsultation(id, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	resp := toConsultationResponse(&detail.Consultation)
+	resp.Messages = make([]ConsultationMessageResponse, 0, len(detail.Messages))
+	for i := range detail.Messages {
+		resp.Messages = append(resp.Messages, toConsultationMessageResponse(&detail.Messages[i]))
+	}
+
+	common.ResponseWithData(c, resp)
+}
+
+// AcceptConsultation 接受咨询并报价
+// @Summary 接受咨询并报价
+// @Tags 咨询
+// @Accept json
+// @Produce json
+// @Param id path int true "咨询ID"
+// @Param data body AcceptConsultationRequest true "报价"
+// @Success 200 {object} common.Response{data=ConsultationResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/consultations/{id}/accept [post]
+// @Security BearerAuth
+func (h *ConsultationHandler) AcceptConsultation(c *gin.Context) {
+	id, userID, ok := parseConsultationRequest(c)
+	if !ok {
+		return
+	}
+
+	var req AcceptConsultationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	consultation, err := h.consultationService.AcceptConsultation(id, userID, req.Price)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, toConsultationResponse(consultation))
+}
+
+// DeclineConsultation 拒绝咨询
+// @Summary 拒绝咨询
+// @Tags 咨询
+// @Accept json
+// @Produce json
+// @Param id path int true "咨询ID"
+// @Param data body CloseConsultationRequest false "拒绝原因"
+// @Success 200 {object} common.Response{data=ConsultationResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/consultations/{id}/decline [post]
+// @Security BearerAuth
+func (h *ConsultationHandler) DeclineConsultation(c *gin.Context) {
+	id, userID, ok := parseConsultationRequest(c)
+	if !ok {
+		return
+	}
+
+	var req CloseConsultationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	consultation, err := h.consultationService.DeclineConsultation(id, userID, req.Reason)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, toConsultationResponse(consultation))
+}
+
+// CancelConsultation 取消咨询
+// @Summary 取消咨询
+// @Description 提问者在付款前取消咨询
+// @Tags 咨询
+// @Accept json
+// @Produce json
+// @Param id path int true "咨询ID"
+// @Param data body CloseConsultationRequest false "取消原因"
+// @Success 200 {object} common.Response{data=ConsultationResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/consultations/{id}/cancel [post]
+// @Security BearerAuth
+func (h *ConsultationHandler) CancelConsultation(c *gin.Context) {
+	id, userID, ok := parseConsultationRequest(c)
+	if !ok {
+		return
+	}
+
+	var req CloseConsultationRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	consultation, err := h.consultationService.CancelConsultation(id, userID, req.Reason)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, toConsultationResponse(consultation))
+}
+
+// PayConsultation 为咨询付款
+// @Summary 为咨询付款
+// @Tags 咨询
+// @Produce json
+// @Param id path int true "咨询ID"
+// @Success 200 {object} common.Response{data=ConsultationResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/consultations/{id}/pay [post]
+// @Security BearerAuth
+func (h *ConsultationHandler) PayConsultation(c *gin.Context) {
+	id, userID, ok := parseConsultationRequest(c)
+	if !ok {
+		return
+	}
+
+	consultation, err := h.consultationService.PayConsultation(c.Request.Context(), id, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.GetLogger().Info("咨询付款成功",
+		zap.Uint("consultationID", id),
+		zap.Uint("userID", userID),
+		zap.Int64("amount", consultation.Price))
+
+	common.ResponseWithData(c, toConsultationResponse(consultation))
+}
+
+// PostMessage 咨询留言
+// @Summary 咨询留言
+// @Description 付款后双方可留言，简历所有者的首次留言视为已回答
+// @Tags 咨询
+// @Accept json
+// @Produce json
+// @Param id path int true "咨询ID"
+// @Param data body ConsultationMessageRequest true "留言内容"
+// @Success 200 {object} common.Response{data=ConsultationMessageResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/consultations/{id}/messages [post]
+// @Security BearerAuth
+func (h *ConsultationHandler) PostMessage(c *gin.Context) {
+	id, userID, ok := parseConsultationRequest(c)
+	if !ok {
+		return
+	}
+
+	var req ConsultationMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	message, err := h.consultationService.PostMessage(id, userID, req.Content)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, toConsultationMessageResponse(message))
+}
+
+// CloseConsultation 确认回答并结束咨询
+// @Summary 结束咨询
+// @Description 提问者确认已获解答，结束咨询
+// @Tags 咨询
+// @Produce json
+// @Param id path int true "咨询ID"
+// @Success 200 {object} common.Response{data=ConsultationResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/consultations/{id}/close [post]
+// @Security BearerAuth
+func (h *ConsultationHandler) CloseConsultation(c *gin.Context) {
+	id, userID, ok := parseConsultationRequest(c)
+	if !ok {
+		return
+	}
+
+	consultation, err := h.consultationService.CloseConsultation(id, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, toConsultationResponse(consultation))
+}
+
+// RefundConsultation 咨询退款
+// @Summary 咨询退款
+// @Description 简历所有者可在结束前退款，提问者仅可在未获回答时退款
+// @Tags 咨询
+// @Produce json
+// @Param id path int true "咨询ID"
+// @Success 200 {object} common.Response{data=ConsultationResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/consultations/{id}/refund [post]
+// @Security BearerAuth
+func (h *ConsultationHandler) RefundConsultation(c *gin.Context) {
+	id, userID, ok := parseConsultationRequest(c)
+	if !ok {
+		return
+	}
+
+	consultation, err := h.consultationService.RefundConsultation(c.Request.Context(), id, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	util.GetLogger().Info("咨询已退款",
+		zap.Uint("consultationID", id),
+		zap.Uint("userID", userID),
+		zap.Int64("amount", consultation.Price))
+
+	common.ResponseWithData(c, toConsultationResponse(consultation))
+}
+
+// handleError 将咨询相关错误转换为响应
+func (h *ConsultationHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrResumeNotFound), errors.Is(err, service.ErrConsultationNotFound):
+		common.ResponseWithError(c, common.CodeDataNotFound)
+	case errors.Is(err, service.ErrNotConsultationParticipant):
+		common.ResponseWithError(c, common.CodeForbidden, http.StatusForbidden)
+	case errors.Is(err, service.ErrConsultOwnResume):
+		common.ResponseWithCustomError(c, common.CodeOperationNotAllowed, err.Error())
+	case errors.Is(err, service.ErrInvalidConsultationState):
+		common.ResponseWithCustomError(c, common.CodeInvalidState, err.Error())
+	case errors.Is(err, service.ErrInvalidConsultationPrice):
+		common.ResponseWithCustomError(c, common.CodeInvalidParams, err.Error())
+	case errors.Is(err, service.ErrConsultationPaymentRequired):
+		common.ResponseWithError(c, common.CodePaymentRequired, http.StatusPaymentRequired)
+	case errors.Is(err, service.ErrInsufficientBalance):
+		common.ResponseWithError(c, common.CodeInsufficientBalance, http.StatusPaymentRequired)
+	case errors.Is(err, service.ErrPaymentFailed), errors.Is(err, service.ErrRefundFailed):
+		util.GetLogger().Warn("咨询支付失败", zap.Error(err))
+		common.ResponseWithCustomError(c, common.CodeBillingError, err.Error())
+	default:
+		util.GetLogger().Error("咨询操作失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// FavoriteHandler 简历收藏处理器
+type FavoriteHandler struct {
+	favoriteService service.FavoriteService
+}
+
+// NewFavoriteHandler 创建简历收藏处理器
+func NewFavoriteHandler(favoriteService service.FavoriteService) *FavoriteHandler {
+	return &FavoriteHandler{
+		favoriteService: favoriteService,
+	}
+}
+
+// AddFavorite 收藏简历
+// @Summary 收藏简历
+// @Tags 简历
+// @Produce json
+// @Param id path int true "简历ID"
+// @Success 200 {object} common.Response
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/resumes/{id}/favorite [post]
+// @Security BearerAuth
+func (h *FavoriteHandler) AddFavorite(c *gin.Context) {
+	// 获取简历ID
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	// 获取当前用户ID
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.favoriteService.AddFavorite(userID, uint(id)); err != nil {
+		switch err {
+		case service.ErrResumeNotFound:
+			common.ResponseWithError(c, common.CodeDataNotFound)
+		default:
+			util.GetLogger().Error("收藏简历失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.ResponseSuccess(c)
+}
+
+// RemoveFavorite 取消收藏简历
+// @Summary 取消收藏简历
+// @Tags 简历
+// @Produce json
+// @Param id path int true "简历ID"
+// @Success 200 {object} common.Response
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/resumes/{id}/favorite [delete]
+// @Security BearerAuth
+func (h *FavoriteHandler) RemoveFavorite(c *gin.Context) {
+	// 获取简历ID
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	// 获取当前用户ID
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.favoriteService.RemoveFavorite(userID, uint(id)); err != nil {
+		util.GetLogger().Error("取消收藏失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseSuccess(c)
+}
+
+// GetMyFavorites 获取当前用户收藏的简历
+// @Summary 获取我的收藏
+// @Description 按收藏时间倒序返回当前用户收藏的简历
+// @Tags 简历
+// @Produce json
+// @Param page query int false "页码，默认1"
+// @Param size query int false "每页数量，默认10"
+// @Success 200 {object} common.Response{data=[]ResumeResponse}
+// @Failure 401,500 {object} common.Response
+// @Router /api/v1/me/favorites [get]
+// @Security BearerAuth
+func (h *FavoriteHandler) GetMyFavorites(c *gin.Context) {
+	// 获取当前用户ID
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	// 获取分页参数
+	page, size := GetPagingParams(c)
+
+	resumes, total, err := h.favoriteService.GetUserFavorites(userID, page, size)
+	if err != nil {
+		util.GetLogger().Error("获取收藏列表失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	// 转换为响应结构
+	respList := make([]ResumeResponse, 0, len(resumes))
+	for _, resume := range resumes {
+		resp := toResumeResponse(c, &resume)
+		resp.IsFavorited = true
+		respList = append(respList, resp)
+	}
+
+	// 构建分页响应
+	common.ResponseWithData(c, gin.H{
+		"items": respList,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
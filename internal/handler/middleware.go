@@ -2,8 +2,14 @@ package handler
 
 import (
 	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/service"
 	"codefolio/internal/util"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -60,65 +66,199 @@ func CORSMiddleware() gin.HandlerFunc {
 }
 
 // AuthMiddleware JWT认证中间件
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// 账户已删除时令牌立即失效，已申请注销的账户在撤销申请前不能访问
+func AuthMiddleware(jwtSecret string, userService service.UserService) gin.HandlerFunc {
+	return authMiddleware(jwtSecret, userService, false)
+}
+
+// PendingDeletionAuth 允许注销冷静期内的账户访问的认证中间件
+// 仅用于查看账户状态和撤销注销申请等接口
+func PendingDeletionAuth(jwtSecret string, userService service.UserService) gin.HandlerFunc {
+	return authMiddleware(jwtSecret, userService, true)
+}
+
+// authMiddleware 校验令牌和账户状态，allowPending为true时放行冷静期内的账户
+func authMiddleware(jwtSecret string, userService service.UserService, allowPending bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 获取Authorization头
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
+		claims, user, err := authenticate(c, jwtSecret, userService)
+		if err != nil {
+			if err != errMissingToken {
+				util.GetLogger().Error("JWT认证失败", zap.Error(err))
+			}
 			common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
 			c.Abort()
 			return
 		}
-
-		// 提取令牌
-		parts := strings.SplitN(authHeader, " ", 2)
-		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		if user.DeletionScheduledAt != nil && !allowPending {
+			common.ResponseWithError(c, common.CodeAccountPendingDeletion, http.StatusForbidden)
 			c.Abort()
 			return
 		}
 
-		// 解析JWT
-		tokenString := parts[1]
-		token, err := jwt.ParseWithClaims(tokenString, &AuthClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
+		// 将用户ID设置到上下文
+		c.Set("userID", claims.UserID)
+		c.Next()
+	}
+}
+
+// QueryTokenAuth 允许通过access_token查询参数传递令牌，需在AuthMiddleware之前使用
+// 仅用于浏览器EventSource等无法设置请求头的场景
+func QueryTokenAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query("access_token"); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
 
-		// 检查解析错误
+// OptionalAuth 可选认证中间件
+// 携带有效令牌时将用户ID写入上下文，未携带或令牌无效时按匿名用户继续处理
+// 已删除或已申请注销的账户同样按匿名用户处理
+func OptionalAuth(jwtSecret string, userService service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, user, err := authenticate(c, jwtSecret, userService)
+		if err == nil && user.DeletionScheduledAt != nil {
+			err = errAccountPendingDeletion
+		}
 		if err != nil {
-			util.GetLogger().Error("JWT解析失败", zap.Error(err))
-			common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
-			c.Abort()
+			if err != errMissingToken {
+				util.GetLogger().Debug("可选认证令牌无效，按匿名用户处理", zap.Error(err))
+			}
+			c.Next()
 			return
 		}
 
-		// 检查令牌有效性
-		if !token.Valid {
-			util.GetLogger().Error("无效的JWT")
+		c.Set("userID", claims.UserID)
+		c.Next()
+	}
+}
+
+// AdminMiddleware 管理员权限中间件，需在AuthMiddleware之后使用
+func AdminMiddleware(userService service.UserService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := getCurrentUserID(c)
+		if userID == 0 {
 			common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
 			c.Abort()
 			return
 		}
 
-		// 验证令牌是否过期
-		claims, ok := token.Claims.(*AuthClaims)
-		if !ok {
-			util.GetLogger().Error("JWT声明类型无效")
-			common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		user, err := userService.GetUserByID(userID)
+		if err != nil || !user.IsAdmin {
+			common.ResponseWithError(c, common.CodePermissionDenied, http.StatusForbidden)
 			c.Abort()
 			return
 		}
 
-		// 检查令牌是否过期
-		if claims.ExpiresAt < time.Now().Unix() {
-			util.GetLogger().Error("JWT已过期")
-			common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		c.Next()
+	}
+}
+
+// RateLimitMiddleware 请求限流中间件
+// 已登录用户按用户ID限流，匿名用户按客户端IP限流；需放在认证中间件之后才能识别用户
+// 客户端IP只采信SERVER_TRUSTED_PROXIES中代理转发的X-Forwarded-For，未配置时使用连接地址
+func RateLimitMiddleware(store util.RateLimitStore, policy util.RateLimitPolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !policy.Enabled() {
+			c.Next()
+			return
+		}
+
+		key := policy.Name + ":ip:" + c.ClientIP()
+		if userID := getCurrentUserID(c); userID != 0 {
+			key = fmt.Sprintf("%s:user:%d", policy.Name, userID)
+		}
+
+		result, err := store.Take(c.Request.Context(), key, policy)
+		if err != nil {
+			// 限流存储故障时放行，避免影响正常业务
+			util.GetLogger().Error("限流检查失败", zap.Error(err), zap.String("key", key))
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))))
+
+		if !result.Allowed {
+			setRetryAfter(c, result.RetryAfter)
+			common.ResponseWithError(c, common.CodeRateLimited, http.StatusTooManyRequests)
 			c.Abort()
 			return
 		}
 
-		// 将用户ID设置到上下文
-		c.Set("userID", claims.UserID)
 		c.Next()
 	}
 }
+
+// 令牌解析相关错误
+var (
+	errMissingToken  = errors.New("缺少认证令牌")
+	errInvalidHeader = errors.New("认证头格式错误")
+	errInvalidToken  = errors.New("无效的JWT")
+	errTokenExpired  = errors.New("JWT已过期")
+
+	errAccountDeleted         = errors.New("账户已删除")
+	errAccountPendingDeletion = errors.New("账户注销中")
+)
+
+// authenticate 解析令牌并查询对应的账户
+// 账户注销后被软删除或永久删除时查询不到，令牌随之失效
+func authenticate(c *gin.Context, jwtSecret string, userService service.UserService) (*AuthClaims, *domain.User, error) {
+	claims, err := parseAuthClaims(c, jwtSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	userID, err := strconv.ParseUint(claims.UserID, 10, 32)
+	if err != nil {
+		return nil, nil, errInvalidToken
+	}
+	user, err := userService.GetUserByID(uint(userID))
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, nil, errAccountDeleted
+		}
+		return nil, nil, err
+	}
+	return claims, user, nil
+}
+
+// parseAuthClaims 从Authorization头中解析并校验JWT声明
+func parseAuthClaims(c *gin.Context, jwtSecret string) (*AuthClaims, error) {
+	// 获取Authorization头
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		return nil, errMissingToken
+	}
+
+	// 提取令牌
+	parts := strings.SplitN(authHeader, " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		return nil, errInvalidHeader
+	}
+
+	// 解析JWT
+	token, err := jwt.ParseWithClaims(parts[1], &AuthClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(jwtSecret), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查令牌有效性
+	claims, ok := token.Claims.(*AuthClaims)
+	if !token.Valid || !ok {
+		return nil, errInvalidToken
+	}
+
+	// 检查令牌是否过期
+	if claims.ExpiresAt < time.Now().Unix() {
+		return nil, errTokenExpired
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// NotificationHandler 站内通知处理器
+type NotificationHandler struct {
+	notificationService service.NotificationService
+}
+
+// NewNotificationHandler 创建站内通知处理器
+func NewNotificationHandler(notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// NotificationResponse 通知响应
+type NotificationResponse struct {
+	ID        uint       `json:"id"`
+	Type      string     `json:"type"`
+	ActorID   uint       `json:"actor_id,omitempty"`
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	Link      string     `json:"link"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt string     `json:"created_at"`
+}
+
+// NotificationPreferenceItem 单个通知类型的偏好
+type NotificationPreferenceItem struct {
+	Type  string `json:"type" binding:"required"`
+	InApp bool   `json:"in_app"` // 是否接收站内通知
+	Email bool   `json:"email"`  // 是否包含在邮件摘要中
+}
+
+// UpdateNotificationPreferencesRequest 更新通知偏好请求
+type UpdateNotificationPreferencesRequest struct {
+	Items []NotificationPreferenceItem `json:"items" binding:"required,dive"`
+}
+
+// toNotificationResponse 转换为通知响应
+func toNotificationResponse(notification *domain.Notification) NotificationResponse {
+	return NotificationResponse{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		ActorID:   notification.ActorID,
+		Title:     notification.Title,
+		Content:   notification.Content,
+		Link:      notification.Link,
+		Read:      notification.ReadAt != nil,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// toNotificationPreferenceItems 转换为通知偏好响应
+func toNotificationPreferenceItems(prefs []domain.NotificationPreference) []NotificationPreferenceItem {
+	items := make([]NotificationPreferenceItem, 0, len(prefs))
+	for _, pref := range prefs {
+		items = append(items, NotificationPreferenceItem{
+			Type:  pref.Type,
+			InApp: pref.InApp,
+			Email: pref.Email,
+		})
+	}
+	return items
+}
+
+// GetNotifications 获取我的通知
+// @Summary 获取我的通知
+// @Description 分页获取当前用户的通知，并返回未读数量
+// @Tags 通知
+// @Produce json
+// @Param unread query bool false "仅返回未读通知"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} common.Response
+// @Failure 401,500 {object} common.Response
+// @Router /api/v1/me/notifications [get]
+// @Security BearerAuth
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	page, size := GetPagingParams(c)
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+
+	notifications, total, err := h.notificationService.GetNotifications(userID, unreadOnly, page, size)
+	if err != nil {
+		util.GetLogger().Error("获取通知失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	unreadCount, err := h.notificationService.CountUnread(userID)
+	if err != nil {
+		util.GetLogger().Error("获取未读通知数失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]NotificationResponse, 0, len(notifications))
+	for i := range notifications {
+		items = append(items, toNotificationResponse(&notifications[i]))
+	}
+
+	common.ResponseWithData(c, gin.H{
+		"items":        items,
+		"total":        total,
+		"page":         page,
+		"size":         size,
+		"unread_count": unreadCount,
+	})
+}
+
+// GetUnreadCount 获取未读通知数
+// @Summary 获取未读通知数
+// @Tags 通知
+// @Produce json
+// @Success 200 {object} common.Response
+// @Failure 401,500 {object} common.Response
+// @Router /api/v1/me/notifications/unread-count [get]
+// @Security BearerAuth
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	count, err := h.notificationService.CountUnread(userID)
+	if err != nil {
+		util.GetLogger().Error("获取未读通知数失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseWithData(c, gin.H{"unread_count": count})
+}
+
+// MarkRead 标记通知为已读
+// @Summary 标记通知为已读
+// @Tags 通知
+// @Produce json
+// @Param id path int true "通知ID"
+// @Success 200 {object} common.Response
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/me/notifications/{id}/read [post]
+// @Security BearerAuth
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.notificationService.MarkRead(userID, uint(id)); err != nil {
+		switch err {
+		case service.ErrNotificationNotFound:
+			common.ResponseWithError(c, common.CodeDataNotFound)
+		default:
+			util.GetLogger().Error("标记通知已读失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.ResponseSuccess(c)
+}
+
+// MarkAllRead 标记全部通知为已读
+// @Summary 标记全部通知为已读
+// @Tags 通知
+// @Produce json
+// @Success 200 {object} common.Response
+// @Failure 401,500 {object} common.Response
+// @Router /api/v1/me/notifications/read-all [post]
+// @Security BearerAuth
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	count, err := h.notificationService.MarkAllRead(userID)
+	if err != nil {
+		util.GetLogger().Error("标记全部通知已读失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseWithData(c, gin.H{"marked": count})
+}
+
+// GetPreferences 获取通知偏好
+// @Summary 获取通知偏好
+// @Description 返回每种通知类型是否接收站内通知以及是否包含在邮件摘要中
+// @Tags 通知
+// @Produce json
+// @Success 200 {object} common.Response{data=[]NotificationPreferenceItem}
+// @Failure 401,500 {object} common.Response
+// @Router /api/v1/me/notifications/preferences [get]
+// @Security BearerAuth
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	prefs, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		util.GetLogger().Error("获取通知偏好失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseWithData(c, toNotificationPreferenceItems(prefs))
+}
+
+// UpdatePreferences 更新通知偏好
+// @Summary 更新通知偏好
+// @Description 按类型更新通知偏好，未提交的类型保持不变
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Param data body UpdateNotificationPreferencesRequest true "通知偏好"
+// @Success 200 {object} common.Response{data=[]NotificationPreferenceItem}
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/me/notifications/preferences [put]
+// @Security BearerAuth
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	prefs := make([]domain.NotificationPreference, 0, len(req.Items))
+	for _, item := range req.Items {
+		prefs = append(prefs, domain.NotificationPreference{
+			Type:  item.Type,
+			InApp: item.InApp,
+			Email: item.Email,
+		})
+	}
+
+	updated, err := h.notificationService.UpdatePreferences(userID, prefs)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidNotificationType:
+			common.ResponseWithCustomError(c, common.CodeInvalidParams, err.Error())
+		default:
+			util.GetLogger().Error("更新通知偏好失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.ResponseWithData(c, toNotificationPreferenceItems(updated))
+}
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// oauthNonceCookie 保存登录和绑定流程nonce的Cookie名称，回调时与state比对
+const oauthNonceCookie = "oauth_nonce"
+
+// setOAuthNonce 将nonce写入只在回调接口发送的Cookie
+func setOAuthNonce(c *gin.Context, nonce string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oauthNonceCookie, nonce, 600, "/api/v1/oauth", "", c.Request.TLS != nil, true)
+}
+
+// OAuthHandler 第三方登录处理器
+type OAuthHandler struct {
+	oauthService service.OAuthService
+	// 登录成功后跳转的前端地址，为空时直接返回JSON
+	successRedirect string
+}
+
+// NewOAuthHandler 创建第三方登录处理器
+func NewOAuthHandler(oauthService service.OAuthService, successRedirect string) *OAuthHandler {
+	return &OAuthHandler{
+		oauthService:    oauthService,
+		successRedirect: successRedirect,
+	}
+}
+
+// IdentityResponse 第三方身份响应
+type IdentityResponse struct {
+	Provider  string `json:"provider"`
+	Login     string `json:"login"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+}
+
+// OAuthLinkResponse 绑定第三方账户响应
+type OAuthLinkResponse struct {
+	URL string `json:"url"` // 第三方授权地址，前端需跳转至该地址
+}
+
+// GetProviders 获取已启用的第三方登录方式
+// @Summary 获取第三方登录方式
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} common.Response{data=[]string}
+// @Router /api/v1/oauth/providers [get]
+func (h *OAuthHandler) GetProviders(c *gin.Context) {
+	common.ResponseWithData(c, h.oauthService.Providers())
+}
+
+// Login 跳转到第三方授权页面
+// @Summary 第三方登录
+// @Description 重定向到第三方提供方的授权页面
+// @Tags 用户
+// @Param provider path string true "提供方名称"
+// @Success 302
+// @Failure 404 {object} common.Response
+// @Router /api/v1/oauth/{provider}/login [get]
+func (h *OAuthHandler) Login(c *gin.Context) {
+	authURL, nonce, err := h.oauthService.BeginLogin(c.Param("provider"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	setOAuthNonce(c, nonce)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 第三方授权回调
+// @Summary 第三方登录回调
+// @Description 完成第三方登录或账户绑定。登录返回登录令牌；绑定只返回用户信息，不签发新令牌。回调必须来自发起流程的浏览器（携带oauth_nonce Cookie）
+// @Tags 用户
+// @Produce json
+// @Param provider path string true "提供方名称"
+// @Param code query string true "授权码"
+// @Param state query string true "授权状态"
+// @Success 200 {object} common.Response{data=AuthResponse} "登录成功；绑定成功时data为UserResponse"
+// @Failure 400,404,500 {object} common.Response
+// @Router /api/v1/oauth/{provider}/callback [get]
+func (h *OAuthHandler) Callback(c *gin.Context) {
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" || state == "" {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	nonce, _ := c.Cookie(oauthNonceCookie)
+	c.SetCookie(oauthNonceCookie, "", -1, "/api/v1/oauth", "", c.Request.TLS != nil, true)
+
+	result, err := h.oauthService.HandleCallback(c.Request.Context(), c.Param("provider"), code, state, nonce)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	if result.Linked {
+		if h.successRedirect != "" {
+			c.Redirect(http.StatusFound, h.successRedirect+"#"+url.Values{"linked": {c.Param("provider")}}.Encode())
+			return
+		}
+		common.ResponseWithData(c, toUserResponse(c, result.User))
+		return
+	}
+
+	if h.successRedirect != "" {
+		c.Redirect(http.StatusFound, h.successRedirect+"#"+url.Values{"token": {result.Token}}.Encode())
+		return
+	}
+
+	common.ResponseWithData(c, AuthResponse{
+		Token: result.Token,
+		User:  toUserResponse(c, result.User),
+	})
+}
+
+// GetIdentities 获取当前用户绑定的第三方账户
+// @Summary 获取已绑定的第三方账户
+// @Tags 用户
+// @Produce json
+// @Success 200 {object} common.Response{data=[]IdentityResponse}
+// @Failure 401,500 {object} common.Response
+// @Router /api/v1/me/identities [get]
+// @Security BearerAuth
+func (h *OAuthHandler) GetIdentities(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := h.oauthService.ListIdentities(userID)
+	if err != nil {
+		util.GetLogger().Error("获取第三方账户失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	respList := make([]IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		respList = append(respList, IdentityResponse{
+			Provider:  identity.Provider,
+			Login:     identity.Login,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	common.ResponseWithData(c, respList)
+}
+
+// LinkIdentity 开始绑定第三方账户
+// @Summary 绑定第三方账户
+// @Description 返回第三方授权地址并设置oauth_nonce Cookie，需在同一浏览器中完成授权，回调接口会将账户绑定到当前用户
+// @Tags 用户
+// @Produce json
+// @Param provider path string true "提供方名称"
+// @Success 200 {object} common.Response{data=OAuthLinkResponse}
+// @Failure 401,404 {object} common.Response
+// @Router /api/v1/me/identities/{provider} [post]
+// @Security BearerAuth
+func (h *OAuthHandler) LinkIdentity(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	authURL, nonce, err := h.oauthService.BeginLink(c.Param("provider"), userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	setOAuthNonce(c, nonce)
+	common.ResponseWithData(c, OAuthLinkResponse{URL: authURL})
+}
+
+// UnlinkIdentity 解绑第三方账户
+// @Summary 解绑第三方账户
+// @Tags 用户
+// @Produce json
+// @Param provider path string true "提供方名称"
+// @Success 200 {object} common.Response
+// @Failure 401,404,500 {object} common.Response
+// @Router /api/v1/me/identities/{provider} [delete]
+// @Security BearerAuth
+func (h *OAuthHandler) UnlinkIdentity(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.oauthService.Unlink(userID, c.Param("provider")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	common.ResponseSuccess(c)
+}
+
+// handleError 将第三方登录相关错误转换为响应
+func (h *OAuthHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOAuthProviderNotFound):
+		common.ResponseWithError(c, common.CodeNotFound, http.StatusNotFound)
+	case errors.Is(err, service.ErrOAuthInvalidState):
+		common.ResponseWithError(c, common.CodeInvalidToken, http.StatusBadRequest)
+	case errors.Is(err, service.ErrOAuthExchangeFailed):
+		util.GetLogger().Warn("第三方登录授权失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeAuthProviderError, http.StatusBadGateway)
+	case errors.Is(err, service.ErrOAuthEmailConflict):
+		common.ResponseWithCustomError(c, common.CodeUserAlreadyExists, err.Error())
+	case errors.Is(err, service.ErrIdentityAlreadyLinked):
+		common.ResponseWithCustomError(c, common.CodeDataAlreadyExists, err.Error())
+	case errors.Is(err, service.ErrIdentityNotFound):
+		common.ResponseWithError(c, common.CodeDataNotFound)
+	case errors.Is(err, service.ErrLastLoginMethod):
+		common.ResponseWithCustomError(c, common.CodeOperationNotAllowed, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		common.ResponseWithError(c, common.CodeUserNotFound)
+	default:
+		util.GetLogger().Error("第三方登录失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+	}
+}
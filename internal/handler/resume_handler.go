@@ -5,11 +5,12 @@ import (
 	"codefolio/internal/domain"
 	"codefolio/internal/service"
 	"codefolio/internal/util"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -17,13 +18,15 @@ import (
 
 // ResumeHandler 简历处理器
 type ResumeHandler struct {
-	resumeService service.ResumeService
+	resumeService   service.ResumeService
+	favoriteService service.FavoriteService
 }
 
 // NewResumeHandler 创建简历处理器
-func NewResumeHandler(resumeService service.ResumeService) *ResumeHandler {
+func NewResumeHandler(resumeService service.ResumeService, favoriteService service.FavoriteService) *ResumeHandler {
 	return &ResumeHandler{
-		resumeService: resumeService,
+		resumeService:   resumeService,
+		favoriteService: favoriteService,
 	}
 }
 
@@ -65,15 +68,19 @@ type UpdateResumeRequest struct {
 
 // ResumeResponse 简历响应
 type ResumeResponse struct {
-	ID          uint   `json:"id"`
-	UserID      uint   `json:"user_id"`
-	ImageURL    string `json:"image_url"`
-	Role        int    `json:"role"`
-	Level       int    `json:"level"`
-	University  int    `json:"university"`
-	PassCompany []int  `json:"pass_company"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID            uint   `json:"id"`
+	UserID        uint   `json:"user_id"`
+	ImageURL      string `json:"image_url"`
+	Role          int    `json:"role"`
+	Level         int    `json:"level"`
+	University    int    `json:"university"`
+	PassCompany   []int  `json:"pass_company"`
+	Status        string `json:"status"`
+	Version       int    `json:"version"`
+	FavoriteCount int    `json:"favorite_count"`
+	IsFavorited   bool   `json:"is_favorited"` // 当前用户是否已收藏，未登录时为false
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
 }
 
 // GetPagingParams 获取分页参数
@@ -115,37 +122,69 @@ func getCurrentUserID(c *gin.Context) uint {
 	return uint(id)
 }
 
-// convertToResumeResponse 转换为简历响应
-func (h *ResumeHandler) convertToResumeResponse(resume *domain.Resume) ResumeResponse {
+// toResumeResponse 转换为简历响应，图片地址签发给当前用户
+func toResumeResponse(c *gin.Context, resume *domain.Resume) ResumeResponse {
 	return ResumeResponse{
-		ID:          resume.ID,
-		UserID:      resume.UserID,
-		ImageURL:    resume.ImageURL,
-		Role:        resume.Role,
-		Level:       resume.Level,
-		University:  resume.University,
-		PassCompany: resume.PassCompany,
-		CreatedAt:   resume.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:   resume.UpdatedAt.Format("2006-01-02 15:04:05"),
+		ID:            resume.ID,
+		UserID:        resume.UserID,
+		ImageURL:      util.GetFileURL(c, resume.ImageURL, getCurrentUserID(c)),
+		Role:          resume.Role,
+		Level:         resume.Level,
+		University:    resume.University,
+		PassCompany:   resume.PassCompany,
+		Status:        resume.Status,
+		Version:       resume.Version,
+		FavoriteCount: resume.FavoriteCount,
+		CreatedAt:     resume.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     resume.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
 }
 
-// UploadPDF 上传简历PDF文件（第一步）
-// @Summary 上传简历PDF文件
-// @Description 仅上传简历PDF文件并转换为图片，返回图片URL和文件标识，供前端预览和后续创建简历使用
+// uploadErrorCodes 上传文件校验和转换失败时返回的错误码和HTTP状态码
+var uploadErrorCodes = map[error][2]int{
+	util.ErrFileTooLarge:         {common.CodeFileTooLarge, http.StatusRequestEntityTooLarge},
+	util.ErrInvalidFileType:      {common.CodeFileTypeNotAllowed, http.StatusUnsupportedMediaType},
+	util.ErrPDFMalformed:         {common.CodePDFMalformed, http.StatusUnprocessableEntity},
+	util.ErrPDFEncrypted:         {common.CodePDFEncrypted, http.StatusUnprocessableEntity},
+	util.ErrPDFTooManyPages:      {common.CodePDFTooManyPages, http.StatusUnprocessableEntity},
+	util.ErrPDFPageTooLarge:      {common.CodePDFPageTooLarge, http.StatusUnprocessableEntity},
+	util.ErrConvertTimeout:       {common.CodeConversionTimeout, http.StatusUnprocessableEntity},
+	util.ErrConvertResourceLimit: {common.CodeConversionLimitReached, http.StatusUnprocessableEntity},
+
+	util.ErrDocumentConvertFailed:    {common.CodeDocumentConvertFailed, http.StatusUnprocessableEntity},
+	util.ErrDocumentConverterMissing: {common.CodeConverterUnavailable, http.StatusServiceUnavailable},
+	util.ErrImageTooLarge:            {common.CodeImageTooLarge, http.StatusUnprocessableEntity},
+	util.ErrChecksumMismatch:         {common.CodeChecksumMismatch, http.StatusUnprocessableEntity},
+}
+
+// respondUploadError 上传文件被拒绝时返回对应的错误码，不是上传文件错误时返回false
+func respondUploadError(c *gin.Context, err error) bool {
+	code, ok := uploadErrorCodes[err]
+	if !ok {
+		return false
+	}
+	common.ResponseWithError(c, code[0], code[1])
+	return true
+}
+
+// UploadPDF 上传简历文件（第一步）
+// @Summary 上传简历文件
+// @Description 仅上传简历文件并转换为图片，返回图片URL和文件标识，供前端预览和后续创建简历使用
+// @Description 支持PDF、DOCX和PNG/JPEG图片（以UPLOAD_ALLOWED_TYPES配置为准），按文件内容识别类型，非PDF文件先转换为PDF
 // @Tags 简历
 // @Accept multipart/form-data
 // @Produce json
-// @Param file formData file true "简历文件(PDF)"
+// @Param file formData file true "简历文件(PDF/DOCX/PNG/JPEG)"
 // @Success 200 {object} common.Response{data=UploadPDFResponse}
-// @Failure 400,500 {object} common.Response
+// @Failure 400,401,413,415,422,500,503 {object} common.Response
 // @Router /api/v1/resumes/upload-pdf [post]
+// @Security BearerAuth
 func (h *ResumeHandler) UploadPDF(c *gin.Context) {
-	// 获取当前用户ID，开发阶段允许匿名上传，默认用户ID为1
+	// 获取当前用户ID
 	userID := getCurrentUserID(c)
 	if userID == 0 {
-		// 开发阶段，使用默认用户ID
-		userID = 1
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
 	}
 
 	// 获取文件
@@ -159,11 +198,10 @@ func (h *ResumeHandler) UploadPDF(c *gin.Context) {
 	// 上传并转换PDF
 	fileResult, err := h.resumeService.UploadAndConvertPDF(c, userID, file)
 	if err != nil {
+		if respondUploadError(c, err) {
+			return
+		}
 		switch err {
-		case util.ErrFileTooLarge:
-			common.ResponseWithError(c, common.CodeInvalidParams)
-		case util.ErrInvalidFileType:
-			common.ResponseWithError(c, common.CodeInvalidParams)
 		case util.ErrCommandNotFound:
 			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
 			util.GetLogger().Error("PDF转图片工具不可用", zap.Error(err))
@@ -177,9 +215,9 @@ func (h *ResumeHandler) UploadPDF(c *gin.Context) {
 		return
 	}
 
-	// 构建响应 - fileResult.FilePath 现在已经是完整的URL
+	// 构建响应，图片地址为签名的文件URL
 	resp := UploadPDFResponse{
-		ImageURL: fileResult.FilePath,
+		ImageURL: util.GetFileURL(c, fileResult.ImageKey.String(), userID),
 		FileKey:  fileResult.FileKey,
 	}
 
@@ -188,7 +226,7 @@ func (h *ResumeHandler) UploadPDF(c *gin.Context) {
 
 // CreateResume 创建简历（第二步）
 // @Summary 创建简历
-// @Description 使用已上传的PDF文件创建简历（需要先调用上传PDF接口）
+// @Description 使用已上传的简历文件创建简历（需要先调用上传简历文件接口）
 // @Tags 简历
 // @Accept json
 // @Produce json
@@ -196,12 +234,13 @@ func (h *ResumeHandler) UploadPDF(c *gin.Context) {
 // @Success 200 {object} common.Response{data=ResumeResponse}
 // @Failure 400,401,500 {object} common.Response
 // @Router /api/v1/resumes/create [post]
+// @Security BearerAuth
 func (h *ResumeHandler) CreateResume(c *gin.Context) {
-	// 获取当前用户ID，开发阶段允许匿名创建，默认用户ID为1
+	// 获取当前用户ID
 	userID := getCurrentUserID(c)
 	if userID == 0 {
-		// 开发阶段，使用默认用户ID
-		userID = 1
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
 	}
 
 	// 绑定参数
@@ -226,6 +265,8 @@ func (h *ResumeHandler) CreateResume(c *gin.Context) {
 		switch err {
 		case service.ErrFileNotFound:
 			common.ResponseWithError(c, common.CodeInvalidParams, http.StatusBadRequest)
+		case service.ErrDuplicateResume, service.ErrResumeUploadedByOthers:
+			common.ResponseWithCustomError(c, common.CodeDataAlreadyExists, err.Error(), http.StatusConflict)
 		default:
 			util.GetLogger().Error("创建简历失败", zap.Error(err))
 			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
@@ -234,7 +275,7 @@ func (h *ResumeHandler) CreateResume(c *gin.Context) {
 	}
 
 	// 转换为响应结构
-	resp := h.convertToResumeResponse(resume)
+	resp := toResumeResponse(c, resume)
 
 	common.ResponseWithData(c, resp)
 }
@@ -245,13 +286,13 @@ func (h *ResumeHandler) CreateResume(c *gin.Context) {
 // @Tags 简历
 // @Accept multipart/form-data
 // @Produce json
-// @Param file formData file true "简历文件(PDF)"
+// @Param file formData file true "简历文件(PDF/DOCX/PNG/JPEG)"
 // @Param role formData string true "应聘职位"
 // @Param level formData string true "经历等级"
 // @Param university formData string true "毕业院校"
 // @Param pass_company[] formData []string false "面试通过的公司"
 // @Success 200 {object} common.Response{data=ResumeResponse}
-// @Failure 400,401,500 {object} common.Response
+// @Failure 400,401,409,413,415,422,500,503 {object} common.Response
 // @Router /api/v1/resumes [post]
 // @Security BearerAuth
 func (h *ResumeHandler) UploadResume(c *gin.Context) {
@@ -290,11 +331,12 @@ func (h *ResumeHandler) UploadResume(c *gin.Context) {
 	)
 
 	if err != nil {
+		if respondUploadError(c, err) {
+			return
+		}
 		switch err {
-		case util.ErrFileTooLarge:
-			common.ResponseWithError(c, common.CodeInvalidParams)
-		case util.ErrInvalidFileType:
-			common.ResponseWithError(c, common.CodeInvalidParams)
+		case service.ErrDuplicateResume, service.ErrResumeUploadedByOthers:
+			common.ResponseWithCustomError(c, common.CodeDataAlreadyExists, err.Error(), http.StatusConflict)
 		default:
 			util.GetLogger().Error("创建简历失败", zap.Error(err))
 			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
@@ -303,7 +345,7 @@ func (h *ResumeHandler) UploadResume(c *gin.Context) {
 	}
 
 	// 转换为响应结构
-	resp := h.convertToResumeResponse(resume)
+	resp := toResumeResponse(c, resume)
 
 	common.ResponseWithData(c, resp)
 }
@@ -345,11 +387,22 @@ func (h *ResumeHandler) GetResume(c *gin.Context) {
 	}
 
 	// 转换为响应结构
-	resp := h.convertToResumeResponse(resume)
+	resp := toResumeResponse(c, resume)
+	resp.IsFavorited = h.favoritedSet(userID, []domain.Resume{*resume})[resume.ID]
 
 	common.ResponseWithData(c, resp)
 }
 
+// favoritedSet 查询当前用户已收藏的简历，查询失败时视为均未收藏
+func (h *ResumeHandler) favoritedSet(userID uint, resumes []domain.Resume) map[uint]bool {
+	set, err := h.favoriteService.FavoritedSet(userID, resumes)
+	if err != nil {
+		util.GetLogger().Warn("查询收藏状态失败", zap.Error(err), zap.Uint("userID", userID))
+		return map[uint]bool{}
+	}
+	return set
+}
+
 // GetResumes 获取简历列表
 // @Summary 获取简历列表
 // @Description 获取所有简历列表，支持分页和筛选
@@ -360,6 +413,7 @@ func (h *ResumeHandler) GetResume(c *gin.Context) {
 // @Param role query int false "按职位筛选"
 // @Param level query int false "按经历等级筛选"
 // @Param university query int false "按毕业院校筛选"
+// @Param sort query string false "排序方式：latest（默认，最新发布）、favorites（收藏最多）"
 // @Success 200 {object} common.Response{data=[]ResumeResponse}
 // @Failure 400,500 {object} common.Response
 // @Router /api/v1/resumes [get]
@@ -376,11 +430,18 @@ func (h *ResumeHandler) GetResumes(c *gin.Context) {
 	level, _ := strconv.Atoi(levelStr)
 	university, _ := strconv.Atoi(universityStr)
 
+	// 排序方式
+	sort := c.DefaultQuery("sort", domain.ResumeSortLatest)
+	if sort != domain.ResumeSortLatest && sort != domain.ResumeSortFavorites {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
 	// 获取当前用户ID
 	userID := getCurrentUserID(c)
 
 	// 获取简历列表
-	resumes, total, err := h.resumeService.GetAllResumes(page, size, role, level, university, userID)
+	resumes, total, err := h.resumeService.GetAllResumes(page, size, role, level, university, sort, userID)
 	if err != nil {
 		switch err {
 		case service.ErrViewLimitExceeded:
@@ -393,9 +454,12 @@ func (h *ResumeHandler) GetResumes(c *gin.Context) {
 	}
 
 	// 转换为响应结构
+	favorited := h.favoritedSet(userID, resumes)
 	var respList []ResumeResponse
 	for _, resume := range resumes {
-		respList = append(respList, h.convertToResumeResponse(&resume))
+		resp := toResumeResponse(c, &resume)
+		resp.IsFavorited = favorited[resume.ID]
+		respList = append(respList, resp)
 	}
 
 	// 构建分页响应
@@ -435,7 +499,7 @@ func (h *ResumeHandler) GetUserResumes(c *gin.Context) {
 	// 转换为响应结构
 	var respList []ResumeResponse
 	for _, resume := range resumes {
-		respList = append(respList, h.convertToResumeResponse(&resume))
+		respList = append(respList, toResumeResponse(c, &resume))
 	}
 
 	common.ResponseWithData(c, respList)
@@ -501,7 +565,7 @@ func (h *ResumeHandler) UpdateResume(c *gin.Context) {
 	}
 
 	// 转换为响应结构
-	resp := h.convertToResumeResponse(resume)
+	resp := toResumeResponse(c, resume)
 
 	common.ResponseWithData(c, resp)
 }
@@ -513,9 +577,9 @@ func (h *ResumeHandler) UpdateResume(c *gin.Context) {
 // @Accept multipart/form-data
 // @Produce json
 // @Param id path int true "简历ID"
-// @Param file formData file true "新简历文件(PDF)"
+// @Param file formData file true "新简历文件(PDF/DOCX/PNG/JPEG)"
 // @Success 200 {object} common.Response{data=ResumeResponse}
-// @Failure 400,401,403,404,500 {object} common.Response
+// @Failure 400,401,403,404,409,413,415,422,500,503 {object} common.Response
 // @Router /api/v1/resumes/{id}/file [put]
 // @Security BearerAuth
 func (h *ResumeHandler) UpdateResumeFile(c *gin.Context) {
@@ -545,15 +609,16 @@ func (h *ResumeHandler) UpdateResumeFile(c *gin.Context) {
 	// 更新文件
 	resume, err := h.resumeService.UpdateResumeFile(c, uint(id), userID, file)
 	if err != nil {
+		if respondUploadError(c, err) {
+			return
+		}
 		switch err {
 		case service.ErrResumeNotFound:
 			common.ResponseWithError(c, common.CodeDataNotFound)
 		case service.ErrNotResumeOwner:
 			common.ResponseWithError(c, common.CodeForbidden, http.StatusForbidden)
-		case util.ErrFileTooLarge:
-			common.ResponseWithError(c, common.CodeInvalidParams)
-		case util.ErrInvalidFileType:
-			common.ResponseWithError(c, common.CodeInvalidParams)
+		case service.ErrResumeUploadedByOthers:
+			common.ResponseWithCustomError(c, common.CodeDataAlreadyExists, err.Error(), http.StatusConflict)
 		default:
 			util.GetLogger().Error("更新简历文件失败", zap.Error(err))
 			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
@@ -562,7 +627,7 @@ func (h *ResumeHandler) UpdateResumeFile(c *gin.Context) {
 	}
 
 	// 转换为响应结构
-	resp := h.convertToResumeResponse(resume)
+	resp := toResumeResponse(c, resume)
 
 	common.ResponseWithData(c, resp)
 }
@@ -611,6 +676,95 @@ func (h *ResumeHandler) DeleteResume(c *gin.Context) {
 	common.ResponseSuccess(c)
 }
 
+// DeletedResumeResponse 回收站中的简历响应
+type DeletedResumeResponse struct {
+	ResumeResponse
+	DeletedAt string `json:"deleted_at"`
+	PurgeAt   string `json:"purge_at"` // 超过该时间后将被永久删除，无法恢复
+}
+
+// GetDeletedResumes 获取回收站中的简历
+// @Summary 获取回收站中的简历
+// @Description 获取当前用户已删除且仍可恢复的简历
+// @Tags 简历
+// @Produce json
+// @Success 200 {object} common.Response{data=[]DeletedResumeResponse}
+// @Failure 401,500 {object} common.Response
+// @Router /api/v1/resumes/trash [get]
+// @Security BearerAuth
+func (h *ResumeHandler) GetDeletedResumes(c *gin.Context) {
+	// 获取当前用户ID
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	resumes, err := h.resumeService.GetDeletedResumes(userID)
+	if err != nil {
+		util.GetLogger().Error("获取回收站简历失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	retention := h.resumeService.TrashRetention()
+	respList := make([]DeletedResumeResponse, 0, len(resumes))
+	for _, resume := range resumes {
+		respList = append(respList, DeletedResumeResponse{
+			ResumeResponse: toResumeResponse(c, &resume),
+			DeletedAt:      resume.DeletedAt.Time.Format("2006-01-02 15:04:05"),
+			PurgeAt:        resume.DeletedAt.Time.Add(retention).Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	common.ResponseWithData(c, respList)
+}
+
+// RestoreResume 从回收站恢复简历
+// @Summary 恢复简历
+// @Description 恢复回收站中的简历，超过保留期的简历无法恢复
+// @Tags 简历
+// @Produce json
+// @Param id path int true "简历ID"
+// @Success 200 {object} common.Response{data=ResumeResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/resumes/{id}/restore [post]
+// @Security BearerAuth
+func (h *ResumeHandler) RestoreResume(c *gin.Context) {
+	// 获取简历ID
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	// 获取当前用户ID
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	resume, err := h.resumeService.RestoreResume(uint(id), userID)
+	if err != nil {
+		switch err {
+		case service.ErrResumeNotFound:
+			common.ResponseWithError(c, common.CodeDataNotFound)
+		case service.ErrNotResumeOwner:
+			common.ResponseWithError(c, common.CodeForbidden, http.StatusForbidden)
+		case service.ErrRestoreExpired:
+			common.ResponseWithCustomError(c, common.CodeOperationNotAllowed, err.Error())
+		default:
+			util.GetLogger().Error("恢复简历失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.ResponseWithData(c, toResumeResponse(c, resume))
+}
+
 // DownloadResume 下载简历
 // @Summary 下载简历
 // @Description 下载简历文件
@@ -648,45 +802,77 @@ func (h *ResumeHandler) DownloadResume(c *gin.Context) {
 	}
 
 	// 发送文件
-	c.FileAttachment(resume.ImageURL, "resume.pdf")
+	key, err := util.ParseStorageKey(resume.ImageURL)
+	if err != nil {
+		util.GetLogger().Error("简历文件存储键无效", zap.Error(err), zap.Uint("resumeID", resume.ID))
+		common.ResponseWithError(c, common.CodeDataNotFound, http.StatusNotFound)
+		return
+	}
+	serveStoredFile(c, key, "resume.pdf")
 }
 
 // ServeResumeFile 提供简历文件服务
 // @Summary 提供简历文件服务
-// @Description 静态文件服务，提供简历文件和图片访问
+// @Description 校验文件URL的签名和有效期后从文件存储读取简历文件和图片，文件URL由各接口返回的image_url、avatar_url等字段提供。
+// @Description 绑定了访问者的URL需携带该用户的令牌，浏览器可通过access_token查询参数传递
+// @Description 简历图片按Accept请求头返回已生成的格式（jpg、png、webp），响应带有Vary: Accept
 // @Tags 文件
 // @Produce octet-stream
 // @Param path path string true "文件路径"
+// @Param expires query int true "过期时间戳"
+// @Param signature query string true "签名"
+// @Param viewer query int false "绑定的访问者ID"
+// @Param Accept header string false "可接受的图片格式，如image/webp,image/*"
 // @Success 200 {file} file "文件内容"
+// @Success 302 "存储支持预签名时重定向到存储的临时地址"
+// @Failure 403,404 {object} common.Response
 // @Router /api/v1/files/{path} [get]
 func (h *ResumeHandler) ServeResumeFile(c *gin.Context) {
-	filePath := c.Param("path")
-	if filePath == "" {
-		c.Status(http.StatusNotFound)
+	key, expiresAt, err := util.VerifyFileURL(c.Param("path"), c.Request.URL.Query(), getCurrentUserID(c))
+	if err != nil {
+		util.GetLogger().Debug("文件URL校验失败", zap.Error(err), zap.String("path", c.Param("path")))
+		common.ResponseWithCustomError(c, common.CodeForbidden, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// 签名URL可能绑定访问者，不允许共享缓存
+	c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d", int(time.Until(expiresAt).Seconds())))
+
+	// 简历图片按Accept请求头返回浏览器支持的格式（如WebP）
+	if util.HasRenditions(key) {
+		c.Header("Vary", "Accept")
+		key = util.NegotiateRendition(c.Request.Context(), key, c.GetHeader("Accept"))
+	}
+
+	if redirectURL := util.StorageRedirectURL(c.Request.Context(), key, time.Until(expiresAt)+time.Minute); redirectURL != "" {
+		c.Redirect(http.StatusFound, redirectURL)
 		return
 	}
 
-	// 构建完整路径
-	fullPath := filepath.Join(util.UploadDir, filePath)
+	serveStoredFile(c, key, "")
+}
 
-	// 检查文件是否存在
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		util.GetLogger().Error("文件不存在", zap.String("path", fullPath))
+// serveStoredFile 从存储读取文件并返回，attachmentName不为空时作为附件下载
+func serveStoredFile(c *gin.Context, key util.StorageKey, attachmentName string) {
+	reader, obj, err := util.OpenFile(c.Request.Context(), key)
+	if err != nil {
+		if !errors.Is(err, util.ErrFileNotFound) && !errors.Is(err, util.ErrInvalidStorageKey) {
+			util.GetLogger().Error("读取文件失败", zap.Error(err), zap.Stringer("key", key))
+		}
 		c.Status(http.StatusNotFound)
 		return
 	}
+	defer reader.Close()
 
-	// 设置正确的Content-Type
-	extension := filepath.Ext(fullPath)
-	switch strings.ToLower(extension) {
-	case ".jpg", ".jpeg":
-		c.Header("Content-Type", "image/jpeg")
-	case ".png":
-		c.Header("Content-Type", "image/png")
-	case ".pdf":
-		c.Header("Content-Type", "application/pdf")
+	if attachmentName != "" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", attachmentName))
 	}
 
-	// 提供文件
-	c.File(fullPath)
+	// 本地文件支持断点续传和条件请求
+	if rs, ok := reader.(io.ReadSeeker); ok {
+		c.Header("Content-Type", obj.ContentType)
+		http.ServeContent(c.Writer, c.Request, "", obj.ModTime, rs)
+		return
+	}
+	c.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, reader, nil)
 }
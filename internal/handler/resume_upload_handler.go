@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// InitUploadRequest 创建分片上传会话请求
+type InitUploadRequest struct {
+	FileName string `json:"file_name" binding:"required,max=255"`
+	FileSize int64  `json:"file_size" binding:"required,min=1"`
+	Checksum string `json:"checksum" binding:"required,len=64"` // 完整文件的SHA-256（十六进制）
+}
+
+// UploadSessionResponse 分片上传会话响应
+type UploadSessionResponse struct {
+	UploadID      string `json:"upload_id"`
+	FileName      string `json:"file_name"`
+	FileSize      int64  `json:"file_size"`
+	ChunkSize     int64  `json:"chunk_size"`     // 除最后一个分片外每个分片的大小
+	TotalParts    int    `json:"total_parts"`    // 分片数量，分片序号从1开始
+	Status        string `json:"status"`         // uploading/completing/completed
+	ReceivedParts []int  `json:"received_parts"` // 已接收的分片序号
+	ExpiresAt     string `json:"expires_at"`
+}
+
+// UploadPartResponse 分片上传响应
+type UploadPartResponse struct {
+	PartNumber int    `json:"part_number"`
+	Size       int64  `json:"size"`
+	Checksum   string `json:"checksum"` // 服务端计算的分片SHA-256
+}
+
+// toUploadSessionResponse 转换为分片上传会话响应
+func toUploadSessionResponse(session *domain.UploadSession, parts []domain.UploadPart) UploadSessionResponse {
+	received := make([]int, 0, len(parts))
+	for _, p := range parts {
+		received = append(received, p.PartNumber)
+	}
+	return UploadSessionResponse{
+		UploadID:      session.ID,
+		FileName:      session.FileName,
+		FileSize:      session.FileSize,
+		ChunkSize:     session.ChunkSize,
+		TotalParts:    session.TotalParts,
+		Status:        session.Status,
+		ReceivedParts: received,
+		ExpiresAt:     session.ExpiresAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// respondUploadSessionError 返回分片上传会话相关的错误，不是会话错误时返回false
+func respondUploadSessionError(c *gin.Context, err error) bool {
+	switch err {
+	case service.ErrUploadNotFound:
+		common.ResponseWithCustomError(c, common.CodeDataNotFound, err.Error(), http.StatusNotFound)
+	case service.ErrUploadNotActive:
+		common.ResponseWithError(c, common.CodeUploadNotActive, http.StatusConflict)
+	case service.ErrUploadIncomplete:
+		common.ResponseWithError(c, common.CodeUploadIncomplete, http.StatusConflict)
+	case service.ErrInvalidUploadPart:
+		common.ResponseWithError(c, common.CodeInvalidUploadPart, http.StatusBadRequest)
+	case service.ErrInvalidChecksum:
+		common.ResponseWithCustomError(c, common.CodeInvalidParams, err.Error(), http.StatusBadRequest)
+	case service.ErrTooManyUploads:
+		common.ResponseWithError(c, common.CodeTooManyUploads, http.StatusTooManyRequests)
+	default:
+		return respondUploadError(c, err)
+	}
+	return true
+}
+
+// InitUpload 创建分片上传会话
+// @Summary 创建分片上传会话
+// @Description 声明文件名、大小和SHA-256，返回上传ID和分片大小。之后按分片序号上传，网络中断后可查询已接收的分片继续上传，全部上传后调用完成接口合并并转换
+// @Tags 简历
+// @Accept json
+// @Produce json
+// @Param request body InitUploadRequest true "文件信息"
+// @Success 200 {object} common.Response{data=UploadSessionResponse}
+// @Failure 400,401,413,429,500 {object} common.Response
+// @Router /api/v1/resumes/uploads [post]
+// @Security BearerAuth
+func (h *ResumeHandler) InitUpload(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	var req InitUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		util.GetLogger().Error("绑定请求参数失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	session, err := h.resumeService.InitUpload(userID, req.FileName, req.FileSize, req.Checksum)
+	if err != nil {
+		if respondUploadSessionError(c, err) {
+			return
+		}
+		util.GetLogger().Error("创建上传会话失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseWithData(c, toUploadSessionResponse(session, nil))
+}
+
+// GetUpload 查询分片上传会话
+// @Summary 查询分片上传会话
+// @Description 返回会话状态和已接收的分片序号，客户端中断后据此上传缺少的分片
+// @Tags 简历
+// @Produce json
+// @Param upload_id path string true "上传ID"
+// @Success 200 {object} common.Response{data=UploadSessionResponse}
+// @Failure 401,404,500 {object} common.Response
+// @Router /api/v1/resumes/uploads/{upload_id} [get]
+// @Security BearerAuth
+func (h *ResumeHandler) GetUpload(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	session, parts, err := h.resumeService.GetUpload(c.Param("upload_id"), userID)
+	if err != nil {
+		if respondUploadSessionError(c, err) {
+			return
+		}
+		util.GetLogger().Error("查询上传会话失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseWithData(c, toUploadSessionResponse(session, parts))
+}
+
+// UploadPart 上传分片
+// @Summary 上传分片
+// @Description 请求体为分片的原始字节，除最后一个分片外大小必须等于chunk_size。可通过X-Part-Checksum请求头提供分片的SHA-256，不一致时拒绝保存。重复上传同一分片会覆盖之前的内容
+// @Tags 简历
+// @Accept octet-stream
+// @Produce json
+// @Param upload_id path string true "上传ID"
+// @Param part path int true "分片序号，从1开始"
+// @Param X-Part-Checksum header string false "分片的SHA-256（十六进制）"
+// @Success 200 {object} common.Response{data=UploadPartResponse}
+// @Failure 400,401,404,409,422,500 {object} common.Response
+// @Router /api/v1/resumes/uploads/{upload_id}/parts/{part} [put]
+// @Security BearerAuth
+func (h *ResumeHandler) UploadPart(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	partNumber, err := strconv.Atoi(c.Param("part"))
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidUploadPart, http.StatusBadRequest)
+		return
+	}
+
+	part, err := h.resumeService.UploadPart(c, c.Param("upload_id"), userID, partNumber, c.Request.Body, c.GetHeader("X-Part-Checksum"))
+	if err != nil {
+		if respondUploadSessionError(c, err) {
+			return
+		}
+		util.GetLogger().Error("保存上传分片失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseWithData(c, UploadPartResponse{
+		PartNumber: part.PartNumber,
+		Size:       part.Size,
+		Checksum:   part.Checksum,
+	})
+}
+
+// CompleteUpload 完成分片上传
+// @Summary 完成分片上传
+// @Description 按序合并全部分片，校验完整文件的SHA-256后与上传简历文件接口一样转换为图片，返回图片URL和文件标识，供创建简历使用。失败时会话保持可用，可重传分片后重试
+// @Tags 简历
+// @Produce json
+// @Param upload_id path string true "上传ID"
+// @Success 200 {object} common.Response{data=UploadPDFResponse}
+// @Failure 401,404,409,415,422,500,503 {object} common.Response
+// @Router /api/v1/resumes/uploads/{upload_id}/complete [post]
+// @Security BearerAuth
+func (h *ResumeHandler) CompleteUpload(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	fileResult, err := h.resumeService.CompleteUpload(c, c.Param("upload_id"), userID)
+	if err != nil {
+		if respondUploadSessionError(c, err) {
+			return
+		}
+		util.GetLogger().Error("合并上传分片失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseWithData(c, UploadPDFResponse{
+		ImageURL: util.GetFileURL(c, fileResult.ImageKey.String(), userID),
+		FileKey:  fileResult.FileKey,
+	})
+}
+
+// AbortUpload 取消分片上传
+// @Summary 取消分片上传
+// @Description 删除上传会话和已上传的分片
+// @Tags 简历
+// @Produce json
+// @Param upload_id path string true "上传ID"
+// @Success 200 {object} common.Response
+// @Failure 401,404,409,500 {object} common.Response
+// @Router /api/v1/resumes/uploads/{upload_id} [delete]
+// @Security BearerAuth
+func (h *ResumeHandler) AbortUpload(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.resumeService.AbortUpload(c.Param("upload_id"), userID); err != nil {
+		if respondUploadSessionError(c, err) {
+			return
+		}
+		util.GetLogger().Error("取消上传会话失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseSuccess(c)
+}
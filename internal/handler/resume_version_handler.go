@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ResumeVersionResponse 简历版本响应
+type ResumeVersionResponse struct {
+	Version        int    `json:"version"`
+	ChangeType     string `json:"change_type"`                // 修订类型：create/file/metadata/rollback
+	RolledBackFrom int    `json:"rolled_back_from,omitempty"` // 回滚时恢复的版本号
+	Current        bool   `json:"current"`                    // 是否为当前版本
+	ImageURL       string `json:"image_url"`
+	Role           int    `json:"role"`
+	Level          int    `json:"level"`
+	University     int    `json:"university"`
+	PassCompany    []int  `json:"pass_company"`
+	NewOffers      []int  `json:"new_offers"` // 相比上一版本新增的offer
+	CreatedAt      string `json:"created_at"`
+}
+
+// toResumeVersionResponse 转换为简历版本响应，prev为上一版本
+func toResumeVersionResponse(c *gin.Context, v, prev *domain.ResumeVersion, currentVersion int) ResumeVersionResponse {
+	return ResumeVersionResponse{
+		Version:        v.Version,
+		ChangeType:     v.ChangeType,
+		RolledBackFrom: v.RolledBackFrom,
+		Current:        v.Version == currentVersion,
+		ImageURL:       util.GetFileURL(c, v.ImageURL, getCurrentUserID(c)),
+		Role:           v.Role,
+		Level:          v.Level,
+		University:     v.University,
+		PassCompany:    v.PassCompany,
+		NewOffers:      v.NewOffers(prev),
+		CreatedAt:      v.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// parseVersionParams 解析简历ID和版本号路径参数
+func parseVersionParams(c *gin.Context) (uint, int, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version <= 0 {
+		return 0, 0, false
+	}
+	return uint(id), version, true
+}
+
+// GetResumeVersions 获取简历版本历史
+// @Summary 获取简历版本历史
+// @Description 按版本号升序返回简历的所有修订记录，并标注每个版本新增的offer
+// @Description 他人只能看到审核通过期间的版本
+// @Tags 简历
+// @Produce json
+// @Param id path int true "简历ID"
+// @Success 200 {object} common.Response{data=[]ResumeVersionResponse}
+// @Failure 400,500 {object} common.Response
+// @Router /api/v1/resumes/{id}/versions [get]
+func (h *ResumeHandler) GetResumeVersions(c *gin.Context) {
+	// 获取简历ID
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+
+	versions, err := h.resumeService.GetResumeVersions(uint(id), userID)
+	if err != nil {
+		h.handleVersionError(c, err)
+		return
+	}
+
+	currentVersion := 0
+	if len(versions) > 0 {
+		currentVersion = versions[len(versions)-1].Version
+	}
+
+	respList := make([]ResumeVersionResponse, 0, len(versions))
+	for i := range versions {
+		var prev *domain.ResumeVersion
+		if i > 0 {
+			prev = &versions[i-1]
+		}
+		respList = append(respList, toResumeVersionResponse(c, &versions[i], prev, currentVersion))
+	}
+
+	common.ResponseWithData(c, respList)
+}
+
+// GetResumeVersion 查看简历的历史版本
+// @Summary 查看简历历史版本
+// @Tags 简历
+// @Produce json
+// @Param id path int true "简历ID"
+// @Param version path int true "版本号"
+// @Success 200 {object} common.Response{data=ResumeVersionResponse}
+// @Failure 400,500 {object} common.Response
+// @Router /api/v1/resumes/{id}/versions/{version} [get]
+func (h *ResumeHandler) GetResumeVersion(c *gin.Context) {
+	id, version, ok := parseVersionParams(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+
+	// 同时需要上一版本计算新增的offer，以及最新版本判断是否为当前版本
+	versions, err := h.resumeService.GetResumeVersions(id, userID)
+	if err != nil {
+		h.handleVersionError(c, err)
+		return
+	}
+
+	for i := range versions {
+		if versions[i].Version != version {
+			continue
+		}
+		var prev *domain.ResumeVersion
+		if i > 0 {
+			prev = &versions[i-1]
+		}
+		common.ResponseWithData(c, toResumeVersionResponse(c, &versions[i], prev, versions[len(versions)-1].Version))
+		return
+	}
+
+	common.ResponseWithError(c, common.CodeDataNotFound)
+}
+
+// RollbackResume 回滚简历到历史版本
+// @Summary 回滚简历
+// @Description 以指定历史版本的文件和信息生成一个新版本，之后的版本仍会保留
+// @Description 与上传新文件一样重新检测重复简历，审核状态随之调整
+// @Tags 简历
+// @Produce json
+// @Param id path int true "简历ID"
+// @Param version path int true "版本号"
+// @Success 200 {object} common.Response{data=ResumeResponse}
+// @Failure 400,401,403,409,500 {object} common.Response
+// @Router /api/v1/resumes/{id}/versions/{version}/rollback [post]
+// @Security BearerAuth
+func (h *ResumeHandler) RollbackResume(c *gin.Context) {
+	id, version, ok := parseVersionParams(c)
+	if !ok {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	// 获取当前用户ID
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	resume, err := h.resumeService.RollbackResume(id, version, userID)
+	if err != nil {
+		h.handleVersionError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, toResumeResponse(c, resume))
+}
+
+// DiffRegion 变化区域，坐标单位为像素
+type DiffRegion struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// PageDiffResponse 单页差异响应
+type PageDiffResponse struct {
+	Page     int          `json:"page"`
+	Status   string       `json:"status"`    // unchanged/changed/added/removed
+	ImageURL string       `json:"image_url"` // 高亮变化区域的页面图片，页面无变化时为空
+	Regions  []DiffRegion `json:"regions"`
+}
+
+// ResumeDiffResponse 简历版本差异响应
+type ResumeDiffResponse struct {
+	From     int                `json:"from"`
+	To       int                `json:"to"`
+	TextDiff string             `json:"text_diff"` // 统一格式的文本差异
+	Pages    []PageDiffResponse `json:"pages"`
+}
+
+// DiffResumeVersions 比较简历的两个版本
+// @Summary 比较简历版本
+// @Description 返回两个版本提取文本的差异，以及逐页高亮变化区域的图片
+// @Description 需要登录，比较他人的简历计入查看次数限制
+// @Tags 简历
+// @Produce json
+// @Param id path int true "简历ID"
+// @Param from query int true "起始版本号"
+// @Param to query int true "目标版本号"
+// @Success 200 {object} common.Response{data=ResumeDiffResponse}
+// @Failure 400,401,429,500,503 {object} common.Response
+// @Router /api/v1/resumes/{id}/diff [get]
+// @Security BearerAuth
+func (h *ResumeHandler) DiffResumeVersions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from <= 0 {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil || to <= 0 {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	diff, err := h.resumeService.DiffResumeVersions(uint(id), from, to, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDiffUnavailable):
+			common.ResponseWithCustomError(c, common.CodeOperationNotAllowed, err.Error())
+		case errors.Is(err, util.ErrCommandNotFound):
+			common.ResponseWithCustomError(c, common.CodeOperationFailed, "服务器暂不支持简历比较", http.StatusServiceUnavailable)
+		default:
+			h.handleVersionError(c, err)
+		}
+		return
+	}
+
+	resp := ResumeDiffResponse{
+		From:     diff.From,
+		To:       diff.To,
+		TextDiff: diff.TextDiff,
+		Pages:    make([]PageDiffResponse, 0, len(diff.Pages)),
+	}
+	for _, page := range diff.Pages {
+		regions := make([]DiffRegion, 0, len(page.Regions))
+		for _, r := range page.Regions {
+			regions = append(regions, DiffRegion{X: r.Min.X, Y: r.Min.Y, Width: r.Dx(), Height: r.Dy()})
+		}
+		resp.Pages = append(resp.Pages, PageDiffResponse{
+			Page:     page.Page,
+			Status:   page.Status,
+			ImageURL: util.GetFileURL(c, page.ImagePath, userID),
+			Regions:  regions,
+		})
+	}
+
+	common.ResponseWithData(c, resp)
+}
+
+// handleVersionError 将版本相关错误转换为响应
+func (h *ResumeHandler) handleVersionError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrResumeNotFound, service.ErrVersionNotFound:
+		common.ResponseWithError(c, common.CodeDataNotFound)
+	case service.ErrNotResumeOwner:
+		common.ResponseWithError(c, common.CodeForbidden, http.StatusForbidden)
+	case service.ErrViewLimitExceeded:
+		common.ResponseWithError(c, common.CodeOperationNotAllowed)
+	case service.ErrResumeUploadedByOthers:
+		common.ResponseWithCustomError(c, common.CodeDataAlreadyExists, err.Error(), http.StatusConflict)
+	default:
+		util.GetLogger().Error("获取简历版本失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+// StreamHandler 实时事件推送处理器
+type StreamHandler struct {
+	hub       *service.EventHub
+	heartbeat time.Duration
+}
+
+// NewStreamHandler 创建实时事件推送处理器，heartbeat为心跳间隔
+func NewStreamHandler(hub *service.EventHub, heartbeat time.Duration) *StreamHandler {
+	return &StreamHandler{
+		hub:       hub,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream 订阅实时事件
+// @Summary 订阅实时事件
+// @Description 以Server-Sent Events推送PDF转换进度(conversion)、简历审核结果(moderation)和站内通知(notification)。
+// @Description 浏览器EventSource无法设置请求头时可通过access_token查询参数传递令牌；断线重连时携带Last-Event-ID补发错过的事件
+// @Tags 通知
+// @Produce text/event-stream
+// @Param access_token query string false "访问令牌"
+// @Param Last-Event-ID header string false "最后收到的事件ID"
+// @Success 200 {string} string "事件流"
+// @Failure 401 {object} common.Response
+// @Router /api/v1/events [get]
+// @Security BearerAuth
+func (h *StreamHandler) Stream(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	lastID, _ := strconv.ParseUint(lastEventID, 10, 64)
+
+	events, missed, unsubscribe := h.hub.Subscribe(userID, lastID)
+	defer unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 关闭Nginx缓冲
+	c.Status(http.StatusOK)
+
+	// 补发断线期间错过的事件
+	for _, event := range missed {
+		writeStreamEvent(c, event)
+	}
+	// 立即发送一次心跳，让客户端确认连接已建立
+	writeHeartbeat(c)
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				// 推送过慢被断开，客户端将携带Last-Event-ID重连
+				return
+			}
+			writeStreamEvent(c, event)
+		case <-ticker.C:
+			writeHeartbeat(c)
+		}
+	}
+}
+
+// writeStreamEvent 写出一条SSE事件
+func writeStreamEvent(c *gin.Context, event service.StreamEvent) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: event.Name,
+		Data:  event.Data,
+	})
+	c.Writer.Flush()
+}
+
+// writeHeartbeat 写出SSE注释作为心跳，防止代理因空闲断开连接
+func writeHeartbeat(c *gin.Context) {
+	_, _ = c.Writer.WriteString(": heartbeat\n\n")
+	c.Writer.Flush()
+}
@@ -2,10 +2,15 @@ package handler
 
 import (
 	"codefolio/internal/common"
+	"codefolio/internal/domain"
 	"codefolio/internal/service"
 	"codefolio/internal/util"
+	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -13,12 +18,16 @@ import (
 
 // UserHandler 处理用户相关的HTTP请求
 type UserHandler struct {
-	userService service.UserService
+	userService   service.UserService
+	resumeService service.ResumeService
 }
 
 // NewUserHandler 创建UserHandler实例
-func NewUserHandler(userService service.UserService) *UserHandler {
-	return &UserHandler{userService: userService}
+func NewUserHandler(userService service.UserService, resumeService service.ResumeService) *UserHandler {
+	return &UserHandler{
+		userService:   userService,
+		resumeService: resumeService,
+	}
 }
 
 // RegisterRequest 用户注册请求结构
@@ -34,11 +43,92 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// UpdateProfileRequest 更新个人资料请求，未提供的字段保持不变
+type UpdateProfileRequest struct {
+	DisplayName    *string              `json:"display_name" binding:"omitempty,max=50"`
+	Bio            *string              `json:"bio" binding:"omitempty,max=500"`
+	Company        *string              `json:"company" binding:"omitempty,max=100"`
+	GraduationYear OptionalInt          `json:"graduation_year" swaggertype:"integer"` // 传null清空
+	Links          []ProfileLinkRequest `json:"links" binding:"omitempty,max=10,dive"` // 传空数组清空链接
+}
+
+// OptionalInt 区分未提供和显式null的整数字段
+type OptionalInt struct {
+	Set   bool // 请求中是否包含该字段
+	Value *int // 为nil表示显式传入null
+}
+
+// UnmarshalJSON 字段出现在请求中时才会调用
+func (o *OptionalInt) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+	var v int
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	o.Value = &v
+	return nil
+}
+
+// ProfileLinkRequest 个人链接
+type ProfileLinkRequest struct {
+	Label string `json:"label" binding:"required,max=30"`
+	URL   string `json:"url" binding:"required,url,max=255"`
+}
+
 // UserResponse 用户信息响应结构
 type UserResponse struct {
-	ID       uint   `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	ID             uint                 `json:"id"`
+	Username       string               `json:"username"`
+	Email          string               `json:"email"`
+	DisplayName    string               `json:"display_name"`
+	AvatarURL      string               `json:"avatar_url"`
+	Bio            string               `json:"bio"`
+	Company        string               `json:"company"`
+	GraduationYear int                  `json:"graduation_year"`
+	Links          []domain.ProfileLink `json:"links"`
+
+	// 计划删除时间，为空表示未申请注销
+	DeletionScheduledAt string `json:"deletion_scheduled_at,omitempty"`
+}
+
+// PublicProfileResponse 公开个人主页响应，不包含邮箱等隐私信息
+type PublicProfileResponse struct {
+	Username       string               `json:"username"`
+	DisplayName    string               `json:"display_name"`
+	AvatarURL      string               `json:"avatar_url"`
+	Bio            string               `json:"bio"`
+	Company        string               `json:"company"`
+	GraduationYear int                  `json:"graduation_year"`
+	Links          []domain.ProfileLink `json:"links"`
+	Resumes        []ResumeResponse     `json:"resumes"`
+	CreatedAt      string               `json:"created_at"`
+}
+
+// toUserResponse 转换为用户信息响应，头像为公开图片，URL不绑定访问者
+func toUserResponse(c *gin.Context, user *domain.User) UserResponse {
+	links := user.Links
+	if links == nil {
+		links = []domain.ProfileLink{}
+	}
+	resp := UserResponse{
+		ID:             user.ID,
+		Username:       user.Username,
+		Email:          user.Email,
+		DisplayName:    user.DisplayName,
+		AvatarURL:      util.GetFileURL(c, user.AvatarURL, 0),
+		Bio:            user.Bio,
+		Company:        user.Company,
+		GraduationYear: user.GraduationYear,
+		Links:          links,
+	}
+	if user.DeletionScheduledAt != nil {
+		resp.DeletionScheduledAt = user.DeletionScheduledAt.Format("2006-01-02 15:04:05")
+	}
+	return resp
 }
 
 // AuthResponse 认证响应结构
@@ -66,8 +156,8 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// 登录获取token
-	token, err := h.userService.Login(req.Username, req.Password)
+	// 签发token
+	token, err := h.userService.GenerateToken(user.ID)
 	if err != nil {
 		util.GetLogger().Error("注册后登录失败", zap.Error(err))
 		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
@@ -76,11 +166,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 
 	common.ResponseWithData(c, AuthResponse{
 		Token: token,
-		User: UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-			Email:    user.Email,
-		},
+		User:  toUserResponse(c, user),
 	})
 }
 
@@ -91,13 +177,22 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return // 错误已在BindAndValidate中处理
 	}
 
-	token, err := h.userService.Login(req.Username, req.Password)
+	token, err := h.userService.Login(req.Username, req.Password, c.ClientIP())
 	if err != nil {
-		switch err {
-		case service.ErrInvalidCredentials:
+		var blocked *service.LoginBlockedError
+		if errors.As(err, &blocked) {
+			setRetryAfter(c, blocked.RetryAfter)
+		}
+
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
 			common.ResponseWithError(c, common.CodeInvalidCredentials)
-		case service.ErrUserNotFound:
+		case errors.Is(err, service.ErrUserNotFound):
 			common.ResponseWithError(c, common.CodeUserNotFound)
+		case errors.Is(err, service.ErrAccountLocked):
+			common.ResponseWithError(c, common.CodeAccountLocked, http.StatusForbidden)
+		case errors.Is(err, service.ErrTooManyLoginAttempts):
+			common.ResponseWithError(c, common.CodeTooManyRequests, http.StatusTooManyRequests)
 		default:
 			util.GetLogger().Error("登录失败", zap.Error(err))
 			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
@@ -122,44 +217,185 @@ func (h *UserHandler) Login(c *gin.Context) {
 
 	common.ResponseWithData(c, AuthResponse{
 		Token: token,
-		User: UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-			Email:    user.Email,
-		},
+		User:  toUserResponse(c, user),
 	})
 }
 
 // GetMe 获取当前认证用户信息
 func (h *UserHandler) GetMe(c *gin.Context) {
-	userID, exists := c.Get("userID")
-	if !exists {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
 		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
 		return
 	}
 
-	// 将userID转换为uint
-	userIDStr, ok := userID.(string)
-	if !ok {
+	user, err := h.userService.GetUserByID(userID)
+	if err != nil {
 		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
 		return
 	}
 
-	idUint, err := strconv.ParseUint(userIDStr, 10, 32)
+	common.ResponseWithData(c, toUserResponse(c, user))
+}
+
+// UpdateMe 更新当前用户的个人资料
+// @Summary 更新个人资料
+// @Description 只修改请求中包含的字段；graduation_year传null清空，links传空数组清空
+// @Tags 用户
+// @Accept json
+// @Produce json
+// @Param request body UpdateProfileRequest true "个人资料"
+// @Success 200 {object} common.Response{data=UserResponse}
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/me [put]
+// @Security BearerAuth
+func (h *UserHandler) UpdateMe(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := util.BindAndValidate(c, &req); err != nil {
+		return // 错误已在BindAndValidate中处理
+	}
+
+	update := service.ProfileUpdate{
+		DisplayName: req.DisplayName,
+		Bio:         req.Bio,
+		Company:     req.Company,
+	}
+	if req.GraduationYear.Set {
+		year := 0
+		if req.GraduationYear.Value != nil {
+			year = *req.GraduationYear.Value
+			// 0表示清空，只能通过null传入
+			if year == 0 {
+				common.ResponseWithCustomError(c, common.CodeValidationFailed, service.ErrInvalidGraduationYear.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		update.GraduationYear = &year
+	}
+	if req.Links != nil {
+		update.Links = make([]domain.ProfileLink, 0, len(req.Links))
+		for _, link := range req.Links {
+			update.Links = append(update.Links, domain.ProfileLink{Label: link.Label, URL: link.URL})
+		}
+	}
+
+	user, err := h.userService.UpdateProfile(userID, update)
 	if err != nil {
-		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		switch err {
+		case service.ErrInvalidProfileLink, service.ErrInvalidGraduationYear:
+			common.ResponseWithCustomError(c, common.CodeValidationFailed, err.Error(), http.StatusBadRequest)
+		case service.ErrUserNotFound:
+			common.ResponseWithError(c, common.CodeUserNotFound)
+		default:
+			util.GetLogger().Error("更新个人资料失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	common.ResponseWithData(c, toUserResponse(c, user))
+}
+
+// UpdateAvatar 上传头像
+// @Summary 上传头像
+// @Tags 用户
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "头像图片(JPEG/PNG/GIF/WebP)"
+// @Success 200 {object} common.Response{data=UserResponse}
+// @Failure 400,401,500 {object} common.Response
+// @Router /api/v1/me/avatar [put]
+// @Security BearerAuth
+func (h *UserHandler) UpdateAvatar(c *gin.Context) {
+	userID := getCurrentUserID(c)
+	if userID == 0 {
+		common.ResponseWithError(c, common.CodeUnauthorized, http.StatusUnauthorized)
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	user, err := h.userService.UpdateAvatar(userID, file)
+	if err != nil {
+		switch err {
+		case util.ErrFileTooLarge:
+			common.ResponseWithError(c, common.CodeDataTooLarge)
+		case util.ErrInvalidFileType:
+			common.ResponseWithError(c, common.CodeInvalidParams)
+		case service.ErrUserNotFound:
+			common.ResponseWithError(c, common.CodeUserNotFound)
+		default:
+			util.GetLogger().Error("上传头像失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
 		return
 	}
 
-	user, err := h.userService.GetUserByID(uint(idUint))
+	common.ResponseWithData(c, toUserResponse(c, user))
+}
+
+// GetPublicProfile 获取用户公开主页
+// @Summary 获取用户公开主页
+// @Description 返回用户公开资料及审核通过的简历，不包含邮箱
+// @Tags 用户
+// @Produce json
+// @Param username path string true "用户名"
+// @Success 200 {object} common.Response{data=PublicProfileResponse}
+// @Failure 404,500 {object} common.Response
+// @Router /api/v1/users/{username} [get]
+func (h *UserHandler) GetPublicProfile(c *gin.Context) {
+	user, err := h.userService.GetUserByUsername(c.Param("username"))
 	if err != nil {
+		switch err {
+		case service.ErrUserNotFound:
+			common.ResponseWithError(c, common.CodeUserNotFound, http.StatusNotFound)
+		default:
+			util.GetLogger().Error("获取用户主页失败", zap.Error(err))
+			common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resumes, err := h.resumeService.GetPublicUserResumes(user.ID)
+	if err != nil {
+		util.GetLogger().Error("获取用户公开简历失败", zap.Error(err))
 		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
 		return
 	}
 
-	common.ResponseWithData(c, UserResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
+	respList := make([]ResumeResponse, 0, len(resumes))
+	for _, resume := range resumes {
+		respList = append(respList, toResumeResponse(c, &resume))
+	}
+
+	profile := toUserResponse(c, user)
+	common.ResponseWithData(c, PublicProfileResponse{
+		Username:       profile.Username,
+		DisplayName:    profile.DisplayName,
+		AvatarURL:      profile.AvatarURL,
+		Bio:            profile.Bio,
+		Company:        profile.Company,
+		GraduationYear: profile.GraduationYear,
+		Links:          profile.Links,
+		Resumes:        respList,
+		CreatedAt:      user.CreatedAt.Format("2006-01-02 15:04:05"),
 	})
 }
+
+// setRetryAfter 设置Retry-After响应头（单位：秒，向上取整）
+func setRetryAfter(c *gin.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+}
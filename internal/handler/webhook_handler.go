@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/service"
+	"codefolio/internal/util"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WebhookHandler Webhook管理处理器
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler 创建Webhook管理处理器
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// WebhookRequest 创建或修改Webhook请求
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=500"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=128"` // 为空时创建会自动生成，修改时保留原密钥
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1"`
+	Active      *bool    `json:"active"` // 为空时创建默认启用，修改时保持不变
+}
+
+// WebhookResponse Webhook响应，签名密钥仅在创建时返回
+type WebhookResponse struct {
+	*domain.Webhook
+	Secret string `json:"secret,omitempty"`
+}
+
+// GetWebhooks 获取Webhook列表
+// @Summary 获取Webhook列表
+// @Description 获取全部Webhook订阅，可订阅的事件为resume.created、resume.approved、resume.rejected、resume.deleted
+// @Tags 管理
+// @Produce json
+// @Success 200 {object} common.Response{data=[]domain.Webhook}
+// @Failure 401,403,500 {object} common.Response
+// @Router /api/v1/admin/webhooks [get]
+// @Security BearerAuth
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.GetWebhooks()
+	if err != nil {
+		util.GetLogger().Error("获取Webhook列表失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+		return
+	}
+
+	common.ResponseWithData(c, webhooks)
+}
+
+// CreateWebhook 创建Webhook
+// @Summary 创建Webhook
+// @Description 创建Webhook订阅，未提供签名密钥时自动生成，密钥仅在本次响应中返回。
+// @Description 推送请求携带X-Codefolio-Timestamp和X-Codefolio-Signature头，签名为sha256=HMAC-SHA256(secret, timestamp + "." + body)的十六进制值
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param data body WebhookRequest true "Webhook信息"
+// @Success 200 {object} common.Response{data=WebhookResponse}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/webhooks [post]
+// @Security BearerAuth
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(req.toInput())
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	util.GetLogger().Info("管理员创建Webhook",
+		zap.Uint("adminID", getCurrentUserID(c)),
+		zap.Uint("webhookID", webhook.ID),
+		zap.String("url", webhook.URL))
+
+	common.ResponseWithData(c, WebhookResponse{Webhook: webhook, Secret: webhook.Secret})
+}
+
+// GetWebhook 获取Webhook详情
+// @Summary 获取Webhook详情
+// @Tags 管理
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} common.Response{data=domain.Webhook}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/webhooks/{id} [get]
+// @Security BearerAuth
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(id)
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, webhook)
+}
+
+// UpdateWebhook 修改Webhook
+// @Summary 修改Webhook
+// @Description 修改Webhook地址、订阅事件和启用状态，提供secret时更换签名密钥
+// @Tags 管理
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param data body WebhookRequest true "Webhook信息"
+// @Success 200 {object} common.Response{data=domain.Webhook}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/webhooks/{id} [put]
+// @Security BearerAuth
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(id, req.toInput())
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, webhook)
+}
+
+// DeleteWebhook 删除Webhook
+// @Summary 删除Webhook
+// @Description 删除Webhook订阅及其推送记录
+// @Tags 管理
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} common.Response
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/webhooks/{id} [delete]
+// @Security BearerAuth
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(id); err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	util.GetLogger().Info("管理员删除Webhook",
+		zap.Uint("adminID", getCurrentUserID(c)),
+		zap.Uint("webhookID", id))
+
+	common.ResponseSuccess(c)
+}
+
+// PingWebhook 测试Webhook
+// @Summary 测试Webhook
+// @Description 立即向Webhook发送一次webhook.ping事件，推送失败时返回接收方的错误信息，不会重试
+// @Tags 管理
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} common.Response{data=domain.WebhookDelivery}
+// @Failure 400,401,403,500,502 {object} common.Response
+// @Router /api/v1/admin/webhooks/{id}/ping [post]
+// @Security BearerAuth
+func (h *WebhookHandler) PingWebhook(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.PingWebhook(id)
+	if err == service.ErrWebhookDeliveryFailed {
+		common.ResponseWithCustomError(c, common.CodeWebhookError, err.Error()+"："+delivery.Error, http.StatusBadGateway)
+		return
+	}
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, delivery)
+}
+
+// GetDeliveries 获取Webhook推送记录
+// @Summary 获取Webhook推送记录
+// @Description 分页获取Webhook的推送记录，按时间倒序
+// @Tags 管理
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "推送状态(pending/succeeded/failed)"
+// @Param page query int false "页码"
+// @Param size query int false "每页数量"
+// @Success 200 {object} common.Response{data=object}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/webhooks/{id}/deliveries [get]
+// @Security BearerAuth
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", domain.WebhookDeliveryPending, domain.WebhookDeliverySucceeded, domain.WebhookDeliveryFailed:
+	default:
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	page, size := GetPagingParams(c)
+	deliveries, total, err := h.webhookService.GetDeliveries(id, status, page, size)
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	common.ResponseWithData(c, gin.H{
+		"items": deliveries,
+		"total": total,
+		"page":  page,
+		"size":  size,
+	})
+}
+
+// ReplayDelivery 重放Webhook推送
+// @Summary 重放Webhook推送
+// @Description 以原事件ID和内容生成新的推送记录并立即推送，失败后按正常规则重试
+// @Tags 管理
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "推送记录ID"
+// @Success 200 {object} common.Response{data=domain.WebhookDelivery}
+// @Failure 400,401,403,500 {object} common.Response
+// @Router /api/v1/admin/webhooks/{id}/deliveries/{deliveryId}/replay [post]
+// @Security BearerAuth
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	id, ok := parseWebhookID(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryId"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return
+	}
+
+	delivery, err := h.webhookService.ReplayDelivery(id, uint(deliveryID))
+	if err != nil {
+		h.handleWebhookError(c, err)
+		return
+	}
+
+	util.GetLogger().Info("管理员重放Webhook推送",
+		zap.Uint("adminID", getCurrentUserID(c)),
+		zap.Uint("webhookID", id),
+		zap.Uint64("originalDeliveryID", deliveryID),
+		zap.Uint("deliveryID", delivery.ID))
+
+	common.ResponseWithData(c, delivery)
+}
+
+// toInput 转换为服务层参数
+func (r *WebhookRequest) toInput() service.WebhookInput {
+	return service.WebhookInput{
+		URL:         r.URL,
+		Secret:      r.Secret,
+		Description: r.Description,
+		Events:      r.Events,
+		Active:      r.Active,
+	}
+}
+
+// parseWebhookID 解析路径中的Webhook ID，失败时写入错误响应
+func parseWebhookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ResponseWithError(c, common.CodeInvalidParams)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// handleWebhookError 处理Webhook服务错误
+func (h *WebhookHandler) handleWebhookError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrWebhookNotFound, service.ErrWebhookDeliveryNotFound:
+		common.ResponseWithCustomError(c, common.CodeDataNotFound, err.Error())
+	case service.ErrInvalidWebhookURL, service.ErrInvalidWebhookEvent:
+		common.ResponseWithCustomError(c, common.CodeInvalidParams, err.Error())
+	default:
+		util.GetLogger().Error("Webhook操作失败", zap.Error(err))
+		common.ResponseWithError(c, common.CodeInternalError, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/util"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// blobRepository 基于PostgreSQL的内容寻址文件引用计数
+type blobRepository struct {
+	db *gorm.DB
+}
+
+// NewBlobRepository 创建内容寻址文件引用计数存储
+func NewBlobRepository(db *gorm.DB) util.BlobIndex {
+	return &blobRepository{db: db}
+}
+
+// Acquire 增加文件引用，记录不存在时创建
+func (r *blobRepository) Acquire(ctx context.Context, key util.StorageKey) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"ref_count":  gorm.Expr("blobs.ref_count + 1"),
+			"updated_at": now,
+		}),
+	}).Create(&domain.Blob{
+		Key:       key.String(),
+		RefCount:  1,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}).Error
+}
+
+// Release 在事务中锁定记录并减少文件引用
+// 引用归零时删除记录并在提交前调用onZero，并发的Acquire会等待事务结束后重新创建记录
+func (r *blobRepository) Release(ctx context.Context, key util.StorageKey, onZero func()) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var blob domain.Blob
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key.String()).
+			First(&blob).Error; err != nil {
+			// 没有引用记录的文件不由引用计数管理
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if blob.RefCount > 1 {
+			return tx.Model(&domain.Blob{}).
+				Where("key = ?", key.String()).
+				Updates(map[string]interface{}{
+					"ref_count":  gorm.Expr("ref_count - 1"),
+					"updated_at": time.Now(),
+				}).Error
+		}
+
+		if err := tx.Where("key = ?", key.String()).Delete(&domain.Blob{}).Error; err != nil {
+			return err
+		}
+		onZero()
+		return nil
+	})
+}
@@ -0,0 +1,222 @@
+package repository
+
+import (
+	"codefolio/internal/domain"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CommentRepository 简历评论仓库接口
+type CommentRepository interface {
+	Create(comment *domain.Comment) error
+	FindByID(id uint) (*domain.Comment, error)
+	FindByResume(resumeID uint) ([]domain.Comment, error)
+	FindAll(page, size int, status string) ([]domain.Comment, int64, error)
+	UpdateContent(id uint, content string, editedAt time.Time) (bool, error)
+	UpdateStatus(id uint, status string) error
+	Delete(id uint) error
+	HasReplies(id uint) (bool, error)
+	AddVote(commentID, userID uint) (bool, error)
+	RemoveVote(commentID, userID uint) (bool, error)
+	FindVotedCommentIDs(userID uint, commentIDs []uint) ([]uint, error)
+	DeleteByUser(userID uint) error
+}
+
+// commentRepository 简历评论仓库实现
+type commentRepository struct {
+	db *gorm.DB
+}
+
+// NewCommentRepository 创建简历评论仓库实例
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+// Create 创建评论
+func (r *commentRepository) Create(comment *domain.Comment) error {
+	return r.db.Create(comment).Error
+}
+
+// FindByID 根据ID查找评论
+func (r *commentRepository) FindByID(id uint) (*domain.Comment, error) {
+	var comment domain.Comment
+	if err := r.db.Where("id = ?", id).First(&comment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// FindByResume 查找简历的全部评论，包括已删除但仍有回复的评论，按创建时间升序
+func (r *commentRepository) FindByResume(resumeID uint) ([]domain.Comment, error) {
+	var comments []domain.Comment
+	if err := r.db.Unscoped().
+		Where("resume_id = ?", resumeID).
+		Order("created_at ASC").
+		Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// FindAll 分页查询所有评论，供管理员审核，status为空时不筛选
+func (r *commentRepository) FindAll(page, size int, status string) ([]domain.Comment, int64, error) {
+	var comments []domain.Comment
+	var total int64
+
+	query := r.db.Model(&domain.Comment{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Offset((page - 1) * size).Limit(size).
+		Order("created_at DESC").
+		Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return comments, total, nil
+}
+
+// UpdateContent 更新评论内容和编辑时间，返回评论是否仍存在
+// 只更新这两个字段，不覆盖并发变化的点赞数和审核状态，也不会恢复已删除的评论
+func (r *commentRepository) UpdateContent(id uint, content string, editedAt time.Time) (bool, error) {
+	result := r.db.Model(&domain.Comment{}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"content":   content,
+			"edited_at": editedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateStatus 更新评论审核状态
+func (r *commentRepository) UpdateStatus(id uint, status string) error {
+	return r.db.Model(&domain.Comment{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
+// Delete 软删除评论
+func (r *commentRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.Comment{}, id).Error
+}
+
+// HasReplies 判断评论是否有未删除的回复
+func (r *commentRepository) HasReplies(id uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&domain.Comment{}).
+		Where("parent_id = ?", id).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AddVote 点赞评论并增加点赞数，已点赞时返回false
+func (r *commentRepository) AddVote(commentID, userID uint) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.CommentVote{
+			CommentID: commentID,
+			UserID:    userID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		created = true
+		return tx.Model(&domain.Comment{}).
+			Where("id = ?", commentID).
+			UpdateColumn("upvote_count", gorm.Expr("upvote_count + ?", 1)).
+			Error
+	})
+	return created, err
+}
+
+// RemoveVote 取消点赞并减少点赞数，未点赞时返回false
+func (r *commentRepository) RemoveVote(commentID, userID uint) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("comment_id = ? AND user_id = ?", commentID, userID).Delete(&domain.CommentVote{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		removed = true
+		return tx.Unscoped().Model(&domain.Comment{}).
+			Where("id = ? AND upvote_count > 0", commentID).
+			UpdateColumn("upvote_count", gorm.Expr("upvote_count - ?", 1)).
+			Error
+	})
+	return removed, err
+}
+
+// FindVotedCommentIDs 返回给定评论中已被用户点赞的评论ID
+func (r *commentRepository) FindVotedCommentIDs(userID uint, commentIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(commentIDs) == 0 {
+		return ids, nil
+	}
+	if err := r.db.Model(&domain.CommentVote{}).
+		Where("user_id = ? AND comment_id IN ?", userID, commentIDs).
+		Pluck("comment_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// DeleteByUser 永久删除用户发表的评论、点赞，以及其简历下的全部评论
+func (r *commentRepository) DeleteByUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// 同步被点赞评论的点赞数
+		if err := tx.Unscoped().Model(&domain.Comment{}).
+			Where("id IN (?) AND upvote_count > 0",
+				tx.Model(&domain.CommentVote{}).Select("comment_id").Where("user_id = ?", userID)).
+			UpdateColumn("upvote_count", gorm.Expr("upvote_count - ?", 1)).
+			Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.CommentVote{}).Error; err != nil {
+			return err
+		}
+
+		// 待删除的评论：用户发表的评论，以及用户简历下的评论
+		targetIDs := func() *gorm.DB {
+			return tx.Unscoped().Model(&domain.Comment{}).Select("id").
+				Where("user_id = ? OR resume_id IN (?)", userID,
+					tx.Unscoped().Model(&domain.Resume{}).Select("id").Where("user_id = ?", userID))
+		}
+
+		if err := tx.Where("comment_id IN (?)", targetIDs()).Delete(&domain.CommentVote{}).Error; err != nil {
+			return err
+		}
+
+		// 其他用户对已删除评论的回复提升为顶层评论
+		if err := tx.Unscoped().Model(&domain.Comment{}).
+			Where("parent_id IN (?)", targetIDs()).
+			Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Where("id IN (?)", targetIDs()).Delete(&domain.Comment{}).Error
+	})
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"codefolio/internal/domain"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// 咨询列表的用户角色
+const (
+	ConsultationRoleAsker = "asker" // 我发起的咨询
+	ConsultationRoleOwner = "owner" // 我收到的咨询
+)
+
+// ConsultationRepository 付费咨询仓库接口
+type ConsultationRepository interface {
+	Create(consultation *domain.Consultation) error
+	FindByID(id uint) (*domain.Consultation, error)
+	FindByUser(userID uint, role, status string, page, size int) ([]domain.Consultation, int64, error)
+	UpdateIfStatus(consultation *domain.Consultation, from ...string) (bool, error)
+	CreateMessage(message *domain.ConsultationMessage) error
+	FindMessages(consultationID uint) ([]domain.ConsultationMessage, error)
+	DeleteByUser(userID uint) error
+}
+
+// consultationRepository 付费咨询仓库实现
+type consultationRepository struct {
+	db *gorm.DB
+}
+
+// NewConsultationRepository 创建付费咨询仓库实例
+func NewConsultationRepository(db *gorm.DB) ConsultationRepository {
+	return &consultationRepository{db: db}
+}
+
+// Create 创建咨询
+func (r *consultationRepository) Create(consultation *domain.Consultation) error {
+	return r.db.Create(consultation).Error
+}
+
+// FindByID 根据ID查找咨询
+func (r *consultationRepository) FindByID(id uint) (*domain.Consultation, error) {
+	var consultation domain.Consultation
+	if err := r.db.First(&consultation, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &consultation, nil
+}
+
+// FindByUser 分页查询用户发起或收到的咨询，按更新时间倒序
+// role为空时同时返回两者，status为空时不筛选
+func (r *consultationRepository) FindByUser(userID uint, role, status string, page, size int) ([]domain.Consultation, int64, error) {
+	var consultations []domain.Consultation
+	var total int64
+
+	query := r.db.Model(&domain.Consultation{})
+	switch role {
+	case ConsultationRoleAsker:
+		query = query.Where("asker_id = ?", userID)
+	case ConsultationRoleOwner:
+		query = query.Where("owner_id = ?", userID)
+	default:
+		query = query.Where("asker_id = ? OR owner_id = ?", userID, userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	if err := query.Order("updated_at DESC").Offset(offset).Limit(size).Find(&consultations).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return consultations, total, nil
+}
+
+// UpdateIfStatus 仅当咨询当前处于给定状态之一时保存修改，用于避免并发操作重复扣款或退款
+func (r *consultationRepository) UpdateIfStatus(consultation *domain.Consultation, from ...string) (bool, error) {
+	result := r.db.Model(consultation).
+		Where("status IN ?", from).
+		Select("*").Omit("id", "created_at").
+		Updates(consultation)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CreateMessage 创建留言
+func (r *consultationRepository) CreateMessage(message *domain.ConsultationMessage) error {
+	return r.db.Create(message).Error
+}
+
+// FindMessages 查询咨询的全部留言，按时间正序
+func (r *consultationRepository) FindMessages(consultationID uint) ([]domain.ConsultationMessage, error) {
+	var messages []domain.ConsultationMessage
+	err := r.db.Where("consultation_id = ?", consultationID).
+		Order("created_at ASC, id ASC").
+		Find(&messages).Error
+	return messages, err
+}
+
+// DeleteByUser 删除用户参与的全部咨询及留言
+func (r *consultationRepository) DeleteByUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		ids := tx.Model(&domain.Consultation{}).
+			Select("id").
+			Where("asker_id = ? OR owner_id = ?", userID, userID)
+		if err := tx.Where("consultation_id IN (?)", ids).Delete(&domain.ConsultationMessage{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("asker_id = ? OR owner_id = ?", userID, userID).Delete(&domain.Consultation{}).Error
+	})
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"codefolio/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FavoriteRepository 简历收藏仓库接口
+type FavoriteRepository interface {
+	Add(userID, resumeID uint) (bool, error)
+	Remove(userID, resumeID uint) (bool, error)
+	FindResumesByUser(userID uint, page, size int) ([]domain.Resume, int64, error)
+	FindFavoritedResumeIDs(userID uint, resumeIDs []uint) ([]uint, error)
+	DeleteByUser(userID uint) error
+}
+
+// favoriteRepository 简历收藏仓库实现
+type favoriteRepository struct {
+	db *gorm.DB
+}
+
+// NewFavoriteRepository 创建简历收藏仓库实例
+func NewFavoriteRepository(db *gorm.DB) FavoriteRepository {
+	return &favoriteRepository{db: db}
+}
+
+// Add 收藏简历并增加收藏次数，已收藏时返回false
+func (r *favoriteRepository) Add(userID, resumeID uint) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.Favorite{
+			UserID:   userID,
+			ResumeID: resumeID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		created = true
+		return tx.Model(&domain.Resume{}).
+			Where("id = ?", resumeID).
+			UpdateColumn("favorite_count", gorm.Expr("favorite_count + ?", 1)).
+			Error
+	})
+	return created, err
+}
+
+// Remove 取消收藏并减少收藏次数，未收藏时返回false
+func (r *favoriteRepository) Remove(userID, resumeID uint) (bool, error) {
+	removed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND resume_id = ?", userID, resumeID).Delete(&domain.Favorite{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		removed = true
+		return tx.Unscoped().Model(&domain.Resume{}).
+			Where("id = ? AND favorite_count > 0", resumeID).
+			UpdateColumn("favorite_count", gorm.Expr("favorite_count - ?", 1)).
+			Error
+	})
+	return removed, err
+}
+
+// FindResumesByUser 分页查询用户收藏的简历，按收藏时间倒序
+// 已删除或未公开的简历不返回
+func (r *favoriteRepository) FindResumesByUser(userID uint, page, size int) ([]domain.Resume, int64, error) {
+	var resumes []domain.Resume
+	var total int64
+
+	query := r.db.Model(&domain.Resume{}).
+		Joins("JOIN favorites ON favorites.resume_id = resumes.id").
+		Where("favorites.user_id = ? AND resumes.status = ?", userID, domain.ResumeStatusApproved)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Select("resumes.*").
+		Order("favorites.created_at DESC").
+		Offset((page - 1) * size).Limit(size).
+		Find(&resumes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return resumes, total, nil
+}
+
+// FindFavoritedResumeIDs 返回给定简历中已被用户收藏的简历ID
+func (r *favoriteRepository) FindFavoritedResumeIDs(userID uint, resumeIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(resumeIDs) == 0 {
+		return ids, nil
+	}
+	if err := r.db.Model(&domain.Favorite{}).
+		Where("user_id = ? AND resume_id IN ?", userID, resumeIDs).
+		Pluck("resume_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// DeleteByUser 删除用户的收藏及其简历被收藏的记录，并同步被收藏简历的收藏次数
+func (r *favoriteRepository) DeleteByUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&domain.Resume{}).
+			Where("id IN (?) AND favorite_count > 0",
+				tx.Model(&domain.Favorite{}).Select("resume_id").Where("user_id = ?", userID)).
+			UpdateColumn("favorite_count", gorm.Expr("favorite_count - ?", 1)).
+			Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.Favorite{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("resume_id IN (?)",
+			tx.Unscoped().Model(&domain.Resume{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&domain.Favorite{}).Error
+	})
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"codefolio/internal/domain"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepository 站内通知仓库接口
+type NotificationRepository interface {
+	Create(notification *domain.Notification) error
+	FindByUser(userID uint, unreadOnly bool, page, size int) ([]domain.Notification, int64, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID, id uint) (bool, error)
+	MarkAllRead(userID uint) (int64, error)
+	FindPreferences(userID uint) ([]domain.NotificationPreference, error)
+	FindPreference(userID uint, notificationType string) (*domain.NotificationPreference, error)
+	SavePreferences(userID uint, prefs []domain.NotificationPreference) error
+	FindDigestPending() ([]domain.Notification, error)
+	MarkEmailed(ids []uint) error
+	DeleteByUser(userID uint) error
+}
+
+// notificationRepository 站内通知仓库实现
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository 创建站内通知仓库实例
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// Create 创建通知
+func (r *notificationRepository) Create(notification *domain.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+// FindByUser 分页查询用户的通知，按时间倒序
+func (r *notificationRepository) FindByUser(userID uint, unreadOnly bool, page, size int) ([]domain.Notification, int64, error) {
+	var notifications []domain.Notification
+	var total int64
+
+	query := r.db.Model(&domain.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(size).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+// CountUnread 统计用户的未读通知数
+func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead 将通知标记为已读，通知不存在时返回false
+func (r *notificationRepository) MarkRead(userID, id uint) (bool, error) {
+	var notification domain.Notification
+	err := r.db.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	err = r.db.Model(&domain.Notification{}).
+		Where("id = ? AND read_at IS NULL", id).
+		Update("read_at", time.Now()).Error
+	return err == nil, err
+}
+
+// MarkAllRead 将用户的全部通知标记为已读，返回标记的数量
+func (r *notificationRepository) MarkAllRead(userID uint) (int64, error) {
+	result := r.db.Model(&domain.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// FindPreferences 查询用户已设置的通知偏好
+func (r *notificationRepository) FindPreferences(userID uint) ([]domain.NotificationPreference, error) {
+	var prefs []domain.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&prefs).Error
+	return prefs, err
+}
+
+// FindPreference 查询用户对某类通知的偏好，未设置时返回nil
+func (r *notificationRepository) FindPreference(userID uint, notificationType string) (*domain.NotificationPreference, error) {
+	var prefs []domain.NotificationPreference
+	if err := r.db.Where("user_id = ? AND type = ?", userID, notificationType).Limit(1).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	if len(prefs) == 0 {
+		return nil, nil
+	}
+	return &prefs[0], nil
+}
+
+// SavePreferences 保存用户的通知偏好，已存在的类型覆盖更新
+func (r *notificationRepository) SavePreferences(userID uint, prefs []domain.NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	for i := range prefs {
+		prefs[i].UserID = userID
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email"}),
+	}).Create(&prefs).Error
+}
+
+// FindDigestPending 查询待发送邮件摘要的通知：未读、未发送过，且用户为该类型开启了邮件摘要
+func (r *notificationRepository) FindDigestPending() ([]domain.Notification, error) {
+	var notifications []domain.Notification
+	err := r.db.Model(&domain.Notification{}).
+		Select("notifications.*").
+		Joins("JOIN notification_preferences p ON p.user_id = notifications.user_id AND p.type = notifications.type").
+		Where("p.email = ? AND notifications.read_at IS NULL AND notifications.emailed_at IS NULL", true).
+		Order("notifications.user_id, notifications.created_at").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkEmailed 标记通知已通过邮件摘要发送
+func (r *notificationRepository) MarkEmailed(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&domain.Notification{}).
+		Where("id IN ?", ids).
+		Update("emailed_at", time.Now()).Error
+}
+
+// DeleteByUser 删除用户的全部通知和偏好设置
+func (r *notificationRepository) DeleteByUser(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.Notification{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&domain.NotificationPreference{}).Error
+	})
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/util"
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// rateLimitRepository 基于PostgreSQL的限流存储，多个实例共享同一组令牌桶
+type rateLimitRepository struct {
+	db *gorm.DB
+}
+
+// NewRateLimitRepository 创建PostgreSQL限流存储，并启动过期令牌桶清理goroutine
+func NewRateLimitRepository(db *gorm.DB) util.RateLimitStore {
+	r := &rateLimitRepository{db: db}
+	go r.cleanup()
+	return r
+}
+
+// Take 在事务中锁定令牌桶行并取出一个令牌
+func (r *rateLimitRepository) Take(ctx context.Context, key string, policy util.RateLimitPolicy) (*util.RateLimitResult, error) {
+	var result *util.RateLimitResult
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// 确保令牌桶存在，新桶为满桶
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.RateLimitBucket{
+			Key:        key,
+			Tokens:     float64(policy.Limit),
+			LastRefill: now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// 行锁保证并发请求串行扣减
+		var bucket domain.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			First(&bucket).Error; err != nil {
+			return err
+		}
+
+		tokens, res := util.TakeToken(bucket.Tokens, bucket.LastRefill, now, policy)
+		result = res
+
+		return tx.Model(&domain.RateLimitBucket{}).
+			Where("key = ?", key).
+			Updates(map[string]interface{}{
+				"tokens":      tokens,
+				"last_refill": now,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// cleanup 定期删除长时间未使用的令牌桶
+func (r *rateLimitRepository) cleanup() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().Add(-24 * time.Hour)
+		if err := r.db.Where("last_refill < ?", cutoff).Delete(&domain.RateLimitBucket{}).Error; err != nil {
+			util.GetLogger().Warn("清理限流令牌桶失败", zap.Error(err))
+		}
+	}
+}
@@ -2,7 +2,11 @@ package repository
 
 import (
 	"codefolio/internal/domain"
+	"codefolio/internal/util"
 	"errors"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -11,11 +15,24 @@ type ResumeRepository interface {
 	Create(resume *domain.Resume) error
 	FindByID(id uint) (*domain.Resume, error)
 	FindByUser(userID uint) ([]domain.Resume, error)
-	FindAll(page, size int, role, level, university int) ([]domain.Resume, int64, error)
-	Update(resume *domain.Resume) error
+	FindByUserAndStatus(userID uint, status string) ([]domain.Resume, error)
+	FindAll(page, size int, role, level, university int, sort string) ([]domain.Resume, int64, error)
+	FindByStatus(status string, page, size int) ([]domain.Resume, int64, error)
+	UpdateStatus(id uint, status string) error
+	UpdateStatusIf(id uint, from, to string) (bool, error)
+	UpdateColumns(resume *domain.Resume, columns ...string) (bool, error)
 	Delete(id uint) error
+	DeleteByUser(userID uint) error
+	FindDeletedByID(id uint) (*domain.Resume, error)
+	FindDeletedByUser(userID uint) ([]domain.Resume, error)
+	FindDeletedBefore(before time.Time) ([]domain.Resume, error)
+	Restore(id uint) error
+	HardDelete(id uint) (bool, error)
+	HardDeleteByUser(userID uint) error
 	IncrementViewCount(id uint) error
 	IncrementDownloadCount(id uint) error
+	FindByContentHash(hash string) ([]domain.Resume, error)
+	FindMostSimilarByPHash(userID uint, phash string, maxDistance int) (uint, int, error)
 }
 
 // resumeRepository 简历仓库实现
@@ -54,15 +71,26 @@ func (r *resumeRepository) FindByUser(userID uint) ([]domain.Resume, error) {
 	return resumes, nil
 }
 
-// FindAll 查询所有简历，支持分页和筛选
-func (r *resumeRepository) FindAll(page, size int, role, level, university int) ([]domain.Resume, int64, error) {
+// FindByUserAndStatus 查找用户指定审核状态的简历
+func (r *resumeRepository) FindByUserAndStatus(userID uint, status string) ([]domain.Resume, error) {
+	var resumes []domain.Resume
+	if err := r.db.Where("user_id = ? AND status = ?", userID, status).
+		Order("created_at DESC").
+		Find(&resumes).Error; err != nil {
+		return nil, err
+	}
+	return resumes, nil
+}
+
+// FindAll 查询所有已审核通过的简历，支持分页、筛选和排序
+func (r *resumeRepository) FindAll(page, size int, role, level, university int, sort string) ([]domain.Resume, int64, error) {
 	var resumes []domain.Resume
 	var total int64
 
 	offset := (page - 1) * size
 
 	// 构建查询
-	query := r.db.Model(&domain.Resume{})
+	query := r.db.Model(&domain.Resume{}).Where("status = ?", domain.ResumeStatusApproved)
 
 	// 职位筛选
 	if role > 0 {
@@ -84,6 +112,12 @@ func (r *resumeRepository) FindAll(page, size int, role, level, university int)
 		return nil, 0, err
 	}
 
+	// 排序
+	switch sort {
+	case domain.ResumeSortFavorites:
+		query = query.Order("favorite_count DESC")
+	}
+
 	// 查询数据
 	if err := query.Offset(offset).Limit(size).
 		Order("created_at DESC").
@@ -94,16 +128,116 @@ func (r *resumeRepository) FindAll(page, size int, role, level, university int)
 	return resumes, total, nil
 }
 
-// Update 更新简历信息
-func (r *resumeRepository) Update(resume *domain.Resume) error {
-	return r.db.Save(resume).Error
+// FindByStatus 按审核状态分页查询简历，按创建时间正序，status为空时不筛选
+func (r *resumeRepository) FindByStatus(status string, page, size int) ([]domain.Resume, int64, error) {
+	var resumes []domain.Resume
+	var total int64
+
+	query := r.db.Model(&domain.Resume{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	if err := query.Order("created_at ASC").Offset(offset).Limit(size).Find(&resumes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return resumes, total, nil
+}
+
+// UpdateStatus 更新简历审核状态
+func (r *resumeRepository) UpdateStatus(id uint, status string) error {
+	return r.db.Model(&domain.Resume{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// UpdateStatusIf 仅当简历处于from状态时更新为to，返回是否更新成功，避免覆盖并发作出的审核结果
+func (r *resumeRepository) UpdateStatusIf(id uint, from, to string) (bool, error) {
+	result := r.db.Model(&domain.Resume{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
 }
 
-// Delete 删除简历
+// UpdateColumns 只更新简历的指定字段，返回简历是否仍存在
+// 不使用Save，避免把并发删除的简历恢复，或用旧数据覆盖其他字段
+func (r *resumeRepository) UpdateColumns(resume *domain.Resume, columns ...string) (bool, error) {
+	result := r.db.Model(&domain.Resume{}).Where("id = ?", resume.ID).Select(columns).Updates(resume)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Delete 软删除简历
 func (r *resumeRepository) Delete(id uint) error {
 	return r.db.Delete(&domain.Resume{}, id).Error
 }
 
+// DeleteByUser 软删除用户的所有简历
+func (r *resumeRepository) DeleteByUser(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&domain.Resume{}).Error
+}
+
+// FindDeletedByID 根据ID查找已软删除的简历
+func (r *resumeRepository) FindDeletedByID(id uint) (*domain.Resume, error) {
+	var resume domain.Resume
+	if err := r.db.Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&resume).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &resume, nil
+}
+
+// FindDeletedByUser 查找用户已软删除的简历，按删除时间倒序
+func (r *resumeRepository) FindDeletedByUser(userID uint) ([]domain.Resume, error) {
+	var resumes []domain.Resume
+	if err := r.db.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&resumes).Error; err != nil {
+		return nil, err
+	}
+	return resumes, nil
+}
+
+// FindDeletedBefore 查找在指定时间之前软删除的简历
+func (r *resumeRepository) FindDeletedBefore(before time.Time) ([]domain.Resume, error) {
+	var resumes []domain.Resume
+	if err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Find(&resumes).Error; err != nil {
+		return nil, err
+	}
+	return resumes, nil
+}
+
+// Restore 恢复已软删除的简历
+func (r *resumeRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&domain.Resume{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+// HardDelete 永久删除简历，返回是否由本次调用删除
+func (r *resumeRepository) HardDelete(id uint) (bool, error) {
+	result := r.db.Unscoped().Delete(&domain.Resume{}, id)
+	return result.RowsAffected > 0, result.Error
+}
+
+// HardDeleteByUser 永久删除用户的所有简历，包括已软删除的简历
+func (r *resumeRepository) HardDeleteByUser(userID uint) error {
+	return r.db.Unscoped().Where("user_id = ?", userID).Delete(&domain.Resume{}).Error
+}
+
 // IncrementViewCount 增加查看次数
 func (r *resumeRepository) IncrementViewCount(id uint) error {
 	return r.db.Model(&domain.Resume{}).
@@ -119,3 +253,49 @@ func (r *resumeRepository) IncrementDownloadCount(id uint) error {
 		UpdateColumn("download_count", gorm.Expr("download_count + ?", 1)).
 		Error
 }
+
+// FindByContentHash 查找文件内容相同的简历，按ID升序，最早上传的在前
+func (r *resumeRepository) FindByContentHash(hash string) ([]domain.Resume, error) {
+	var resumes []domain.Resume
+	if err := r.db.Where("content_hash = ?", hash).Order("id ASC").Find(&resumes).Error; err != nil {
+		return nil, err
+	}
+	return resumes, nil
+}
+
+// phashDistanceExpr 在数据库中计算与参数中感知哈希的汉明距离，格式不正确的哈希距离为NULL
+const phashDistanceExpr = "CASE WHEN p_hash ~ '^[0-9a-f]{16}$' THEN " +
+	"length(replace((('x' || p_hash)::bit(64) # ('x' || ?)::bit(64))::text, '0', '')) END"
+
+// FindMostSimilarByPHash 查找其他用户的简历中与phash汉明距离最小且不超过maxDistance的简历
+// 返回简历ID和距离，没有时ID为0；maxDistance小于分段数时先按分段索引筛选候选，不必扫描全部简历
+func (r *resumeRepository) FindMostSimilarByPHash(userID uint, phash string, maxDistance int) (uint, int, error) {
+	bands, err := util.PHashBandValues(phash)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	candidates := r.db.Model(&domain.Resume{}).
+		Select("id, "+phashDistanceExpr+" AS distance", strings.ToLower(phash)).
+		Where("user_id <> ? AND p_hash <> ''", userID)
+	if maxDistance < util.PHashBands {
+		conds := make([]string, len(bands))
+		args := make([]interface{}, len(bands))
+		for i, band := range bands {
+			conds[i] = util.PHashBandExpr(i) + " = ?"
+			args[i] = band
+		}
+		candidates = candidates.Where(strings.Join(conds, " OR "), args...)
+	}
+
+	var match struct {
+		ID       uint
+		Distance int
+	}
+	err = r.db.Table("(?) AS candidates", candidates).
+		Where("distance <= ?", maxDistance).
+		Order("distance ASC, id ASC").
+		Limit(1).
+		Scan(&match).Error
+	return match.ID, match.Distance, err
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"codefolio/internal/domain"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ResumeVersionRepository 简历修订记录仓库接口
+type ResumeVersionRepository interface {
+	Create(version *domain.ResumeVersion) error
+	FindByResume(resumeID uint) ([]domain.ResumeVersion, error)
+	FindByResumeAndVersion(resumeID uint, version int) (*domain.ResumeVersion, error)
+	FindByUser(userID uint) ([]domain.ResumeVersion, error)
+	UpdateStatus(resumeID uint, version int, status string) error
+	DeleteByResume(resumeID uint) ([]domain.ResumeVersion, error)
+	DeleteByUser(userID uint) ([]domain.ResumeVersion, error)
+}
+
+// resumeVersionRepository 简历修订记录仓库实现
+type resumeVersionRepository struct {
+	db *gorm.DB
+}
+
+// NewResumeVersionRepository 创建简历修订记录仓库实例
+func NewResumeVersionRepository(db *gorm.DB) ResumeVersionRepository {
+	return &resumeVersionRepository{db: db}
+}
+
+// Create 创建修订记录
+func (r *resumeVersionRepository) Create(version *domain.ResumeVersion) error {
+	return r.db.Create(version).Error
+}
+
+// FindByResume 查找简历的所有修订记录，按版本号升序
+func (r *resumeVersionRepository) FindByResume(resumeID uint) ([]domain.ResumeVersion, error) {
+	var versions []domain.ResumeVersion
+	if err := r.db.Where("resume_id = ?", resumeID).
+		Order("version ASC").
+		Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// FindByResumeAndVersion 查找简历的指定版本
+func (r *resumeVersionRepository) FindByResumeAndVersion(resumeID uint, version int) (*domain.ResumeVersion, error) {
+	var v domain.ResumeVersion
+	if err := r.db.Where("resume_id = ? AND version = ?", resumeID, version).First(&v).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &v, nil
+}
+
+// FindByUser 查找用户所有简历的修订记录
+func (r *resumeVersionRepository) FindByUser(userID uint) ([]domain.ResumeVersion, error) {
+	var versions []domain.ResumeVersion
+	if err := r.db.Where("user_id = ?", userID).Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// UpdateStatus 更新修订记录的审核状态
+func (r *resumeVersionRepository) UpdateStatus(resumeID uint, version int, status string) error {
+	return r.db.Model(&domain.ResumeVersion{}).
+		Where("resume_id = ? AND version = ?", resumeID, version).
+		Update("status", status).Error
+}
+
+// DeleteByResume 删除简历的所有修订记录，返回本次实际删除的记录
+// 并发的清理任务只会各自拿到自己删除的行，据此释放文件引用不会重复
+func (r *resumeVersionRepository) DeleteByResume(resumeID uint) ([]domain.ResumeVersion, error) {
+	var versions []domain.ResumeVersion
+	if err := r.db.Clauses(clause.Returning{}).
+		Where("resume_id = ?", resumeID).
+		Delete(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// DeleteByUser 删除用户所有简历的修订记录，返回本次实际删除的记录
+func (r *resumeVersionRepository) DeleteByUser(userID uint) ([]domain.ResumeVersion, error) {
+	var versions []domain.ResumeVersion
+	if err := r.db.Clauses(clause.Returning{}).
+		Where("user_id = ?", userID).
+		Delete(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
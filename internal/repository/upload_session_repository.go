@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"codefolio/internal/domain"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UploadSessionRepository 分片上传会话仓库接口
+type UploadSessionRepository interface {
+	Create(session *domain.UploadSession) error
+	FindByID(id string) (*domain.UploadSession, error)
+	CountActiveByUser(userID uint, now time.Time) (int64, error)
+	UpdateStatus(id, from, to string) (bool, error)
+	Complete(session *domain.UploadSession) (bool, error)
+	SavePart(part *domain.UploadPart) error
+	FindParts(sessionID string) ([]domain.UploadPart, error)
+	FindExpired(now time.Time, limit int) ([]domain.UploadSession, error)
+	Delete(id string) error
+}
+
+// uploadSessionRepository 分片上传会话仓库实现
+type uploadSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUploadSessionRepository 创建分片上传会话仓库实例
+func NewUploadSessionRepository(db *gorm.DB) UploadSessionRepository {
+	return &uploadSessionRepository{db: db}
+}
+
+// Create 创建上传会话
+func (r *uploadSessionRepository) Create(session *domain.UploadSession) error {
+	return r.db.Create(session).Error
+}
+
+// FindByID 根据ID查找上传会话
+func (r *uploadSessionRepository) FindByID(id string) (*domain.UploadSession, error) {
+	var session domain.UploadSession
+	if err := r.db.Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// CountActiveByUser 统计用户未完成且未过期的上传会话数量
+func (r *uploadSessionRepository) CountActiveByUser(userID uint, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.UploadSession{}).
+		Where("user_id = ? AND status NOT IN ? AND expires_at > ?", userID,
+			[]string{domain.UploadSessionCompleted, domain.UploadSessionClaimed}, now).
+		Count(&count).Error
+	return count, err
+}
+
+// UpdateStatus 仅当会话处于from状态时更新为to，返回是否更新成功，用于防止并发合并
+func (r *uploadSessionRepository) UpdateStatus(id, from, to string) (bool, error) {
+	result := r.db.Model(&domain.UploadSession{}).
+		Where("id = ? AND status = ?", id, from).
+		Updates(map[string]interface{}{
+			"status":     to,
+			"updated_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Complete 保存合并转换的结果，仅当会话处于completing状态时更新为completed，返回是否更新成功
+func (r *uploadSessionRepository) Complete(session *domain.UploadSession) (bool, error) {
+	result := r.db.Model(&domain.UploadSession{}).
+		Where("id = ? AND status = ?", session.ID, domain.UploadSessionCompleting).
+		Updates(map[string]interface{}{
+			"status":       domain.UploadSessionCompleted,
+			"image_key":    session.ImageKey,
+			"source_key":   session.SourceKey,
+			"content_hash": session.ContentHash,
+			"p_hash":       session.PHash,
+			"expires_at":   session.ExpiresAt,
+			"updated_at":   time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// SavePart 保存分片记录，重复上传的分片覆盖原记录
+func (r *uploadSessionRepository) SavePart(part *domain.UploadPart) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}, {Name: "part_number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"size", "checksum", "created_at"}),
+	}).Create(part).Error
+}
+
+// FindParts 查询会话已接收的分片，按分片序号排序
+func (r *uploadSessionRepository) FindParts(sessionID string) ([]domain.UploadPart, error) {
+	var parts []domain.UploadPart
+	err := r.db.Where("session_id = ?", sessionID).Order("part_number ASC").Find(&parts).Error
+	return parts, err
+}
+
+// FindExpired 查询已过期的上传会话
+func (r *uploadSessionRepository) FindExpired(now time.Time, limit int) ([]domain.UploadSession, error) {
+	var sessions []domain.UploadSession
+	err := r.db.Where("expires_at <= ?", now).Order("expires_at ASC").Limit(limit).Find(&sessions).Error
+	return sessions, err
+}
+
+// Delete 删除上传会话及其分片记录
+func (r *uploadSessionRepository) Delete(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("session_id = ?", id).Delete(&domain.UploadPart{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&domain.UploadSession{}).Error
+	})
+}
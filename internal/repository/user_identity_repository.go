@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentityRepository 第三方登录身份仓库接口
+type UserIdentityRepository interface {
+	Create(identity *domain.UserIdentity) error
+	FindByProviderSubject(provider, subject string) (*domain.UserIdentity, error)
+	FindByUser(userID uint) ([]domain.UserIdentity, error)
+	FindByUserAndProvider(userID uint, provider string) (*domain.UserIdentity, error)
+	Update(identity *domain.UserIdentity) error
+	Delete(id uint) error
+	DeleteByUser(userID uint) error
+}
+
+// userIdentityRepository 第三方登录身份仓库实现
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewUserIdentityRepository 创建第三方登录身份仓库实例
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+// Create 创建身份关联
+func (r *userIdentityRepository) Create(identity *domain.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// FindByProviderSubject 根据提供方和提供方用户标识查找
+func (r *userIdentityRepository) FindByProviderSubject(provider, subject string) (*domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// FindByUser 查找用户关联的所有身份
+func (r *userIdentityRepository) FindByUser(userID uint) ([]domain.UserIdentity, error) {
+	var identities []domain.UserIdentity
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// FindByUserAndProvider 查找用户在指定提供方的身份
+func (r *userIdentityRepository) FindByUserAndProvider(userID uint, provider string) (*domain.UserIdentity, error) {
+	var identity domain.UserIdentity
+	if err := r.db.Where("user_id = ? AND provider = ?", userID, provider).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Update 更新身份信息
+func (r *userIdentityRepository) Update(identity *domain.UserIdentity) error {
+	return r.db.Save(identity).Error
+}
+
+// Delete 删除身份关联
+func (r *userIdentityRepository) Delete(id uint) error {
+	return r.db.Delete(&domain.UserIdentity{}, id).Error
+}
+
+// DeleteByUser 删除用户的所有身份关联
+func (r *userIdentityRepository) DeleteByUser(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&domain.UserIdentity{}).Error
+}
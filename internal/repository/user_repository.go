@@ -4,6 +4,7 @@ import (
 	"codefolio/internal/common"
 	"codefolio/internal/domain"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -12,10 +13,24 @@ import (
 type UserRepository interface {
 	Create(user *domain.User) error
 	FindByID(id uint) (*domain.User, error)
+	FindByIDs(ids []uint) ([]domain.User, error)
 	FindByEmail(email string) (*domain.User, error)
 	FindByUsername(username string) (*domain.User, error)
 	Update(user *domain.User) error
+	UpdateProfile(user *domain.User) error
+	UpdateAvatar(user *domain.User) error
+	UpdateLoginState(user *domain.User) error
+	IncrementFailedLogin(id uint) (int, error)
+	LockAccount(id uint, threshold int, lockedUntil func(lockCount int) time.Time) (int, *time.Time, error)
+	UpdateDeletionState(user *domain.User) error
+	FindDeletionDue(before time.Time) ([]domain.User, error)
+	ExistsByUsername(username string) (bool, error)
+	ExistsByEmail(email string) (bool, error)
 	Delete(id uint) error
+	FindDeletedByID(id uint) (*domain.User, error)
+	FindDeletedBefore(before time.Time) ([]domain.User, error)
+	Restore(id uint) error
+	HardDelete(id uint) error
 }
 
 // userRepository 用户仓库实现
@@ -45,6 +60,18 @@ func (r *userRepository) FindByID(id uint) (*domain.User, error) {
 	return &user, nil
 }
 
+// FindByIDs 批量查找用户，不存在的用户会被忽略
+func (r *userRepository) FindByIDs(ids []uint) ([]domain.User, error) {
+	var users []domain.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := r.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 // FindByEmail 根据邮箱查找用户
 func (r *userRepository) FindByEmail(email string) (*domain.User, error) {
 	var user domain.User
@@ -74,7 +101,134 @@ func (r *userRepository) Update(user *domain.User) error {
 	return r.db.Save(user).Error
 }
 
-// Delete 删除用户
+// UpdateProfile 仅更新个人资料字段，避免覆盖并发修改的登录保护等字段
+func (r *userRepository) UpdateProfile(user *domain.User) error {
+	return r.db.Model(user).
+		Select("display_name", "bio", "company", "graduation_year", "links").
+		Updates(user).Error
+}
+
+// UpdateAvatar 仅更新头像字段
+func (r *userRepository) UpdateAvatar(user *domain.User) error {
+	return r.db.Model(user).
+		Select("avatar_url").
+		Updates(user).Error
+}
+
+// UpdateLoginState 仅更新登录保护相关字段，避免覆盖并发修改的其他字段
+func (r *userRepository) UpdateLoginState(user *domain.User) error {
+	return r.db.Model(user).
+		Select("failed_login_count", "lock_count", "locked_until").
+		Updates(user).Error
+}
+
+// IncrementFailedLogin 原子地增加登录失败次数，返回增加后的值
+func (r *userRepository) IncrementFailedLogin(id uint) (int, error) {
+	var count int
+	err := r.db.Raw("UPDATE users SET failed_login_count = failed_login_count + 1 WHERE id = ? RETURNING failed_login_count", id).
+		Scan(&count).Error
+	return count, err
+}
+
+// LockAccount 失败次数达到threshold时清零失败次数、增加锁定次数并按lockedUntil设置解锁时间
+// 并发请求中只有一个会锁定成功，其他请求返回的锁定次数为0
+func (r *userRepository) LockAccount(id uint, threshold int, lockedUntil func(lockCount int) time.Time) (int, *time.Time, error) {
+	var lockCount int
+	var until *time.Time
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Raw("UPDATE users SET failed_login_count = 0, lock_count = lock_count + 1 "+
+			"WHERE id = ? AND failed_login_count >= ? RETURNING lock_count", id, threshold).
+			Scan(&lockCount)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		t := lockedUntil(lockCount)
+		until = &t
+		return tx.Model(&domain.User{}).Where("id = ?", id).Update("locked_until", t).Error
+	})
+	if err != nil || until == nil {
+		return 0, nil, err
+	}
+	return lockCount, until, nil
+}
+
+// UpdateDeletionState 仅更新账户注销相关字段
+func (r *userRepository) UpdateDeletionState(user *domain.User) error {
+	return r.db.Model(user).
+		Select("deletion_requested_at", "deletion_scheduled_at").
+		Updates(user).Error
+}
+
+// FindDeletionDue 查找冷静期已结束、需要删除的用户
+func (r *userRepository) FindDeletionDue(before time.Time) ([]domain.User, error) {
+	var users []domain.User
+	if err := r.db.Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", before).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// ExistsByUsername 检查用户名是否已被占用，已软删除的用户仍占用用户名
+func (r *userRepository) ExistsByUsername(username string) (bool, error) {
+	var count int64
+	if err := r.db.Unscoped().Model(&domain.User{}).
+		Where("username = ?", username).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ExistsByEmail 检查邮箱是否已被占用，已软删除的用户仍占用邮箱
+func (r *userRepository) ExistsByEmail(email string) (bool, error) {
+	var count int64
+	if err := r.db.Unscoped().Model(&domain.User{}).
+		Where("email = ?", email).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Delete 软删除用户
 func (r *userRepository) Delete(id uint) error {
 	return r.db.Delete(&domain.User{}, id).Error
 }
+
+// FindDeletedByID 根据ID查找已软删除的用户
+func (r *userRepository) FindDeletedByID(id uint) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, common.ErrRecordNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindDeletedBefore 查找在指定时间之前软删除的用户
+func (r *userRepository) FindDeletedBefore(before time.Time) ([]domain.User, error) {
+	var users []domain.User
+	if err := r.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Restore 恢复已软删除的用户
+func (r *userRepository) Restore(id uint) error {
+	return r.db.Unscoped().Model(&domain.User{}).
+		Where("id = ?", id).
+		Update("deleted_at", nil).Error
+}
+
+// HardDelete 永久删除用户
+func (r *userRepository) HardDelete(id uint) error {
+	return r.db.Unscoped().Delete(&domain.User{}, id).Error
+}
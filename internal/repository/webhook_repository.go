@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"codefolio/internal/domain"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookRepository Webhook仓库接口
+type WebhookRepository interface {
+	Create(webhook *domain.Webhook) error
+	FindByID(id uint) (*domain.Webhook, error)
+	FindAll() ([]domain.Webhook, error)
+	FindActive() ([]domain.Webhook, error)
+	Update(webhook *domain.Webhook) error
+	Delete(id uint) error
+	CreateDelivery(delivery *domain.WebhookDelivery) error
+	FindDeliveryByID(id uint) (*domain.WebhookDelivery, error)
+	FindDeliveries(webhookID uint, status string, page, size int) ([]domain.WebhookDelivery, int64, error)
+	FindDueDeliveries(now time.Time, limit int) ([]domain.WebhookDelivery, error)
+	ClaimDelivery(id uint, now, leaseUntil time.Time) (bool, error)
+	UpdateDelivery(delivery *domain.WebhookDelivery) error
+}
+
+// webhookRepository Webhook仓库实现
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository 创建Webhook仓库实例
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// Create 创建Webhook订阅
+func (r *webhookRepository) Create(webhook *domain.Webhook) error {
+	return r.db.Create(webhook).Error
+}
+
+// FindByID 根据ID查找Webhook订阅
+func (r *webhookRepository) FindByID(id uint) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	if err := r.db.First(&webhook, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// FindAll 查询全部Webhook订阅
+func (r *webhookRepository) FindAll() ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	err := r.db.Order("id ASC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// FindActive 查询已启用的Webhook订阅
+func (r *webhookRepository) FindActive() ([]domain.Webhook, error) {
+	var webhooks []domain.Webhook
+	err := r.db.Where("active = ?", true).Order("id ASC").Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Update 更新Webhook订阅
+func (r *webhookRepository) Update(webhook *domain.Webhook) error {
+	return r.db.Save(webhook).Error
+}
+
+// Delete 删除Webhook订阅及其推送记录
+func (r *webhookRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&domain.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&domain.Webhook{}, id).Error
+	})
+}
+
+// CreateDelivery 创建推送记录
+func (r *webhookRepository) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// FindDeliveryByID 根据ID查找推送记录
+func (r *webhookRepository) FindDeliveryByID(id uint) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindDeliveries 分页查询Webhook的推送记录，按时间倒序，status为空时不过滤状态
+func (r *webhookRepository) FindDeliveries(webhookID uint, status string, page, size int) ([]domain.WebhookDelivery, int64, error) {
+	var deliveries []domain.WebhookDelivery
+	var total int64
+
+	query := r.db.Model(&domain.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(size).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// FindDueDeliveries 查询到期待推送的记录
+func (r *webhookRepository) FindDueDeliveries(now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	var deliveries []domain.WebhookDelivery
+	err := r.db.Where("status = ? AND next_attempt_at <= ?", domain.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC, id ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDelivery 将到期的推送记录顺延到leaseUntil，成功时返回true
+// 多个实例同时处理时只有一个能领取成功，推送中途退出的记录在租约到期后会被重新领取
+func (r *webhookRepository) ClaimDelivery(id uint, now, leaseUntil time.Time) (bool, error) {
+	result := r.db.Model(&domain.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", id, domain.WebhookDeliveryPending, now).
+		Update("next_attempt_at", leaseUntil)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateDelivery 保存推送结果
+func (r *webhookRepository) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
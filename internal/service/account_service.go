@@ -0,0 +1,369 @@
+package service
+
+import (
+	"archive/zip"
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+	"codefolio/internal/util"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// 账户注销相关错误
+var (
+	ErrDeletionConfirmFailed = errors.New("注销确认信息不正确")
+	ErrDeletionNotRequested  = errors.New("账户未申请注销")
+	ErrDeletedUserNotFound   = errors.New("已删除的用户不存在")
+)
+
+// UserDataCleaner 用户数据清理器
+// 各业务模块实现该接口，在账户被永久删除时清理自己持有的用户数据
+type UserDataCleaner interface {
+	PurgeUserData(userID uint) error
+}
+
+// AccountService 账户管理服务接口
+type AccountService interface {
+	RequestDeletion(userID uint, password, confirmUsername string) (*domain.User, error)
+	CancelDeletion(userID uint) (*domain.User, error)
+	PurgeUser(userID uint) error
+	RestoreUser(userID uint) (*domain.User, error)
+	ExportUserData(userID uint, w io.Writer) error
+}
+
+// accountService 账户管理服务实现
+type accountService struct {
+	userRepo     repository.UserRepository
+	resumeRepo   repository.ResumeRepository
+	identityRepo repository.UserIdentityRepository
+	mailer       util.Mailer
+	cleaners     []UserDataCleaner
+
+	// 注销冷静期
+	gracePeriod time.Duration
+	// 已删除账户在回收站中的保留时间
+	trashRetention time.Duration
+}
+
+// NewAccountService 创建账户管理服务，并启动到期账户清理goroutine
+func NewAccountService(userRepo repository.UserRepository, resumeRepo repository.ResumeRepository, identityRepo repository.UserIdentityRepository, mailer util.Mailer, gracePeriod, trashRetention time.Duration, cleaners ...UserDataCleaner) AccountService {
+	s := &accountService{
+		userRepo:       userRepo,
+		resumeRepo:     resumeRepo,
+		identityRepo:   identityRepo,
+		mailer:         mailer,
+		cleaners:       cleaners,
+		gracePeriod:    gracePeriod,
+		trashRetention: trashRetention,
+	}
+	go s.purgeDueAccounts()
+	return s
+}
+
+// RequestDeletion 申请注销账户
+// 设置了密码的账户需要验证密码，第三方登录账户需要输入用户名确认
+func (s *accountService) RequestDeletion(userID uint, password, confirmUsername string) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	if user.Password != "" {
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+			return nil, ErrDeletionConfirmFailed
+		}
+	} else if confirmUsername != user.Username {
+		return nil, ErrDeletionConfirmFailed
+	}
+
+	// 重复申请时保持原有的删除时间
+	if user.DeletionScheduledAt != nil {
+		return user, nil
+	}
+
+	now := time.Now()
+	scheduledAt := now.Add(s.gracePeriod)
+	user.DeletionRequestedAt = &now
+	user.DeletionScheduledAt = &scheduledAt
+	if err := s.userRepo.UpdateDeletionState(user); err != nil {
+		return nil, err
+	}
+
+	util.GetLogger().Info("用户申请注销账户",
+		zap.Uint("userID", user.ID),
+		zap.Time("scheduledAt", scheduledAt))
+
+	util.SendMailAsync(s.mailer, user.Email, "Codefolio 账户注销申请",
+		fmt.Sprintf("您好 %s：\n\n我们已收到您的账户注销申请。账户及其全部简历、文件将于 %s 永久删除。\n"+
+			"在此之前，您可以登录后在个人中心撤销注销申请。\n\n如果这不是您本人的操作，请立即登录并修改密码。",
+			user.Username, scheduledAt.Format("2006-01-02 15:04:05")))
+
+	return user, nil
+}
+
+// CancelDeletion 撤销注销申请
+func (s *accountService) CancelDeletion(userID uint) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.DeletionScheduledAt == nil {
+		return nil, ErrDeletionNotRequested
+	}
+
+	user.DeletionRequestedAt = nil
+	user.DeletionScheduledAt = nil
+	if err := s.userRepo.UpdateDeletionState(user); err != nil {
+		return nil, err
+	}
+
+	util.GetLogger().Info("用户撤销注销申请", zap.Uint("userID", user.ID))
+	return user, nil
+}
+
+// deleteAccount 软删除冷静期已结束的账户，账户和简历移入回收站，保留期内管理员可恢复
+// 先删除用户再删除简历，恢复时据此区分随账户一起删除的简历
+func (s *accountService) deleteAccount(user *domain.User) error {
+	resumes, err := s.resumeRepo.FindByUser(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return err
+	}
+	if err := s.resumeRepo.DeleteByUser(user.ID); err != nil {
+		return err
+	}
+
+	_ = util.MoveToTrash(user.AvatarURL)
+	for _, resume := range resumes {
+		_ = util.MoveToTrash(resume.ImageURL)
+		_ = util.MoveToTrash(resume.SourceURL)
+	}
+
+	util.GetLogger().Info("用户账户已删除并移入回收站", zap.Uint("userID", user.ID))
+	return nil
+}
+
+// RestoreUser 恢复回收站中的账户，以及随账户一起删除的简历
+func (s *accountService) RestoreUser(userID uint) (*domain.User, error) {
+	user, err := s.userRepo.FindDeletedByID(userID)
+	if err != nil {
+		if errors.Is(err, common.ErrRecordNotFound) {
+			return nil, ErrDeletedUserNotFound
+		}
+		return nil, err
+	}
+	if time.Since(user.DeletedAt.Time) > s.trashRetention {
+		return nil, ErrRestoreExpired
+	}
+
+	resumes, err := s.resumeRepo.FindDeletedByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 用户此前单独删除的简历仍留在回收站中
+	for _, resume := range resumes {
+		if resume.DeletedAt.Time.Before(user.DeletedAt.Time) {
+			continue
+		}
+		_ = util.RestoreFromTrash(resume.ImageURL)
+		_ = util.RestoreFromTrash(resume.SourceURL)
+		if err := s.resumeRepo.Restore(resume.ID); err != nil {
+			return nil, err
+		}
+	}
+	_ = util.RestoreFromTrash(user.AvatarURL)
+
+	if err := s.userRepo.Restore(userID); err != nil {
+		return nil, err
+	}
+	user.DeletedAt = gorm.DeletedAt{}
+	user.DeletionRequestedAt = nil
+	user.DeletionScheduledAt = nil
+	if err := s.userRepo.UpdateDeletionState(user); err != nil {
+		return nil, err
+	}
+
+	util.GetLogger().Info("用户账户已从回收站恢复", zap.Uint("userID", userID))
+	return user, nil
+}
+
+// PurgeUser 永久删除用户及其全部数据，包括回收站中的记录和文件
+// 先删除数据库记录再删除文件，文件删除失败只会留下可回收的孤立文件
+func (s *accountService) PurgeUser(userID uint) error {
+	for _, cleaner := range s.cleaners {
+		if err := cleaner.PurgeUserData(userID); err != nil {
+			return err
+		}
+	}
+	if err := s.identityRepo.DeleteByUser(userID); err != nil {
+		return err
+	}
+	if err := s.resumeRepo.HardDeleteByUser(userID); err != nil {
+		return err
+	}
+	if err := s.userRepo.HardDelete(userID); err != nil {
+		return err
+	}
+
+	// 删除用户的简历和头像目录，以及回收站中对应的目录
+	userDir := fmt.Sprintf("%d", userID)
+	for _, dir := range []util.StorageKey{
+		util.StorageKey(util.ResumeDir).Join(userDir),
+		util.StorageKey(util.AvatarDir).Join(userDir),
+		util.StorageKey(util.ResumeDir).Join(userDir).Trash(),
+		util.StorageKey(util.AvatarDir).Join(userDir).Trash(),
+	} {
+		if err := util.DeleteDir(context.Background(), dir); err != nil {
+			util.GetLogger().Error("删除用户文件失败", zap.Error(err), zap.Stringer("key", dir))
+		}
+	}
+
+	util.GetLogger().Info("用户账户已永久删除", zap.Uint("userID", userID))
+	return nil
+}
+
+// purgeDueAccounts 定期删除冷静期已结束的账户，并永久删除超过回收站保留期的账户
+func (s *accountService) purgeDueAccounts() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		users, err := s.userRepo.FindDeletionDue(time.Now())
+		if err != nil {
+			util.GetLogger().Error("查询待删除账户失败", zap.Error(err))
+		}
+		for i := range users {
+			if err := s.deleteAccount(&users[i]); err != nil {
+				util.GetLogger().Error("删除账户失败", zap.Error(err), zap.Uint("userID", users[i].ID))
+			}
+		}
+
+		expired, err := s.userRepo.FindDeletedBefore(time.Now().Add(-s.trashRetention))
+		if err != nil {
+			util.GetLogger().Error("查询过期账户失败", zap.Error(err))
+			continue
+		}
+		for _, user := range expired {
+			if err := s.PurgeUser(user.ID); err != nil {
+				util.GetLogger().Error("永久删除账户失败", zap.Error(err), zap.Uint("userID", user.ID))
+			}
+		}
+	}
+}
+
+// exportProfile 导出的个人资料
+type exportProfile struct {
+	*domain.User
+	DeletionScheduledAt *time.Time            `json:"deletion_scheduled_at,omitempty"`
+	Identities          []domain.UserIdentity `json:"identities"`
+}
+
+// exportResume 导出的简历元数据
+type exportResume struct {
+	domain.Resume
+	Files []string `json:"files"` // 压缩包内对应的文件路径
+}
+
+// ExportUserData 将用户的个人资料、简历元数据和原始文件打包为ZIP写入w
+func (s *accountService) ExportUserData(userID uint, w io.Writer) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	identities, err := s.identityRepo.FindByUser(userID)
+	if err != nil {
+		return err
+	}
+	resumes, err := s.resumeRepo.FindByUser(userID)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	// 个人资料
+	profile := exportProfile{
+		User:                user,
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		Identities:          identities,
+	}
+	if err := writeZipJSON(zw, "profile.json", profile); err != nil {
+		return err
+	}
+
+	// 头像
+	if user.AvatarURL != "" {
+		name := "files/avatar" + filepath.Ext(user.AvatarURL)
+		if err := writeZipFile(zw, name, user.AvatarURL); err != nil {
+			util.GetLogger().Warn("导出头像失败", zap.Error(err), zap.Uint("userID", userID))
+		}
+	}
+
+	// 简历及文件
+	exported := make([]exportResume, 0, len(resumes))
+	for _, resume := range resumes {
+		item := exportResume{Resume: resume, Files: []string{}}
+		for _, stored := range []string{resume.SourceURL, resume.ImageURL} {
+			if stored == "" {
+				continue
+			}
+			name := fmt.Sprintf("files/resume-%d/%s", resume.ID, filepath.Base(stored))
+			if err := writeZipFile(zw, name, stored); err != nil {
+				util.GetLogger().Warn("导出简历文件失败", zap.Error(err), zap.String("path", stored))
+				continue
+			}
+			item.Files = append(item.Files, name)
+		}
+		exported = append(exported, item)
+	}
+	if err := writeZipJSON(zw, "resumes.json", exported); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeZipJSON 向压缩包写入格式化的JSON文件
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// writeZipFile 将存储中的文件写入压缩包
+func writeZipFile(zw *zip.Writer, name, stored string) error {
+	key, err := util.ParseStorageKey(stored)
+	if err != nil {
+		return err
+	}
+	src, _, err := util.OpenFile(context.Background(), key)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, src)
+	return err
+}
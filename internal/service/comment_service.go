@@ -0,0 +1,353 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+	"codefolio/internal/util"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 评论相关错误
+var (
+	ErrCommentNotFound      = errors.New("评论不存在")
+	ErrNotCommentAuthor     = errors.New("非评论作者，无权操作")
+	ErrInvalidCommentParent = errors.New("回复的评论不存在")
+	ErrInvalidCommentAnchor = errors.New("评论锚定位置无效")
+	ErrInvalidCommentStatus = errors.New("无效的评论状态")
+)
+
+// CommentInput 发表评论的参数
+type CommentInput struct {
+	Content  string
+	ParentID *uint
+	Page     int
+	Region   *domain.CommentRegion
+}
+
+// CommentThread 评论及其回复，用于按楼层展示
+type CommentThread struct {
+	Comment       domain.Comment
+	Author        *domain.User // 作者已注销时为nil
+	IsResumeOwner bool         // 是否为简历所有者的评论
+	Upvoted       bool         // 当前用户是否已点赞
+	Deleted       bool         // 已删除，仅作为回复的占位保留
+	Replies       []*CommentThread
+}
+
+// CommentService 简历评论服务接口
+type CommentService interface {
+	GetResumeComments(resumeID, userID uint) ([]*CommentThread, error)
+	CreateComment(resumeID, userID uint, input CommentInput) (*domain.Comment, error)
+	UpdateComment(commentID, userID uint, content string) (*domain.Comment, error)
+	DeleteComment(commentID, userID uint) error
+	Upvote(commentID, userID uint) error
+	RemoveUpvote(commentID, userID uint) error
+
+	// 管理员审核
+	ListComments(page, size int, status string) ([]domain.Comment, int64, error)
+	ModerateComment(commentID uint, status string) error
+	RemoveComment(commentID uint) error
+
+	// 账户删除时清理评论数据
+	PurgeUserData(userID uint) error
+}
+
+// commentService 简历评论服务实现
+type commentService struct {
+	commentRepo repository.CommentRepository
+	resumeRepo  repository.ResumeRepository
+	userRepo    repository.UserRepository
+	events      EventPublisher
+}
+
+// NewCommentService 创建简历评论服务实例
+func NewCommentService(commentRepo repository.CommentRepository, resumeRepo repository.ResumeRepository, userRepo repository.UserRepository, events EventPublisher) CommentService {
+	return &commentService{
+		commentRepo: commentRepo,
+		resumeRepo:  resumeRepo,
+		userRepo:    userRepo,
+		events:      events,
+	}
+}
+
+// GetResumeComments 获取简历的评论，按楼层组织
+func (s *commentService) GetResumeComments(resumeID, userID uint) ([]*CommentThread, error) {
+	resume, err := s.resumeRepo.FindByID(resumeID)
+	if err != nil || resume == nil || !canSeeResume(resume, userID) {
+		return nil, ErrResumeNotFound
+	}
+
+	comments, err := s.commentRepo.FindByResume(resumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 批量加载作者和点赞状态
+	commentIDs := make([]uint, 0, len(comments))
+	authorIDs := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		commentIDs = append(commentIDs, comment.ID)
+		authorIDs = append(authorIDs, comment.UserID)
+	}
+	authors, err := s.userRepo.FindByIDs(authorIDs)
+	if err != nil {
+		return nil, err
+	}
+	authorMap := make(map[uint]*domain.User, len(authors))
+	for i := range authors {
+		authorMap[authors[i].ID] = &authors[i]
+	}
+	upvoted := make(map[uint]bool)
+	if userID != 0 {
+		ids, err := s.commentRepo.FindVotedCommentIDs(userID, commentIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			upvoted[id] = true
+		}
+	}
+
+	// 构建楼层，父评论不存在时作为顶层评论
+	nodes := make(map[uint]*CommentThread, len(comments))
+	for _, comment := range comments {
+		node := &CommentThread{
+			Comment:       comment,
+			IsResumeOwner: comment.UserID == resume.UserID,
+			Upvoted:       upvoted[comment.ID],
+			Deleted:       comment.DeletedAt.Valid,
+		}
+		if !node.Deleted {
+			node.Author = authorMap[comment.UserID]
+		}
+		nodes[comment.ID] = node
+	}
+
+	var roots []*CommentThread
+	for _, comment := range comments {
+		node := nodes[comment.ID]
+		if comment.ParentID != nil {
+			if parent, ok := nodes[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	return pruneDeletedThreads(roots), nil
+}
+
+// pruneDeletedThreads 移除没有回复的已删除评论
+func pruneDeletedThreads(threads []*CommentThread) []*CommentThread {
+	kept := make([]*CommentThread, 0, len(threads))
+	for _, thread := range threads {
+		thread.Replies = pruneDeletedThreads(thread.Replies)
+		if thread.Deleted && len(thread.Replies) == 0 {
+			continue
+		}
+		kept = append(kept, thread)
+	}
+	return kept
+}
+
+// CreateComment 发表评论或回复
+func (s *commentService) CreateComment(resumeID, userID uint, input CommentInput) (*domain.Comment, error) {
+	resume, err := s.resumeRepo.FindByID(resumeID)
+	if err != nil || resume == nil || !canSeeResume(resume, userID) {
+		return nil, ErrResumeNotFound
+	}
+
+	if err := validateCommentAnchor(input.Page, input.Region); err != nil {
+		return nil, err
+	}
+
+	var parent *domain.Comment
+	if input.ParentID != nil {
+		parent, err = s.commentRepo.FindByID(*input.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil || parent.ResumeID != resumeID {
+			return nil, ErrInvalidCommentParent
+		}
+	}
+
+	comment := &domain.Comment{
+		ResumeID: resumeID,
+		UserID:   userID,
+		ParentID: input.ParentID,
+		Content:  input.Content,
+		Page:     input.Page,
+		Region:   input.Region,
+		Status:   domain.CommentStatusVisible,
+	}
+	if err := s.commentRepo.Create(comment); err != nil {
+		return nil, err
+	}
+
+	s.notifyNewComment(resume, parent, comment)
+	return comment, nil
+}
+
+// validateCommentAnchor 校验评论锚定的页码和区域
+func validateCommentAnchor(page int, region *domain.CommentRegion) error {
+	if page < 0 {
+		return ErrInvalidCommentAnchor
+	}
+	if region == nil {
+		return nil
+	}
+	// 区域必须位于具体页面上
+	if page == 0 || region.X < 0 || region.Y < 0 || region.Width <= 0 || region.Height <= 0 {
+		return ErrInvalidCommentAnchor
+	}
+	return nil
+}
+
+// notifyNewComment 通知简历所有者和被回复的评论作者
+func (s *commentService) notifyNewComment(resume *domain.Resume, parent *domain.Comment, comment *domain.Comment) {
+	commenter, err := s.userRepo.FindByID(comment.UserID)
+	if err != nil {
+		util.GetLogger().Warn("获取评论作者失败", zap.Error(err), zap.Uint("userID", comment.UserID))
+		return
+	}
+
+	link := fmt.Sprintf("/resumes/%d#comment-%d", resume.ID, comment.ID)
+	if parent != nil && parent.UserID != comment.UserID {
+		s.events.Publish(Event{
+			Type:    domain.NotificationCommentReplied,
+			UserID:  parent.UserID,
+			ActorID: comment.UserID,
+			Title:   fmt.Sprintf("%s 回复了您在简历 #%d 下的评论", commenter.Username, resume.ID),
+			Content: comment.Content,
+			Link:    link,
+		})
+	}
+	if parent == nil || parent.UserID != resume.UserID {
+		s.events.Publish(Event{
+			Type:    domain.NotificationCommentCreated,
+			UserID:  resume.UserID,
+			ActorID: comment.UserID,
+			Title:   fmt.Sprintf("%s 评论了您的简历 #%d", commenter.Username, resume.ID),
+			Content: comment.Content,
+			Link:    link,
+		})
+	}
+}
+
+// findOwnComment 获取当前用户发表的评论
+func (s *commentService) findOwnComment(commentID, userID uint) (*domain.Comment, error) {
+	comment, err := s.commentRepo.FindByID(commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment == nil {
+		return nil, ErrCommentNotFound
+	}
+	if comment.UserID != userID {
+		return nil, ErrNotCommentAuthor
+	}
+	return comment, nil
+}
+
+// UpdateComment 编辑评论内容
+func (s *commentService) UpdateComment(commentID, userID uint, content string) (*domain.Comment, error) {
+	comment, err := s.findOwnComment(commentID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	updated, err := s.commentRepo.UpdateContent(commentID, content, now)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrCommentNotFound
+	}
+	comment.Content = content
+	comment.EditedAt = &now
+	return comment, nil
+}
+
+// DeleteComment 删除自己发表的评论
+func (s *commentService) DeleteComment(commentID, userID uint) error {
+	if _, err := s.findOwnComment(commentID, userID); err != nil {
+		return err
+	}
+	return s.commentRepo.Delete(commentID)
+}
+
+// Upvote 点赞评论，重复点赞视为成功
+func (s *commentService) Upvote(commentID, userID uint) error {
+	comment, err := s.commentRepo.FindByID(commentID)
+	if err != nil {
+		return err
+	}
+	if comment == nil || comment.Status != domain.CommentStatusVisible {
+		return ErrCommentNotFound
+	}
+
+	_, err = s.commentRepo.AddVote(commentID, userID)
+	return err
+}
+
+// RemoveUpvote 取消点赞，未点赞时视为成功
+func (s *commentService) RemoveUpvote(commentID, userID uint) error {
+	_, err := s.commentRepo.RemoveVote(commentID, userID)
+	return err
+}
+
+// ListComments 分页获取评论，供管理员审核
+func (s *commentService) ListComments(page, size int, status string) ([]domain.Comment, int64, error) {
+	if status != "" && status != domain.CommentStatusVisible && status != domain.CommentStatusHidden {
+		return nil, 0, ErrInvalidCommentStatus
+	}
+	return s.commentRepo.FindAll(page, size, status)
+}
+
+// ModerateComment 修改评论审核状态，隐藏的评论内容仅作者可见
+func (s *commentService) ModerateComment(commentID uint, status string) error {
+	if status != domain.CommentStatusVisible && status != domain.CommentStatusHidden {
+		return ErrInvalidCommentStatus
+	}
+
+	comment, err := s.commentRepo.FindByID(commentID)
+	if err != nil {
+		return err
+	}
+	if comment == nil {
+		return ErrCommentNotFound
+	}
+
+	if err := s.commentRepo.UpdateStatus(commentID, status); err != nil {
+		return err
+	}
+
+	util.GetLogger().Info("评论审核状态已更新",
+		zap.Uint("commentID", commentID),
+		zap.String("status", status))
+	return nil
+}
+
+// RemoveComment 管理员删除评论
+func (s *commentService) RemoveComment(commentID uint) error {
+	comment, err := s.commentRepo.FindByID(commentID)
+	if err != nil {
+		return err
+	}
+	if comment == nil {
+		return ErrCommentNotFound
+	}
+	return s.commentRepo.Delete(commentID)
+}
+
+// PurgeUserData 删除用户发表的评论和点赞，以及其简历下的评论
+func (s *commentService) PurgeUserData(userID uint) error {
+	return s.commentRepo.DeleteByUser(userID)
+}
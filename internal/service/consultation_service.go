@@ -0,0 +1,418 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+	"codefolio/internal/util"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 咨询相关错误
+var (
+	ErrConsultationNotFound        = errors.New("咨询不存在")
+	ErrNotConsultationParticipant  = errors.New("非咨询参与者，无权操作")
+	ErrConsultOwnResume            = errors.New("不能咨询自己的简历")
+	ErrInvalidConsultationState    = errors.New("当前咨询状态不允许该操作")
+	ErrInvalidConsultationPrice    = errors.New("咨询报价无效")
+	ErrConsultationPaymentRequired = errors.New("咨询尚未付款")
+)
+
+// ConsultationDetail 咨询及其留言
+type ConsultationDetail struct {
+	Consultation domain.Consultation
+	Messages     []domain.ConsultationMessage
+}
+
+// ConsultationService 付费咨询服务接口
+type ConsultationService interface {
+	CreateConsultation(resumeID, askerID uint, question string) (*domain.Consultation, error)
+	GetConsultation(id, userID uint) (*ConsultationDetail, error)
+	GetUserConsultations(userID uint, role, status string, page, size int) ([]domain.Consultation, int64, error)
+
+	// 简历所有者操作
+	AcceptConsultation(id, ownerID uint, price int64) (*domain.Consultation, error)
+	DeclineConsultation(id, ownerID uint, reason string) (*domain.Consultation, error)
+
+	// 提问者操作
+	CancelConsultation(id, askerID uint, reason string) (*domain.Consultation, error)
+	PayConsultation(ctx context.Context, id, askerID uint) (*domain.Consultation, error)
+	CloseConsultation(id, askerID uint) (*domain.Consultation, error)
+
+	// 双方操作
+	PostMessage(id, userID uint, content string) (*domain.ConsultationMessage, error)
+	RefundConsultation(ctx context.Context, id, userID uint) (*domain.Consultation, error)
+
+	// 账户删除时清理咨询数据
+	PurgeUserData(userID uint) error
+}
+
+// consultationService 付费咨询服务实现
+type consultationService struct {
+	consultationRepo repository.ConsultationRepository
+	resumeRepo       repository.ResumeRepository
+	payment          PaymentProvider
+	events           EventPublisher
+	currency         string
+	maxPrice         int64
+}
+
+// NewConsultationService 创建付费咨询服务实例，maxPrice为报价上限（分），0表示不限
+func NewConsultationService(
+	consultationRepo repository.ConsultationRepository,
+	resumeRepo repository.ResumeRepository,
+	payment PaymentProvider,
+	events EventPublisher,
+	currency string,
+	maxPrice int64,
+) ConsultationService {
+	return &consultationService{
+		consultationRepo: consultationRepo,
+		resumeRepo:       resumeRepo,
+		payment:          payment,
+		events:           events,
+		currency:         currency,
+		maxPrice:         maxPrice,
+	}
+}
+
+// CreateConsultation 针对公开简历向所有者提问
+func (s *consultationService) CreateConsultation(resumeID, askerID uint, question string) (*domain.Consultation, error) {
+	resume, err := s.resumeRepo.FindByID(resumeID)
+	if err != nil || resume == nil || !canSeeResume(resume, askerID) {
+		return nil, ErrResumeNotFound
+	}
+	if resume.UserID == askerID {
+		return nil, ErrConsultOwnResume
+	}
+
+	consultation := &domain.Consultation{
+		ResumeID: resumeID,
+		AskerID:  askerID,
+		OwnerID:  resume.UserID,
+		Question: question,
+		Currency: s.currency,
+		Status:   domain.ConsultationStatusRequested,
+	}
+	if err := s.consultationRepo.Create(consultation); err != nil {
+		return nil, err
+	}
+
+	s.publish(consultation, domain.NotificationConsultationRequested, consultation.OwnerID, askerID,
+		fmt.Sprintf("您的简历 #%d 收到新的咨询，请报价或拒绝", resumeID), question)
+	return consultation, nil
+}
+
+// findConsultation 获取咨询并校验当前用户是否为参与者
+func (s *consultationService) findConsultation(id, userID uint) (*domain.Consultation, error) {
+	consultation, err := s.consultationRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if consultation == nil {
+		return nil, ErrConsultationNotFound
+	}
+	if consultation.AskerID != userID && consultation.OwnerID != userID {
+		return nil, ErrNotConsultationParticipant
+	}
+	return consultation, nil
+}
+
+// GetConsultation 获取咨询详情及留言
+func (s *consultationService) GetConsultation(id, userID uint) (*ConsultationDetail, error) {
+	consultation, err := s.findConsultation(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := s.consultationRepo.FindMessages(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConsultationDetail{Consultation: *consultation, Messages: messages}, nil
+}
+
+// GetUserConsultations 分页获取用户发起或收到的咨询
+func (s *consultationService) GetUserConsultations(userID uint, role, status string, page, size int) ([]domain.Consultation, int64, error) {
+	return s.consultationRepo.FindByUser(userID, role, status, page, size)
+}
+
+// transition 在咨询处于from状态时保存修改，状态已被并发修改时返回ErrInvalidConsultationState
+func (s *consultationService) transition(consultation *domain.Consultation, from ...string) error {
+	ok, err := s.consultationRepo.UpdateIfStatus(consultation, from...)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidConsultationState
+	}
+	return nil
+}
+
+// AcceptConsultation 简历所有者接受咨询并报价
+func (s *consultationService) AcceptConsultation(id, ownerID uint, price int64) (*domain.Consultation, error) {
+	consultation, err := s.findConsultation(id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if consultation.OwnerID != ownerID {
+		return nil, ErrNotConsultationParticipant
+	}
+	if consultation.Status != domain.ConsultationStatusRequested {
+		return nil, ErrInvalidConsultationState
+	}
+	if price <= 0 || (s.maxPrice > 0 && price > s.maxPrice) {
+		return nil, ErrInvalidConsultationPrice
+	}
+
+	now := time.Now()
+	consultation.Price = price
+	consultation.Status = domain.ConsultationStatusAccepted
+	consultation.AcceptedAt = &now
+	if err := s.transition(consultation, domain.ConsultationStatusRequested); err != nil {
+		return nil, err
+	}
+
+	s.publish(consultation, domain.NotificationConsultationAccepted, consultation.AskerID, ownerID,
+		fmt.Sprintf("您对简历 #%d 的咨询已被接受，报价 %s", consultation.ResumeID, formatPrice(price, consultation.Currency)),
+		"付款后简历所有者将为您解答。")
+	return consultation, nil
+}
+
+// DeclineConsultation 简历所有者拒绝咨询
+func (s *consultationService) DeclineConsultation(id, ownerID uint, reason string) (*domain.Consultation, error) {
+	consultation, err := s.findConsultation(id, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if consultation.OwnerID != ownerID {
+		return nil, ErrNotConsultationParticipant
+	}
+	if consultation.Status != domain.ConsultationStatusRequested {
+		return nil, ErrInvalidConsultationState
+	}
+
+	now := time.Now()
+	consultation.Status = domain.ConsultationStatusClosed
+	consultation.CloseReason = reason
+	consultation.ClosedAt = &now
+	if err := s.transition(consultation, domain.ConsultationStatusRequested); err != nil {
+		return nil, err
+	}
+
+	s.publish(consultation, domain.NotificationConsultationDeclined, consultation.AskerID, ownerID,
+		fmt.Sprintf("您对简历 #%d 的咨询已被拒绝", consultation.ResumeID), reason)
+	return consultation, nil
+}
+
+// CancelConsultation 提问者在付款前取消咨询
+func (s *consultationService) CancelConsultation(id, askerID uint, reason string) (*domain.Consultation, error) {
+	consultation, err := s.findConsultation(id, askerID)
+	if err != nil {
+		return nil, err
+	}
+	if consultation.AskerID != askerID {
+		return nil, ErrNotConsultationParticipant
+	}
+	if consultation.Status != domain.ConsultationStatusRequested && consultation.Status != domain.ConsultationStatusAccepted {
+		return nil, ErrInvalidConsultationState
+	}
+
+	now := time.Now()
+	consultation.Status = domain.ConsultationStatusClosed
+	consultation.CloseReason = reason
+	consultation.ClosedAt = &now
+	if err := s.transition(consultation, domain.ConsultationStatusRequested, domain.ConsultationStatusAccepted); err != nil {
+		return nil, err
+	}
+	return consultation, nil
+}
+
+// PayConsultation 提问者为已报价的咨询付款
+func (s *consultationService) PayConsultation(ctx context.Context, id, askerID uint) (*domain.Consultation, error) {
+	consultation, err := s.findConsultation(id, askerID)
+	if err != nil {
+		return nil, err
+	}
+	if consultation.AskerID != askerID {
+		return nil, ErrNotConsultationParticipant
+	}
+	if consultation.Status != domain.ConsultationStatusAccepted {
+		return nil, ErrInvalidConsultationState
+	}
+
+	// 先占用状态再扣款，并发的付款请求只有一个能发起扣款
+	consultation.Status = domain.ConsultationStatusPaying
+	if err := s.transition(consultation, domain.ConsultationStatusAccepted); err != nil {
+		return nil, err
+	}
+
+	paymentID, err := s.payment.Charge(ctx, ChargeRequest{
+		OrderID:     fmt.Sprintf("consultation-%d", consultation.ID),
+		PayerID:     consultation.AskerID,
+		PayeeID:     consultation.OwnerID,
+		Amount:      consultation.Price,
+		Currency:    consultation.Currency,
+		Description: fmt.Sprintf("简历 #%d 付费咨询", consultation.ResumeID),
+	})
+	if err != nil {
+		consultation.Status = domain.ConsultationStatusAccepted
+		if _, rollbackErr := s.consultationRepo.UpdateIfStatus(consultation, domain.ConsultationStatusPaying); rollbackErr != nil {
+			util.GetLogger().Error("扣款失败后恢复咨询状态失败", zap.Error(rollbackErr), zap.Uint("consultationID", id))
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	consultation.Status = domain.ConsultationStatusPaid
+	consultation.PaymentID = paymentID
+	consultation.PaymentMethod = s.payment.Name()
+	consultation.PaidAt = &now
+	if err := s.transition(consultation, domain.ConsultationStatusPaying); err != nil {
+		// 扣款由本次请求独占发起，状态未能保存时退回款项
+		if refundErr := s.payment.Refund(ctx, paymentID); refundErr != nil {
+			util.GetLogger().Error("咨询状态更新失败后退款失败",
+				zap.Error(refundErr),
+				zap.Uint("consultationID", consultation.ID),
+				zap.String("paymentID", paymentID))
+		}
+		return nil, err
+	}
+
+	s.publish(consultation, domain.NotificationConsultationPaid, consultation.OwnerID, askerID,
+		fmt.Sprintf("您简历 #%d 收到的咨询已付款 %s", consultation.ResumeID, formatPrice(consultation.Price, consultation.Currency)),
+		"请尽快解答。")
+	return consultation, nil
+}
+
+// PostMessage 在已付款的咨询中留言，简历所有者的首次留言视为已回答
+func (s *consultationService) PostMessage(id, userID uint, content string) (*domain.ConsultationMessage, error) {
+	consultation, err := s.findConsultation(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch consultation.Status {
+	case domain.ConsultationStatusPaid, domain.ConsultationStatusAnswered:
+	case domain.ConsultationStatusRequested, domain.ConsultationStatusAccepted, domain.ConsultationStatusPaying:
+		return nil, ErrConsultationPaymentRequired
+	default:
+		return nil, ErrInvalidConsultationState
+	}
+
+	message := &domain.ConsultationMessage{
+		ConsultationID: id,
+		SenderID:       userID,
+		Content:        content,
+	}
+	if err := s.consultationRepo.CreateMessage(message); err != nil {
+		return nil, err
+	}
+
+	if userID == consultation.OwnerID && consultation.Status == domain.ConsultationStatusPaid {
+		now := time.Now()
+		consultation.Status = domain.ConsultationStatusAnswered
+		consultation.AnsweredAt = &now
+		if err := s.transition(consultation, domain.ConsultationStatusPaid); err != nil && err != ErrInvalidConsultationState {
+			util.GetLogger().Error("更新咨询回答状态失败", zap.Error(err), zap.Uint("consultationID", id))
+		}
+	}
+
+	recipient := consultation.OwnerID
+	if userID == consultation.OwnerID {
+		recipient = consultation.AskerID
+	}
+	s.publish(consultation, domain.NotificationConsultationMessage, recipient, userID,
+		fmt.Sprintf("简历 #%d 的咨询有新留言", consultation.ResumeID), content)
+	return message, nil
+}
+
+// CloseConsultation 提问者确认回答，结束咨询
+func (s *consultationService) CloseConsultation(id, askerID uint) (*domain.Consultation, error) {
+	consultation, err := s.findConsultation(id, askerID)
+	if err != nil {
+		return nil, err
+	}
+	if consultation.AskerID != askerID {
+		return nil, ErrNotConsultationParticipant
+	}
+	if consultation.Status != domain.ConsultationStatusAnswered {
+		return nil, ErrInvalidConsultationState
+	}
+
+	now := time.Now()
+	consultation.Status = domain.ConsultationStatusClosed
+	consultation.ClosedAt = &now
+	if err := s.transition(consultation, domain.ConsultationStatusAnswered); err != nil {
+		return nil, err
+	}
+	return consultation, nil
+}
+
+// RefundConsultation 退款：简历所有者可在结束前随时退款，提问者仅可在未获回答时退款
+func (s *consultationService) RefundConsultation(ctx context.Context, id, userID uint) (*domain.Consultation, error) {
+	consultation, err := s.findConsultation(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := consultation.Status
+	switch {
+	case from == domain.ConsultationStatusPaid:
+	case from == domain.ConsultationStatusAnswered && userID == consultation.OwnerID:
+	default:
+		return nil, ErrInvalidConsultationState
+	}
+
+	// 先占用状态再退款，避免并发请求重复退款
+	now := time.Now()
+	consultation.Status = domain.ConsultationStatusRefunded
+	consultation.RefundedAt = &now
+	if err := s.transition(consultation, from); err != nil {
+		return nil, err
+	}
+
+	if err := s.payment.Refund(ctx, consultation.PaymentID); err != nil {
+		consultation.Status = from
+		consultation.RefundedAt = nil
+		if _, rollbackErr := s.consultationRepo.UpdateIfStatus(consultation, domain.ConsultationStatusRefunded); rollbackErr != nil {
+			util.GetLogger().Error("退款失败后恢复咨询状态失败", zap.Error(rollbackErr), zap.Uint("consultationID", id))
+		}
+		return nil, err
+	}
+
+	recipient := consultation.AskerID
+	if userID == consultation.AskerID {
+		recipient = consultation.OwnerID
+	}
+	s.publish(consultation, domain.NotificationConsultationRefunded, recipient, userID,
+		fmt.Sprintf("简历 #%d 的咨询已退款 %s", consultation.ResumeID, formatPrice(consultation.Price, consultation.Currency)), "")
+	return consultation, nil
+}
+
+// PurgeUserData 删除用户参与的咨询
+func (s *consultationService) PurgeUserData(userID uint) error {
+	return s.consultationRepo.DeleteByUser(userID)
+}
+
+// publish 向咨询参与者发布事件
+func (s *consultationService) publish(consultation *domain.Consultation, eventType string, userID, actorID uint, title, content string) {
+	s.events.Publish(Event{
+		Type:    eventType,
+		UserID:  userID,
+		ActorID: actorID,
+		Title:   title,
+		Content: content,
+		Link:    fmt.Sprintf("/consultations/%d", consultation.ID),
+	})
+}
+
+// formatPrice 将以分为单位的金额格式化为展示文本
+func formatPrice(amount int64, currency string) string {
+	return fmt.Sprintf("%d.%02d %s", amount/100, amount%100, currency)
+}
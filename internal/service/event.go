@@ -0,0 +1,85 @@
+package service
+
+import (
+	"codefolio/internal/util"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// EventConversionProgress PDF转换进度事件，仅用于实时推送，不生成站内通知
+const EventConversionProgress = "conversion_progress"
+
+// 简历生命周期事件，仅用于Webhook推送，不生成站内通知
+const (
+	EventResumeCreated = "resume_created"
+	EventResumeDeleted = "resume_deleted"
+)
+
+// Event 业务事件，由各服务发布，通知、推送等订阅者各自处理
+type Event struct {
+	Type      string                 // 事件类型，通知类事件与通知类型一致
+	UserID    uint                   // 接收事件的用户
+	ActorID   uint                   // 触发事件的用户，系统事件为0
+	Title     string                 // 标题
+	Content   string                 // 详细内容
+	Link      string                 // 前端跳转路径
+	Data      map[string]interface{} // 附加数据，如转换进度
+	CreatedAt time.Time              // 事件发生时间
+}
+
+// EventPublisher 事件发布接口
+type EventPublisher interface {
+	Publish(event Event)
+}
+
+// EventHandler 事件订阅者
+type EventHandler func(event Event)
+
+// EventBus 进程内事件总线，按订阅顺序同步分发事件
+type EventBus struct {
+	mu       sync.RWMutex
+	handlers []EventHandler
+}
+
+// NewEventBus 创建事件总线
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe 订阅全部事件
+func (b *EventBus) Subscribe(handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish 发布事件，单个订阅者出错不影响其他订阅者和发布方
+func (b *EventBus) Publish(event Event) {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		b.dispatch(handler, event)
+	}
+}
+
+// dispatch 调用订阅者并捕获panic
+func (b *EventBus) dispatch(handler EventHandler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			util.GetLogger().Error("事件处理失败",
+				zap.String("type", event.Type),
+				zap.Uint("userID", event.UserID),
+				zap.String("panic", fmt.Sprint(r)))
+		}
+	}()
+	handler(event)
+}
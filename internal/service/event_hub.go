@@ -0,0 +1,191 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"sync"
+	"time"
+)
+
+// 实时推送的事件名称
+const (
+	StreamEventConversion   = "conversion"   // PDF转换进度
+	StreamEventModeration   = "moderation"   // 简历审核结果
+	StreamEventNotification = "notification" // 其他站内通知
+)
+
+// 每个连接的待发送缓冲，写满时断开连接，客户端重连后通过Last-Event-ID补发
+const streamBufferSize = 32
+
+// StreamEvent 推送给客户端的事件
+type StreamEvent struct {
+	ID        uint64
+	Name      string
+	Data      map[string]interface{}
+	CreatedAt time.Time
+}
+
+// streamSubscriber 单个SSE连接
+type streamSubscriber struct {
+	ch chan StreamEvent
+}
+
+// EventHub 进程内的实时推送中心，按用户分发事件，并保留最近的事件用于断线重连补发
+type EventHub struct {
+	mu          sync.Mutex
+	nextID      uint64
+	subscribers map[uint]map[*streamSubscriber]struct{}
+	history     map[uint][]StreamEvent
+	historySize int           // 每个用户保留的最近事件数
+	historyTTL  time.Duration // 事件保留时长
+}
+
+// NewEventHub 创建实时推送中心，historySize和historyTTL控制断线重连可补发的事件范围
+func NewEventHub(historySize int, historyTTL time.Duration) *EventHub {
+	h := &EventHub{
+		// 以启动时间为起点，保证重启后事件ID仍然递增，客户端携带的旧ID不会误判为未来事件
+		nextID:      uint64(time.Now().UnixMilli()) * 1000,
+		subscribers: make(map[uint]map[*streamSubscriber]struct{}),
+		history:     make(map[uint][]StreamEvent),
+		historySize: historySize,
+		historyTTL:  historyTTL,
+	}
+
+	go func() {
+		ticker := time.NewTicker(historyTTL)
+		defer ticker.Stop()
+		for range ticker.C {
+			h.pruneHistory()
+		}
+	}()
+
+	return h
+}
+
+// Subscribe 订阅用户的事件，返回事件通道、lastEventID之后的待补发事件和取消订阅函数
+// 通道被关闭表示连接处理过慢，调用方应结束连接
+func (h *EventHub) Subscribe(userID uint, lastEventID uint64) (<-chan StreamEvent, []StreamEvent, func()) {
+	sub := &streamSubscriber{ch: make(chan StreamEvent, streamBufferSize)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*streamSubscriber]struct{})
+	}
+	h.subscribers[userID][sub] = struct{}{}
+
+	var missed []StreamEvent
+	if lastEventID > 0 {
+		for _, event := range h.history[userID] {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.removeLocked(userID, sub)
+	}
+	return sub.ch, missed, unsubscribe
+}
+
+// Send 向用户的全部连接推送事件
+func (h *EventHub) Send(userID uint, name string, data map[string]interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	event := StreamEvent{
+		ID:        h.nextID,
+		Name:      name,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+
+	history := append(h.history[userID], event)
+	if len(history) > h.historySize {
+		history = history[len(history)-h.historySize:]
+	}
+	h.history[userID] = history
+
+	for sub := range h.subscribers[userID] {
+		select {
+		case sub.ch <- event:
+		default:
+			// 连接消费过慢，断开后由客户端重连补发
+			h.removeLocked(userID, sub)
+		}
+	}
+}
+
+// HandleEvent 订阅事件总线，将业务事件转换为推送事件
+func (h *EventHub) HandleEvent(event Event) {
+	if event.UserID == 0 {
+		return
+	}
+
+	data := map[string]interface{}{
+		"type":       event.Type,
+		"created_at": event.CreatedAt,
+	}
+	for k, v := range event.Data {
+		data[k] = v
+	}
+	if event.Title != "" {
+		data["title"] = event.Title
+	}
+	if event.Content != "" {
+		data["content"] = event.Content
+	}
+	if event.Link != "" {
+		data["link"] = event.Link
+	}
+
+	switch event.Type {
+	case EventConversionProgress:
+		h.Send(event.UserID, StreamEventConversion, data)
+	case domain.NotificationResumeApproved, domain.NotificationResumeRejected:
+		h.Send(event.UserID, StreamEventModeration, data)
+	default:
+		if domain.IsNotificationType(event.Type) && event.UserID != event.ActorID {
+			h.Send(event.UserID, StreamEventNotification, data)
+		}
+	}
+}
+
+// removeLocked 移除连接并关闭其通道，调用方需持有锁
+func (h *EventHub) removeLocked(userID uint, sub *streamSubscriber) {
+	subs, ok := h.subscribers[userID]
+	if !ok {
+		return
+	}
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	close(sub.ch)
+	if len(subs) == 0 {
+		delete(h.subscribers, userID)
+	}
+}
+
+// pruneHistory 清理过期的历史事件
+func (h *EventHub) pruneHistory() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := time.Now().Add(-h.historyTTL)
+	for userID, history := range h.history {
+		i := 0
+		for i < len(history) && history[i].CreatedAt.Before(cutoff) {
+			i++
+		}
+		if i == len(history) {
+			delete(h.history, userID)
+		} else if i > 0 {
+			h.history[userID] = append([]StreamEvent(nil), history[i:]...)
+		}
+	}
+}
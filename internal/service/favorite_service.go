@@ -0,0 +1,79 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+)
+
+// FavoriteService 简历收藏服务接口
+type FavoriteService interface {
+	AddFavorite(userID, resumeID uint) error
+	RemoveFavorite(userID, resumeID uint) error
+	GetUserFavorites(userID uint, page, size int) ([]domain.Resume, int64, error)
+	FavoritedSet(userID uint, resumes []domain.Resume) (map[uint]bool, error)
+	PurgeUserData(userID uint) error
+}
+
+// favoriteService 简历收藏服务实现
+type favoriteService struct {
+	favoriteRepo repository.FavoriteRepository
+	resumeRepo   repository.ResumeRepository
+}
+
+// NewFavoriteService 创建简历收藏服务实例
+func NewFavoriteService(favoriteRepo repository.FavoriteRepository, resumeRepo repository.ResumeRepository) FavoriteService {
+	return &favoriteService{
+		favoriteRepo: favoriteRepo,
+		resumeRepo:   resumeRepo,
+	}
+}
+
+// AddFavorite 收藏简历，重复收藏视为成功
+func (s *favoriteService) AddFavorite(userID, resumeID uint) error {
+	// 只能收藏自己可见的简历
+	resume, err := s.resumeRepo.FindByID(resumeID)
+	if err != nil || resume == nil || !canSeeResume(resume, userID) {
+		return ErrResumeNotFound
+	}
+
+	_, err = s.favoriteRepo.Add(userID, resumeID)
+	return err
+}
+
+// RemoveFavorite 取消收藏，未收藏时视为成功
+func (s *favoriteService) RemoveFavorite(userID, resumeID uint) error {
+	_, err := s.favoriteRepo.Remove(userID, resumeID)
+	return err
+}
+
+// GetUserFavorites 分页获取用户收藏的简历
+func (s *favoriteService) GetUserFavorites(userID uint, page, size int) ([]domain.Resume, int64, error) {
+	return s.favoriteRepo.FindResumesByUser(userID, page, size)
+}
+
+// FavoritedSet 返回简历列表中已被用户收藏的简历ID集合，未登录用户返回空集合
+func (s *favoriteService) FavoritedSet(userID uint, resumes []domain.Resume) (map[uint]bool, error) {
+	set := make(map[uint]bool)
+	if userID == 0 || len(resumes) == 0 {
+		return set, nil
+	}
+
+	resumeIDs := make([]uint, 0, len(resumes))
+	for _, resume := range resumes {
+		resumeIDs = append(resumeIDs, resume.ID)
+	}
+
+	ids, err := s.favoriteRepo.FindFavoritedResumeIDs(userID, resumeIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// PurgeUserData 删除用户的收藏记录
+func (s *favoriteService) PurgeUserData(userID uint) error {
+	return s.favoriteRepo.DeleteByUser(userID)
+}
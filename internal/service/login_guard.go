@@ -0,0 +1,140 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// LoginPolicy 登录防暴力破解策略
+type LoginPolicy struct {
+	MaxFailedAttempts  int           // 单个账户连续失败多少次后锁定
+	LockoutDuration    time.Duration // 首次锁定时长
+	MaxLockoutDuration time.Duration // 锁定时长上限
+	IPMaxFailures      int           // 单个IP在统计窗口内允许的最大失败次数
+	IPWindow           time.Duration // IP失败次数统计窗口
+}
+
+// DefaultLoginPolicy 默认登录策略
+var DefaultLoginPolicy = LoginPolicy{
+	MaxFailedAttempts:  5,
+	LockoutDuration:    15 * time.Minute,
+	MaxLockoutDuration: 24 * time.Hour,
+	IPMaxFailures:      20,
+	IPWindow:           15 * time.Minute,
+}
+
+// withDefaults 使用默认值填充未配置的字段
+func (p LoginPolicy) withDefaults() LoginPolicy {
+	if p.MaxFailedAttempts <= 0 {
+		p.MaxFailedAttempts = DefaultLoginPolicy.MaxFailedAttempts
+	}
+	if p.LockoutDuration <= 0 {
+		p.LockoutDuration = DefaultLoginPolicy.LockoutDuration
+	}
+	if p.MaxLockoutDuration < p.LockoutDuration {
+		p.MaxLockoutDuration = p.LockoutDuration
+	}
+	if p.IPMaxFailures <= 0 {
+		p.IPMaxFailures = DefaultLoginPolicy.IPMaxFailures
+	}
+	if p.IPWindow <= 0 {
+		p.IPWindow = DefaultLoginPolicy.IPWindow
+	}
+	return p
+}
+
+// lockoutDuration 计算第n次锁定的时长（指数退避）
+func (p LoginPolicy) lockoutDuration(lockCount int) time.Duration {
+	d := p.LockoutDuration
+	for i := 1; i < lockCount; i++ {
+		d *= 2
+		if d >= p.MaxLockoutDuration {
+			return p.MaxLockoutDuration
+		}
+	}
+	return d
+}
+
+// ipAttempts 单个IP的失败记录
+type ipAttempts struct {
+	failures []time.Time
+}
+
+// ipLoginTracker 按IP统计登录失败次数（滑动窗口，进程内存储）
+type ipLoginTracker struct {
+	mu       sync.Mutex
+	window   time.Duration
+	limit    int
+	attempts map[string]*ipAttempts
+}
+
+// newIPLoginTracker 创建IP失败统计器，并启动过期记录清理goroutine
+func newIPLoginTracker(limit int, window time.Duration) *ipLoginTracker {
+	t := &ipLoginTracker{
+		window:   window,
+		limit:    limit,
+		attempts: make(map[string]*ipAttempts),
+	}
+	go t.cleanup()
+	return t
+}
+
+// prune 移除窗口外的失败记录，调用方需持有锁
+func (t *ipLoginTracker) prune(a *ipAttempts, now time.Time) {
+	cutoff := now.Add(-t.window)
+	i := 0
+	for i < len(a.failures) && a.failures[i].Before(cutoff) {
+		i++
+	}
+	a.failures = a.failures[i:]
+}
+
+// Blocked 判断IP是否已被限制，返回剩余等待时间
+func (t *ipLoginTracker) Blocked(ip string, now time.Time) (bool, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a, ok := t.attempts[ip]
+	if !ok {
+		return false, 0
+	}
+	t.prune(a, now)
+	if len(a.failures) < t.limit {
+		return false, 0
+	}
+
+	// 最早的一次失败移出窗口后即可恢复
+	return true, a.failures[0].Add(t.window).Sub(now)
+}
+
+// Fail 记录一次失败
+func (t *ipLoginTracker) Fail(ip string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a, ok := t.attempts[ip]
+	if !ok {
+		a = &ipAttempts{}
+		t.attempts[ip] = a
+	}
+	t.prune(a, now)
+	a.failures = append(a.failures, now)
+}
+
+// cleanup 定期清理已过期的IP记录
+func (t *ipLoginTracker) cleanup() {
+	ticker := time.NewTicker(t.window)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		t.mu.Lock()
+		for ip, a := range t.attempts {
+			t.prune(a, now)
+			if len(a.failures) == 0 {
+				delete(t.attempts, ip)
+			}
+		}
+		t.mu.Unlock()
+	}
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+	"codefolio/internal/util"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 通知相关错误
+var (
+	ErrNotificationNotFound    = errors.New("通知不存在")
+	ErrInvalidNotificationType = errors.New("无效的通知类型")
+)
+
+// NotificationService 站内通知服务接口
+type NotificationService interface {
+	// HandleEvent 订阅事件总线，按用户偏好生成站内通知
+	HandleEvent(event Event)
+
+	GetNotifications(userID uint, unreadOnly bool, page, size int) ([]domain.Notification, int64, error)
+	CountUnread(userID uint) (int64, error)
+	MarkRead(userID, id uint) error
+	MarkAllRead(userID uint) (int64, error)
+	GetPreferences(userID uint) ([]domain.NotificationPreference, error)
+	UpdatePreferences(userID uint, prefs []domain.NotificationPreference) ([]domain.NotificationPreference, error)
+
+	// 账户删除时清理通知数据
+	PurgeUserData(userID uint) error
+}
+
+// notificationService 站内通知服务实现
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+	userRepo         repository.UserRepository
+	mailer           util.Mailer
+}
+
+// NewNotificationService 创建站内通知服务实例，digestInterval大于0时定期发送未读通知的邮件摘要
+func NewNotificationService(notificationRepo repository.NotificationRepository, userRepo repository.UserRepository, mailer util.Mailer, digestInterval time.Duration) NotificationService {
+	s := &notificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		mailer:           mailer,
+	}
+
+	if digestInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(digestInterval)
+			defer ticker.Stop()
+			for range ticker.C {
+				s.sendDigests()
+			}
+		}()
+	}
+
+	return s
+}
+
+// HandleEvent 按用户偏好生成站内通知
+func (s *notificationService) HandleEvent(event Event) {
+	if event.UserID == 0 || event.UserID == event.ActorID || !domain.IsNotificationType(event.Type) {
+		return
+	}
+
+	pref, err := s.notificationRepo.FindPreference(event.UserID, event.Type)
+	if err != nil {
+		util.GetLogger().Warn("获取通知偏好失败", zap.Error(err), zap.Uint("userID", event.UserID))
+	} else if pref != nil && !pref.InApp {
+		return
+	}
+
+	notification := &domain.Notification{
+		UserID:    event.UserID,
+		Type:      event.Type,
+		ActorID:   event.ActorID,
+		Title:     event.Title,
+		Content:   event.Content,
+		Link:      event.Link,
+		CreatedAt: event.CreatedAt,
+	}
+	if err := s.notificationRepo.Create(notification); err != nil {
+		util.GetLogger().Error("创建通知失败",
+			zap.Error(err),
+			zap.String("type", event.Type),
+			zap.Uint("userID", event.UserID))
+	}
+}
+
+// GetNotifications 分页获取用户的通知
+func (s *notificationService) GetNotifications(userID uint, unreadOnly bool, page, size int) ([]domain.Notification, int64, error) {
+	return s.notificationRepo.FindByUser(userID, unreadOnly, page, size)
+}
+
+// CountUnread 获取用户的未读通知数
+func (s *notificationService) CountUnread(userID uint) (int64, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+// MarkRead 将通知标记为已读
+func (s *notificationService) MarkRead(userID, id uint) error {
+	found, err := s.notificationRepo.MarkRead(userID, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead 将用户的全部通知标记为已读
+func (s *notificationService) MarkAllRead(userID uint) (int64, error) {
+	return s.notificationRepo.MarkAllRead(userID)
+}
+
+// GetPreferences 获取用户对全部通知类型的偏好，未设置的类型使用默认值
+func (s *notificationService) GetPreferences(userID uint) ([]domain.NotificationPreference, error) {
+	saved, err := s.notificationRepo.FindPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	savedMap := make(map[string]domain.NotificationPreference, len(saved))
+	for _, pref := range saved {
+		savedMap[pref.Type] = pref
+	}
+
+	prefs := make([]domain.NotificationPreference, 0, len(domain.NotificationTypes))
+	for _, t := range domain.NotificationTypes {
+		pref, ok := savedMap[t]
+		if !ok {
+			pref = domain.NotificationPreference{UserID: userID, Type: t, InApp: true}
+		}
+		prefs = append(prefs, pref)
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences 更新用户的通知偏好，返回更新后的全部偏好
+func (s *notificationService) UpdatePreferences(userID uint, prefs []domain.NotificationPreference) ([]domain.NotificationPreference, error) {
+	// 同一类型重复提交时以最后一项为准
+	byType := make(map[string]int, len(prefs))
+	deduped := make([]domain.NotificationPreference, 0, len(prefs))
+	for _, pref := range prefs {
+		if !domain.IsNotificationType(pref.Type) {
+			return nil, ErrInvalidNotificationType
+		}
+		if i, ok := byType[pref.Type]; ok {
+			deduped[i] = pref
+			continue
+		}
+		byType[pref.Type] = len(deduped)
+		deduped = append(deduped, pref)
+	}
+
+	if err := s.notificationRepo.SavePreferences(userID, deduped); err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(userID)
+}
+
+// PurgeUserData 删除用户的通知和偏好设置
+func (s *notificationService) PurgeUserData(userID uint) error {
+	return s.notificationRepo.DeleteByUser(userID)
+}
+
+// sendDigests 为开启邮件摘要的用户汇总发送未读通知
+func (s *notificationService) sendDigests() {
+	notifications, err := s.notificationRepo.FindDigestPending()
+	if err != nil {
+		util.GetLogger().Error("查询待发送的通知摘要失败", zap.Error(err))
+		return
+	}
+
+	byUser := make(map[uint][]domain.Notification)
+	var userIDs []uint
+	for _, n := range notifications {
+		if _, ok := byUser[n.UserID]; !ok {
+			userIDs = append(userIDs, n.UserID)
+		}
+		byUser[n.UserID] = append(byUser[n.UserID], n)
+	}
+
+	for _, userID := range userIDs {
+		if err := s.sendDigest(userID, byUser[userID]); err != nil {
+			util.GetLogger().Warn("发送通知摘要失败", zap.Error(err), zap.Uint("userID", userID))
+		}
+	}
+}
+
+// sendDigest 向单个用户发送通知摘要，发送成功后标记为已发送
+func (s *notificationService) sendDigest(userID uint, notifications []domain.Notification) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "您好 %s：\n\n您有 %d 条未读通知：\n\n", user.Username, len(notifications))
+	ids := make([]uint, 0, len(notifications))
+	for _, n := range notifications {
+		fmt.Fprintf(&b, "[%s] %s\n", n.CreatedAt.Format("2006-01-02 15:04"), n.Title)
+		if n.Content != "" {
+			fmt.Fprintf(&b, "%s\n", n.Content)
+		}
+		b.WriteString("\n")
+		ids = append(ids, n.ID)
+	}
+	b.WriteString("登录 Codefolio 查看全部通知，或在通知设置中关闭邮件摘要。")
+
+	if err := s.mailer.Send(user.Email, fmt.Sprintf("Codefolio 通知摘要（%d 条未读）", len(notifications)), b.String()); err != nil {
+		return err
+	}
+	return s.notificationRepo.MarkEmailed(ids)
+}
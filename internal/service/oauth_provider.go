@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// ErrOAuthExchangeFailed 与第三方提供方交互失败
+var ErrOAuthExchangeFailed = errors.New("第三方登录授权失败")
+
+// OAuthUserInfo 第三方提供方返回的用户信息
+type OAuthUserInfo struct {
+	Subject       string // 提供方内唯一且不变的用户标识
+	Login         string // 提供方用户名
+	Email         string // 邮箱
+	EmailVerified bool   // 邮箱是否已由提供方验证
+}
+
+// OAuthProvider 第三方登录提供方接口
+type OAuthProvider interface {
+	// Name 提供方名称，用于路由和身份记录
+	Name() string
+	// AuthCodeURL 构建授权跳转地址，nonce为与发起流程的浏览器绑定的随机值
+	AuthCodeURL(state, nonce string) string
+	// Exchange 使用授权码换取用户信息，支持ID令牌的提供方需校验其中的nonce
+	Exchange(ctx context.Context, code, nonce string) (*OAuthUserInfo, error)
+}
+
+// OAuthClientConfig OAuth客户端通用配置
+type OAuthClientConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// oauthHTTPClient 访问第三方接口使用的HTTP客户端
+var oauthHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// authCodeURL 按OAuth2规范拼接授权地址，extra为提供方特有的参数
+func authCodeURL(authURL string, cfg OAuthClientConfig, state string, extra url.Values) string {
+	params := url.Values{
+		"response_type": {"code"},
+		"client_id":     {cfg.ClientID},
+		"redirect_uri":  {cfg.RedirectURL},
+		"state":         {state},
+	}
+	for k, v := range extra {
+		params[k] = v
+	}
+	if len(cfg.Scopes) > 0 {
+		params.Set("scope", strings.Join(cfg.Scopes, " "))
+	}
+
+	sep := "?"
+	if strings.Contains(authURL, "?") {
+		sep = "&"
+	}
+	return authURL + sep + params.Encode()
+}
+
+// oauthToken 令牌端点返回的令牌
+type oauthToken struct {
+	AccessToken string
+	IDToken     string // OIDC提供方返回的ID令牌
+}
+
+// exchangeCode 使用授权码换取访问令牌
+func exchangeCode(ctx context.Context, tokenURL string, cfg OAuthClientConfig, code string) (*oauthToken, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {cfg.RedirectURL},
+		"client_id":     {cfg.ClientID},
+		"client_secret": {cfg.ClientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		AccessToken      string `json:"access_token"`
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := doOAuthJSON(req, &token); err != nil {
+		return nil, err
+	}
+	if token.Error != "" {
+		return nil, fmt.Errorf("%w: %s %s", ErrOAuthExchangeFailed, token.Error, token.ErrorDescription)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("%w: 未返回access_token", ErrOAuthExchangeFailed)
+	}
+	return &oauthToken{AccessToken: token.AccessToken, IDToken: token.IDToken}, nil
+}
+
+// getOAuthJSON 携带访问令牌请求JSON接口
+func getOAuthJSON(ctx context.Context, endpoint, accessToken string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+	return doOAuthJSON(req, out)
+}
+
+// doOAuthJSON 发送请求并解析JSON响应
+func doOAuthJSON(req *http.Request, out interface{}) error {
+	resp, err := oauthHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOAuthExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOAuthExchangeFailed, err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%w: %s 返回 %d", ErrOAuthExchangeFailed, req.URL.Host, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%w: 解析响应失败: %v", ErrOAuthExchangeFailed, err)
+	}
+	return nil
+}
+
+// githubProvider GitHub登录
+type githubProvider struct {
+	cfg     OAuthClientConfig
+	authURL string
+	apiURL  string
+}
+
+// NewGitHubProvider 创建GitHub登录提供方
+func NewGitHubProvider(cfg OAuthClientConfig) OAuthProvider {
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"read:user", "user:email"}
+	}
+	return &githubProvider{
+		cfg:     cfg,
+		authURL: "https://github.com/login/oauth",
+		apiURL:  "https://api.github.com",
+	}
+}
+
+func (p *githubProvider) Name() string {
+	return "github"
+}
+
+// AuthCodeURL GitHub不支持OIDC nonce，流程与浏览器的绑定由state完成
+func (p *githubProvider) AuthCodeURL(state, _ string) string {
+	return authCodeURL(p.authURL+"/authorize", p.cfg, state, nil)
+}
+
+func (p *githubProvider) Exchange(ctx context.Context, code, _ string) (*OAuthUserInfo, error) {
+	token, err := exchangeCode(ctx, p.authURL+"/access_token", p.cfg, code)
+	if err != nil {
+		return nil, err
+	}
+	accessToken := token.AccessToken
+
+	var user struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Email string `json:"email"`
+	}
+	if err := getOAuthJSON(ctx, p.apiURL+"/user", accessToken, &user); err != nil {
+		return nil, err
+	}
+	if user.ID == 0 {
+		return nil, fmt.Errorf("%w: GitHub未返回用户ID", ErrOAuthExchangeFailed)
+	}
+
+	info := &OAuthUserInfo{
+		Subject: strconv.FormatInt(user.ID, 10),
+		Login:   user.Login,
+	}
+
+	// 公开资料中的邮箱未必经过验证，优先使用已验证的主邮箱
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getOAuthJSON(ctx, p.apiURL+"/user/emails", accessToken, &emails); err == nil {
+		for _, e := range emails {
+			if e.Primary && e.Verified {
+				info.Email = e.Email
+				info.EmailVerified = true
+				break
+			}
+		}
+	}
+	if info.Email == "" {
+		info.Email = user.Email
+	}
+
+	return info, nil
+}
+
+// OIDCProviderConfig 通用OIDC提供方配置
+type OIDCProviderConfig struct {
+	OAuthClientConfig
+	Name        string // 提供方名称
+	Issuer      string // 签发者地址，用于自动发现端点和校验ID令牌的iss
+	AuthURL     string // 授权端点，留空时通过发现文档获取
+	TokenURL    string // 令牌端点，留空时通过发现文档获取
+	UserInfoURL string // 用户信息端点，留空时通过发现文档获取
+}
+
+// oidcProvider 通用OIDC登录
+type oidcProvider struct {
+	cfg OIDCProviderConfig
+}
+
+// NewOIDCProvider 创建通用OIDC登录提供方，未显式配置端点时从发现文档加载
+func NewOIDCProvider(ctx context.Context, cfg OIDCProviderConfig) (OAuthProvider, error) {
+	if cfg.Name == "" {
+		cfg.Name = "oidc"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "profile", "email"}
+	}
+
+	// ID令牌的iss必须与issuer一致，即使显式配置了全部端点也需要issuer
+	if cfg.Issuer == "" {
+		return nil, errors.New("OIDC提供方缺少issuer配置")
+	}
+
+	if cfg.AuthURL == "" || cfg.TokenURL == "" || cfg.UserInfoURL == "" {
+
+		discoveryURL := strings.TrimSuffix(cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var doc struct {
+			Issuer                string `json:"issuer"`
+			AuthorizationEndpoint string `json:"authorization_endpoint"`
+			TokenEndpoint         string `json:"token_endpoint"`
+			UserInfoEndpoint      string `json:"userinfo_endpoint"`
+		}
+		if err := doOAuthJSON(req, &doc); err != nil {
+			return nil, err
+		}
+		if doc.Issuer != cfg.Issuer {
+			return nil, fmt.Errorf("OIDC发现文档的issuer %q 与配置 %q 不一致", doc.Issuer, cfg.Issuer)
+		}
+
+		if cfg.AuthURL == "" {
+			cfg.AuthURL = doc.AuthorizationEndpoint
+		}
+		if cfg.TokenURL == "" {
+			cfg.TokenURL = doc.TokenEndpoint
+		}
+		if cfg.UserInfoURL == "" {
+			cfg.UserInfoURL = doc.UserInfoEndpoint
+		}
+	}
+
+	return &oidcProvider{cfg: cfg}, nil
+}
+
+func (p *oidcProvider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthCodeURL 授权请求携带由nonce派生的值，提供方将其原样写入ID令牌
+func (p *oidcProvider) AuthCodeURL(state, nonce string) string {
+	return authCodeURL(p.cfg.AuthURL, p.cfg.OAuthClientConfig, state, url.Values{"nonce": {oidcNonce(nonce)}})
+}
+
+func (p *oidcProvider) Exchange(ctx context.Context, code, nonce string) (*OAuthUserInfo, error) {
+	token, err := exchangeCode(ctx, p.cfg.TokenURL, p.cfg.OAuthClientConfig, code)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := p.verifyIDToken(token.IDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+	accessToken := token.AccessToken
+
+	var claims struct {
+		Subject           string `json:"sub"`
+		PreferredUsername string `json:"preferred_username"`
+		Nickname          string `json:"nickname"`
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+	}
+	if err := getOAuthJSON(ctx, p.cfg.UserInfoURL, accessToken, &claims); err != nil {
+		return nil, err
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: 用户信息缺少sub", ErrOAuthExchangeFailed)
+	}
+	// 用户信息必须属于ID令牌中的用户
+	if claims.Subject != subject {
+		return nil, fmt.Errorf("%w: 用户信息的sub与ID令牌不一致", ErrOAuthExchangeFailed)
+	}
+
+	login := claims.PreferredUsername
+	if login == "" {
+		login = claims.Nickname
+	}
+
+	return &OAuthUserInfo{
+		Subject:       claims.Subject,
+		Login:         login,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+	}, nil
+}
+
+// verifyIDToken 校验ID令牌的iss、aud、azp、exp和nonce，返回其中的sub
+// ID令牌由本服务通过TLS直接从令牌端点获取，按OIDC Core 3.1.3.7可以不校验签名
+func (p *oidcProvider) verifyIDToken(idToken, nonce string) (string, error) {
+	if idToken == "" {
+		return "", fmt.Errorf("%w: 未返回id_token", ErrOAuthExchangeFailed)
+	}
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(idToken, claims); err != nil {
+		return "", fmt.Errorf("%w: 解析id_token失败: %v", ErrOAuthExchangeFailed, err)
+	}
+
+	if !claims.VerifyIssuer(p.cfg.Issuer, true) {
+		return "", fmt.Errorf("%w: id_token的iss不匹配", ErrOAuthExchangeFailed)
+	}
+	if !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return "", fmt.Errorf("%w: id_token的aud不匹配", ErrOAuthExchangeFailed)
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != p.cfg.ClientID {
+		return "", fmt.Errorf("%w: id_token的azp不匹配", ErrOAuthExchangeFailed)
+	}
+	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return "", fmt.Errorf("%w: id_token已过期", ErrOAuthExchangeFailed)
+	}
+	if got, _ := claims["nonce"].(string); nonce == "" || got != oidcNonce(nonce) {
+		return "", fmt.Errorf("%w: id_token的nonce不匹配", ErrOAuthExchangeFailed)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return "", fmt.Errorf("%w: id_token缺少sub", ErrOAuthExchangeFailed)
+	}
+	return subject, nil
+}
+
+// oidcNonce 授权请求中的nonce，使用浏览器Cookie中nonce的哈希，避免Cookie的值出现在URL中
+func oidcNonce(nonce string) string {
+	sum := sha256.Sum256([]byte("oidc-nonce:" + nonce))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,380 @@
+package service
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+	"codefolio/internal/util"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 第三方登录相关错误
+var (
+	ErrOAuthProviderNotFound = errors.New("不支持的第三方登录方式")
+	ErrOAuthInvalidState     = errors.New("第三方登录状态无效或已过期")
+	ErrOAuthEmailConflict    = errors.New("该邮箱已注册，请登录后在个人中心绑定第三方账户")
+	ErrIdentityAlreadyLinked = errors.New("该第三方账户已绑定其他用户")
+	ErrIdentityNotFound      = errors.New("未绑定该第三方账户")
+	ErrLastLoginMethod       = errors.New("无法解绑唯一的登录方式")
+)
+
+// oauthStateTTL 授权状态有效期
+const oauthStateTTL = 10 * time.Minute
+
+// oauthStateClaims 授权状态，签名后作为state参数往返于第三方提供方
+type oauthStateClaims struct {
+	Provider   string `json:"provider"`
+	LinkUserID uint   `json:"link_user_id,omitempty"` // 非0表示为已登录用户绑定账户
+	Nonce      string `json:"nonce"`                  // 与发起流程的浏览器Cookie比对，防止登录CSRF和绑定他人的第三方账户
+	jwt.StandardClaims
+}
+
+// OAuthLoginResult 第三方登录结果
+type OAuthLoginResult struct {
+	User    *domain.User
+	Token   string // 登录令牌，绑定流程不签发
+	Created bool   // 是否新注册用户
+	Linked  bool   // 是否为绑定流程
+}
+
+// OAuthService 第三方登录服务接口
+type OAuthService interface {
+	Providers() []string
+	BeginLogin(provider string) (authURL, nonce string, err error)
+	BeginLink(provider string, userID uint) (authURL, nonce string, err error)
+	HandleCallback(ctx context.Context, provider, code, state, nonce string) (*OAuthLoginResult, error)
+	ListIdentities(userID uint) ([]domain.UserIdentity, error)
+	Unlink(userID uint, provider string) error
+}
+
+// oauthService 第三方登录服务实现
+type oauthService struct {
+	providers    map[string]OAuthProvider
+	identityRepo repository.UserIdentityRepository
+	userRepo     repository.UserRepository
+	userService  UserService
+	stateSecret  []byte
+}
+
+// NewOAuthService 创建第三方登录服务
+func NewOAuthService(identityRepo repository.UserIdentityRepository, userRepo repository.UserRepository, userService UserService, jwtSecret string, providers ...OAuthProvider) OAuthService {
+	registry := make(map[string]OAuthProvider, len(providers))
+	for _, p := range providers {
+		registry[p.Name()] = p
+	}
+
+	return &oauthService{
+		providers:    registry,
+		identityRepo: identityRepo,
+		userRepo:     userRepo,
+		userService:  userService,
+		// 使用独立的派生密钥，避免state被当作登录令牌使用
+		stateSecret: []byte(jwtSecret + ":oauth-state"),
+	}
+}
+
+// Providers 返回已启用的提供方名称
+func (s *oauthService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginLogin 开始登录流程，返回授权地址和需要写入浏览器Cookie的nonce
+func (s *oauthService) BeginLogin(provider string) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrOAuthProviderNotFound
+	}
+
+	nonce := uuid.New().String()
+	state, err := s.signState(provider, 0, nonce)
+	if err != nil {
+		return "", "", err
+	}
+	return p.AuthCodeURL(state, nonce), nonce, nil
+}
+
+// BeginLink 为已登录用户开始绑定流程，返回授权地址和需要写入浏览器Cookie的nonce
+func (s *oauthService) BeginLink(provider string, userID uint) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", ErrOAuthProviderNotFound
+	}
+
+	nonce := uuid.New().String()
+	state, err := s.signState(provider, userID, nonce)
+	if err != nil {
+		return "", "", err
+	}
+	return p.AuthCodeURL(state, nonce), nonce, nil
+}
+
+// HandleCallback 处理第三方回调，完成登录、注册或绑定
+func (s *oauthService) HandleCallback(ctx context.Context, provider, code, state, nonce string) (*OAuthLoginResult, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrOAuthProviderNotFound
+	}
+
+	claims, err := s.parseState(state)
+	if err != nil || claims.Provider != provider {
+		return nil, ErrOAuthInvalidState
+	}
+	// 登录和绑定流程都要求回调来自发起流程的浏览器，否则攻击者可诱导他人完成自己发起的流程
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, ErrOAuthInvalidState
+	}
+
+	info, err := p.Exchange(ctx, code, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.LinkUserID != 0 {
+		return s.link(provider, claims.LinkUserID, info)
+	}
+	return s.login(provider, info)
+}
+
+// login 使用第三方身份登录，首次登录时自动注册
+func (s *oauthService) login(provider string, info *OAuthUserInfo) (*OAuthLoginResult, error) {
+	identity, err := s.identityRepo.FindByProviderSubject(provider, info.Subject)
+	if err != nil && !errors.Is(err, common.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// 已绑定，直接登录
+	if identity != nil {
+		user, err := s.userService.GetUserByID(identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		s.refreshIdentity(identity, info)
+		return s.issue(user, false)
+	}
+
+	// 未绑定，自动注册新用户
+	email := info.Email
+	if !info.EmailVerified || email == "" {
+		email = fmt.Sprintf("%s+%s@users.noreply.codefolio.local", provider, info.Subject)
+	} else {
+		// 已存在相同邮箱的账户时不自动合并，防止账户被接管
+		taken, err := s.userRepo.ExistsByEmail(email)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrOAuthEmailConflict
+		}
+	}
+
+	username, err := s.availableUsername(info.Login, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	// 第三方注册的用户没有密码，只能通过已绑定的提供方登录
+	user := &domain.User{
+		Username: username,
+		Email:    email,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+
+	identity = &domain.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  info.Subject,
+		Login:    info.Login,
+		Email:    info.Email,
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		// 回滚刚创建的用户，避免产生无法登录的账户
+		_ = s.userRepo.Delete(user.ID)
+		return nil, err
+	}
+
+	util.GetLogger().Info("第三方登录注册新用户",
+		zap.String("provider", provider),
+		zap.Uint("userID", user.ID))
+
+	return s.issue(user, true)
+}
+
+// link 将第三方身份绑定到发起绑定的用户
+func (s *oauthService) link(provider string, userID uint, info *OAuthUserInfo) (*OAuthLoginResult, error) {
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := s.identityRepo.FindByProviderSubject(provider, info.Subject)
+	if err != nil && !errors.Is(err, common.ErrRecordNotFound) {
+		return nil, err
+	}
+	if identity != nil {
+		if identity.UserID != userID {
+			return nil, ErrIdentityAlreadyLinked
+		}
+		// 重复绑定同一账户，视为成功
+		s.refreshIdentity(identity, info)
+		return &OAuthLoginResult{User: user, Linked: true}, nil
+	}
+
+	// 每个提供方只允许绑定一个账户
+	existing, err := s.identityRepo.FindByUserAndProvider(userID, provider)
+	if err != nil && !errors.Is(err, common.ErrRecordNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrIdentityAlreadyLinked
+	}
+
+	identity = &domain.UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  info.Subject,
+		Login:    info.Login,
+		Email:    info.Email,
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, err
+	}
+
+	// 绑定不改变登录状态，不签发新的令牌
+	return &OAuthLoginResult{User: user, Linked: true}, nil
+}
+
+// ListIdentities 获取用户已绑定的第三方身份
+func (s *oauthService) ListIdentities(userID uint) ([]domain.UserIdentity, error) {
+	return s.identityRepo.FindByUser(userID)
+}
+
+// Unlink 解绑第三方身份，不允许解绑唯一的登录方式
+func (s *oauthService) Unlink(userID uint, provider string) error {
+	identity, err := s.identityRepo.FindByUserAndProvider(userID, provider)
+	if err != nil {
+		if errors.Is(err, common.ErrRecordNotFound) {
+			return ErrIdentityNotFound
+		}
+		return err
+	}
+
+	user, err := s.userService.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	if user.Password == "" {
+		identities, err := s.identityRepo.FindByUser(userID)
+		if err != nil {
+			return err
+		}
+		if len(identities) <= 1 {
+			return ErrLastLoginMethod
+		}
+	}
+
+	return s.identityRepo.Delete(identity.ID)
+}
+
+// issue 为第三方登录的用户签发登录令牌
+func (s *oauthService) issue(user *domain.User, created bool) (*OAuthLoginResult, error) {
+	token, err := s.userService.GenerateToken(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthLoginResult{
+		User:    user,
+		Token:   token,
+		Created: created,
+	}, nil
+}
+
+// refreshIdentity 同步提供方的最新用户名和邮箱
+func (s *oauthService) refreshIdentity(identity *domain.UserIdentity, info *OAuthUserInfo) {
+	if identity.Login == info.Login && identity.Email == info.Email {
+		return
+	}
+	identity.Login = info.Login
+	identity.Email = info.Email
+	if err := s.identityRepo.Update(identity); err != nil {
+		util.GetLogger().Warn("更新第三方身份信息失败", zap.Error(err), zap.Uint("identityID", identity.ID))
+	}
+}
+
+// usernamePattern 用户名中不允许出现的字符
+var usernamePattern = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// availableUsername 根据提供方用户名生成本站可用的用户名
+func (s *oauthService) availableUsername(login, provider string) (string, error) {
+	base := usernamePattern.ReplaceAllString(login, "")
+	if len(base) < 3 {
+		base = provider + "_user"
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		taken, err := s.userRepo.ExistsByUsername(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = base + "_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:6]
+	}
+	return "", ErrUserAlreadyExists
+}
+
+// signState 签发授权状态
+func (s *oauthService) signState(provider string, linkUserID uint, nonce string) (string, error) {
+	now := time.Now()
+	claims := &oauthStateClaims{
+		Provider:   provider,
+		LinkUserID: linkUserID,
+		Nonce:      nonce,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: now.Add(oauthStateTTL).Unix(),
+			IssuedAt:  now.Unix(),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.stateSecret)
+}
+
+// parseState 校验并解析授权状态
+func (s *oauthService) parseState(state string) (*oauthStateClaims, error) {
+	token, err := jwt.ParseWithClaims(state, &oauthStateClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrOAuthInvalidState
+		}
+		return s.stateSecret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*oauthStateClaims)
+	if !ok || !token.Valid {
+		return nil, ErrOAuthInvalidState
+	}
+	return claims, nil
+}
@@ -0,0 +1,484 @@
+package service
+
+import (
+	"codefolio/internal/common"
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	testClientID     = "codefolio-test"
+	testClientSecret = "secret"
+)
+
+// mockAccount 模拟提供方中的用户
+type mockAccount struct {
+	Subject string
+	Login   string
+	Email   string
+}
+
+// mockGrant 授权码对应的授权
+type mockGrant struct {
+	account mockAccount
+	nonce   string // 授权请求中的nonce，写入ID令牌
+}
+
+// mockProvider 本地模拟的OAuth2/OIDC提供方
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu     sync.Mutex
+	grants map[string]mockGrant
+	tokens map[string]mockAccount
+	seq    int
+
+	// 修改签发的ID令牌，用于测试校验失败的情况
+	idTokenClaims func(claims jwt.MapClaims)
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	m := &mockProvider{
+		t:      t,
+		grants: make(map[string]mockGrant),
+		tokens: make(map[string]mockAccount),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeTestJSON(w, map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"userinfo_endpoint":      m.server.URL + "/userinfo",
+		})
+	})
+	// GitHub的令牌端点
+	mux.HandleFunc("/access_token", m.handleToken)
+	mux.HandleFunc("/token", m.handleToken)
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		account, ok := m.account(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeTestJSON(w, map[string]interface{}{
+			"sub":                account.Subject,
+			"preferred_username": account.Login,
+			"email":              account.Email,
+			"email_verified":     true,
+		})
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		account, ok := m.account(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		id, _ := strconv.ParseInt(account.Subject, 10, 64)
+		writeTestJSON(w, map[string]interface{}{"id": id, "login": account.Login})
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		account, ok := m.account(r)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeTestJSON(w, []map[string]interface{}{{"email": account.Email, "primary": true, "verified": true}})
+	})
+
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize 模拟用户在提供方完成授权，返回回调携带的授权码
+func (m *mockProvider) authorize(authURL string, account mockAccount) (code, state string) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatalf("解析授权地址失败: %v", err)
+	}
+	q := u.Query()
+	if q.Get("client_id") != testClientID {
+		m.t.Fatalf("授权地址的client_id为 %q", q.Get("client_id"))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.seq++
+	code = "code-" + strconv.Itoa(m.seq)
+	m.grants[code] = mockGrant{account: account, nonce: q.Get("nonce")}
+	return code, q.Get("state")
+}
+
+func (m *mockProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("client_secret") != testClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	m.mu.Lock()
+	grant, ok := m.grants[r.PostForm.Get("code")]
+	delete(m.grants, r.PostForm.Get("code"))
+	accessToken := "access-" + grant.account.Subject
+	if ok {
+		m.tokens[accessToken] = grant.account
+	}
+	m.mu.Unlock()
+	if !ok {
+		writeTestJSON(w, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   testClientID,
+		"sub":   grant.account.Subject,
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": grant.nonce,
+	}
+	if m.idTokenClaims != nil {
+		m.idTokenClaims(claims)
+	}
+	idToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("provider-key"))
+	if err != nil {
+		m.t.Fatalf("签发ID令牌失败: %v", err)
+	}
+	writeTestJSON(w, map[string]string{"access_token": accessToken, "id_token": idToken})
+}
+
+func (m *mockProvider) account(r *http.Request) (mockAccount, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	account, ok := m.tokens[r.Header.Get("Authorization")[len("Bearer "):]]
+	return account, ok
+}
+
+func writeTestJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// memUserRepo 内存中的用户仓库，只实现第三方登录用到的方法
+type memUserRepo struct {
+	repository.UserRepository
+	users  map[uint]*domain.User
+	nextID uint
+}
+
+func newMemUserRepo() *memUserRepo {
+	return &memUserRepo{users: make(map[uint]*domain.User)}
+}
+
+func (r *memUserRepo) Create(user *domain.User) error {
+	r.nextID++
+	user.ID = r.nextID
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *memUserRepo) FindByID(id uint) (*domain.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, common.ErrRecordNotFound
+}
+
+func (r *memUserRepo) ExistsByUsername(username string) (bool, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memUserRepo) ExistsByEmail(email string) (bool, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memUserRepo) Delete(id uint) error {
+	delete(r.users, id)
+	return nil
+}
+
+// memIdentityRepo 内存中的第三方身份仓库
+type memIdentityRepo struct {
+	identities map[uint]*domain.UserIdentity
+	nextID     uint
+}
+
+func newMemIdentityRepo() *memIdentityRepo {
+	return &memIdentityRepo{identities: make(map[uint]*domain.UserIdentity)}
+}
+
+func (r *memIdentityRepo) Create(identity *domain.UserIdentity) error {
+	r.nextID++
+	identity.ID = r.nextID
+	r.identities[identity.ID] = identity
+	return nil
+}
+
+func (r *memIdentityRepo) FindByProviderSubject(provider, subject string) (*domain.UserIdentity, error) {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return i, nil
+		}
+	}
+	return nil, common.ErrRecordNotFound
+}
+
+func (r *memIdentityRepo) FindByUser(userID uint) ([]domain.UserIdentity, error) {
+	var list []domain.UserIdentity
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			list = append(list, *i)
+		}
+	}
+	return list, nil
+}
+
+func (r *memIdentityRepo) FindByUserAndProvider(userID uint, provider string) (*domain.UserIdentity, error) {
+	for _, i := range r.identities {
+		if i.UserID == userID && i.Provider == provider {
+			return i, nil
+		}
+	}
+	return nil, common.ErrRecordNotFound
+}
+
+func (r *memIdentityRepo) Update(identity *domain.UserIdentity) error {
+	r.identities[identity.ID] = identity
+	return nil
+}
+
+func (r *memIdentityRepo) Delete(id uint) error {
+	delete(r.identities, id)
+	return nil
+}
+
+func (r *memIdentityRepo) DeleteByUser(userID uint) error {
+	for id, i := range r.identities {
+		if i.UserID == userID {
+			delete(r.identities, id)
+		}
+	}
+	return nil
+}
+
+// oauthFixture 连接模拟提供方的第三方登录服务
+type oauthFixture struct {
+	mock       *mockProvider
+	users      *memUserRepo
+	identities *memIdentityRepo
+	service    OAuthService
+}
+
+func newOAuthFixture(t *testing.T) *oauthFixture {
+	mock := newMockProvider(t)
+
+	oidc, err := NewOIDCProvider(context.Background(), OIDCProviderConfig{
+		OAuthClientConfig: OAuthClientConfig{
+			ClientID:     testClientID,
+			ClientSecret: testClientSecret,
+			RedirectURL:  "http://localhost/api/v1/oauth/oidc/callback",
+		},
+		Issuer: mock.server.URL,
+	})
+	if err != nil {
+		t.Fatalf("创建OIDC提供方失败: %v", err)
+	}
+
+	github := NewGitHubProvider(OAuthClientConfig{
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  "http://localhost/api/v1/oauth/github/callback",
+	}).(*githubProvider)
+	github.authURL = mock.server.URL
+	github.apiURL = mock.server.URL
+
+	users := newMemUserRepo()
+	identities := newMemIdentityRepo()
+	userService := NewUserService(users, nil, "jwt-secret", 1, LoginPolicy{})
+	return &oauthFixture{
+		mock:       mock,
+		users:      users,
+		identities: identities,
+		service:    NewOAuthService(identities, users, userService, "jwt-secret", oidc, github),
+	}
+}
+
+// login 在同一浏览器中完成一次登录流程
+func (f *oauthFixture) login(t *testing.T, provider string, account mockAccount) (*OAuthLoginResult, error) {
+	authURL, nonce, err := f.service.BeginLogin(provider)
+	if err != nil {
+		t.Fatalf("开始登录失败: %v", err)
+	}
+	code, state := f.mock.authorize(authURL, account)
+	return f.service.HandleCallback(context.Background(), provider, code, state, nonce)
+}
+
+func TestOAuthLogin(t *testing.T) {
+	for _, provider := range []string{"oidc", "github"} {
+		t.Run(provider, func(t *testing.T) {
+			f := newOAuthFixture(t)
+			account := mockAccount{Subject: "1001", Login: "octocat", Email: "octocat@example.com"}
+
+			first, err := f.login(t, provider, account)
+			if err != nil {
+				t.Fatalf("首次登录失败: %v", err)
+			}
+			if !first.Created || first.Token == "" || first.User.Username != "octocat" {
+				t.Fatalf("首次登录应注册新用户并签发令牌: %+v", first)
+			}
+
+			second, err := f.login(t, provider, account)
+			if err != nil {
+				t.Fatalf("再次登录失败: %v", err)
+			}
+			if second.Created || second.User.ID != first.User.ID || second.Token == "" {
+				t.Fatalf("再次登录应登录已注册的用户: %+v", second)
+			}
+		})
+	}
+}
+
+func TestOAuthLinkDoesNotIssueToken(t *testing.T) {
+	f := newOAuthFixture(t)
+	user := &domain.User{Username: "alice", Email: "alice@example.com", Password: "hash"}
+	_ = f.users.Create(user)
+
+	authURL, nonce, err := f.service.BeginLink("oidc", user.ID)
+	if err != nil {
+		t.Fatalf("开始绑定失败: %v", err)
+	}
+	code, state := f.mock.authorize(authURL, mockAccount{Subject: "sub-alice", Login: "alice"})
+	result, err := f.service.HandleCallback(context.Background(), "oidc", code, state, nonce)
+	if err != nil {
+		t.Fatalf("绑定失败: %v", err)
+	}
+	if !result.Linked || result.Token != "" || result.User.ID != user.ID {
+		t.Fatalf("绑定应只返回用户且不签发令牌: %+v", result)
+	}
+
+	identity, err := f.identities.FindByProviderSubject("oidc", "sub-alice")
+	if err != nil || identity.UserID != user.ID {
+		t.Fatalf("第三方身份未绑定到发起绑定的用户: %+v, %v", identity, err)
+	}
+}
+
+func TestOAuthCallbackRejectsForeignBrowser(t *testing.T) {
+	f := newOAuthFixture(t)
+	attacker := &domain.User{Username: "mallory", Email: "mallory@example.com", Password: "hash"}
+	_ = f.users.Create(attacker)
+
+	// 攻击者发起绑定后把回调地址发给受害者，受害者的浏览器没有攻击者的nonce Cookie
+	authURL, _, err := f.service.BeginLink("oidc", attacker.ID)
+	if err != nil {
+		t.Fatalf("开始绑定失败: %v", err)
+	}
+	victim := mockAccount{Subject: "sub-victim", Login: "victim"}
+
+	for name, cookie := range map[string]string{"无Cookie": "", "其他流程的Cookie": "another-nonce"} {
+		code, state := f.mock.authorize(authURL, victim)
+		_, err := f.service.HandleCallback(context.Background(), "oidc", code, state, cookie)
+		if !errors.Is(err, ErrOAuthInvalidState) {
+			t.Fatalf("%s: 期望ErrOAuthInvalidState，实际 %v", name, err)
+		}
+	}
+	if _, err := f.identities.FindByProviderSubject("oidc", victim.Subject); err == nil {
+		t.Fatal("受害者的第三方身份不应被绑定")
+	}
+
+	// 登录流程同样需要nonce
+	authURL, _, _ = f.service.BeginLogin("oidc")
+	code, state := f.mock.authorize(authURL, victim)
+	if _, err := f.service.HandleCallback(context.Background(), "oidc", code, state, ""); !errors.Is(err, ErrOAuthInvalidState) {
+		t.Fatalf("登录流程缺少nonce: 期望ErrOAuthInvalidState，实际 %v", err)
+	}
+}
+
+func TestOAuthCallbackRejectsInvalidState(t *testing.T) {
+	f := newOAuthFixture(t)
+	authURL, nonce, _ := f.service.BeginLogin("oidc")
+	code, state := f.mock.authorize(authURL, mockAccount{Subject: "1"})
+
+	cases := map[string]struct {
+		provider string
+		state    string
+	}{
+		"篡改的state": {"oidc", state + "x"},
+		"其他提供方":    {"github", state},
+		"空state":   {"oidc", ""},
+	}
+	for name, tc := range cases {
+		_, err := f.service.HandleCallback(context.Background(), tc.provider, code, tc.state, nonce)
+		if !errors.Is(err, ErrOAuthInvalidState) {
+			t.Fatalf("%s: 期望ErrOAuthInvalidState，实际 %v", name, err)
+		}
+	}
+}
+
+func TestOIDCVerifiesIDToken(t *testing.T) {
+	cases := map[string]func(jwt.MapClaims){
+		"nonce不匹配": func(c jwt.MapClaims) { c["nonce"] = "other" },
+		"iss不匹配":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"aud不匹配":   func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"已过期":      func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"sub不一致":   func(c jwt.MapClaims) { c["sub"] = "someone-else" },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := newOAuthFixture(t)
+			f.mock.idTokenClaims = mutate
+			_, err := f.login(t, "oidc", mockAccount{Subject: "1001", Login: "octocat"})
+			if !errors.Is(err, ErrOAuthExchangeFailed) {
+				t.Fatalf("期望ErrOAuthExchangeFailed，实际 %v", err)
+			}
+		})
+	}
+}
+
+func TestOAuthUnlink(t *testing.T) {
+	f := newOAuthFixture(t)
+	result, err := f.login(t, "oidc", mockAccount{Subject: "1001", Login: "octocat"})
+	if err != nil {
+		t.Fatalf("登录失败: %v", err)
+	}
+	userID := result.User.ID
+
+	// 第三方注册的用户没有密码，唯一的登录方式不能解绑
+	if err := f.service.Unlink(userID, "oidc"); !errors.Is(err, ErrLastLoginMethod) {
+		t.Fatalf("期望ErrLastLoginMethod，实际 %v", err)
+	}
+
+	f.users.users[userID].Password = "hash"
+	if err := f.service.Unlink(userID, "oidc"); err != nil {
+		t.Fatalf("解绑失败: %v", err)
+	}
+	if identities, _ := f.service.ListIdentities(userID); len(identities) != 0 {
+		t.Fatalf("解绑后仍有第三方身份: %+v", identities)
+	}
+	if err := f.service.Unlink(userID, "oidc"); !errors.Is(err, ErrIdentityNotFound) {
+		t.Fatalf("期望ErrIdentityNotFound，实际 %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// 支付相关错误
+var (
+	ErrPaymentFailed       = errors.New("支付失败")
+	ErrInsufficientBalance = errors.New("余额不足")
+	ErrRefundFailed        = errors.New("退款失败")
+)
+
+// ChargeRequest 扣款请求
+type ChargeRequest struct {
+	OrderID     string // 业务订单号，相同订单号重复扣款时支付渠道应返回同一笔交易
+	PayerID     uint   // 付款用户
+	PayeeID     uint   // 收款用户
+	Amount      int64  // 金额，单位为分
+	Currency    string // 币种
+	Description string // 交易描述
+}
+
+// PaymentProvider 支付渠道接口
+type PaymentProvider interface {
+	// Name 支付渠道名称
+	Name() string
+	// Charge 扣款并返回渠道交易号
+	Charge(ctx context.Context, req ChargeRequest) (string, error)
+	// Refund 按交易号全额退款
+	Refund(ctx context.Context, paymentID string) error
+}
+
+// fakePayment 本地模拟支付的交易记录
+type fakePayment struct {
+	payerID  uint
+	amount   int64
+	refunded bool
+}
+
+// fakePaymentProvider 本地模拟支付渠道，不产生真实资金流动，用于开发和测试
+type fakePaymentProvider struct {
+	mu             sync.Mutex
+	initialBalance int64                   // 每个用户的初始余额，0表示不限额
+	spent          map[uint]int64          // 用户已消费金额
+	payments       map[string]*fakePayment // 交易号 -> 交易
+	orders         map[string]string       // 订单号 -> 交易号
+}
+
+// NewFakePaymentProvider 创建本地模拟支付渠道，initialBalance为每个用户的模拟余额（分），0表示不限额
+func NewFakePaymentProvider(initialBalance int64) PaymentProvider {
+	return &fakePaymentProvider{
+		initialBalance: initialBalance,
+		spent:          make(map[uint]int64),
+		payments:       make(map[string]*fakePayment),
+		orders:         make(map[string]string),
+	}
+}
+
+// Name 支付渠道名称
+func (p *fakePaymentProvider) Name() string {
+	return "fake"
+}
+
+// Charge 模拟扣款
+func (p *fakePaymentProvider) Charge(ctx context.Context, req ChargeRequest) (string, error) {
+	if req.Amount <= 0 {
+		return "", fmt.Errorf("%w: 金额无效", ErrPaymentFailed)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if paymentID, ok := p.orders[req.OrderID]; ok {
+		return paymentID, nil
+	}
+	if p.initialBalance > 0 && p.spent[req.PayerID]+req.Amount > p.initialBalance {
+		return "", ErrInsufficientBalance
+	}
+
+	paymentID := "fake_" + uuid.New().String()
+	p.spent[req.PayerID] += req.Amount
+	p.payments[paymentID] = &fakePayment{payerID: req.PayerID, amount: req.Amount}
+	p.orders[req.OrderID] = paymentID
+	return paymentID, nil
+}
+
+// Refund 模拟退款，重复退款视为成功
+func (p *fakePaymentProvider) Refund(ctx context.Context, paymentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	payment, ok := p.payments[paymentID]
+	if !ok {
+		return fmt.Errorf("%w: 交易不存在", ErrRefundFailed)
+	}
+	if payment.refunded {
+		return nil
+	}
+
+	payment.refunded = true
+	p.spent[payment.payerID] -= payment.amount
+	return nil
+}
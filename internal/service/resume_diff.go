@@ -0,0 +1,188 @@
+package service
+
+import (
+	"codefolio/internal/util"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ErrDiffUnavailable 版本缺少原始PDF，无法比较
+var ErrDiffUnavailable = errors.New("该版本缺少原始PDF文件，无法比较")
+
+// diffCacheFile 比较结果缓存文件名，版本内容不可变，结果可长期复用
+const diffCacheFile = "diff.json"
+
+// maxCachedDiffs 每份简历最多缓存的比较结果数量，超出时删除最早生成的结果
+const maxCachedDiffs = 20
+
+// ResumeDiff 两个简历版本的差异
+type ResumeDiff struct {
+	From     int             `json:"from"`
+	To       int             `json:"to"`
+	TextDiff string          `json:"text_diff"` // 提取文本的统一格式差异
+	Pages    []util.PageDiff `json:"pages"`     // 逐页图片差异
+}
+
+// diffDir 简历版本比较结果的存储目录，位于用户目录下以便随账户一起删除
+func diffDir(userID, resumeID uint) util.StorageKey {
+	return util.StorageKey(util.ResumeDir).Join(fmt.Sprintf("%d", userID), "diffs", fmt.Sprintf("%d", resumeID))
+}
+
+// DiffResumeVersions 比较简历的两个版本，返回文本差异和逐页图片差异
+func (s *resumeService) DiffResumeVersions(resumeID uint, from, to int, userID uint) (*ResumeDiff, error) {
+	resume, err := s.findVisibleResume(resumeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	// 比较他人的简历与查看简历一样受查看次数限制
+	if resume.UserID != userID && !s.CanViewResume(userID) {
+		return nil, ErrViewLimitExceeded
+	}
+	if resume.UserID == userID {
+		if err := s.ensureHistory(resume); err != nil {
+			return nil, err
+		}
+	}
+
+	fromVersion, err := s.findVisibleVersion(resume, from, userID)
+	if err != nil {
+		return nil, err
+	}
+	toVersion, err := s.findVisibleVersion(resume, to, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(filepath.Ext(fromVersion.SourceURL), ".pdf") || !strings.EqualFold(filepath.Ext(toVersion.SourceURL), ".pdf") {
+		return nil, ErrDiffUnavailable
+	}
+
+	// 优先使用缓存的比较结果
+	ctx := context.Background()
+	outDir := diffDir(resume.UserID, resumeID).Join(fmt.Sprintf("%d-%d", from, to))
+	cacheFile := outDir.Join(diffCacheFile)
+	if data, err := util.ReadFile(ctx, cacheFile); err == nil {
+		var cached ResumeDiff
+		if err := json.Unmarshal(data, &cached); err == nil {
+			return &cached, nil
+		}
+	}
+
+	// 比较工具需要本地文件，在临时目录中下载原始PDF并生成结果
+	workDir, err := os.MkdirTemp("", "codefolio-diff-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	fromPDF := filepath.Join(workDir, "from.pdf")
+	toPDF := filepath.Join(workDir, "to.pdf")
+	for local, stored := range map[string]string{fromPDF: fromVersion.SourceURL, toPDF: toVersion.SourceURL} {
+		key, err := util.ParseStorageKey(stored)
+		if err != nil {
+			return nil, ErrDiffUnavailable
+		}
+		if err := util.FetchToLocal(ctx, key, local); err != nil {
+			if errors.Is(err, util.ErrFileNotFound) {
+				return nil, ErrDiffUnavailable
+			}
+			return nil, err
+		}
+	}
+	pagesDir := filepath.Join(workDir, "pages")
+	if err := os.MkdirAll(pagesDir, 0755); err != nil {
+		return nil, err
+	}
+
+	diff, err := buildResumeDiff(ctx, fromPDF, toPDF, pagesDir, outDir, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	// 最后写入缓存文件，并发请求生成相同结果时互相覆盖不影响正确性
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return nil, err
+	}
+	if err := util.WriteFile(ctx, cacheFile, data, "application/json"); err != nil {
+		return nil, err
+	}
+	evictDiffCache(ctx, diffDir(resume.UserID, resumeID))
+
+	util.GetLogger().Info("生成简历版本差异",
+		zap.Uint("resumeID", resumeID),
+		zap.Int("from", from),
+		zap.Int("to", to))
+
+	return diff, nil
+}
+
+// evictDiffCache 删除超出数量上限的比较结果，按缓存文件的生成时间从早到晚删除
+// 清理失败只记录日志，下次生成比较结果时会再次清理
+func evictDiffCache(ctx context.Context, dir util.StorageKey) {
+	objects, err := util.GetStorage().List(ctx, dir.String()+"/")
+	if err != nil {
+		util.GetLogger().Warn("列出简历比较结果失败", zap.Error(err), zap.Stringer("dir", dir))
+		return
+	}
+
+	var cached []util.StorageObject
+	for _, obj := range objects {
+		if path.Base(obj.Key.String()) == diffCacheFile {
+			cached = append(cached, obj)
+		}
+	}
+	if len(cached) <= maxCachedDiffs {
+		return
+	}
+
+	sort.Slice(cached, func(i, j int) bool { return cached[i].ModTime.Before(cached[j].ModTime) })
+	for _, obj := range cached[:len(cached)-maxCachedDiffs] {
+		pairDir := util.StorageKey(path.Dir(obj.Key.String()))
+		if err := util.DeleteDir(ctx, pairDir); err != nil {
+			util.GetLogger().Warn("删除简历比较结果失败", zap.Error(err), zap.Stringer("dir", pairDir))
+		}
+	}
+}
+
+// buildResumeDiff 在workDir中生成比较结果，并将差异图片写入存储目录outDir
+func buildResumeDiff(ctx context.Context, fromPDF, toPDF, workDir string, outDir util.StorageKey, from, to int) (*ResumeDiff, error) {
+	fromText, err := util.ExtractPDFText(fromPDF)
+	if err != nil {
+		return nil, err
+	}
+	toText, err := util.ExtractPDFText(toPDF)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := util.DiffPDFPages(fromPDF, toPDF, workDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pages {
+		if pages[i].ImagePath != "" {
+			key := outDir.Join(filepath.Base(pages[i].ImagePath))
+			if err := util.StoreLocalFile(ctx, pages[i].ImagePath, key); err != nil {
+				return nil, err
+			}
+			pages[i].ImagePath = key.String()
+		}
+	}
+
+	return &ResumeDiff{
+		From:     from,
+		To:       to,
+		TextDiff: util.UnifiedDiff(fmt.Sprintf("v%d", from), fmt.Sprintf("v%d", to), fromText, toText, 3),
+		Pages:    pages,
+	}, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/util"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+// 重复简历相关错误
+var (
+	ErrDuplicateResume        = errors.New("已上传过相同的简历")
+	ErrResumeUploadedByOthers = errors.New("该简历已由其他用户上传")
+)
+
+// 其他用户已上传完全相同的简历时的处理方式
+const (
+	DuplicateActionReject = "reject" // 拒绝上传
+	DuplicateActionFlag   = "flag"   // 允许上传，简历进入审核队列
+)
+
+// DuplicatePolicy 重复简历检测策略
+type DuplicatePolicy struct {
+	ExactAction     string // 其他用户已上传完全相同的简历时的处理方式
+	SimilarDistance int    // 第一页感知哈希的汉明距离不超过该值时视为相似简历，0表示不检测
+}
+
+// detectDuplicate 检测简历文件是否与已有简历重复，在保存简历前调用
+// 同一用户重复创建相同的简历、或按策略拒绝其他用户已上传的简历时返回错误；
+// 与其他用户的简历相同或相似时将简历标记为待审核，并记录原简历供管理员比对
+func (s *resumeService) detectDuplicate(resume *domain.Resume, creating bool) error {
+	resume.DuplicateOfID = nil
+	resume.DuplicateKind = ""
+	resume.DuplicateDistance = 0
+	if resume.ContentHash == "" {
+		return nil
+	}
+
+	// 文件内容完全相同，以最早上传的简历为原简历
+	matches, err := s.resumeRepo.FindByContentHash(resume.ContentHash)
+	if err != nil {
+		return err
+	}
+	var original *domain.Resume
+	for i := range matches {
+		match := &matches[i]
+		if match.ID == resume.ID {
+			continue
+		}
+		if match.UserID == resume.UserID {
+			if creating {
+				return ErrDuplicateResume
+			}
+			continue
+		}
+		if original == nil {
+			original = match
+		}
+	}
+	if original != nil {
+		if s.duplicates.ExactAction != DuplicateActionFlag {
+			return ErrResumeUploadedByOthers
+		}
+		flagDuplicate(resume, original.ID, domain.ResumeDuplicateExact, 0)
+		return nil
+	}
+
+	// 第一页相似，取汉明距离最小的简历为原简历
+	if resume.PHash == "" || s.duplicates.SimilarDistance <= 0 {
+		return nil
+	}
+	similarID, distance, err := s.resumeRepo.FindMostSimilarByPHash(resume.UserID, resume.PHash, s.duplicates.SimilarDistance)
+	if err != nil {
+		if errors.Is(err, util.ErrInvalidPHash) {
+			return nil
+		}
+		return err
+	}
+	if similarID != 0 {
+		flagDuplicate(resume, similarID, domain.ResumeDuplicateSimilar, distance)
+	}
+	return nil
+}
+
+// recheckResumeFile 更换简历文件后重新检测重复并调整审核状态，在保存简历前调用
+// 审核结论只针对旧文件：审核未通过的简历需重新审核，因疑似重复进入审核队列的简历不再重复时恢复公开
+func (s *resumeService) recheckResumeFile(resume *domain.Resume) error {
+	wasFlagged := resume.DuplicateOfID != nil
+	if err := s.detectDuplicate(resume, false); err != nil {
+		return err
+	}
+	switch {
+	case resume.DuplicateOfID != nil:
+		// 已由flagDuplicate标记为待审核
+	case resume.Status == domain.ResumeStatusRejected:
+		resume.Status = domain.ResumeStatusPending
+	case wasFlagged && resume.Status == domain.ResumeStatusPending:
+		resume.Status = domain.ResumeStatusApproved
+	}
+	return nil
+}
+
+// saveRecheckedStatus 保存重新检测后变化的审核状态
+// 只在状态仍为检测前的值时更新，不覆盖管理员在此期间作出的审核结果
+func (s *resumeService) saveRecheckedStatus(resume *domain.Resume, previous string) error {
+	if resume.Status == previous {
+		return nil
+	}
+	updated, err := s.resumeRepo.UpdateStatusIf(resume.ID, previous, resume.Status)
+	if err != nil || updated {
+		return err
+	}
+
+	current, err := s.resumeRepo.FindByID(resume.ID)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return ErrResumeNotFound
+	}
+	resume.Status = current.Status
+	return nil
+}
+
+// flagDuplicate 将疑似重复的简历标记为待审核
+func flagDuplicate(resume *domain.Resume, originalID uint, kind string, distance int) {
+	resume.Status = domain.ResumeStatusPending
+	resume.DuplicateOfID = &originalID
+	resume.DuplicateKind = kind
+	resume.DuplicateDistance = distance
+
+	util.GetLogger().Info("简历疑似重复，已进入审核队列",
+		zap.Uint("resumeID", resume.ID),
+		zap.Uint("userID", resume.UserID),
+		zap.Uint("duplicateOf", originalID),
+		zap.String("kind", kind),
+		zap.Int("distance", distance))
+}
+
+// isDuplicateError 判断是否为检测到重复简历而拒绝上传
+func isDuplicateError(err error) bool {
+	return errors.Is(err, ErrDuplicateResume) || errors.Is(err, ErrResumeUploadedByOthers)
+}
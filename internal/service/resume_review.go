@@ -0,0 +1,90 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/util"
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// ErrInvalidResumeStatus 无效的简历审核状态
+var ErrInvalidResumeStatus = errors.New("无效的简历审核状态")
+
+// isResumeStatus 判断是否为有效的简历审核状态
+func isResumeStatus(status string) bool {
+	switch status {
+	case domain.ResumeStatusPending, domain.ResumeStatusApproved, domain.ResumeStatusRejected:
+		return true
+	}
+	return false
+}
+
+// GetResumesByStatus 按审核状态分页获取简历，供管理员审核
+func (s *resumeService) GetResumesByStatus(status string, page, size int) ([]domain.Resume, int64, error) {
+	if status != "" && !isResumeStatus(status) {
+		return nil, 0, ErrInvalidResumeStatus
+	}
+	return s.resumeRepo.FindByStatus(status, page, size)
+}
+
+// ReviewResume 修改简历审核状态，审核通过或未通过时通知简历所有者
+func (s *resumeService) ReviewResume(resumeID uint, status, reason string, adminID uint) (*domain.Resume, error) {
+	if !isResumeStatus(status) {
+		return nil, ErrInvalidResumeStatus
+	}
+
+	resume, err := s.resumeRepo.FindByID(resumeID)
+	if err != nil {
+		return nil, err
+	}
+	if resume == nil {
+		return nil, ErrResumeNotFound
+	}
+	if resume.Status == status {
+		return resume, nil
+	}
+
+	if err := s.resumeRepo.UpdateStatus(resumeID, status); err != nil {
+		return nil, err
+	}
+	resume.Status = status
+	// 当前版本随审核结果决定是否对他人可见
+	if err := s.versionRepo.UpdateStatus(resumeID, resume.Version, status); err != nil {
+		util.GetLogger().Error("更新简历版本审核状态失败", zap.Error(err), zap.Uint("resumeID", resumeID))
+	}
+
+	util.GetLogger().Info("简历审核状态已更新",
+		zap.Uint("resumeID", resumeID),
+		zap.Uint("adminID", adminID),
+		zap.String("status", status))
+
+	link := fmt.Sprintf("/resumes/%d", resumeID)
+	switch status {
+	case domain.ResumeStatusApproved:
+		s.events.Publish(Event{
+			Type:    domain.NotificationResumeApproved,
+			UserID:  resume.UserID,
+			Title:   fmt.Sprintf("您的简历 #%d 已通过审核", resumeID),
+			Content: "简历已公开展示。",
+			Link:    link,
+			Data:    map[string]interface{}{"resume": resumeEventData(resume)},
+		})
+	case domain.ResumeStatusRejected:
+		content := "请根据审核意见修改后重新提交。"
+		if reason != "" {
+			content = "审核意见：" + reason
+		}
+		s.events.Publish(Event{
+			Type:    domain.NotificationResumeRejected,
+			UserID:  resume.UserID,
+			Title:   fmt.Sprintf("您的简历 #%d 未通过审核", resumeID),
+			Content: content,
+			Link:    link,
+			Data:    map[string]interface{}{"resume": resumeEventData(resume), "reason": reason},
+		})
+	}
+
+	return resume, nil
+}
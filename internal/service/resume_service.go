@@ -4,14 +4,17 @@ import (
 	"codefolio/internal/domain"
 	"codefolio/internal/repository"
 	"codefolio/internal/util"
+	"context"
 	"errors"
-	"fmt"
+	"io"
 	"mime/multipart"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // 简历相关错误
@@ -20,23 +23,43 @@ var (
 	ErrNotResumeOwner    = errors.New("非简历所有者，无权操作")
 	ErrViewLimitExceeded = errors.New("已超过简历查看限制")
 	ErrFileNotFound      = errors.New("文件不存在或已过期")
+	ErrRestoreExpired    = errors.New("简历已超过可恢复期限")
 )
 
 // FileResult 文件处理结果
 type FileResult struct {
-	FilePath string // 文件路径
-	FileKey  string // 文件唯一标识
+	ImageKey util.StorageKey // 转换后图片的存储键
+	FileKey  string          // 文件唯一标识
 }
 
 // TempFileInfo 临时文件信息
 type TempFileInfo struct {
-	UserID    uint      // 上传用户ID
-	FilePath  string    // 文件路径
-	CreatedAt time.Time // 创建时间
+	UserID      uint            // 上传用户ID
+	ImageKey    util.StorageKey // 转换后图片的存储键
+	SourceKey   util.StorageKey // 原始文件的存储键
+	ContentHash string          // 原始文件的SHA-256
+	PHash       string          // 第一页的感知哈希
+	CreatedAt   time.Time       // 创建时间
 }
 
-// 临时文件缓存，用于存储已上传但尚未关联到简历的文件
-var tempFiles = make(map[string]TempFileInfo)
+// 各修改操作只更新自己负责的字段，查看、下载和收藏计数由仓库原子维护
+// 审核状态由saveRecheckedStatus按条件单独更新
+var (
+	resumeMetadataColumns = []string{"role", "level", "university", "pass_company", "version"}
+	resumeFileColumns     = []string{"image_url", "source_url", "content_hash", "p_hash", "version",
+		"duplicate_of_id", "duplicate_kind", "duplicate_distance"}
+	resumeRollbackColumns = append([]string{"role", "level", "university", "pass_company"}, resumeFileColumns...)
+)
+
+// 临时文件缓存，用于存储表单上传后尚未关联到简历的文件
+// 分片上传的转换结果保存在上传会话记录上，不进入缓存
+var (
+	tempFiles   = make(map[string]TempFileInfo)
+	tempFilesMu sync.Mutex
+)
+
+// tempFileTTL 转换结果未用于创建简历时保留的时间
+const tempFileTTL = 30 * time.Minute
 
 // ResumeService 简历服务接口
 type ResumeService interface {
@@ -44,43 +67,92 @@ type ResumeService interface {
 	CreateResume(c *gin.Context, userID uint, file *multipart.FileHeader, role, level, university int, passCompany []int) (*domain.Resume, error)
 	GetResumeByID(c *gin.Context, id uint, currentUserID uint) (*domain.Resume, error)
 	GetUserResumes(userID uint) ([]domain.Resume, error)
-	GetAllResumes(page, size int, role, level, university int, currentUserID uint) ([]domain.Resume, int64, error)
+	GetPublicUserResumes(userID uint) ([]domain.Resume, error)
+	GetAllResumes(page, size int, role, level, university int, sort string, currentUserID uint) ([]domain.Resume, int64, error)
 	UpdateResume(resumeID, userID uint, role, level, university int, passCompany []int) (*domain.Resume, error)
 	UpdateResumeFile(c *gin.Context, resumeID, userID uint, file *multipart.FileHeader) (*domain.Resume, error)
 	DeleteResume(resumeID, userID uint) error
 
+	// 版本历史
+	GetResumeVersions(resumeID, userID uint) ([]domain.ResumeVersion, error)
+	RollbackResume(resumeID uint, version int, userID uint) (*domain.Resume, error)
+	DiffResumeVersions(resumeID uint, from, to int, userID uint) (*ResumeDiff, error)
+
+	// 回收站
+	GetDeletedResumes(userID uint) ([]domain.Resume, error)
+	RestoreResume(resumeID, userID uint) (*domain.Resume, error)
+	TrashRetention() time.Duration
+
+	// 审核
+	GetResumesByStatus(status string, page, size int) ([]domain.Resume, int64, error)
+	ReviewResume(resumeID uint, status, reason string, adminID uint) (*domain.Resume, error)
+
 	// 文件相关
 	UploadAndConvertPDF(c *gin.Context, userID uint, file *multipart.FileHeader) (*FileResult, error)
 	CreateResumeWithFileKey(userID uint, fileKey string, role, level, university int, passCompany []int) (*domain.Resume, error)
-	GetResumeFileURL(c *gin.Context, resume *domain.Resume) string
+	GetResumeFileURL(c *gin.Context, resume *domain.Resume, viewerID uint) string
 	DownloadResume(c *gin.Context, resumeID, userID uint) (*domain.Resume, error)
 
+	// 分片上传
+	InitUpload(userID uint, fileName string, fileSize int64, checksum string) (*domain.UploadSession, error)
+	GetUpload(uploadID string, userID uint) (*domain.UploadSession, []domain.UploadPart, error)
+	UploadPart(c *gin.Context, uploadID string, userID uint, partNumber int, body io.Reader, checksum string) (*domain.UploadPart, error)
+	CompleteUpload(c *gin.Context, uploadID string, userID uint) (*FileResult, error)
+	AbortUpload(uploadID string, userID uint) error
+
 	// 访问控制
 	CanViewResume(userID uint) bool
+
+	// 账户删除时清理简历修订记录
+	PurgeUserData(userID uint) error
 }
 
 // resumeService 简历服务实现
 type resumeService struct {
-	resumeRepo repository.ResumeRepository
-	userRepo   repository.UserRepository
+	resumeRepo  repository.ResumeRepository
+	versionRepo repository.ResumeVersionRepository
+	userRepo    repository.UserRepository
+	events      EventPublisher
+	duplicates  DuplicatePolicy
+
+	// 分片上传会话
+	uploadRepo repository.UploadSessionRepository
+	uploads    UploadPolicy
 
 	// 未登录用户可浏览的简历数量
 	anonymousViewLimit int
 	// 未上传简历的注册用户可浏览的简历数量
 	registeredViewLimit int
+	// 已删除简历在回收站中的保留时间
+	trashRetention time.Duration
 }
 
 // NewResumeService 创建简历服务实例
-func NewResumeService(resumeRepo repository.ResumeRepository, userRepo repository.UserRepository, anonymousViewLimit, registeredViewLimit int) ResumeService {
+func NewResumeService(resumeRepo repository.ResumeRepository, versionRepo repository.ResumeVersionRepository, userRepo repository.UserRepository, uploadRepo repository.UploadSessionRepository, events EventPublisher, duplicates DuplicatePolicy, uploads UploadPolicy, anonymousViewLimit, registeredViewLimit int, trashRetention time.Duration) ResumeService {
 	// 启动临时文件清理goroutine
 	go cleanupTempFiles()
 
-	return &resumeService{
+	if uploads.ChunkSize <= 0 {
+		uploads.ChunkSize = 1024 * 1024
+	}
+
+	s := &resumeService{
 		resumeRepo:          resumeRepo,
+		versionRepo:         versionRepo,
 		userRepo:            userRepo,
+		events:              events,
+		duplicates:          duplicates,
+		uploadRepo:          uploadRepo,
+		uploads:             uploads,
 		anonymousViewLimit:  anonymousViewLimit,
 		registeredViewLimit: registeredViewLimit,
+		trashRetention:      trashRetention,
 	}
+	// 启动回收站清理goroutine
+	go s.purgeDeletedResumes()
+	// 启动过期上传会话清理goroutine
+	go s.cleanupUploadSessions()
+	return s
 }
 
 // 定期清理过期的临时文件（超过30分钟未使用的文件）
@@ -90,93 +162,190 @@ func cleanupTempFiles() {
 
 	for range ticker.C {
 		now := time.Now()
+		var expired []TempFileInfo
+		tempFilesMu.Lock()
 		for key, info := range tempFiles {
-			// 超过30分钟的文件视为过期
-			if now.Sub(info.CreatedAt) > 30*time.Minute {
-				_ = util.DeleteFile(info.FilePath)
+			if now.Sub(info.CreatedAt) > tempFileTTL {
+				expired = append(expired, info)
 				delete(tempFiles, key)
 			}
 		}
+		tempFilesMu.Unlock()
+
+		for _, info := range expired {
+			releaseTempFile(info)
+		}
+	}
+}
+
+// releaseTempFile 释放未关联到简历的临时文件
+func releaseTempFile(info TempFileInfo) {
+	_ = util.ReleaseFile(info.ImageKey.String())
+	_ = util.ReleaseFile(info.SourceKey.String())
+}
+
+// PDF转换进度阶段
+const (
+	ConversionStarted   = "started"
+	ConversionCompleted = "completed"
+	ConversionFailed    = "failed"
+)
+
+// convertWithProgress 保存上传文件并转换为图片，通过事件推送转换进度
+// 客户端可在上传请求中携带X-Upload-ID请求头，用于将推送的进度与本次上传关联
+func (s *resumeService) convertWithProgress(
+	c *gin.Context,
+	userID uint,
+	filename string,
+	convert func() (*util.UploadFileResult, error),
+) (*util.UploadFileResult, error) {
+	publish := func(stage string, data map[string]interface{}) {
+		data["stage"] = stage
+		data["filename"] = filename
+		if uploadID := c.GetHeader("X-Upload-ID"); uploadID != "" {
+			data["upload_id"] = uploadID
+		}
+		s.events.Publish(Event{
+			Type:   EventConversionProgress,
+			UserID: userID,
+			Data:   data,
+		})
+	}
+
+	publish(ConversionStarted, map[string]interface{}{})
+	result, err := convert()
+	if err != nil {
+		publish(ConversionFailed, map[string]interface{}{"error": err.Error()})
+		return nil, err
 	}
+	publish(ConversionCompleted, map[string]interface{}{"image_url": util.GetFileURL(c, result.Key.String(), userID)})
+	return result, nil
+}
+
+// saveUploadedFile 保存通过表单上传的简历文件并转换为图片
+func (s *resumeService) saveUploadedFile(c *gin.Context, userID uint, file *multipart.FileHeader) (*util.UploadFileResult, error) {
+	return s.convertWithProgress(c, userID, file.Filename, func() (*util.UploadFileResult, error) {
+		return util.SaveUploadedFile(c, file, userID)
+	})
 }
 
 // UploadAndConvertPDF 上传并转换PDF文件为图片（第一步）
 func (s *resumeService) UploadAndConvertPDF(c *gin.Context, userID uint, file *multipart.FileHeader) (*FileResult, error) {
 	// 上传并转换PDF为图片
-	uploadResult, err := util.SaveUploadedPDF(c, file, userID)
+	uploadResult, err := s.saveUploadedFile(c, userID, file)
 	if err != nil {
 		return nil, err
 	}
+	return cacheTempFile(userID, uploadResult), nil
+}
 
+// cacheTempFile 缓存已转换但尚未关联到简历的文件，返回供创建简历使用的文件标识
+func cacheTempFile(userID uint, uploadResult *util.UploadFileResult) *FileResult {
 	// 生成文件唯一标识
 	fileKey := uuid.New().String()
 
-	// 记录物理文件路径（用于调试）
-	filePath := uploadResult.FilePath
-
-	// 构建直接访问的URL路径
-	scheme := "http"
-	if c.Request.TLS != nil {
-		scheme = "https"
-	}
-
-	host := c.Request.Host
-	if host == "" {
-		host = "localhost:8080"
-	}
-
-	// 使用新增的直接静态文件访问路径
-	imageURL := fmt.Sprintf("%s://%s%s", scheme, host, filePath)
-
 	util.GetLogger().Info("PDF转图片成功",
-		zap.String("filePath", filePath),
-		zap.String("imageURL", imageURL))
+		zap.Stringer("imageKey", uploadResult.Key),
+		zap.String("fileKey", fileKey))
 
 	// 存入临时文件缓存
+	tempFilesMu.Lock()
 	tempFiles[fileKey] = TempFileInfo{
-		UserID:    userID,
-		FilePath:  uploadResult.FilePath,
-		CreatedAt: time.Now(),
+		UserID:      userID,
+		ImageKey:    uploadResult.Key,
+		SourceKey:   uploadResult.SourceKey,
+		ContentHash: uploadResult.ContentHash,
+		PHash:       uploadResult.PHash,
+		CreatedAt:   time.Now(),
 	}
+	tempFilesMu.Unlock()
 
-	// 返回结果包含图片URL和文件标识
+	// 返回结果包含图片存储键和文件标识，图片地址由调用方签发
 	return &FileResult{
-		FilePath: imageURL, // 返回完整的URL，而不是文件路径
+		ImageKey: uploadResult.Key,
 		FileKey:  fileKey,
-	}, nil
+	}
 }
 
 // CreateResumeWithFileKey 使用文件标识创建简历（第二步）
+// 文件标识先被原子地取用，重复提交时只有一个请求能创建简历，失败时恢复供客户端重试
 func (s *resumeService) CreateResumeWithFileKey(userID uint, fileKey string, role, level, university int, passCompany []int) (*domain.Resume, error) {
-	// 获取临时文件信息
-	fileInfo, exists := tempFiles[fileKey]
-	if !exists {
-		return nil, ErrFileNotFound
+	// 获取临时文件信息，不在缓存中时按分片上传ID取用会话上保存的转换结果
+	fileInfo, err := takeTempFile(fileKey, userID)
+	if err != nil {
+		return nil, err
+	}
+	var restore func()
+	if fileInfo != nil {
+		cached := *fileInfo
+		restore = func() { restoreTempFile(fileKey, cached) }
+	} else {
+		if fileInfo, err = s.claimCompletedUpload(fileKey, userID); err != nil {
+			return nil, err
+		}
+		restore = func() { s.unclaimUpload(fileKey) }
+	}
+
+	resume, err := s.createResumeFromFile(userID, fileInfo, role, level, university, passCompany)
+	if err != nil && !isDuplicateError(err) {
+		// 被拒绝的文件已经释放，其他错误时恢复文件标识
+		restore()
 	}
+	return resume, err
+}
+
+// takeTempFile 从缓存中取出用户的临时文件，文件不在缓存中时返回nil
+func takeTempFile(fileKey string, userID uint) (*TempFileInfo, error) {
+	tempFilesMu.Lock()
+	defer tempFilesMu.Unlock()
 
-	// 验证用户身份
-	if fileInfo.UserID != userID {
+	info, exists := tempFiles[fileKey]
+	if !exists {
+		return nil, nil
+	}
+	if info.UserID != userID {
 		return nil, ErrNotResumeOwner
 	}
+	delete(tempFiles, fileKey)
+	return &info, nil
+}
 
+// restoreTempFile 创建简历失败时将临时文件放回缓存
+func restoreTempFile(fileKey string, info TempFileInfo) {
+	tempFilesMu.Lock()
+	tempFiles[fileKey] = info
+	tempFilesMu.Unlock()
+}
+
+// createResumeFromFile 使用已转换的文件创建简历，检测到重复时释放文件
+func (s *resumeService) createResumeFromFile(userID uint, fileInfo *TempFileInfo, role, level, university int, passCompany []int) (*domain.Resume, error) {
 	// 创建简历记录
 	resume := &domain.Resume{
 		UserID:      userID,
-		ImageURL:    fileInfo.FilePath,
+		ImageURL:    fileInfo.ImageKey.String(),
+		SourceURL:   fileInfo.SourceKey.String(),
+		ContentHash: fileInfo.ContentHash,
+		PHash:       fileInfo.PHash,
 		Role:        role,
 		Level:       level,
 		University:  university,
 		PassCompany: passCompany,
 	}
 
-	// 保存到数据库
-	err := s.resumeRepo.Create(resume)
-	if err != nil {
+	// 检测重复简历，被拒绝的文件不能再用于创建简历
+	if err := s.detectDuplicate(resume, true); err != nil {
+		if isDuplicateError(err) {
+			releaseTempFile(*fileInfo)
+		}
 		return nil, err
 	}
 
-	// 从临时文件缓存中移除
-	delete(tempFiles, fileKey)
+	// 保存到数据库
+	if err := s.resumeRepo.Create(resume); err != nil {
+		return nil, err
+	}
+	s.recordVersion(resume, domain.ResumeChangeCreate, 0)
+	s.publishResumeEvent(EventResumeCreated, resume)
 
 	return resume, nil
 }
@@ -184,7 +353,7 @@ func (s *resumeService) CreateResumeWithFileKey(userID uint, fileKey string, rol
 // CreateResume 创建简历（一次性操作，保留兼容性）
 func (s *resumeService) CreateResume(c *gin.Context, userID uint, file *multipart.FileHeader, role, level, university int, passCompany []int) (*domain.Resume, error) {
 	// 保存文件并转换为图片
-	fileResult, err := util.SaveUploadedFile(c, file, userID)
+	fileResult, err := s.saveUploadedFile(c, userID, file)
 	if err != nil {
 		return nil, err
 	}
@@ -192,20 +361,29 @@ func (s *resumeService) CreateResume(c *gin.Context, userID uint, file *multipar
 	// 创建简历记录
 	resume := &domain.Resume{
 		UserID:      userID,
-		ImageURL:    fileResult.FilePath,
+		ImageURL:    fileResult.Key.String(),
+		SourceURL:   fileResult.SourceKey.String(),
+		ContentHash: fileResult.ContentHash,
+		PHash:       fileResult.PHash,
 		Role:        role,
 		Level:       level,
 		University:  university,
 		PassCompany: passCompany,
 	}
 
-	// 使用事务确保数据一致性
-	err = s.resumeRepo.Create(resume)
+	// 检测重复简历后保存到数据库
+	err = s.detectDuplicate(resume, true)
+	if err == nil {
+		err = s.resumeRepo.Create(resume)
+	}
 	if err != nil {
-		// 如果保存数据库失败，删除已上传的文件
-		_ = util.DeleteFile(fileResult.FilePath)
+		// 如果保存数据库失败，释放已上传的文件
+		_ = util.ReleaseFile(fileResult.Key.String())
+		_ = util.ReleaseFile(fileResult.SourceKey.String())
 		return nil, err
 	}
+	s.recordVersion(resume, domain.ResumeChangeCreate, 0)
+	s.publishResumeEvent(EventResumeCreated, resume)
 
 	return resume, nil
 }
@@ -217,9 +395,9 @@ func (s *resumeService) GetResumeByID(_ *gin.Context, id uint, currentUserID uin
 		return nil, ErrViewLimitExceeded
 	}
 
-	// 获取简历
+	// 获取简历，未审核通过的简历仅所有者可见
 	resume, err := s.resumeRepo.FindByID(id)
-	if err != nil || resume == nil {
+	if err != nil || resume == nil || !canSeeResume(resume, currentUserID) {
 		return nil, ErrResumeNotFound
 	}
 
@@ -236,14 +414,24 @@ func (s *resumeService) GetUserResumes(userID uint) ([]domain.Resume, error) {
 	return s.resumeRepo.FindByUser(userID)
 }
 
+// GetPublicUserResumes 获取用户公开展示（审核通过）的简历
+func (s *resumeService) GetPublicUserResumes(userID uint) ([]domain.Resume, error) {
+	return s.resumeRepo.FindByUserAndStatus(userID, domain.ResumeStatusApproved)
+}
+
+// canSeeResume 判断用户能否看到简历：审核通过的简历公开，其余仅所有者可见
+func canSeeResume(resume *domain.Resume, userID uint) bool {
+	return resume.Status == domain.ResumeStatusApproved || resume.UserID == userID
+}
+
 // GetAllResumes 获取所有简历（分页）
-func (s *resumeService) GetAllResumes(page, size int, role, level, university int, currentUserID uint) ([]domain.Resume, int64, error) {
+func (s *resumeService) GetAllResumes(page, size int, role, level, university int, sort string, currentUserID uint) ([]domain.Resume, int64, error) {
 	// 检查访问权限
 	if !s.CanViewResume(currentUserID) {
 		return nil, 0, ErrViewLimitExceeded
 	}
 
-	return s.resumeRepo.FindAll(page, size, role, level, university)
+	return s.resumeRepo.FindAll(page, size, role, level, university, sort)
 }
 
 // UpdateResume 更新简历信息（不包括文件）
@@ -259,16 +447,29 @@ func (s *resumeService) UpdateResume(resumeID, userID uint, role, level, univers
 		return nil, ErrNotResumeOwner
 	}
 
+	// 信息未变化时不产生新版本
+	if resume.Role == role && resume.Level == level && resume.University == university &&
+		equalInts(resume.PassCompany, passCompany) {
+		return resume, nil
+	}
+
+	// 修改前确保历史简历已有初始版本
+	if err := s.ensureHistory(resume); err != nil {
+		return nil, err
+	}
+
 	// 更新基本信息
 	resume.Role = role
 	resume.Level = level
 	resume.University = university
 	resume.PassCompany = passCompany
+	resume.Version++
 
 	// 保存基本信息
-	if err := s.resumeRepo.Update(resume); err != nil {
+	if err := s.updateResumeColumns(resume, resumeMetadataColumns...); err != nil {
 		return nil, err
 	}
+	s.recordVersion(resume, domain.ResumeChangeMetadata, 0)
 
 	return resume, nil
 }
@@ -286,31 +487,56 @@ func (s *resumeService) UpdateResumeFile(c *gin.Context, resumeID, userID uint,
 		return nil, ErrNotResumeOwner
 	}
 
+	// 修改前确保历史简历已有初始版本
+	if err := s.ensureHistory(resume); err != nil {
+		return nil, err
+	}
+
 	// 保存新文件
-	fileResult, err := util.SaveUploadedFile(c, file, userID)
+	fileResult, err := s.saveUploadedFile(c, userID, file)
 	if err != nil {
 		return nil, err
 	}
 
-	// 保存旧文件路径，以便失败时恢复
-	oldFilePath := resume.ImageURL
-
-	// 更新简历信息
-	resume.ImageURL = fileResult.FilePath
-
-	// 保存到数据库
-	if err := s.resumeRepo.Update(resume); err != nil {
-		// 如果更新失败，删除新上传的文件
-		_ = util.DeleteFile(fileResult.FilePath)
+	// 更新简历信息，旧文件由历史版本继续引用，不再删除
+	resume.ImageURL = fileResult.Key.String()
+	resume.SourceURL = fileResult.SourceKey.String()
+	resume.ContentHash = fileResult.ContentHash
+	resume.PHash = fileResult.PHash
+	resume.Version++
+
+	// 检测重复简历并调整审核状态后保存到数据库
+	previousStatus := resume.Status
+	err = s.recheckResumeFile(resume)
+	if err == nil {
+		err = s.updateResumeColumns(resume, resumeFileColumns...)
+	}
+	if err != nil {
+		// 如果更新失败，释放新上传的文件
+		_ = util.ReleaseFile(fileResult.Key.String())
+		_ = util.ReleaseFile(fileResult.SourceKey.String())
 		return nil, err
 	}
-
-	// 更新成功后，删除旧文件
-	_ = util.DeleteFile(oldFilePath)
+	if err := s.saveRecheckedStatus(resume, previousStatus); err != nil {
+		return nil, err
+	}
+	s.recordVersion(resume, domain.ResumeChangeFile, 0)
 
 	return resume, nil
 }
 
+// updateResumeColumns 更新简历的指定字段，简历在此期间被删除时返回ErrResumeNotFound
+func (s *resumeService) updateResumeColumns(resume *domain.Resume, columns ...string) error {
+	updated, err := s.resumeRepo.UpdateColumns(resume, columns...)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrResumeNotFound
+	}
+	return nil
+}
+
 // DeleteResume 删除简历
 func (s *resumeService) DeleteResume(resumeID, userID uint) error {
 	// 获取简历
@@ -324,16 +550,121 @@ func (s *resumeService) DeleteResume(resumeID, userID uint) error {
 		return ErrNotResumeOwner
 	}
 
-	// 删除文件
-	_ = util.DeleteFile(resume.ImageURL)
+	// 软删除数据库记录，文件移入回收站，保留期内可恢复
+	if err := s.resumeRepo.Delete(resumeID); err != nil {
+		return err
+	}
+	_ = util.MoveToTrash(resume.ImageURL)
+	_ = util.MoveToTrash(resume.SourceURL)
+	s.publishResumeEvent(EventResumeDeleted, resume)
+
+	util.GetLogger().Info("简历已移入回收站", zap.Uint("resumeID", resumeID), zap.Uint("userID", userID))
+	return nil
+}
+
+// publishResumeEvent 发布简历生命周期事件，供Webhook推送给外部系统
+func (s *resumeService) publishResumeEvent(eventType string, resume *domain.Resume) {
+	s.events.Publish(Event{
+		Type:    eventType,
+		UserID:  resume.UserID,
+		ActorID: resume.UserID,
+		Data:    map[string]interface{}{"resume": resumeEventData(resume)},
+	})
+}
+
+// resumeEventData 简历事件携带的简历信息，不包含文件地址
+func resumeEventData(resume *domain.Resume) map[string]interface{} {
+	return map[string]interface{}{
+		"resume_id":    resume.ID,
+		"user_id":      resume.UserID,
+		"role":         resume.Role,
+		"level":        resume.Level,
+		"university":   resume.University,
+		"pass_company": resume.PassCompany,
+		"status":       resume.Status,
+		"created_at":   resume.CreatedAt,
+	}
+}
+
+// GetDeletedResumes 获取用户回收站中仍可恢复的简历
+func (s *resumeService) GetDeletedResumes(userID uint) ([]domain.Resume, error) {
+	resumes, err := s.resumeRepo.FindDeletedByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-s.trashRetention)
+	restorable := make([]domain.Resume, 0, len(resumes))
+	for _, resume := range resumes {
+		if resume.DeletedAt.Time.After(cutoff) {
+			restorable = append(restorable, resume)
+		}
+	}
+	return restorable, nil
+}
+
+// RestoreResume 从回收站恢复简历
+func (s *resumeService) RestoreResume(resumeID, userID uint) (*domain.Resume, error) {
+	resume, err := s.resumeRepo.FindDeletedByID(resumeID)
+	if err != nil || resume == nil {
+		return nil, ErrResumeNotFound
+	}
+
+	// 检查是否是简历所有者
+	if resume.UserID != userID {
+		return nil, ErrNotResumeOwner
+	}
+
+	// 超过保留期的简历等待清理，不再允许恢复
+	if time.Since(resume.DeletedAt.Time) > s.trashRetention {
+		return nil, ErrRestoreExpired
+	}
+
+	// 先恢复文件再恢复记录，避免恢复后的简历引用不存在的文件
+	for _, stored := range []string{resume.ImageURL, resume.SourceURL} {
+		if err := util.RestoreFromTrash(stored); err != nil && !errors.Is(err, util.ErrFileNotFound) {
+			return nil, err
+		}
+	}
+	if err := s.resumeRepo.Restore(resumeID); err != nil {
+		return nil, err
+	}
+
+	resume.DeletedAt = gorm.DeletedAt{}
+	util.GetLogger().Info("简历已从回收站恢复", zap.Uint("resumeID", resumeID), zap.Uint("userID", userID))
+	return resume, nil
+}
+
+// TrashRetention 返回回收站保留时间
+func (s *resumeService) TrashRetention() time.Duration {
+	return s.trashRetention
+}
+
+// purgeDeletedResumes 定期永久删除超过保留期的简历及其文件
+func (s *resumeService) purgeDeletedResumes() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
 
-	// 删除数据库记录
-	return s.resumeRepo.Delete(resumeID)
+	for range ticker.C {
+		resumes, err := s.resumeRepo.FindDeletedBefore(time.Now().Add(-s.trashRetention))
+		if err != nil {
+			util.GetLogger().Error("查询过期简历失败", zap.Error(err))
+			continue
+		}
+		for _, resume := range resumes {
+			if err := s.purgeResume(&resume); err != nil {
+				util.GetLogger().Error("永久删除简历失败", zap.Error(err), zap.Uint("resumeID", resume.ID))
+			}
+		}
+		if len(resumes) > 0 {
+			util.GetLogger().Info("已清理回收站中的过期简历", zap.Int("count", len(resumes)))
+		}
+	}
 }
 
-// GetResumeFileURL 获取简历文件URL
-func (s *resumeService) GetResumeFileURL(c *gin.Context, resume *domain.Resume) string {
-	return util.GetFileURL(c, resume.ImageURL)
+// GetResumeFileURL 获取签发给viewerID的简历文件URL
+func (s *resumeService) GetResumeFileURL(c *gin.Context, resume *domain.Resume, viewerID uint) string {
+	return util.GetFileURL(c, resume.ImageURL, viewerID)
 }
 
 // DownloadResume 下载简历
@@ -343,9 +674,9 @@ func (s *resumeService) DownloadResume(_ *gin.Context, resumeID, userID uint) (*
 		return nil, ErrViewLimitExceeded
 	}
 
-	// 获取简历
+	// 获取简历，未审核通过的简历仅所有者可见
 	resume, err := s.resumeRepo.FindByID(resumeID)
-	if err != nil || resume == nil {
+	if err != nil || resume == nil || !canSeeResume(resume, userID) {
 		return nil, ErrResumeNotFound
 	}
 
@@ -377,3 +708,59 @@ func (s *resumeService) CanViewResume(userID uint) bool {
 	// 已上传简历的用户可以无限制查看
 	return true
 }
+
+// purgeResume 永久删除简历、修订记录及其全部文件
+func (s *resumeService) purgeResume(resume *domain.Resume) error {
+	// 只释放本次删除的修订记录引用的文件，与注销账户的清理任务并发时不会重复释放
+	versions, err := s.versionRepo.DeleteByResume(resume.ID)
+	if err != nil {
+		return err
+	}
+	for i := range versions {
+		releaseVersionFiles(&versions[i], resume.ImageURL, resume.SourceURL)
+	}
+	deleted, err := s.resumeRepo.HardDelete(resume.ID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		// 简历已被其他清理任务删除，文件也由其处理
+		return nil
+	}
+
+	// 删除版本比较结果
+	if err := util.DeleteDir(context.Background(), diffDir(resume.UserID, resume.ID)); err != nil {
+		util.GetLogger().Warn("删除简历比较结果失败", zap.Error(err), zap.Uint("resumeID", resume.ID))
+	}
+
+	// 当前文件位于回收站，历史版本的文件仍在原位置
+	_ = util.DeleteFromTrash(resume.ImageURL)
+	_ = util.DeleteFromTrash(resume.SourceURL)
+	return nil
+}
+
+// releaseVersionFiles 释放修订记录引用的文件，keep中的文件已单独处理
+// 内容寻址文件可能与其他简历共享，只由上传该文件的版本释放一次引用
+func releaseVersionFiles(v *domain.ResumeVersion, keep ...string) {
+	for _, stored := range []string{v.ImageURL, v.SourceURL} {
+		switch {
+		case stored == "":
+		case util.IsBlobKey(stored):
+			if v.OwnsFiles() {
+				_ = util.ReleaseFile(stored)
+			}
+		case !containsString(keep, stored):
+			_ = util.DeleteFile(stored)
+		}
+	}
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/util"
+	"context"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// 分片上传相关错误
+var (
+	ErrUploadNotFound    = errors.New("上传会话不存在或已过期")
+	ErrUploadNotActive   = errors.New("上传会话已完成或正在处理")
+	ErrUploadIncomplete  = errors.New("分片尚未全部上传")
+	ErrInvalidUploadPart = errors.New("分片序号或大小无效")
+	ErrInvalidChecksum   = errors.New("校验和必须为SHA-256的十六进制字符串")
+	ErrTooManyUploads    = errors.New("未完成的上传会话过多")
+)
+
+// UploadPolicy 分片上传配置
+type UploadPolicy struct {
+	ChunkSize   int64         // 分片大小（字节），最后一个分片可以更小
+	MaxFileSize int64         // 分片上传允许的最大文件大小
+	SessionTTL  time.Duration // 上传会话的有效期，过期后删除已上传的分片
+	MaxSessions int           // 每个用户同时存在的未完成上传会话数量上限
+}
+
+// InitUpload 创建分片上传会话，checksum为完整文件的SHA-256
+func (s *resumeService) InitUpload(userID uint, fileName string, fileSize int64, checksum string) (*domain.UploadSession, error) {
+	if fileSize > s.uploads.MaxFileSize {
+		return nil, util.ErrFileTooLarge
+	}
+	if b, err := hex.DecodeString(checksum); err != nil || len(b) != 32 {
+		return nil, ErrInvalidChecksum
+	}
+
+	now := time.Now()
+	active, err := s.uploadRepo.CountActiveByUser(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	if active >= int64(s.uploads.MaxSessions) {
+		return nil, ErrTooManyUploads
+	}
+
+	session := &domain.UploadSession{
+		ID:         uuid.New().String(),
+		UserID:     userID,
+		FileName:   fileName,
+		FileSize:   fileSize,
+		ChunkSize:  s.uploads.ChunkSize,
+		TotalParts: int((fileSize + s.uploads.ChunkSize - 1) / s.uploads.ChunkSize),
+		Checksum:   checksum,
+		Status:     domain.UploadSessionUploading,
+		ExpiresAt:  now.Add(s.uploads.SessionTTL),
+	}
+	if err := s.uploadRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// findUploadSession 查找用户自己的未过期上传会话，其他用户的会话同样视为不存在
+func (s *resumeService) findUploadSession(uploadID string, userID uint) (*domain.UploadSession, error) {
+	session, err := s.uploadRepo.FindByID(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.UserID != userID || time.Now().After(session.ExpiresAt) {
+		return nil, ErrUploadNotFound
+	}
+	return session, nil
+}
+
+// GetUpload 查询上传会话和已接收的分片，客户端中断后据此继续上传缺少的分片
+func (s *resumeService) GetUpload(uploadID string, userID uint) (*domain.UploadSession, []domain.UploadPart, error) {
+	session, err := s.findUploadSession(uploadID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	parts, err := s.uploadRepo.FindParts(session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, parts, nil
+}
+
+// UploadPart 接收一个分片，重复上传同一分片时覆盖
+// 分片大小必须与会话约定一致，checksum不为空时校验分片的SHA-256
+func (s *resumeService) UploadPart(c *gin.Context, uploadID string, userID uint, partNumber int, body io.Reader, checksum string) (*domain.UploadPart, error) {
+	session, err := s.findUploadSession(uploadID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != domain.UploadSessionUploading {
+		return nil, ErrUploadNotActive
+	}
+	if partNumber < 1 || partNumber > session.TotalParts {
+		return nil, ErrInvalidUploadPart
+	}
+
+	// 多读一个字节用于判断分片是否超过约定大小
+	size := session.PartSize(partNumber)
+	data, err := io.ReadAll(io.LimitReader(body, size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != size {
+		return nil, ErrInvalidUploadPart
+	}
+
+	sum, err := util.SaveUploadPart(c.Request.Context(), session.ID, partNumber, data, checksum)
+	if err != nil {
+		return nil, err
+	}
+	part := &domain.UploadPart{
+		SessionID:  session.ID,
+		PartNumber: partNumber,
+		Size:       size,
+		Checksum:   sum,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.uploadRepo.SavePart(part); err != nil {
+		return nil, err
+	}
+	return part, nil
+}
+
+// CompleteUpload 合并分片并转换为图片，结果与表单上传一样返回供创建简历使用的文件标识
+// 合并或转换失败时会话恢复为上传中，客户端可以重传分片后再次合并
+func (s *resumeService) CompleteUpload(c *gin.Context, uploadID string, userID uint) (*FileResult, error) {
+	session, err := s.findUploadSession(uploadID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != domain.UploadSessionUploading {
+		return nil, ErrUploadNotActive
+	}
+	parts, err := s.uploadRepo.FindParts(session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) != session.TotalParts {
+		return nil, ErrUploadIncomplete
+	}
+
+	// 标记为处理中，防止并发合并或合并期间继续上传分片
+	claimed, err := s.uploadRepo.UpdateStatus(session.ID, domain.UploadSessionUploading, domain.UploadSessionCompleting)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrUploadNotActive
+	}
+
+	result, err := s.convertWithProgress(c, userID, session.FileName, func() (*util.UploadFileResult, error) {
+		return util.SaveChunkedUpload(c.Request.Context(), session.ID, session.TotalParts, session.FileName, session.FileSize, session.Checksum)
+	})
+	if err != nil {
+		if _, updateErr := s.uploadRepo.UpdateStatus(session.ID, domain.UploadSessionCompleting, domain.UploadSessionUploading); updateErr != nil {
+			util.GetLogger().Error("恢复上传会话状态失败", zap.Error(updateErr), zap.String("uploadID", session.ID))
+		}
+		return nil, err
+	}
+
+	// 转换结果保存在会话记录上，上传ID即为创建简历使用的文件标识
+	// 会话记录保留到过期后由清理任务删除，未被取用的文件随之释放
+	session.ImageKey = result.Key.String()
+	session.SourceKey = result.SourceKey.String()
+	session.ContentHash = result.ContentHash
+	session.PHash = result.PHash
+	session.ExpiresAt = time.Now().Add(tempFileTTL)
+	completed, err := s.uploadRepo.Complete(session)
+	if err == nil && !completed {
+		// 转换期间会话已过期被清理
+		err = ErrUploadNotFound
+	}
+	if err != nil {
+		_ = util.ReleaseFile(session.ImageKey)
+		_ = util.ReleaseFile(session.SourceKey)
+		return nil, err
+	}
+	if err := util.DeleteUploadParts(context.Background(), session.ID); err != nil {
+		util.GetLogger().Warn("删除上传分片失败", zap.Error(err), zap.String("uploadID", session.ID))
+	}
+	return &FileResult{
+		ImageKey: result.Key,
+		FileKey:  session.ID,
+	}, nil
+}
+
+// claimCompletedUpload 取用分片上传会话上保存的转换结果
+// 会话由completed原子地更新为claimed，重复提交时只有一个请求能取用成功
+func (s *resumeService) claimCompletedUpload(uploadID string, userID uint) (*TempFileInfo, error) {
+	session, err := s.uploadRepo.FindByID(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil || session.Status != domain.UploadSessionCompleted || time.Now().After(session.ExpiresAt) {
+		return nil, ErrFileNotFound
+	}
+	if session.UserID != userID {
+		return nil, ErrNotResumeOwner
+	}
+
+	claimed, err := s.uploadRepo.UpdateStatus(session.ID, domain.UploadSessionCompleted, domain.UploadSessionClaimed)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrFileNotFound
+	}
+	return &TempFileInfo{
+		UserID:      session.UserID,
+		ImageKey:    util.StorageKey(session.ImageKey),
+		SourceKey:   util.StorageKey(session.SourceKey),
+		ContentHash: session.ContentHash,
+		PHash:       session.PHash,
+		CreatedAt:   session.UpdatedAt,
+	}, nil
+}
+
+// unclaimUpload 创建简历失败时将会话恢复为completed，转换结果可以再次使用
+func (s *resumeService) unclaimUpload(uploadID string) {
+	if _, err := s.uploadRepo.UpdateStatus(uploadID, domain.UploadSessionClaimed, domain.UploadSessionCompleted); err != nil {
+		util.GetLogger().Error("恢复上传会话状态失败", zap.Error(err), zap.String("uploadID", uploadID))
+	}
+}
+
+// AbortUpload 取消上传会话并删除已上传的分片
+func (s *resumeService) AbortUpload(uploadID string, userID uint) error {
+	session, err := s.findUploadSession(uploadID, userID)
+	if err != nil {
+		return err
+	}
+	if session.Status == domain.UploadSessionCompleting {
+		return ErrUploadNotActive
+	}
+	return s.deleteUploadSession(session)
+}
+
+// deleteUploadSession 删除上传会话的分片和记录，转换结果未被取用时释放文件
+func (s *resumeService) deleteUploadSession(session *domain.UploadSession) error {
+	if session.Status == domain.UploadSessionCompleted {
+		// 先占用会话再释放，避免与创建简历并发时释放已被使用的文件
+		claimed, err := s.uploadRepo.UpdateStatus(session.ID, domain.UploadSessionCompleted, domain.UploadSessionClaimed)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			_ = util.ReleaseFile(session.ImageKey)
+			_ = util.ReleaseFile(session.SourceKey)
+		}
+	}
+
+	if err := util.DeleteUploadParts(context.Background(), session.ID); err != nil {
+		util.GetLogger().Warn("删除上传分片失败", zap.Error(err), zap.String("uploadID", session.ID))
+		return err
+	}
+	return s.uploadRepo.Delete(session.ID)
+}
+
+// cleanupUploadSessions 定期删除过期的上传会话及其分片
+func (s *resumeService) cleanupUploadSessions() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sessions, err := s.uploadRepo.FindExpired(time.Now(), 100)
+		if err != nil {
+			util.GetLogger().Error("查询过期上传会话失败", zap.Error(err))
+			continue
+		}
+		for i := range sessions {
+			if err := s.deleteUploadSession(&sessions[i]); err != nil {
+				util.GetLogger().Error("删除过期上传会话失败", zap.Error(err), zap.String("uploadID", sessions[i].ID))
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/util"
+	"errors"
+
+	"go.uber.org/zap"
+)
+
+// ErrVersionNotFound 简历版本不存在
+var ErrVersionNotFound = errors.New("简历版本不存在")
+
+// snapshotVersion 根据简历当前状态生成修订记录
+func snapshotVersion(resume *domain.Resume, changeType string, rolledBackFrom int) *domain.ResumeVersion {
+	return &domain.ResumeVersion{
+		ResumeID:       resume.ID,
+		UserID:         resume.UserID,
+		Version:        resume.Version,
+		ChangeType:     changeType,
+		RolledBackFrom: rolledBackFrom,
+		ImageURL:       resume.ImageURL,
+		SourceURL:      resume.SourceURL,
+		ContentHash:    resume.ContentHash,
+		PHash:          resume.PHash,
+		Role:           resume.Role,
+		Level:          resume.Level,
+		University:     resume.University,
+		PassCompany:    resume.PassCompany,
+		Status:         resume.Status,
+	}
+}
+
+// recordVersion 保存简历当前状态为新版本
+// 简历本身已保存成功，修订记录写入失败只记录日志，不影响本次修改
+func (s *resumeService) recordVersion(resume *domain.Resume, changeType string, rolledBackFrom int) {
+	if err := s.versionRepo.Create(snapshotVersion(resume, changeType, rolledBackFrom)); err != nil {
+		util.GetLogger().Error("保存简历版本失败",
+			zap.Error(err),
+			zap.Uint("resumeID", resume.ID),
+			zap.Int("version", resume.Version))
+	}
+}
+
+// ensureHistory 为引入版本历史之前创建的简历补充初始版本
+func (s *resumeService) ensureHistory(resume *domain.Resume) error {
+	existing, err := s.versionRepo.FindByResumeAndVersion(resume.ID, resume.Version)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+	return s.versionRepo.Create(snapshotVersion(resume, domain.ResumeChangeCreate, 0))
+}
+
+// findVisibleResume 获取用户可见的简历
+func (s *resumeService) findVisibleResume(resumeID, userID uint) (*domain.Resume, error) {
+	resume, err := s.resumeRepo.FindByID(resumeID)
+	if err != nil || resume == nil || !canSeeResume(resume, userID) {
+		return nil, ErrResumeNotFound
+	}
+	return resume, nil
+}
+
+// GetResumeVersions 获取简历的版本历史，按版本号升序
+// 他人只能看到审核通过期间的版本，待审核或未通过的文件不对外展示
+func (s *resumeService) GetResumeVersions(resumeID, userID uint) ([]domain.ResumeVersion, error) {
+	resume, err := s.findVisibleResume(resumeID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if resume.UserID == userID {
+		if err := s.ensureHistory(resume); err != nil {
+			return nil, err
+		}
+		return s.versionRepo.FindByResume(resumeID)
+	}
+
+	versions, err := s.versionRepo.FindByResume(resumeID)
+	if err != nil {
+		return nil, err
+	}
+	public := make([]domain.ResumeVersion, 0, len(versions))
+	for _, v := range versions {
+		if v.IsPublic() {
+			public = append(public, v)
+		}
+	}
+	return public, nil
+}
+
+// findVisibleVersion 获取用户可见的简历版本，他人只能看到审核通过期间的版本
+func (s *resumeService) findVisibleVersion(resume *domain.Resume, version int, userID uint) (*domain.ResumeVersion, error) {
+	v, err := s.versionRepo.FindByResumeAndVersion(resume.ID, version)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil || (resume.UserID != userID && !v.IsPublic()) {
+		return nil, ErrVersionNotFound
+	}
+	return v, nil
+}
+
+// RollbackResume 将简历回滚到指定版本
+// 回滚不会删除之后的版本，而是以历史版本的内容生成一个新版本
+func (s *resumeService) RollbackResume(resumeID uint, version int, userID uint) (*domain.Resume, error) {
+	resume, err := s.resumeRepo.FindByID(resumeID)
+	if err != nil || resume == nil {
+		return nil, ErrResumeNotFound
+	}
+
+	// 检查是否是简历所有者
+	if resume.UserID != userID {
+		return nil, ErrNotResumeOwner
+	}
+
+	if err := s.ensureHistory(resume); err != nil {
+		return nil, err
+	}
+
+	target, err := s.versionRepo.FindByResumeAndVersion(resumeID, version)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, ErrVersionNotFound
+	}
+
+	resume.ImageURL = target.ImageURL
+	resume.SourceURL = target.SourceURL
+	resume.ContentHash = target.ContentHash
+	resume.PHash = target.PHash
+	resume.Role = target.Role
+	resume.Level = target.Level
+	resume.University = target.University
+	resume.PassCompany = target.PassCompany
+	resume.Version++
+
+	// 回滚同样会更换文件，与上传新文件一样重新检测重复并调整审核状态
+	previousStatus := resume.Status
+	if err := s.recheckResumeFile(resume); err != nil {
+		return nil, err
+	}
+	if err := s.updateResumeColumns(resume, resumeRollbackColumns...); err != nil {
+		return nil, err
+	}
+	if err := s.saveRecheckedStatus(resume, previousStatus); err != nil {
+		return nil, err
+	}
+	s.recordVersion(resume, domain.ResumeChangeRollback, target.Version)
+
+	util.GetLogger().Info("简历已回滚",
+		zap.Uint("resumeID", resumeID),
+		zap.Int("from", target.Version),
+		zap.Int("version", resume.Version))
+
+	return resume, nil
+}
+
+// PurgeUserData 删除用户所有简历的修订记录，并释放内容寻址文件的引用
+// 用户目录下的文件随用户目录一起删除
+func (s *resumeService) PurgeUserData(userID uint) error {
+	// 只释放本次删除的修订记录引用的文件，与回收站清理任务并发时不会重复释放
+	versions, err := s.versionRepo.DeleteByUser(userID)
+	if err != nil {
+		return err
+	}
+	for i := range versions {
+		if util.IsBlobKey(versions[i].ImageURL) || util.IsBlobKey(versions[i].SourceURL) {
+			releaseVersionFiles(&versions[i])
+		}
+	}
+	return nil
+}
+
+// equalInts 判断两个整数切片内容是否相同
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -4,12 +4,17 @@ import (
 	"codefolio/internal/common"
 	"codefolio/internal/domain"
 	"codefolio/internal/repository"
+	"codefolio/internal/util"
 	"errors"
 	"fmt"
+	"mime/multipart"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -20,8 +25,39 @@ var (
 	ErrUserNotFound = errors.New("用户不存在")
 	// ErrUserAlreadyExists 用户已存在
 	ErrUserAlreadyExists = errors.New("用户已存在")
+	// ErrAccountLocked 账户因多次登录失败被临时锁定
+	ErrAccountLocked = errors.New("账户已被临时锁定")
+	// ErrTooManyLoginAttempts 来自同一IP的失败登录过多
+	ErrTooManyLoginAttempts = errors.New("登录尝试次数过多")
+	// ErrInvalidProfileLink 个人链接格式不正确
+	ErrInvalidProfileLink = errors.New("个人链接必须是http或https地址")
+	// ErrInvalidGraduationYear 毕业年份超出范围
+	ErrInvalidGraduationYear = errors.New("毕业年份必须在1950到2100之间")
 )
 
+// ProfileUpdate 个人资料更新内容，nil字段表示不修改
+type ProfileUpdate struct {
+	DisplayName    *string
+	Bio            *string
+	Company        *string
+	GraduationYear *int                 // 指向0表示清空
+	Links          []domain.ProfileLink // nil表示不修改，空切片表示清空
+}
+
+// LoginBlockedError 登录被限制错误，携带建议的重试等待时间
+type LoginBlockedError struct {
+	Reason     error
+	RetryAfter time.Duration
+}
+
+func (e *LoginBlockedError) Error() string {
+	return e.Reason.Error()
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	return e.Reason
+}
+
 // AuthClaims JWT声明结构
 type AuthClaims struct {
 	UserID string `json:"user_id"`
@@ -31,45 +67,62 @@ type AuthClaims struct {
 // UserService 用户服务接口
 type UserService interface {
 	Register(username, password, email string) (*domain.User, error)
-	Login(username, password string) (string, error)
+	Login(username, password, clientIP string) (string, error)
 	GetUserByID(id uint) (*domain.User, error)
+	GetUserByUsername(username string) (*domain.User, error)
 	UpdateUser(user *domain.User) error
+	UpdateProfile(userID uint, update ProfileUpdate) (*domain.User, error)
+	UpdateAvatar(userID uint, file *multipart.FileHeader) (*domain.User, error)
 	ParseToken(tokenString string) (uint, error)
+	GenerateToken(userID uint) (string, error)
+
+	// 管理员操作
+	UnlockUser(id uint) error
 }
 
 // userService 用户服务实现
 type userService struct {
 	userRepo    repository.UserRepository
+	mailer      util.Mailer
 	jwtSecret   string
 	expireHours int
+
+	// 登录保护
+	loginPolicy LoginPolicy
+	ipTracker   *ipLoginTracker
 }
 
 // NewUserService 创建用户服务
-func NewUserService(userRepo repository.UserRepository, jwtSecret string, expireHours int) UserService {
+func NewUserService(userRepo repository.UserRepository, mailer util.Mailer, jwtSecret string, expireHours int, loginPolicy LoginPolicy) UserService {
+	loginPolicy = loginPolicy.withDefaults()
+
 	return &userService{
 		userRepo:    userRepo,
+		mailer:      mailer,
 		jwtSecret:   jwtSecret,
 		expireHours: expireHours,
+		loginPolicy: loginPolicy,
+		ipTracker:   newIPLoginTracker(loginPolicy.IPMaxFailures, loginPolicy.IPWindow),
 	}
 }
 
 // Register 用户注册
 func (s *userService) Register(username, password, email string) (*domain.User, error) {
-	// 检查用户是否已存在
-	existingUser, err := s.userRepo.FindByUsername(username)
-	if err != nil && !errors.Is(err, common.ErrRecordNotFound) {
+	// 检查用户是否已存在，已删除但未清理的账户仍占用用户名
+	usernameTaken, err := s.userRepo.ExistsByUsername(username)
+	if err != nil {
 		return nil, err
 	}
-	if existingUser != nil {
+	if usernameTaken {
 		return nil, ErrUserAlreadyExists
 	}
 
 	// 检查邮箱是否已存在
-	existingEmail, err := s.userRepo.FindByEmail(email)
-	if err != nil && !errors.Is(err, common.ErrRecordNotFound) {
+	emailTaken, err := s.userRepo.ExistsByEmail(email)
+	if err != nil {
 		return nil, err
 	}
-	if existingEmail != nil {
+	if emailTaken {
 		return nil, errors.New("邮箱已被注册")
 	}
 
@@ -95,24 +148,51 @@ func (s *userService) Register(username, password, email string) (*domain.User,
 }
 
 // Login 用户登录
-func (s *userService) Login(username, password string) (string, error) {
+func (s *userService) Login(username, password, clientIP string) (string, error) {
+	now := time.Now()
+
+	// 检查IP是否因失败次数过多被限制
+	if blocked, retryAfter := s.ipTracker.Blocked(clientIP, now); blocked {
+		return "", &LoginBlockedError{Reason: ErrTooManyLoginAttempts, RetryAfter: retryAfter}
+	}
+
 	// 查找用户
 	user, err := s.userRepo.FindByUsername(username)
 	if err != nil {
 		if errors.Is(err, common.ErrRecordNotFound) {
+			s.ipTracker.Fail(clientIP, now)
 			return "", ErrInvalidCredentials
 		}
 		return "", err
 	}
 
+	// 账户锁定期间不校验密码
+	if user.IsLocked(now) {
+		return "", &LoginBlockedError{Reason: ErrAccountLocked, RetryAfter: user.LockedUntil.Sub(now)}
+	}
+
 	// 验证密码
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
+		s.ipTracker.Fail(clientIP, now)
+		if lockErr := s.recordLoginFailure(user, clientIP, now); lockErr != nil {
+			return "", lockErr
+		}
 		return "", ErrInvalidCredentials
 	}
 
+	// 登录成功，重置失败计数
+	if user.FailedLoginCount > 0 || user.LockCount > 0 || user.LockedUntil != nil {
+		user.FailedLoginCount = 0
+		user.LockCount = 0
+		user.LockedUntil = nil
+		if err := s.userRepo.UpdateLoginState(user); err != nil {
+			return "", err
+		}
+	}
+
 	// 生成JWT token
-	token, err := s.generateToken(user.ID)
+	token, err := s.GenerateToken(user.ID)
 	if err != nil {
 		return "", err
 	}
@@ -120,6 +200,59 @@ func (s *userService) Login(username, password string) (string, error) {
 	return token, nil
 }
 
+// recordLoginFailure 记录账户登录失败，达到阈值时锁定账户
+// 失败次数在数据库中原子地增加，并发的失败请求不会重复计数或绕过锁定；账户被锁定时返回LoginBlockedError
+func (s *userService) recordLoginFailure(user *domain.User, clientIP string, now time.Time) error {
+	count, err := s.userRepo.IncrementFailedLogin(user.ID)
+	if err != nil {
+		util.GetLogger().Error("更新登录失败次数失败", zap.Error(err), zap.Uint("userID", user.ID))
+		return nil
+	}
+	if count < s.loginPolicy.MaxFailedAttempts {
+		return nil
+	}
+
+	// 达到阈值，按指数退避计算锁定时长；并发请求中只有一个会执行锁定
+	lockCount, lockedUntil, err := s.userRepo.LockAccount(user.ID, s.loginPolicy.MaxFailedAttempts, func(lockCount int) time.Time {
+		return now.Add(s.loginPolicy.lockoutDuration(lockCount))
+	})
+	if err != nil {
+		util.GetLogger().Error("锁定账户失败", zap.Error(err), zap.Uint("userID", user.ID))
+		return nil
+	}
+	if lockedUntil == nil {
+		// 其他请求已锁定账户
+		return &LoginBlockedError{Reason: ErrAccountLocked, RetryAfter: s.loginPolicy.lockoutDuration(user.LockCount + 1)}
+	}
+	duration := lockedUntil.Sub(now)
+
+	util.GetLogger().Warn("账户因多次登录失败被锁定",
+		zap.Uint("userID", user.ID),
+		zap.String("ip", clientIP),
+		zap.Int("lockCount", lockCount),
+		zap.Time("lockedUntil", *lockedUntil))
+
+	util.SendMailAsync(s.mailer, user.Email, "Codefolio 账户安全提醒",
+		fmt.Sprintf("您好 %s：\n\n您的账户因连续多次登录失败已被临时锁定，将于 %s 自动解锁。\n"+
+			"最近一次失败登录来自 IP：%s。\n\n如果这不是您本人的操作，建议在解锁后立即修改密码；如需提前解锁，请联系管理员。",
+			user.Username, lockedUntil.Format("2006-01-02 15:04:05"), clientIP))
+
+	return &LoginBlockedError{Reason: ErrAccountLocked, RetryAfter: duration}
+}
+
+// UnlockUser 管理员解除账户锁定
+func (s *userService) UnlockUser(id uint) error {
+	user, err := s.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+
+	user.FailedLoginCount = 0
+	user.LockCount = 0
+	user.LockedUntil = nil
+	return s.userRepo.UpdateLoginState(user)
+}
+
 // GetUserByID 根据ID获取用户
 func (s *userService) GetUserByID(id uint) (*domain.User, error) {
 	user, err := s.userRepo.FindByID(id)
@@ -132,8 +265,90 @@ func (s *userService) GetUserByID(id uint) (*domain.User, error) {
 	return user, nil
 }
 
-// generateToken 生成JWT令牌
-func (s *userService) generateToken(userID uint) (string, error) {
+// GetUserByUsername 根据用户名获取用户
+func (s *userService) GetUserByUsername(username string) (*domain.User, error) {
+	user, err := s.userRepo.FindByUsername(username)
+	if err != nil {
+		if errors.Is(err, common.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateProfile 更新个人资料
+func (s *userService) UpdateProfile(userID uint, update ProfileUpdate) (*domain.User, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*update.DisplayName)
+	}
+	if update.Bio != nil {
+		user.Bio = strings.TrimSpace(*update.Bio)
+	}
+	if update.Company != nil {
+		user.Company = strings.TrimSpace(*update.Company)
+	}
+	if update.GraduationYear != nil {
+		year := *update.GraduationYear
+		if year != 0 && (year < 1950 || year > 2100) {
+			return nil, ErrInvalidGraduationYear
+		}
+		user.GraduationYear = year
+	}
+	if update.Links != nil {
+		links := make([]domain.ProfileLink, 0, len(update.Links))
+		for _, link := range update.Links {
+			// 仅允许http(s)链接，避免在公开主页中注入javascript:等地址
+			u, err := url.Parse(strings.TrimSpace(link.URL))
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, ErrInvalidProfileLink
+			}
+			links = append(links, domain.ProfileLink{
+				Label: strings.TrimSpace(link.Label),
+				URL:   u.String(),
+			})
+		}
+		user.Links = links
+	}
+
+	if err := s.userRepo.UpdateProfile(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdateAvatar 上传并更新头像，成功后删除旧头像
+func (s *userService) UpdateAvatar(userID uint, file *multipart.FileHeader) (*domain.User, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := util.SaveAvatar(file, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldAvatar := user.AvatarURL
+	user.AvatarURL = result.Key.String()
+	if err := s.userRepo.UpdateAvatar(user); err != nil {
+		_ = util.DeleteFile(result.Key.String())
+		return nil, err
+	}
+
+	if oldAvatar != "" {
+		_ = util.DeleteFile(oldAvatar)
+	}
+	return user, nil
+}
+
+// GenerateToken 生成JWT令牌
+func (s *userService) GenerateToken(userID uint) (string, error) {
 	// 设置过期时间
 	expirationTime := time.Now().Add(time.Duration(s.expireHours) * time.Hour)
 
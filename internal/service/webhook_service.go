@@ -0,0 +1,577 @@
+package service
+
+import (
+	"bytes"
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+	"codefolio/internal/util"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Webhook相关错误
+var (
+	ErrWebhookNotFound         = errors.New("Webhook不存在")
+	ErrWebhookDeliveryNotFound = errors.New("推送记录不存在")
+	ErrInvalidWebhookURL       = errors.New("Webhook地址必须是http或https地址")
+	ErrInvalidWebhookEvent     = errors.New("无效的Webhook事件类型")
+	ErrWebhookDeliveryFailed   = errors.New("Webhook推送失败")
+)
+
+// Webhook推送请求头
+const (
+	WebhookHeaderEvent     = "X-Codefolio-Event"     // 事件类型
+	WebhookHeaderEventID   = "X-Codefolio-Event-ID"  // 事件ID，重放时不变，供接收方去重
+	WebhookHeaderDelivery  = "X-Codefolio-Delivery"  // 推送记录ID
+	WebhookHeaderTimestamp = "X-Codefolio-Timestamp" // 签名时间戳（Unix秒）
+	WebhookHeaderSignature = "X-Codefolio-Signature" // sha256=HMAC-SHA256(secret, timestamp + "." + body)
+)
+
+const (
+	webhookBatchSize       = 50   // 每轮最多处理的到期推送记录数
+	webhookConcurrency     = 4    // 同时进行的推送数
+	webhookResponseMaxSize = 1024 // 推送记录中保存的响应内容上限（字节）
+)
+
+// WebhookInput 创建或修改Webhook的参数
+type WebhookInput struct {
+	URL         string
+	Secret      string // 为空时创建会自动生成，修改时保留原密钥
+	Description string
+	Events      []string
+	Active      *bool // 为空时创建默认启用，修改时保持不变
+}
+
+// WebhookPayload 推送给外部系统的请求体
+type WebhookPayload struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// WebhookService Webhook服务接口
+type WebhookService interface {
+	// HandleEvent 订阅事件总线，为订阅了该事件的Webhook生成推送记录
+	HandleEvent(event Event)
+
+	CreateWebhook(input WebhookInput) (*domain.Webhook, error)
+	GetWebhooks() ([]domain.Webhook, error)
+	GetWebhook(id uint) (*domain.Webhook, error)
+	UpdateWebhook(id uint, input WebhookInput) (*domain.Webhook, error)
+	DeleteWebhook(id uint) error
+	PingWebhook(id uint) (*domain.WebhookDelivery, error)
+	GetDeliveries(webhookID uint, status string, page, size int) ([]domain.WebhookDelivery, int64, error)
+	ReplayDelivery(webhookID, deliveryID uint) (*domain.WebhookDelivery, error)
+}
+
+// webhookService Webhook服务实现
+type webhookService struct {
+	webhookRepo  repository.WebhookRepository
+	client       *http.Client
+	timeout      time.Duration
+	maxAttempts  int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+	wake         chan struct{}
+}
+
+// NewWebhookService 创建Webhook服务实例，并启动后台推送任务
+// 新事件会立即推送，失败的推送按retryBackoff起始、每次翻倍、不超过maxBackoff的间隔重试，共推送maxAttempts次
+func NewWebhookService(webhookRepo repository.WebhookRepository, timeout time.Duration, maxAttempts int, retryBackoff, maxBackoff, pollInterval time.Duration) WebhookService {
+	s := &webhookService{
+		webhookRepo: webhookRepo,
+		client: &http.Client{
+			Timeout: timeout,
+			// 不跟随重定向，避免POST被改写为GET，3xx按推送失败处理
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		timeout:      timeout,
+		maxAttempts:  maxAttempts,
+		retryBackoff: retryBackoff,
+		maxBackoff:   maxBackoff,
+		wake:         make(chan struct{}, 1),
+	}
+
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			}
+			s.processDueDeliveries()
+		}
+	}()
+
+	return s
+}
+
+// HandleEvent 将业务事件转换为Webhook事件并生成推送记录，实际推送在后台进行
+func (s *webhookService) HandleEvent(event Event) {
+	eventType := webhookEventType(event.Type)
+	if eventType == "" {
+		return
+	}
+
+	webhooks, err := s.webhookRepo.FindActive()
+	if err != nil {
+		util.GetLogger().Error("获取Webhook订阅失败", zap.Error(err))
+		return
+	}
+
+	var payload []byte
+	eventID := uuid.New().String()
+	created := false
+	for i := range webhooks {
+		if !webhooks[i].Subscribes(eventType) {
+			continue
+		}
+		if payload == nil {
+			payload, err = json.Marshal(WebhookPayload{
+				ID:        eventID,
+				Type:      eventType,
+				CreatedAt: event.CreatedAt,
+				Data:      event.Data,
+			})
+			if err != nil {
+				util.GetLogger().Error("序列化Webhook事件失败", zap.Error(err), zap.String("type", eventType))
+				return
+			}
+		}
+
+		now := time.Now()
+		delivery := &domain.WebhookDelivery{
+			WebhookID:     webhooks[i].ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       string(payload),
+			Status:        domain.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		}
+		if err := s.webhookRepo.CreateDelivery(delivery); err != nil {
+			util.GetLogger().Error("创建Webhook推送记录失败", zap.Error(err), zap.Uint("webhookID", webhooks[i].ID))
+			continue
+		}
+		created = true
+	}
+
+	if created {
+		s.notify()
+	}
+}
+
+// CreateWebhook 创建Webhook订阅
+func (s *webhookService) CreateWebhook(input WebhookInput) (*domain.Webhook, error) {
+	if err := validateWebhookInput(input); err != nil {
+		return nil, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		var err error
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	webhook := &domain.Webhook{
+		URL:         input.URL,
+		Secret:      secret,
+		Description: input.Description,
+		Events:      dedupeStrings(input.Events),
+		Active:      input.Active == nil || *input.Active,
+	}
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// GetWebhooks 获取全部Webhook订阅
+func (s *webhookService) GetWebhooks() ([]domain.Webhook, error) {
+	return s.webhookRepo.FindAll()
+}
+
+// GetWebhook 获取Webhook订阅
+func (s *webhookService) GetWebhook(id uint) (*domain.Webhook, error) {
+	webhook, err := s.webhookRepo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook 修改Webhook订阅，未提供密钥时保留原密钥
+func (s *webhookService) UpdateWebhook(id uint, input WebhookInput) (*domain.Webhook, error) {
+	if err := validateWebhookInput(input); err != nil {
+		return nil, err
+	}
+
+	webhook, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = input.URL
+	webhook.Description = input.Description
+	webhook.Events = dedupeStrings(input.Events)
+	if input.Active != nil {
+		webhook.Active = *input.Active
+	}
+	if input.Secret != "" {
+		webhook.Secret = input.Secret
+	}
+
+	if err := s.webhookRepo.Update(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook 删除Webhook订阅及其推送记录
+func (s *webhookService) DeleteWebhook(id uint) error {
+	if _, err := s.GetWebhook(id); err != nil {
+		return err
+	}
+	return s.webhookRepo.Delete(id)
+}
+
+// PingWebhook 立即发送一次测试推送，用于确认接收方地址和签名校验，失败时不重试
+func (s *webhookService) PingWebhook(id uint) (*domain.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+
+	eventID := uuid.New().String()
+	payload, err := json.Marshal(WebhookPayload{
+		ID:        eventID,
+		Type:      domain.WebhookEventPing,
+		CreatedAt: time.Now(),
+		Data:      map[string]interface{}{"webhook_id": webhook.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &domain.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		EventID:       eventID,
+		EventType:     domain.WebhookEventPing,
+		Payload:       string(payload),
+		Status:        domain.WebhookDeliveryPending,
+		NextAttemptAt: s.lease(),
+	}
+	if err := s.webhookRepo.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	if !s.attempt(webhook, delivery, false) {
+		return delivery, ErrWebhookDeliveryFailed
+	}
+	return delivery, nil
+}
+
+// GetDeliveries 分页获取Webhook的推送记录
+func (s *webhookService) GetDeliveries(webhookID uint, status string, page, size int) ([]domain.WebhookDelivery, int64, error) {
+	if _, err := s.GetWebhook(webhookID); err != nil {
+		return nil, 0, err
+	}
+	return s.webhookRepo.FindDeliveries(webhookID, status, page, size)
+}
+
+// ReplayDelivery 以原事件ID和内容重新推送，生成新的推送记录并立即推送一次，失败后按正常规则重试
+func (s *webhookService) ReplayDelivery(webhookID, deliveryID uint) (*domain.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	original, err := s.webhookRepo.FindDeliveryByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil || original.WebhookID != webhookID {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	delivery := &domain.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        domain.WebhookDeliveryPending,
+		NextAttemptAt: s.lease(),
+		ReplayOf:      &original.ID,
+	}
+	if err := s.webhookRepo.CreateDelivery(delivery); err != nil {
+		return nil, err
+	}
+
+	s.attempt(webhook, delivery, true)
+	return delivery, nil
+}
+
+// lease 立即推送的记录先占用租约，避免与后台任务重复推送，进程中途退出时由后台任务接手
+func (s *webhookService) lease() *time.Time {
+	until := time.Now().Add(2 * s.timeout)
+	return &until
+}
+
+// notify 唤醒后台任务立即处理待推送记录
+func (s *webhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// processDueDeliveries 推送全部到期的记录
+func (s *webhookService) processDueDeliveries() {
+	for {
+		now := time.Now()
+		deliveries, err := s.webhookRepo.FindDueDeliveries(now, webhookBatchSize)
+		if err != nil {
+			util.GetLogger().Error("获取待推送Webhook记录失败", zap.Error(err))
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		webhooks := make(map[uint]*domain.Webhook)
+		sem := make(chan struct{}, webhookConcurrency)
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			delivery := &deliveries[i]
+
+			claimed, err := s.webhookRepo.ClaimDelivery(delivery.ID, now, *s.lease())
+			if err != nil {
+				util.GetLogger().Error("领取Webhook推送记录失败", zap.Error(err), zap.Uint("deliveryID", delivery.ID))
+				continue
+			}
+			if !claimed {
+				continue
+			}
+
+			webhook, ok := webhooks[delivery.WebhookID]
+			if !ok {
+				webhook, err = s.webhookRepo.FindByID(delivery.WebhookID)
+				if err != nil {
+					util.GetLogger().Error("获取Webhook订阅失败", zap.Error(err), zap.Uint("webhookID", delivery.WebhookID))
+					continue
+				}
+				webhooks[delivery.WebhookID] = webhook
+			}
+			if webhook == nil || !webhook.Active {
+				s.abandon(delivery, "Webhook已删除或停用")
+				continue
+			}
+
+			wg.Add(1)
+			sem <- struct{}{}
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				s.attempt(webhook, delivery, true)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+// attempt 推送一次并保存结果，retry为false时失败后不再重试，返回是否推送成功
+func (s *webhookService) attempt(webhook *domain.Webhook, delivery *domain.WebhookDelivery, retry bool) bool {
+	status, body, err := s.send(webhook, delivery)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	delivery.Error = ""
+
+	succeeded := err == nil && status >= 200 && status < 300
+	switch {
+	case succeeded:
+		delivery.Status = domain.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case retry && delivery.Attempts < s.maxAttempts:
+		next := now.Add(s.backoff(delivery.Attempts))
+		delivery.Status = domain.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+	default:
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+	}
+	if !succeeded {
+		if err != nil {
+			delivery.Error = truncateString(err.Error(), 500)
+		} else {
+			delivery.Error = fmt.Sprintf("接收方返回HTTP %d", status)
+		}
+	}
+
+	if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+		util.GetLogger().Error("保存Webhook推送结果失败", zap.Error(err), zap.Uint("deliveryID", delivery.ID))
+	}
+	if !succeeded {
+		util.GetLogger().Warn("Webhook推送失败",
+			zap.Uint("webhookID", webhook.ID),
+			zap.Uint("deliveryID", delivery.ID),
+			zap.Int("attempts", delivery.Attempts),
+			zap.String("error", delivery.Error))
+	}
+	return succeeded
+}
+
+// abandon 放弃推送，用于订阅已删除或停用的情况
+func (s *webhookService) abandon(delivery *domain.WebhookDelivery, reason string) {
+	delivery.Status = domain.WebhookDeliveryFailed
+	delivery.NextAttemptAt = nil
+	delivery.Error = reason
+	if err := s.webhookRepo.UpdateDelivery(delivery); err != nil {
+		util.GetLogger().Error("保存Webhook推送结果失败", zap.Error(err), zap.Uint("deliveryID", delivery.ID))
+	}
+}
+
+// send 发送带签名的推送请求，返回响应状态码和截断后的响应内容
+func (s *webhookService) send(webhook *domain.Webhook, delivery *domain.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Codefolio-Webhook/1.0")
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderEventID, delivery.EventID)
+	req.Header.Set(WebhookHeaderDelivery, strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(webhook.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseMaxSize))
+	return resp.StatusCode, strings.ToValidUTF8(string(respBody), ""), nil
+}
+
+// backoff 第attempts次推送失败后的等待时间
+func (s *webhookService) backoff(attempts int) time.Duration {
+	delay := s.retryBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.maxBackoff {
+			return s.maxBackoff
+		}
+	}
+	return delay
+}
+
+// SignWebhookPayload 计算推送签名，接收方应使用相同算法校验并拒绝时间戳过旧的请求
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookEventType 将业务事件类型转换为Webhook事件类型，不推送的事件返回空字符串
+func webhookEventType(eventType string) string {
+	switch eventType {
+	case EventResumeCreated:
+		return domain.WebhookEventResumeCreated
+	case domain.NotificationResumeApproved:
+		return domain.WebhookEventResumeApproved
+	case domain.NotificationResumeRejected:
+		return domain.WebhookEventResumeRejected
+	case EventResumeDeleted:
+		return domain.WebhookEventResumeDeleted
+	default:
+		return ""
+	}
+}
+
+// validateWebhookInput 校验Webhook地址和事件类型
+func validateWebhookInput(input WebhookInput) error {
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if len(input.Events) == 0 {
+		return ErrInvalidWebhookEvent
+	}
+	for _, e := range input.Events {
+		if !domain.IsWebhookEventType(e) {
+			return ErrInvalidWebhookEvent
+		}
+	}
+	return nil
+}
+
+// generateWebhookSecret 生成随机签名密钥
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// dedupeStrings 去除重复项并保持原有顺序
+func dedupeStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// truncateString 按字节截断字符串，不截断多字节字符
+func truncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	for maxLen > 0 && !utf8.RuneStart(s[maxLen]) {
+		maxLen--
+	}
+	return s[:maxLen]
+}
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"codefolio/internal/domain"
+	"codefolio/internal/repository"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// receivedWebhook 接收方收到的推送请求
+type receivedWebhook struct {
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// webhookReceiver 本地模拟的Webhook接收方，按顺序返回预设的响应
+type webhookReceiver struct {
+	server *httptest.Server
+
+	mu        sync.Mutex
+	requests  []receivedWebhook
+	responses []int
+	received  chan receivedWebhook
+}
+
+func newWebhookReceiver(t *testing.T, responses ...int) *webhookReceiver {
+	rcv := &webhookReceiver{
+		responses: responses,
+		received:  make(chan receivedWebhook, 16),
+	}
+	rcv.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := receivedWebhook{Path: r.URL.Path, Header: r.Header.Clone(), Body: body}
+
+		rcv.mu.Lock()
+		rcv.requests = append(rcv.requests, req)
+		status := http.StatusOK
+		if len(rcv.responses) > 0 {
+			status, rcv.responses = rcv.responses[0], rcv.responses[1:]
+		}
+		rcv.mu.Unlock()
+		rcv.received <- req
+
+		if status >= 300 && status < 400 {
+			// 重定向到另一个地址，推送方不应跟随
+			w.Header().Set("Location", rcv.server.URL+"/redirected")
+		}
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, "status "+strconv.Itoa(status))
+	}))
+	t.Cleanup(rcv.server.Close)
+	return rcv
+}
+
+// count 返回已收到的请求数
+func (rcv *webhookReceiver) count() int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+	return len(rcv.requests)
+}
+
+// wait 等待下一个推送请求
+func (rcv *webhookReceiver) wait(t *testing.T) receivedWebhook {
+	t.Helper()
+	select {
+	case req := <-rcv.received:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待推送请求超时")
+		return receivedWebhook{}
+	}
+}
+
+// memWebhookRepo 内存中的Webhook仓库，保存副本以模拟数据库读写
+type memWebhookRepo struct {
+	repository.WebhookRepository
+
+	mu         sync.Mutex
+	webhooks   map[uint]domain.Webhook
+	deliveries map[uint]domain.WebhookDelivery
+	seq        uint
+}
+
+func newMemWebhookRepo() *memWebhookRepo {
+	return &memWebhookRepo{
+		webhooks:   make(map[uint]domain.Webhook),
+		deliveries: make(map[uint]domain.WebhookDelivery),
+	}
+}
+
+func (r *memWebhookRepo) Create(webhook *domain.Webhook) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	webhook.ID = r.seq
+	r.webhooks[webhook.ID] = *webhook
+	return nil
+}
+
+func (r *memWebhookRepo) FindByID(id uint) (*domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, nil
+	}
+	return &webhook, nil
+}
+
+func (r *memWebhookRepo) FindActive() ([]domain.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var webhooks []domain.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.Active {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks, nil
+}
+
+func (r *memWebhookRepo) CreateDelivery(delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	delivery.ID = r.seq
+	delivery.CreatedAt = time.Now()
+	r.deliveries[delivery.ID] = *delivery
+	return nil
+}
+
+func (r *memWebhookRepo) FindDeliveryByID(id uint) (*domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	return &delivery, nil
+}
+
+func (r *memWebhookRepo) FindDueDeliveries(now time.Time, limit int) ([]domain.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due []domain.WebhookDelivery
+	for _, d := range r.deliveries {
+		if d.Status == domain.WebhookDeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].ID < due[j].ID })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (r *memWebhookRepo) ClaimDelivery(id uint, now, leaseUntil time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deliveries[id]
+	if !ok || d.Status != domain.WebhookDeliveryPending || d.NextAttemptAt == nil || d.NextAttemptAt.After(now) {
+		return false, nil
+	}
+	d.NextAttemptAt = &leaseUntil
+	r.deliveries[id] = d
+	return true, nil
+}
+
+func (r *memWebhookRepo) UpdateDelivery(delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries[delivery.ID] = *delivery
+	return nil
+}
+
+// makeDue 将推送记录的下次推送时间提前到当前时间，模拟等待重试间隔
+func (r *memWebhookRepo) makeDue(id uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.deliveries[id]
+	now := time.Now()
+	d.NextAttemptAt = &now
+	r.deliveries[id] = d
+}
+
+// webhookFixture 测试用的Webhook服务及其依赖
+type webhookFixture struct {
+	repo     *memWebhookRepo
+	receiver *webhookReceiver
+	svc      *webhookService
+	webhook  *domain.Webhook
+}
+
+// newWebhookFixture 创建指向本地接收方的Webhook，最多推送3次，重试间隔从1分钟起翻倍且不超过90秒
+// 后台任务的轮询间隔足够长，测试中由调用方手动处理到期记录
+func newWebhookFixture(t *testing.T, responses ...int) *webhookFixture {
+	f := &webhookFixture{
+		repo:     newMemWebhookRepo(),
+		receiver: newWebhookReceiver(t, responses...),
+	}
+	f.svc = NewWebhookService(f.repo, 5*time.Second, 3, time.Minute, 90*time.Second, time.Hour).(*webhookService)
+
+	webhook, err := f.svc.CreateWebhook(WebhookInput{
+		URL:    f.receiver.server.URL + "/hooks",
+		Secret: testWebhookSecret,
+		Events: []string{domain.WebhookEventResumeCreated},
+	})
+	if err != nil {
+		t.Fatalf("创建Webhook失败: %v", err)
+	}
+	f.webhook = webhook
+	return f
+}
+
+// enqueue 创建一条立即到期的推送记录
+func (f *webhookFixture) enqueue(t *testing.T) *domain.WebhookDelivery {
+	t.Helper()
+	now := time.Now()
+	delivery := &domain.WebhookDelivery{
+		WebhookID:     f.webhook.ID,
+		EventID:       "evt-1",
+		EventType:     domain.WebhookEventResumeCreated,
+		Payload:       `{"id":"evt-1","type":"resume.created"}`,
+		Status:        domain.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+	if err := f.repo.CreateDelivery(delivery); err != nil {
+		t.Fatalf("创建推送记录失败: %v", err)
+	}
+	return delivery
+}
+
+// delivery 读取推送记录的当前状态
+func (f *webhookFixture) delivery(t *testing.T, id uint) *domain.WebhookDelivery {
+	t.Helper()
+	d, _ := f.repo.FindDeliveryByID(id)
+	if d == nil {
+		t.Fatalf("推送记录%d不存在", id)
+	}
+	return d
+}
+
+// verifyWebhookSignature 按文档中的算法独立校验签名
+func verifyWebhookSignature(t *testing.T, req receivedWebhook) {
+	t.Helper()
+	timestamp := req.Header.Get(WebhookHeaderTimestamp)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Fatalf("签名时间戳无效: %q", timestamp)
+	}
+
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(req.Body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.Header.Get(WebhookHeaderSignature); !hmac.Equal([]byte(got), []byte(want)) {
+		t.Fatalf("签名 = %q，期望 %q", got, want)
+	}
+}
+
+func TestWebhookEventDeliveredWithSignature(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	f.svc.HandleEvent(Event{
+		Type:      EventResumeCreated,
+		CreatedAt: time.Now(),
+		Data:      map[string]interface{}{"resume": map[string]interface{}{"resume_id": 7}},
+	})
+	req := f.receiver.wait(t)
+
+	if req.Path != "/hooks" {
+		t.Errorf("推送地址 = %q", req.Path)
+	}
+	if got := req.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := req.Header.Get(WebhookHeaderEvent); got != domain.WebhookEventResumeCreated {
+		t.Errorf("%s = %q", WebhookHeaderEvent, got)
+	}
+	verifyWebhookSignature(t, req)
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		t.Fatalf("解析推送内容失败: %v", err)
+	}
+	if payload.Type != domain.WebhookEventResumeCreated || payload.ID != req.Header.Get(WebhookHeaderEventID) {
+		t.Errorf("推送内容 = %+v，事件ID头 = %q", payload, req.Header.Get(WebhookHeaderEventID))
+	}
+
+	// 推送结果在接收方响应后写入，等待后台任务保存
+	deliveryID, err := strconv.ParseUint(req.Header.Get(WebhookHeaderDelivery), 10, 64)
+	if err != nil {
+		t.Fatalf("%s = %q", WebhookHeaderDelivery, req.Header.Get(WebhookHeaderDelivery))
+	}
+	var d *domain.WebhookDelivery
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if d = f.delivery(t, uint(deliveryID)); d.Status != domain.WebhookDeliveryPending {
+			break
+		}
+	}
+	if d.Status != domain.WebhookDeliverySucceeded || d.Attempts != 1 || d.DeliveredAt == nil || d.NextAttemptAt != nil {
+		t.Fatalf("推送记录 = %+v", d)
+	}
+	if d.ResponseStatus != http.StatusOK || d.ResponseBody != "status 200" || d.Error != "" {
+		t.Errorf("记录的响应 = %d %q，错误 = %q", d.ResponseStatus, d.ResponseBody, d.Error)
+	}
+	if d.Payload != string(req.Body) {
+		t.Errorf("记录的推送内容 = %q，实际发送 %q", d.Payload, req.Body)
+	}
+}
+
+func TestWebhookIgnoresUnsubscribedEvents(t *testing.T) {
+	f := newWebhookFixture(t)
+
+	f.svc.HandleEvent(Event{Type: EventResumeDeleted, CreatedAt: time.Now()})
+	f.svc.HandleEvent(Event{Type: "comment.created", CreatedAt: time.Now()})
+
+	if due, _ := f.repo.FindDueDeliveries(time.Now(), 10); len(due) != 0 {
+		t.Fatalf("未订阅的事件生成了推送记录: %+v", due)
+	}
+}
+
+func TestWebhookRetriesWithBackoff(t *testing.T) {
+	f := newWebhookFixture(t, http.StatusInternalServerError, http.StatusFound, http.StatusBadGateway)
+	delivery := f.enqueue(t)
+
+	// 第1次推送返回5xx，1分钟后重试
+	start := time.Now()
+	f.svc.processDueDeliveries()
+	d := f.delivery(t, delivery.ID)
+	if d.Status != domain.WebhookDeliveryPending || d.Attempts != 1 {
+		t.Fatalf("第1次推送后的记录 = %+v", d)
+	}
+	if d.ResponseStatus != http.StatusInternalServerError || d.ResponseBody != "status 500" || d.Error != "接收方返回HTTP 500" {
+		t.Errorf("第1次推送记录的响应 = %d %q，错误 = %q", d.ResponseStatus, d.ResponseBody, d.Error)
+	}
+	assertNextAttempt(t, d, start, time.Minute)
+
+	// 未到重试时间不会再次推送
+	f.svc.processDueDeliveries()
+	if n := f.receiver.count(); n != 1 {
+		t.Fatalf("未到重试时间时收到%d次推送", n)
+	}
+
+	// 第2次推送返回3xx，不跟随重定向，间隔翻倍后受最大间隔限制
+	f.repo.makeDue(delivery.ID)
+	start = time.Now()
+	f.svc.processDueDeliveries()
+	d = f.delivery(t, delivery.ID)
+	if d.Status != domain.WebhookDeliveryPending || d.Attempts != 2 || d.ResponseStatus != http.StatusFound {
+		t.Fatalf("第2次推送后的记录 = %+v", d)
+	}
+	if d.Error != "接收方返回HTTP 302" {
+		t.Errorf("第2次推送的错误 = %q", d.Error)
+	}
+	assertNextAttempt(t, d, start, 90*time.Second)
+	if n := f.receiver.count(); n != 2 {
+		t.Fatalf("收到%d次请求，重定向不应被跟随", n)
+	}
+
+	// 第3次推送仍失败，重试次数用尽
+	f.repo.makeDue(delivery.ID)
+	f.svc.processDueDeliveries()
+	d = f.delivery(t, delivery.ID)
+	if d.Status != domain.WebhookDeliveryFailed || d.Attempts != 3 || d.NextAttemptAt != nil || d.DeliveredAt != nil {
+		t.Fatalf("重试次数用尽后的记录 = %+v", d)
+	}
+	if d.ResponseStatus != http.StatusBadGateway || d.Error != "接收方返回HTTP 502" {
+		t.Errorf("第3次推送记录的响应 = %d，错误 = %q", d.ResponseStatus, d.Error)
+	}
+
+	// 每次重试的事件ID和推送记录ID不变，签名按各自的时间戳计算
+	f.receiver.mu.Lock()
+	requests := append([]receivedWebhook(nil), f.receiver.requests...)
+	f.receiver.mu.Unlock()
+	for _, req := range requests {
+		if req.Path != "/hooks" {
+			t.Errorf("请求地址 = %q", req.Path)
+		}
+		if req.Header.Get(WebhookHeaderEventID) != "evt-1" || req.Header.Get(WebhookHeaderDelivery) != strconv.Itoa(int(delivery.ID)) {
+			t.Errorf("重试请求头 = %v", req.Header)
+		}
+		verifyWebhookSignature(t, req)
+	}
+}
+
+// assertNextAttempt 校验下次推送时间为start之后delay左右
+func assertNextAttempt(t *testing.T, d *domain.WebhookDelivery, start time.Time, delay time.Duration) {
+	t.Helper()
+	if d.NextAttemptAt == nil {
+		t.Fatal("未安排重试")
+	}
+	if got := d.NextAttemptAt.Sub(start); got < delay || got > delay+5*time.Second {
+		t.Errorf("重试间隔 = %s，期望 %s", got, delay)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	s := &webhookService{retryBackoff: time.Minute, maxBackoff: 10 * time.Minute}
+	want := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i, w := range want {
+		if got := s.backoff(i + 1); got != w {
+			t.Errorf("第%d次失败后的间隔 = %s，期望 %s", i+1, got, w)
+		}
+	}
+}
+
+func TestWebhookReplay(t *testing.T) {
+	f := newWebhookFixture(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	original := f.enqueue(t)
+
+	// 原推送失败一次后标记为失败，模拟重试已用尽
+	f.svc.processDueDeliveries()
+	first := f.receiver.wait(t)
+	failed := f.delivery(t, original.ID)
+	failed.Status = domain.WebhookDeliveryFailed
+	failed.NextAttemptAt = nil
+	_ = f.repo.UpdateDelivery(failed)
+
+	// 重放生成新记录，事件ID和内容不变，失败后按正常规则重试
+	replay, err := f.svc.ReplayDelivery(f.webhook.ID, original.ID)
+	if err != nil {
+		t.Fatalf("重放失败: %v", err)
+	}
+	second := f.receiver.wait(t)
+	if replay.ID == original.ID || replay.ReplayOf == nil || *replay.ReplayOf != original.ID {
+		t.Fatalf("重放记录 = %+v", replay)
+	}
+	if second.Header.Get(WebhookHeaderEventID) != first.Header.Get(WebhookHeaderEventID) || string(second.Body) != string(first.Body) {
+		t.Errorf("重放的事件ID或内容与原推送不同")
+	}
+	if second.Header.Get(WebhookHeaderDelivery) != strconv.Itoa(int(replay.ID)) {
+		t.Errorf("%s = %q，期望重放记录ID %d", WebhookHeaderDelivery, second.Header.Get(WebhookHeaderDelivery), replay.ID)
+	}
+	verifyWebhookSignature(t, second)
+
+	d := f.delivery(t, replay.ID)
+	if d.Status != domain.WebhookDeliveryPending || d.Attempts != 1 || d.NextAttemptAt == nil {
+		t.Fatalf("重放失败后的记录 = %+v", d)
+	}
+
+	// 到期后由后台任务重试成功，原记录保持不变
+	f.repo.makeDue(replay.ID)
+	f.svc.processDueDeliveries()
+	if d = f.delivery(t, replay.ID); d.Status != domain.WebhookDeliverySucceeded || d.Attempts != 2 {
+		t.Fatalf("重放重试后的记录 = %+v", d)
+	}
+	if o := f.delivery(t, original.ID); o.Status != domain.WebhookDeliveryFailed || o.Attempts != 1 {
+		t.Errorf("原推送记录被修改: %+v", o)
+	}
+
+	// 不能重放其他Webhook的推送记录
+	other, err := f.svc.CreateWebhook(WebhookInput{URL: f.receiver.server.URL, Events: []string{domain.WebhookEventResumeDeleted}})
+	if err != nil {
+		t.Fatalf("创建Webhook失败: %v", err)
+	}
+	if _, err := f.svc.ReplayDelivery(other.ID, original.ID); !errors.Is(err, ErrWebhookDeliveryNotFound) {
+		t.Errorf("跨Webhook重放的错误 = %v", err)
+	}
+}
+
+func TestWebhookPingDoesNotRetry(t *testing.T) {
+	f := newWebhookFixture(t, http.StatusServiceUnavailable)
+
+	delivery, err := f.svc.PingWebhook(f.webhook.ID)
+	if !errors.Is(err, ErrWebhookDeliveryFailed) {
+		t.Fatalf("测试推送的错误 = %v", err)
+	}
+	req := f.receiver.wait(t)
+	if req.Header.Get(WebhookHeaderEvent) != domain.WebhookEventPing {
+		t.Errorf("%s = %q", WebhookHeaderEvent, req.Header.Get(WebhookHeaderEvent))
+	}
+	verifyWebhookSignature(t, req)
+
+	d := f.delivery(t, delivery.ID)
+	if d.Status != domain.WebhookDeliveryFailed || d.Attempts != 1 || d.NextAttemptAt != nil || d.ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("测试推送记录 = %+v", d)
+	}
+}
@@ -0,0 +1,102 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// BlobIndex 内容寻址文件的引用计数存储
+// 内容寻址的文件可能被多个简历版本甚至多个用户共享，只有最后一个引用释放时才删除
+type BlobIndex interface {
+	// Acquire 增加文件引用，写入文件前调用，持有引用期间文件不会被删除
+	Acquire(ctx context.Context, key StorageKey) error
+	// Release 减少文件引用，引用归零时在同一事务中调用onZero删除文件
+	Release(ctx context.Context, key StorageKey, onZero func()) error
+}
+
+// blobIndex 内容寻址文件的引用计数，未设置时不删除任何内容寻址文件
+var blobIndex BlobIndex
+
+// SetBlobIndex 设置内容寻址文件的引用计数存储
+func SetBlobIndex(index BlobIndex) {
+	blobIndex = index
+}
+
+// BlobKey 内容寻址文件的存储键，按哈希前两位分目录
+// 例如: blobs/3f/3fa2...e1.pdf
+func BlobKey(hash, ext string) StorageKey {
+	return StorageKey(BlobDir).Join(hash[:2], hash+ext)
+}
+
+// IsBlobKey 判断数据库中保存的文件是否为内容寻址文件
+func IsBlobKey(stored string) bool {
+	key, err := ParseStorageKey(stored)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(key.String(), BlobDir+"/")
+}
+
+// acquireBlobs 增加内容寻址文件的引用，失败时释放已增加的引用
+func acquireBlobs(ctx context.Context, keys ...StorageKey) error {
+	if blobIndex == nil {
+		return nil
+	}
+	for i, key := range keys {
+		if err := blobIndex.Acquire(ctx, key); err != nil {
+			for _, acquired := range keys[:i] {
+				_ = releaseBlob(ctx, acquired)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// blobExists 判断内容寻址文件是否已存在
+func blobExists(ctx context.Context, key StorageKey) (bool, error) {
+	_, err := GetStorage().Stat(ctx, key)
+	if errors.Is(err, ErrFileNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// releaseBlob 减少内容寻址文件的引用，引用归零时删除文件
+// 简历图片的其他格式版本随主格式图片一起删除
+func releaseBlob(ctx context.Context, key StorageKey) error {
+	if blobIndex == nil {
+		return nil
+	}
+	return blobIndex.Release(ctx, key, func() {
+		// 删除失败的文件不再被引用，由存储清理任务处理
+		for _, k := range append([]StorageKey{key}, renditionKeys(key)...) {
+			if err := GetStorage().Delete(ctx, k); err != nil {
+				GetLogger().Warn("删除内容寻址文件失败", zap.Error(err), zap.Stringer("key", k))
+			}
+		}
+	})
+}
+
+// ReleaseFile 释放数据库中保存的文件
+// 内容寻址文件减少引用，最后一个引用释放时删除；其他文件为单次上传独占，直接删除
+func ReleaseFile(stored string) error {
+	if stored == "" {
+		return nil
+	}
+	if !IsBlobKey(stored) {
+		return DeleteFile(stored)
+	}
+	key, err := ParseStorageKey(stored)
+	if err != nil {
+		return err
+	}
+	if err := releaseBlob(context.Background(), key); err != nil {
+		GetLogger().Error("释放文件引用失败", zap.Error(err), zap.Stringer("key", key))
+		return err
+	}
+	return nil
+}
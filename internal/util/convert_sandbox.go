@@ -0,0 +1,169 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 外部转换命令（ImageMagick、Poppler、Ghostscript）处理的是用户上传的文件，
+// 统一通过runConverter在超时、CPU时间和内存限制下运行，ImageMagick额外使用受限的安全策略
+
+// converterWaitDelay 超时终止命令后等待输出管道关闭的时间
+const converterWaitDelay = 5 * time.Second
+
+// converterStderrLimit 错误日志中保留的标准错误输出长度
+const converterStderrLimit = 2048
+
+// runConverter 在资源限制下运行外部转换命令，返回标准输出
+// 超时返回ErrConvertTimeout，因超出CPU时间或内存被系统终止返回ErrConvertResourceLimit
+func runConverter(name string, args ...string) ([]byte, error) {
+	limits := conversionLimits
+
+	ctx := context.Background()
+	if limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	if wrapper := ulimitWrapper(limits); wrapper != nil {
+		// 通过shell设置资源限制后exec替换为转换命令，限制对其启动的子进程同样有效
+		cmd = exec.CommandContext(ctx, "sh", append(append(wrapper, name), args...)...)
+	}
+	cmd.WaitDelay = converterWaitDelay
+	cmd.Env = os.Environ()
+	if dir, err := magickPolicyDir(); err == nil {
+		cmd.Env = append(cmd.Env, "MAGICK_CONFIGURE_PATH="+dir)
+	} else {
+		GetLogger().Warn("写入ImageMagick安全策略失败", zap.Error(err))
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return stdout.Bytes(), nil
+	}
+
+	message := stderr.String()
+	if len(message) > converterStderrLimit {
+		message = message[:converterStderrLimit]
+	}
+	fields := []zap.Field{zap.Error(err), zap.String("command", name), zap.String("stderr", message)}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		GetLogger().Warn("转换命令超时", append(fields, zap.Duration("timeout", limits.Timeout))...)
+		return nil, ErrConvertTimeout
+	}
+	var exitErr *exec.ExitError
+	if (errors.As(err, &exitErr) && !exitErr.Exited()) || isResourceLimitMessage(message) {
+		GetLogger().Warn("转换命令超出资源限制", fields...)
+		return nil, ErrConvertResourceLimit
+	}
+	GetLogger().Warn("转换命令执行失败", fields...)
+	return nil, fmt.Errorf("%s: %w", name, err)
+}
+
+// isConvertLimitError 是否为超时或超出资源限制，此时不应换用其他方式重试
+func isConvertLimitError(err error) bool {
+	return err == ErrConvertTimeout || err == ErrConvertResourceLimit
+}
+
+// ulimitWrapper 生成设置资源限制的shell参数，没有需要设置的限制或系统不支持时返回nil
+func ulimitWrapper(limits ConversionLimits) []string {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	var script []string
+	if limits.CPUTime > 0 {
+		seconds := int64((limits.CPUTime + time.Second - 1) / time.Second)
+		script = append(script, fmt.Sprintf("ulimit -t %d", seconds))
+	}
+	if limits.Memory > 0 {
+		script = append(script, fmt.Sprintf("ulimit -v %d", (limits.Memory+1023)/1024))
+	}
+	if len(script) == 0 {
+		return nil
+	}
+	script = append(script, `exec "$0" "$@"`)
+	return []string{"-c", strings.Join(script, "; ")}
+}
+
+// isResourceLimitMessage 转换工具因内存分配失败或ImageMagick资源策略退出时的错误信息
+func isResourceLimitMessage(stderr string) bool {
+	stderr = strings.ToLower(stderr)
+	for _, s := range []string{"cannot allocate memory", "out of memory", "memory allocation failed", "vmerror", "resource limit", "cache resources exhausted", "exceeds limit"} {
+		if strings.Contains(stderr, s) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	magickPolicyOnce sync.Once
+	magickPolicyPath string
+	magickPolicyErr  error
+)
+
+// magickPolicyDir 写入ImageMagick安全策略，返回所在目录，供MAGICK_CONFIGURE_PATH使用
+func magickPolicyDir() (string, error) {
+	magickPolicyOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "codefolio-magick-")
+		if err != nil {
+			magickPolicyErr = err
+			return
+		}
+		if err := os.WriteFile(filepath.Join(dir, "policy.xml"), []byte(magickPolicy(conversionLimits)), 0644); err != nil {
+			magickPolicyErr = err
+			return
+		}
+		magickPolicyPath = dir
+	})
+	return magickPolicyPath, magickPolicyErr
+}
+
+// magickPolicy 生成ImageMagick安全策略
+// 只允许读取PDF和读写拼接长图需要的位图格式，禁止从文件读取参数（@文件），
+// 并限制像素尺寸和内存，超过限制时ImageMagick使用磁盘缓存或直接报错
+func magickPolicy(limits ConversionLimits) string {
+	memory, mapSize := "256MiB", "512MiB"
+	if limits.Memory > 0 {
+		memory = fmt.Sprintf("%dMiB", max(limits.Memory/4/(1024*1024), 64))
+		mapSize = fmt.Sprintf("%dMiB", max(limits.Memory/2/(1024*1024), 128))
+	}
+	timeLimit := ""
+	if limits.Timeout > 0 {
+		timeLimit = fmt.Sprintf("\n  <policy domain=\"resource\" name=\"time\" value=\"%d\"/>", int64(limits.Timeout/time.Second)+1)
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<policymap>
+  <policy domain="resource" name="memory" value="%s"/>
+  <policy domain="resource" name="map" value="%s"/>
+  <policy domain="resource" name="width" value="16KP"/>
+  <policy domain="resource" name="height" value="128KP"/>
+  <policy domain="resource" name="area" value="256MP"/>
+  <policy domain="resource" name="disk" value="2GiB"/>
+  <policy domain="resource" name="thread" value="2"/>%s
+  <policy domain="path" rights="none" pattern="@*"/>
+  <policy domain="coder" rights="none" pattern="*"/>
+  <policy domain="coder" rights="read" pattern="PDF"/>
+  <policy domain="coder" rights="read|write" pattern="{JPEG,JPG,PNG,PNM,PPM,PGM,PBM,PAM,WEBP}"/>
+</policymap>
+`, memory, mapSize, timeLimit)
+}
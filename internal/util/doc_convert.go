@@ -0,0 +1,107 @@
+package util
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// 文档转换相关错误
+var (
+	ErrDocumentConvertFailed    = errors.New("文档转换为PDF失败")
+	ErrDocumentConverterMissing = errors.New("服务器未配置文档转换工具")
+)
+
+// DocumentConverter 将上传的文档转换为PDF，转换结果与直接上传的PDF走相同的校验和渲染流程
+type DocumentConverter interface {
+	// Name 转换器名称，用于日志
+	Name() string
+	// Available 转换工具是否可用
+	Available() bool
+	// ConvertToPDF 将srcPath转换为PDF，输出到outDir，返回PDF路径
+	ConvertToPDF(srcPath, outDir string) (string, error)
+}
+
+// documentConverter 当前使用的文档转换器
+var documentConverter DocumentConverter = NewLibreOfficeConverter("")
+
+// SetDocumentConverter 设置文档转换器，允许上传DOCX但转换工具不可用时记录警告
+func SetDocumentConverter(converter DocumentConverter) {
+	documentConverter = converter
+	if allowedUploadTypes[UploadTypeDOCX] && (converter == nil || !converter.Available()) {
+		GetLogger().Warn("允许上传DOCX但文档转换工具不可用，DOCX上传将失败")
+	}
+}
+
+// convertDocumentToPDF 使用当前的文档转换器将文档转换为PDF
+func convertDocumentToPDF(srcPath, outDir string) (string, error) {
+	converter := documentConverter
+	if converter == nil || !converter.Available() {
+		return "", ErrDocumentConverterMissing
+	}
+	pdfPath, err := converter.ConvertToPDF(srcPath, outDir)
+	if err != nil {
+		GetLogger().Warn("文档转换为PDF失败", zap.Error(err), zap.String("converter", converter.Name()))
+		if isConvertLimitError(err) {
+			return "", err
+		}
+		return "", ErrDocumentConvertFailed
+	}
+	return pdfPath, nil
+}
+
+// libreOfficeConverter 使用无界面的LibreOffice转换文档
+type libreOfficeConverter struct {
+	command string
+}
+
+// NewLibreOfficeConverter 创建LibreOffice文档转换器，command为空时使用soffice
+func NewLibreOfficeConverter(command string) DocumentConverter {
+	if command == "" {
+		command = "soffice"
+	}
+	return &libreOfficeConverter{command: command}
+}
+
+func (l *libreOfficeConverter) Name() string { return "libreoffice" }
+
+func (l *libreOfficeConverter) Available() bool {
+	_, err := exec.LookPath(l.command)
+	return err == nil
+}
+
+// ConvertToPDF 每次转换使用独立的用户配置目录，多个转换可以并发运行，也不会读取其他转换留下的配置
+func (l *libreOfficeConverter) ConvertToPDF(srcPath, outDir string) (string, error) {
+	profileDir, err := os.MkdirTemp(outDir, "libreoffice-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(profileDir)
+
+	if profileDir, err = filepath.Abs(profileDir); err != nil {
+		return "", err
+	}
+	profileURL := &url.URL{Scheme: "file", Path: filepath.ToSlash(profileDir)}
+	if _, err := runConverter(l.command,
+		"--headless", "--norestore", "--nolockcheck", "--nodefault",
+		"-env:UserInstallation="+profileURL.String(),
+		"--convert-to", "pdf",
+		"--outdir", outDir,
+		srcPath,
+	); err != nil {
+		return "", err
+	}
+
+	// LibreOffice按源文件名输出，转换失败时也可能返回成功
+	base := filepath.Base(srcPath)
+	pdfPath := filepath.Join(outDir, strings.TrimSuffix(base, filepath.Ext(base))+".pdf")
+	if _, err := os.Stat(pdfPath); err != nil {
+		return "", err
+	}
+	return pdfPath, nil
+}
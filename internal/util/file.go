@@ -1,16 +1,17 @@
 package util
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"sort"
-	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -23,10 +24,18 @@ var (
 	UploadDir = "uploads"
 	// ResumeDir 简历存储子目录
 	ResumeDir = "resumes"
+	// AvatarDir 头像存储子目录
+	AvatarDir = "avatars"
+	// BlobDir 内容寻址存储子目录，简历文件按内容哈希保存，相同内容只保存一份
+	BlobDir = "blobs"
+	// TrashDir 回收站子目录，软删除的文件移动到此处等待永久删除
+	TrashDir = "trash"
+	// ChunkDir 分片上传子目录，存放尚未合并的分片
+	ChunkDir = "chunks"
+	// MaxAvatarSize 允许的最大头像大小 (2MB)
+	MaxAvatarSize int64 = 2 * 1024 * 1024
 	// MaxFileSize 允许的最大文件大小 (10MB)
 	MaxFileSize int64 = 10 * 1024 * 1024
-	// AllowedFileType 允许的文件类型
-	AllowedFileType = "application/pdf"
 )
 
 // 图片格式
@@ -37,19 +46,26 @@ const (
 	DefaultImageQuality = 90
 	// 默认DPI
 	DefaultDPI = 150
+	// 计算感知哈希时渲染第一页使用的DPI
+	PHashDPI = 72
 )
 
-// 设置上传配置
-func SetUploadConfig(uploadDir string, maxSize int64, allowedType string) {
+// 设置上传配置，allowedTypes为以逗号分隔的允许上传的简历文件类型
+func SetUploadConfig(uploadDir string, maxSize int64, allowedTypes string) error {
 	if uploadDir != "" {
 		UploadDir = uploadDir
 	}
 	if maxSize > 0 {
 		MaxFileSize = maxSize
 	}
-	if allowedType != "" {
-		AllowedFileType = allowedType
+	if allowedTypes != "" {
+		types, err := ParseUploadTypes(allowedTypes)
+		if err != nil {
+			return err
+		}
+		allowedUploadTypes = types
 	}
+	return nil
 }
 
 // 文件相关错误
@@ -65,201 +81,74 @@ var (
 
 // UploadFileResult 文件上传结果
 type UploadFileResult struct {
-	FilePath string
-	FileName string
-	FileType string
-	FileSize int64
+	Key         StorageKey // 保存到数据库的存储键
+	SourceKey   StorageKey // 用户上传的原始文件的存储键
+	ContentHash string     // 原始文件的SHA-256
+	PHash       string     // 第一页的感知哈希，无法渲染时为空
+	FileName    string
+	FileType    string
+	FileSize    int64
 }
 
-// ConvertPDFToImage 将PDF文件转换为长图片（所有页面）
+// ConvertPDFToImage 将PDF文件转换为长图片（所有页面），按渲染配置输出主格式
 func ConvertPDFToImage(pdfPath string) (string, error) {
 	// 检查文件是否存在
 	if _, err := os.Stat(pdfPath); os.IsNotExist(err) {
 		return "", ErrFileNotFound
 	}
 
-	// 提取目录和文件名
-	dir := filepath.Dir(pdfPath)
-	base := filepath.Base(pdfPath)
-	filename := strings.TrimSuffix(base, filepath.Ext(base))
-
-	// 构建输出图片路径
-	outputImageBase := filepath.Join(dir, filename)
-	outputImagePath := fmt.Sprintf("%s.%s", outputImageBase, DefaultImageFormat)
-
-	// 首先尝试直接使用ImageMagick一步完成转换
-	if _, err := exec.LookPath("convert"); err == nil {
-		cmd := exec.Command(
-			"convert",
-			"-density", fmt.Sprintf("%d", DefaultDPI), // 设置DPI
-			"-quality", fmt.Sprintf("%d", DefaultImageQuality), // 设置质量
-			"-append",       // 垂直拼接
-			pdfPath,         // 输入PDF文件
-			outputImagePath, // 输出文件
-		)
-
-		// 执行命令
-		if err := cmd.Run(); err != nil {
-			GetLogger().Warn("直接转换多页PDF失败，尝试分步转换", zap.Error(err))
-		} else {
-			// 成功完成转换
-			GetLogger().Info("多页PDF直接转换为长图成功", zap.String("输出", outputImagePath))
-			return outputImagePath, nil
-		}
-	}
-
-	// 如果直接转换失败，尝试分步执行：先分页转换，再拼接
-
-	// 临时目录，用于存放单页图片
-	tmpDir := filepath.Join(dir, fmt.Sprintf("%s_tmp", filename))
-	if err := os.MkdirAll(tmpDir, 0755); err != nil {
-		GetLogger().Error("创建临时目录失败", zap.Error(err))
+	images, err := renderResumeImages(pdfPath, []string{primaryImageFormat()})
+	if err != nil {
 		return "", err
 	}
-	defer os.RemoveAll(tmpDir) // 清理临时目录
-
-	// 1. 使用pdftoppm将PDF分页转换为图片
-	var pagePaths []string
-
-	if _, err := exec.LookPath("pdftoppm"); err == nil {
-		// 分页转换
-		pageFileBase := filepath.Join(tmpDir, "page")
-		cmd := exec.Command(
-			"pdftoppm",
-			"-jpeg",                             // 输出JPEG格式
-			"-r", fmt.Sprintf("%d", DefaultDPI), // 设置DPI
-			"-jpegopt", fmt.Sprintf("quality=%d", DefaultImageQuality), // 设置JPEG质量
-			pdfPath,      // 输入PDF文件
-			pageFileBase, // 输出基础名称
-		)
-
-		if err := cmd.Run(); err != nil {
-			GetLogger().Error("分页转换PDF失败 (pdftoppm)", zap.Error(err))
-			return "", ErrConvertPDFFailed
-		}
-
-		// 查找生成的图片文件
-		files, err := os.ReadDir(tmpDir)
-		if err != nil {
-			GetLogger().Error("读取临时目录失败", zap.Error(err))
-			return "", err
-		}
-
-		for _, file := range files {
-			if file.IsDir() {
-				continue
-			}
-			pagePaths = append(pagePaths, filepath.Join(tmpDir, file.Name()))
-		}
-
-		// 按页码排序
-		sort.Strings(pagePaths)
-
-	} else if _, err := exec.LookPath("ghostscript"); err == nil {
-		// 如果pdftoppm不可用，尝试使用ghostscript
-		for i := 1; ; i++ {
-			pageFile := filepath.Join(tmpDir, fmt.Sprintf("page-%03d.jpg", i))
-			cmd := exec.Command(
-				"gs",
-				"-sDEVICE=jpeg",
-				fmt.Sprintf("-dJPEGQ=%d", DefaultImageQuality),
-				fmt.Sprintf("-r%d", DefaultDPI),
-				"-dBATCH",
-				"-dNOPAUSE",
-				fmt.Sprintf("-dFirstPage=%d", i),
-				fmt.Sprintf("-dLastPage=%d", i),
-				fmt.Sprintf("-sOutputFile=%s", pageFile),
-				pdfPath,
-			)
-
-			if err := cmd.Run(); err != nil {
-				// 假设返回错误表示已处理完所有页面
-				if i > 1 {
-					break
-				}
-				GetLogger().Error("分页转换PDF失败 (ghostscript)", zap.Error(err))
-				return "", ErrConvertPDFFailed
-			}
+	return images[primaryImageFormat()], nil
+}
 
-			if _, err := os.Stat(pageFile); err == nil {
-				pagePaths = append(pagePaths, pageFile)
-			} else {
-				break // 没有更多页面
-			}
-		}
-	} else {
-		GetLogger().Error("未找到合适的PDF转图片工具")
-		return "", ErrCommandNotFound
+// RenderPDFFirstPage 以指定DPI将PDF第一页渲染为JPEG图片，输出到outDir
+func RenderPDFFirstPage(pdfPath, outDir string, dpi int) (string, error) {
+	pages, err := renderPDF(pdfPath, outDir, RenderOptions{
+		DPI:       dpi,
+		Quality:   DefaultImageQuality,
+		FirstPage: 1,
+		LastPage:  1,
+	})
+	if err != nil {
+		GetLogger().Error("渲染PDF第一页失败", zap.Error(err))
+		return "", err
 	}
+	return pages[0], nil
+}
 
-	// 检查是否有页面生成
-	if len(pagePaths) == 0 {
-		GetLogger().Error("未能生成任何图片页面")
-		return "", ErrConvertPDFFailed
+// firstPagePHash 计算PDF第一页的感知哈希
+func firstPagePHash(pdfPath, workDir string) (string, error) {
+	outDir, err := os.MkdirTemp(workDir, "phash-")
+	if err != nil {
+		return "", err
 	}
+	defer os.RemoveAll(outDir)
 
-	// 2. 使用ImageMagick的convert命令垂直拼接图片
-	if _, err := exec.LookPath("convert"); err == nil {
-		args := []string{
-			"-append",                                          // 垂直拼接
-			"-quality", fmt.Sprintf("%d", DefaultImageQuality), // 设置质量
-		}
-		args = append(args, pagePaths...)    // 添加所有页面图片
-		args = append(args, outputImagePath) // 添加输出路径
-
-		cmd := exec.Command("convert", args...)
-
-		if err := cmd.Run(); err != nil {
-			GetLogger().Error("拼接图片失败", zap.Error(err))
-			return "", ErrConvertPDFFailed
-		}
-
-		GetLogger().Info("多页PDF转换为长图成功",
-			zap.String("输出", outputImagePath),
-			zap.Int("页数", len(pagePaths)))
-
-		return outputImagePath, nil
+	pagePath, err := RenderPDFFirstPage(pdfPath, outDir, PHashDPI)
+	if err != nil {
+		return "", err
 	}
-
-	// 如果没有ImageMagick，但至少有一页图片，就使用第一页
-	if len(pagePaths) > 0 {
-		GetLogger().Warn("无法拼接图片，使用第一页作为结果", zap.String("页面", pagePaths[0]))
-		// 复制第一页到最终输出位置
-		srcFile, err := os.Open(pagePaths[0])
-		if err != nil {
-			return "", err
-		}
-		defer srcFile.Close()
-
-		dstFile, err := os.Create(outputImagePath)
-		if err != nil {
-			return "", err
-		}
-		defer dstFile.Close()
-
-		if _, err := io.Copy(dstFile, srcFile); err != nil {
-			return "", err
-		}
-
-		return outputImagePath, nil
+	img, err := decodeImageFile(pagePath)
+	if err != nil {
+		return "", err
 	}
-
-	// 如果所有方法都失败
-	return "", ErrConvertPDFFailed
+	return PerceptualHash(img), nil
 }
 
-// SaveUploadedPDF 保存上传的PDF并转换为图片
-func SaveUploadedPDF(c *gin.Context, file *multipart.FileHeader, userID uint) (*UploadFileResult, error) {
+// SaveUploadedFile 保存上传的简历文件并转换为图片
+// 按文件内容识别类型并检查是否在允许上传的类型中：PDF直接处理，DOCX通过文档转换器转换为PDF，
+// PNG/JPEG图片包装为PDF，之后统一校验、渲染并计算感知哈希。
+// 文件按内容寻址保存：PDF和转换后的图片以上传文件的SHA-256命名，相同内容只转换和保存一次，
+// 每次上传增加一次引用，由调用方在不再使用时通过ReleaseFile释放
+func SaveUploadedFile(c *gin.Context, file *multipart.FileHeader, userID uint) (*UploadFileResult, error) {
 	// 检查文件大小
 	if file.Size > MaxFileSize {
 		return nil, ErrFileTooLarge
 	}
 
-	// 检查文件类型
-	if !strings.Contains(file.Header.Get("Content-Type"), "pdf") {
-		return nil, ErrInvalidFileType
-	}
-
 	// 打开源文件
 	src, err := file.Open()
 	if err != nil {
@@ -268,87 +157,198 @@ func SaveUploadedPDF(c *gin.Context, file *multipart.FileHeader, userID uint) (*
 	}
 	defer src.Close()
 
-	// 创建目录结构 uploads/resumes/user_id/year_month/
-	yearMonth := time.Now().Format("2006_01")
-	dirPath := filepath.Join(UploadDir, ResumeDir, fmt.Sprintf("%d", userID), yearMonth)
-	if err := os.MkdirAll(dirPath, 0755); err != nil {
-		GetLogger().Error("创建上传目录失败", zap.Error(err), zap.String("path", dirPath))
+	// 创建临时工作目录
+	workDir, err := os.MkdirTemp("", "codefolio-upload-")
+	if err != nil {
+		GetLogger().Error("创建临时目录失败", zap.Error(err))
 		return nil, err
 	}
+	defer os.RemoveAll(workDir)
 
-	// 生成唯一文件名
-	fileID := uuid.New().String()[:8]
-	originalName := strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename))
-
-	// 临时PDF文件路径
-	tempPDFName := fmt.Sprintf("%s_%s.pdf", fileID, originalName)
-	tempPDFPath := filepath.Join(dirPath, tempPDFName)
+	// 临时文件路径，类型识别前不使用客户端提供的扩展名
+	uploadPath := filepath.Join(workDir, "upload")
 
-	// 创建临时PDF文件
-	pdfFile, err := os.Create(tempPDFPath)
+	// 创建临时文件
+	uploadFile, err := os.Create(uploadPath)
 	if err != nil {
-		GetLogger().Error("创建临时PDF文件失败", zap.Error(err), zap.String("path", tempPDFPath))
+		GetLogger().Error("创建临时文件失败", zap.Error(err), zap.String("path", uploadPath))
 		return nil, err
 	}
-	defer pdfFile.Close()
+	defer uploadFile.Close()
 
-	// 复制文件内容到临时PDF文件
-	if _, err = io.Copy(pdfFile, src); err != nil {
-		GetLogger().Error("复制PDF内容失败", zap.Error(err))
-		_ = os.Remove(tempPDFPath) // 清理临时文件
+	// 复制文件内容到临时文件，同时计算内容哈希
+	hasher := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(uploadFile, hasher), src); err != nil {
+		GetLogger().Error("复制上传文件内容失败", zap.Error(err))
 		return nil, err
 	}
 
 	// 确保文件内容已写入磁盘
-	if err = pdfFile.Sync(); err != nil {
-		GetLogger().Error("同步PDF文件内容失败", zap.Error(err))
-		_ = os.Remove(tempPDFPath) // 清理临时文件
+	if err = uploadFile.Sync(); err != nil {
+		GetLogger().Error("同步上传文件内容失败", zap.Error(err))
 		return nil, err
 	}
-	pdfFile.Close() // 关闭文件以便后续操作
+	uploadFile.Close() // 关闭文件以便后续操作
+
+	contentHash := hex.EncodeToString(hasher.Sum(nil))
 
-	// 将PDF转换为图片
-	imagePath, err := ConvertPDFToImage(tempPDFPath)
+	ctx := context.Background()
+	if c != nil {
+		ctx = c.Request.Context()
+	}
+	return saveResumeFile(ctx, workDir, uploadPath, contentHash, file.Filename, file.Size)
+}
+
+// saveResumeFile 识别、校验并转换已保存到工作目录的上传文件，写入内容寻址存储
+func saveResumeFile(ctx context.Context, workDir, uploadPath, contentHash, fileName string, fileSize int64) (*UploadFileResult, error) {
+	// 按文件内容识别类型，不信任扩展名和客户端提供的Content-Type
+	uploadType, err := DetectUploadType(uploadPath)
 	if err != nil {
-		_ = os.Remove(tempPDFPath) // 清理临时文件
 		return nil, err
 	}
+	if !allowedUploadTypes[uploadType] {
+		GetLogger().Info("上传的文件类型不允许", zap.String("type", string(uploadType)), zap.String("hash", contentHash))
+		return nil, ErrInvalidFileType
+	}
 
-	// 转换成功后删除临时PDF文件
-	_ = os.Remove(tempPDFPath)
+	tempPDFPath, err := uploadToPDF(uploadPath, uploadType, workDir)
+	if err != nil {
+		GetLogger().Warn("上传文件转换为PDF失败", zap.Error(err), zap.String("type", string(uploadType)), zap.String("hash", contentHash))
+		return nil, err
+	}
 
-	// 记录文件路径
-	GetLogger().Info("图片生成成功",
-		zap.String("原PDF", tempPDFPath),
-		zap.String("转换图片", imagePath))
+	// 校验PDF结构，未通过校验的文件不会交给外部转换工具
+	info, err := ValidatePDF(tempPDFPath)
+	if err != nil {
+		GetLogger().Warn("上传的PDF未通过校验", zap.Error(err), zap.String("hash", contentHash))
+		return nil, err
+	}
+	GetLogger().Info("上传的PDF通过校验",
+		zap.String("hash", contentHash),
+		zap.String("version", info.Version),
+		zap.Int("pages", info.Pages))
+
+	// 第一页的感知哈希用于检测相似简历，计算失败不影响上传
+	phash, err := firstPagePHash(tempPDFPath, workDir)
+	if isConvertLimitError(err) {
+		return nil, err
+	} else if err != nil {
+		GetLogger().Warn("计算简历感知哈希失败", zap.Error(err), zap.String("hash", contentHash))
+	}
 
-	// 获取相对于项目根目录的相对路径
-	relPath := "/" + imagePath
+	imageKey := imageBlobKey(contentHash)
+	pdfKey := BlobKey(contentHash, ".pdf")
+
+	// 先增加引用再检查文件是否存在，持有引用期间文件不会被其他请求删除
+	if err := acquireBlobs(ctx, imageKey, pdfKey); err != nil {
+		GetLogger().Error("增加文件引用失败", zap.Error(err), zap.String("hash", contentHash))
+		return nil, ErrSaveFileFailed
+	}
+	if err := storeBlobs(ctx, tempPDFPath, imageKey, pdfKey); err != nil {
+		_ = releaseBlob(ctx, imageKey)
+		_ = releaseBlob(ctx, pdfKey)
+		return nil, err
+	}
 
 	// 返回结果
 	return &UploadFileResult{
-		FilePath: relPath, // 保存相对路径，方便构建URL
-		FileName: file.Filename,
-		FileType: "image/" + DefaultImageFormat,
-		FileSize: file.Size,
+		Key:         imageKey,
+		SourceKey:   pdfKey,
+		ContentHash: contentHash,
+		PHash:       phash,
+		FileName:    fileName,
+		FileType:    contentTypeByKey(imageKey),
+		FileSize:    fileSize,
 	}, nil
 }
 
-// SaveUploadedFile 保存上传的文件（保留兼容性）
-func SaveUploadedFile(c *gin.Context, file *multipart.FileHeader, userID uint) (*UploadFileResult, error) {
-	// 检查文件大小
-	if file.Size > MaxFileSize {
-		return nil, ErrFileTooLarge
+// uploadToPDF 将上传文件统一转换为PDF，返回PDF路径
+func uploadToPDF(uploadPath string, uploadType UploadType, workDir string) (string, error) {
+	pdfPath := filepath.Join(workDir, "source.pdf")
+	switch uploadType {
+	case UploadTypePDF:
+		return uploadPath, nil
+	case UploadTypePNG, UploadTypeJPEG:
+		return pdfPath, imageToPDF(uploadPath, pdfPath)
+	case UploadTypeDOCX:
+		// LibreOffice按扩展名选择导入过滤器
+		docxPath := filepath.Join(workDir, "source.docx")
+		if err := os.Rename(uploadPath, docxPath); err != nil {
+			return "", err
+		}
+		return convertDocumentToPDF(docxPath, workDir)
 	}
+	return "", ErrInvalidFileType
+}
 
-	// 检查文件类型
-	if !strings.Contains(file.Header.Get("Content-Type"), "pdf") {
-		return nil, ErrInvalidFileType
+// storeBlobs 将PDF转换为图片并与原始PDF一起写入存储，相同内容按相同渲染配置生成的图片不再重复转换
+// 除主格式外还按渲染配置生成其他格式的版本，与主格式图片同名、扩展名不同
+func storeBlobs(ctx context.Context, pdfPath string, imageKey, pdfKey StorageKey) error {
+	var missing []string
+	for _, format := range renderProfile.Formats {
+		exists, err := blobExists(ctx, RenditionKey(imageKey, format))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			missing = append(missing, format)
+		}
+	}
+	pdfExists, err := blobExists(ctx, pdfKey)
+	if err != nil {
+		return err
+	}
+	if len(missing) == 0 && pdfExists {
+		GetLogger().Info("简历文件已存在，跳过转换", zap.Stringer("key", pdfKey))
+		return nil
 	}
 
-	// 如果是PDF文件，使用新方法进行转换
-	if strings.Contains(file.Header.Get("Content-Type"), "pdf") {
-		return SaveUploadedPDF(c, file, userID)
+	if len(missing) > 0 {
+		// 将PDF转换为图片，渲染一次后输出所有缺少的格式
+		images, err := renderResumeImages(pdfPath, missing)
+		if err != nil {
+			return err
+		}
+		for _, format := range missing {
+			imagePath, ok := images[format]
+			if !ok {
+				continue
+			}
+			key := RenditionKey(imageKey, format)
+			if err := putLocalFile(ctx, imagePath, key); err != nil {
+				GetLogger().Error("保存简历图片失败", zap.Error(err), zap.Stringer("key", key))
+				return ErrSaveFileFailed
+			}
+		}
+	}
+	// 原始PDF保留用于数据导出和版本比较
+	if !pdfExists {
+		if err := putLocalFile(ctx, pdfPath, pdfKey); err != nil {
+			GetLogger().Error("保存简历PDF失败", zap.Error(err), zap.Stringer("key", pdfKey))
+			return ErrSaveFileFailed
+		}
+	}
+
+	GetLogger().Info("图片生成成功",
+		zap.Stringer("原PDF", pdfKey),
+		zap.Stringer("转换图片", imageKey),
+		zap.Strings("格式", missing))
+	return nil
+}
+
+// avatarExtensions 允许的头像格式及对应扩展名
+var avatarExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// SaveAvatar 保存用户头像，按文件内容识别图片格式
+func SaveAvatar(file *multipart.FileHeader, userID uint) (*UploadFileResult, error) {
+	// 检查文件大小
+	if file.Size > MaxAvatarSize {
+		return nil, ErrFileTooLarge
 	}
 
 	// 打开源文件
@@ -359,85 +359,101 @@ func SaveUploadedFile(c *gin.Context, file *multipart.FileHeader, userID uint) (
 	}
 	defer src.Close()
 
-	// 创建目录结构 uploads/resumes/user_id/year_month/
-	yearMonth := time.Now().Format("2006_01")
-	dirPath := filepath.Join(UploadDir, ResumeDir, fmt.Sprintf("%d", userID), yearMonth)
-	if err := os.MkdirAll(dirPath, 0755); err != nil {
-		GetLogger().Error("创建上传目录失败", zap.Error(err), zap.String("path", dirPath))
-		return nil, err
+	// 根据文件头识别真实类型，不信任客户端提供的Content-Type
+	head := make([]byte, 512)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, ErrInvalidFileType
 	}
-
-	// 生成唯一文件名
-	fileExt := filepath.Ext(file.Filename)
-	originalName := strings.TrimSuffix(file.Filename, fileExt)
-	newFileName := fmt.Sprintf("%s_%s%s", uuid.New().String()[:8], originalName, fileExt)
-	filePath := filepath.Join(dirPath, newFileName)
-
-	// 创建目标文件
-	dst, err := os.Create(filePath)
-	if err != nil {
-		GetLogger().Error("创建目标文件失败", zap.Error(err), zap.String("path", filePath))
-		return nil, err
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := avatarExtensions[contentType]
+	if !ok {
+		return nil, ErrInvalidFileType
 	}
-	defer dst.Close()
 
-	// 复制文件内容
-	if _, err = io.Copy(dst, src); err != nil {
-		GetLogger().Error("复制文件内容失败", zap.Error(err))
+	// 目录结构 avatars/user_id/
+	key := StorageKey(AvatarDir).Join(fmt.Sprintf("%d", userID), uuid.New().String()+ext)
+
+	// 写入已读取的文件头和剩余内容
+	if err := GetStorage().Put(context.Background(), key, io.MultiReader(bytes.NewReader(head[:n]), src), file.Size, contentType); err != nil {
+		GetLogger().Error("保存头像失败", zap.Error(err), zap.Stringer("key", key))
 		return nil, err
 	}
 
 	return &UploadFileResult{
-		FilePath: filePath,
+		Key:      key,
 		FileName: file.Filename,
-		FileType: file.Header.Get("Content-Type"),
+		FileType: contentType,
 		FileSize: file.Size,
 	}, nil
 }
 
-// DeleteFile 删除文件
-func DeleteFile(filePath string) error {
-	// 检查文件是否存在
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return ErrFileNotFound
+// DeleteFile 删除数据库中保存的文件
+func DeleteFile(stored string) error {
+	key, err := ParseStorageKey(stored)
+	if err != nil {
+		return err
 	}
 
 	// 删除文件
-	if err := os.Remove(filePath); err != nil {
-		GetLogger().Error("删除文件失败", zap.Error(err), zap.String("path", filePath))
+	if err := GetStorage().Delete(context.Background(), key); err != nil {
+		GetLogger().Error("删除文件失败", zap.Error(err), zap.Stringer("key", key))
 		return err
 	}
 
 	return nil
 }
 
-// GetFileURL 获取文件URL
-func GetFileURL(c *gin.Context, filePath string) string {
-	// 将文件路径转换为URL
-	// 例如: uploads/resumes/1/2023_03/abc.jpg -> /api/v1/files/resumes/1/2023_03/abc.jpg
-	relativePath := strings.TrimPrefix(filePath, UploadDir)
-	relativePath = strings.TrimPrefix(relativePath, "/")
-
-	// 如果运行在Docker中，Host可能需要设置为外部访问地址
-	host := c.Request.Host
-
-	// 如果没有主机头，使用默认值
-	if host == "" {
-		host = "localhost:8080"
+// trashKeys 计算数据库中保存的文件对应的存储键和回收站键
+func trashKeys(stored string) (StorageKey, StorageKey, error) {
+	key, err := ParseStorageKey(stored)
+	if err != nil {
+		return "", "", fmt.Errorf("无效的文件存储键: %s", stored)
 	}
+	return key, key.Trash(), nil
+}
 
-	scheme := "http"
-	if c.Request.TLS != nil {
-		scheme = "https"
+// MoveToTrash 将文件移动到回收站
+// 内容寻址文件可能被其他简历共享，保留在原位置，永久删除时释放引用
+func MoveToTrash(stored string) error {
+	if stored == "" || IsBlobKey(stored) {
+		return nil
 	}
+	key, trash, err := trashKeys(stored)
+	if err != nil {
+		return err
+	}
+	if err := moveObject(context.Background(), GetStorage(), key, trash); err != nil {
+		GetLogger().Warn("移动文件到回收站失败", zap.Error(err), zap.Stringer("key", key))
+		return err
+	}
+	return nil
+}
 
-	fileURL := fmt.Sprintf("%s://%s/api/v1/files/%s", scheme, host, relativePath)
-
-	// 记录URL转换过程（方便调试）
-	GetLogger().Debug("文件URL生成",
-		zap.String("filePath", filePath),
-		zap.String("relativePath", relativePath),
-		zap.String("fileURL", fileURL))
+// RestoreFromTrash 将文件从回收站移回原位置
+func RestoreFromTrash(stored string) error {
+	if stored == "" || IsBlobKey(stored) {
+		return nil
+	}
+	key, trash, err := trashKeys(stored)
+	if err != nil {
+		return err
+	}
+	if err := moveObject(context.Background(), GetStorage(), trash, key); err != nil {
+		GetLogger().Warn("从回收站恢复文件失败", zap.Error(err), zap.Stringer("key", key))
+		return err
+	}
+	return nil
+}
 
-	return fileURL
+// DeleteFromTrash 永久删除回收站中的文件
+func DeleteFromTrash(stored string) error {
+	if stored == "" || IsBlobKey(stored) {
+		return nil
+	}
+	_, trash, err := trashKeys(stored)
+	if err != nil {
+		return err
+	}
+	return GetStorage().Delete(context.Background(), trash)
 }
@@ -0,0 +1,146 @@
+package util
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 文件URL签名错误
+var (
+	ErrFileURLInvalid = errors.New("文件链接无效")
+	ErrFileURLExpired = errors.New("文件链接已过期")
+	ErrFileURLViewer  = errors.New("文件链接不属于当前用户")
+)
+
+// 签名文件URL的查询参数
+const (
+	fileURLExpiresParam   = "expires"
+	fileURLViewerParam    = "viewer"
+	fileURLSignatureParam = "signature"
+)
+
+var (
+	fileURLSecret     []byte
+	fileURLBindViewer bool
+)
+
+// SetFileURLSigning 设置文件URL签名密钥，bindViewer为true时签发给登录用户的URL只能由该用户访问
+func SetFileURLSigning(secret string, bindViewer bool) {
+	fileURLSecret = []byte(secret)
+	fileURLBindViewer = bindViewer
+}
+
+// GetFileURL 获取文件URL
+// 返回由应用提供文件的签名地址，有效期为fileURLExpiry，viewerID不为0且开启了访问者绑定时仅该用户可以访问
+// 例如: resumes/1/2023_03/abc.jpg -> http://host/api/v1/files/resumes/1/2023_03/abc.jpg?expires=...&signature=...
+func GetFileURL(c *gin.Context, stored string, viewerID uint) string {
+	if stored == "" {
+		return ""
+	}
+	key, err := ParseStorageKey(stored)
+	if err != nil {
+		GetLogger().Warn("无效的文件存储键", zap.String("stored", stored))
+		return ""
+	}
+	if !fileURLBindViewer {
+		viewerID = 0
+	}
+
+	// 过期时间按有效期取整，同一时间段内生成的URL相同，便于浏览器缓存
+	expires := time.Now().Add(fileURLExpiry).Truncate(fileURLExpiry / 3).Add(fileURLExpiry / 3).Unix()
+
+	query := url.Values{}
+	query.Set(fileURLExpiresParam, strconv.FormatInt(expires, 10))
+	if viewerID != 0 {
+		query.Set(fileURLViewerParam, strconv.FormatUint(uint64(viewerID), 10))
+	}
+	query.Set(fileURLSignatureParam, signFileURL(key, expires, viewerID))
+
+	// 如果运行在Docker中，Host可能需要设置为外部访问地址
+	host := c.Request.Host
+
+	// 如果没有主机头，使用默认值
+	if host == "" {
+		host = "localhost:8080"
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+
+	fileURL := fmt.Sprintf("%s://%s/api/v1/files/%s?%s", scheme, host, (&url.URL{Path: key.String()}).EscapedPath(), query.Encode())
+
+	// 记录URL转换过程（方便调试）
+	GetLogger().Debug("文件URL生成",
+		zap.Stringer("key", key),
+		zap.String("fileURL", fileURL))
+
+	return fileURL
+}
+
+// VerifyFileURL 校验签名文件URL，返回文件的存储键和URL的过期时间
+// filePath为URL中/api/v1/files之后的路径，必须是规范的存储键；viewerID为当前登录用户，未登录时为0
+func VerifyFileURL(filePath string, query url.Values, viewerID uint) (StorageKey, time.Time, error) {
+	key := StorageKey(strings.TrimPrefix(filePath, "/"))
+	if err := key.Validate(); err != nil {
+		return "", time.Time{}, ErrFileURLInvalid
+	}
+
+	expires, err := strconv.ParseInt(query.Get(fileURLExpiresParam), 10, 64)
+	if err != nil {
+		return "", time.Time{}, ErrFileURLInvalid
+	}
+	var boundViewer uint
+	if v := query.Get(fileURLViewerParam); v != "" {
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil || id == 0 {
+			return "", time.Time{}, ErrFileURLInvalid
+		}
+		boundViewer = uint(id)
+	}
+
+	expected := signFileURL(key, expires, boundViewer)
+	if !hmac.Equal([]byte(expected), []byte(query.Get(fileURLSignatureParam))) {
+		return "", time.Time{}, ErrFileURLInvalid
+	}
+
+	expiresAt := time.Unix(expires, 0)
+	if time.Now().After(expiresAt) {
+		return "", time.Time{}, ErrFileURLExpired
+	}
+	if boundViewer != 0 && boundViewer != viewerID {
+		return "", time.Time{}, ErrFileURLViewer
+	}
+	return key, expiresAt, nil
+}
+
+// StorageRedirectURL 存储支持预签名时返回可直接访问文件的地址，否则返回空字符串由应用提供文件
+func StorageRedirectURL(ctx context.Context, key StorageKey, expires time.Duration) string {
+	fileURL, err := GetStorage().PresignedURL(ctx, key, expires)
+	if err != nil {
+		if !errors.Is(err, ErrPresignNotSupported) {
+			GetLogger().Warn("生成预签名URL失败", zap.Error(err), zap.Stringer("key", key))
+		}
+		return ""
+	}
+	return fileURL
+}
+
+// signFileURL 计算文件URL签名: HMAC-SHA256(secret, key + "\n" + expires + "\n" + viewerID)
+func signFileURL(key StorageKey, expires int64, viewerID uint) string {
+	mac := hmac.New(sha256.New, fileURLSecret)
+	fmt.Fprintf(mac, "%s\n%d\n%d", key, expires, viewerID)
+	return hex.EncodeToString(mac.Sum(nil))
+}
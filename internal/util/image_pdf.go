@@ -0,0 +1,145 @@
+package util
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"strings"
+)
+
+// ErrImageTooLarge 上传图片的像素尺寸超过限制
+var ErrImageTooLarge = errors.New("图片尺寸超过限制")
+
+// 图片上传转换为PDF时的页面尺寸（A4，pt）
+const (
+	imagePageWidth  = 595.0
+	imagePageHeight = 842.0
+)
+
+// maxUploadImagePixels 上传图片的最大像素数，解码前按图片头检查，避免解压炸弹
+const maxUploadImagePixels = 40 * 1000 * 1000
+
+// imageToPDF 将上传的PNG/JPEG图片包装为PDF，之后与PDF上传走相同的校验和渲染流程
+// 图片缩放到A4宽度，超过一页高度的长截图按A4高度分成多页
+func imageToPDF(imagePath, pdfPath string) error {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		return err
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ErrInvalidFileType
+	}
+	if cfg.Width < 1 || cfg.Height < 1 {
+		return ErrInvalidFileType
+	}
+	if cfg.Width*cfg.Height > maxUploadImagePixels {
+		return ErrImageTooLarge
+	}
+
+	stream, filter, colorSpace, err := pdfImageStream(data, format, cfg.ColorModel)
+	if err != nil {
+		return err
+	}
+
+	// 图片宽度对应A4宽度，按A4高度分页，最后一页只保留剩余部分
+	scale := imagePageWidth / float64(cfg.Width)
+	totalHeight := float64(cfg.Height) * scale
+	var pageHeights []float64
+	for offset := 0.0; offset < totalHeight-0.5; offset += imagePageHeight {
+		pageHeights = append(pageHeights, min(imagePageHeight, totalHeight-offset))
+	}
+
+	// 对象编号：1目录，2页面树，3图片，之后每页依次为页面和内容流
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // 页面树在确定页面编号后填写
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s /Length %d >>\nstream\n%s\nendstream",
+			cfg.Width, cfg.Height, colorSpace, filter, len(stream), stream),
+	}
+	var kids []string
+	offset := 0.0
+	for _, height := range pageHeights {
+		pageNum := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageNum))
+
+		// 整张图片放置在页面坐标中，当前页对应的部分落在页面范围内，超出部分被裁剪
+		y := height + offset - totalHeight
+		content := fmt.Sprintf("q 0 0 %.2f %.2f re W n %.4f 0 0 %.4f 0 %.4f cm /Im0 Do Q",
+			imagePageWidth, height, imagePageWidth, totalHeight, y)
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Contents %d 0 R /Resources << /XObject << /Im0 3 0 R >> >> >>",
+				imagePageWidth, height, pageNum+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		)
+		offset += height
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, o := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", o)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return os.WriteFile(pdfPath, b.Bytes(), 0644)
+}
+
+// pdfImageStream 生成图片XObject的数据流
+// RGB或灰度JPEG直接嵌入，其他图片解码后合成到白色背景上，按RGB压缩保存
+func pdfImageStream(data []byte, format string, model color.Model) (stream []byte, filter, colorSpace string, err error) {
+	if format == "jpeg" && (model == color.YCbCrModel || model == color.GrayModel) {
+		colorSpace = "DeviceRGB"
+		if model == color.GrayModel {
+			colorSpace = "DeviceGray"
+		}
+		return data, "DCTDecode", colorSpace, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", "", ErrInvalidFileType
+	}
+	if format == "jpeg" {
+		// CMYK等JPEG转为RGB重新编码，PDF中CMYK JPEG的颜色反转约定各阅读器不一致
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+			return nil, "", "", err
+		}
+		return buf.Bytes(), "DCTDecode", "DeviceRGB", nil
+	}
+
+	b := img.Bounds()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, b.Dx()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, a := img.At(x, y).RGBA()
+			// 预乘Alpha的颜色加上白色背景
+			white := 0xffff - a
+			i := (x - b.Min.X) * 3
+			row[i], row[i+1], row[i+2] = uint8((r+white)>>8), uint8((g+white)>>8), uint8((bl+white)>>8)
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, "", "", err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, "", "", err
+	}
+	return buf.Bytes(), "FlateDecode", "DeviceRGB", nil
+}
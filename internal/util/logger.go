@@ -2,6 +2,7 @@ package util
 
 import (
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -76,7 +77,7 @@ func LoggerMiddleware() gin.HandlerFunc {
 		// 开始时间
 		start := time.Now()
 		path := c.Request.URL.Path
-		query := c.Request.URL.RawQuery
+		query := redactQuery(c.Request.URL.RawQuery)
 
 		// 处理请求
 		c.Next()
@@ -113,6 +114,19 @@ func LoggerMiddleware() gin.HandlerFunc {
 	}
 }
 
+// redactQuery 隐藏查询参数中的访问令牌，避免写入日志
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil || !values.Has("access_token") {
+		return rawQuery
+	}
+	values.Set("access_token", "REDACTED")
+	return values.Encode()
+}
+
 // GinLogger 返回Gin的日志中间件 (为保持兼容性)
 func GinLogger() gin.HandlerFunc {
 	return LoggerMiddleware()
@@ -0,0 +1,90 @@
+package util
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// smtpMailer 基于SMTP的邮件发送实现
+type smtpMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+// logMailer 未配置SMTP时使用，仅将邮件内容写入日志
+type logMailer struct{}
+
+// NewMailer 创建邮件发送器，未配置SMTP服务器时退化为日志输出
+func NewMailer(host string, port int, username, password, from string) Mailer {
+	if host == "" || from == "" {
+		GetLogger().Warn("未配置SMTP服务器，邮件将仅记录到日志")
+		return &logMailer{}
+	}
+	return &smtpMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Send 发送纯文本邮件
+func (m *smtpMailer) Send(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+
+	headers := []string{
+		"From: " + m.from,
+		"To: " + to,
+		"Subject: " + mime.BEncoding.Encode("UTF-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+	msg := strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+
+	addr := fmt.Sprintf("%s:%d", m.host, m.port)
+	if err := smtp.SendMail(addr, auth, m.from, []string{to}, []byte(msg)); err != nil {
+		GetLogger().Error("发送邮件失败", zap.Error(err), zap.String("to", to))
+		return err
+	}
+	return nil
+}
+
+// Send 将邮件内容写入日志
+func (m *logMailer) Send(to, subject, body string) error {
+	GetLogger().Info("模拟发送邮件",
+		zap.String("to", to),
+		zap.String("subject", subject),
+		zap.String("body", body))
+	return nil
+}
+
+// SendMailAsync 异步发送邮件，失败时仅记录日志
+func SendMailAsync(mailer Mailer, to, subject, body string) {
+	if mailer == nil || to == "" {
+		return
+	}
+	go func() {
+		if err := mailer.Send(to, subject, body); err != nil {
+			GetLogger().Warn("异步发送邮件失败", zap.Error(err), zap.String("to", to))
+		}
+	}()
+}
@@ -0,0 +1,443 @@
+package util
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg" // 解码pdftoppm输出的JPEG页面
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// 页面差异状态
+const (
+	PageUnchanged = "unchanged" // 页面无变化
+	PageChanged   = "changed"   // 页面有变化
+	PageAdded     = "added"     // 新版本新增的页面
+	PageRemoved   = "removed"   // 新版本删除的页面
+)
+
+// 图片差异参数
+const (
+	// diffCellSize 比较网格的单元格边长（像素），以单元格为单位标记变化区域
+	diffCellSize = 12
+	// diffPixelThreshold 像素RGB差值之和超过该值视为不同，用于忽略JPEG压缩噪声
+	diffPixelThreshold = 96
+	// diffCellRatio 单元格内不同像素占比超过该值视为变化
+	diffCellRatio = 0.02
+	// maxTextDiffCells 文本差异计算的最大规模（行数乘积），超过时整体视为替换
+	maxTextDiffCells = 4000000
+)
+
+// highlightColor 变化区域的高亮颜色
+var highlightColor = color.RGBA{R: 255, G: 48, B: 48, A: 255}
+
+// PageDiff 单页图片差异
+type PageDiff struct {
+	Page      int               `json:"page"`       // 页码，从1开始
+	Status    string            `json:"status"`     // 差异状态
+	ImagePath string            `json:"image_path"` // 高亮变化区域的图片路径，页面无变化时为空
+	Regions   []image.Rectangle `json:"regions"`    // 变化区域，坐标基于新版本页面
+}
+
+// ExtractPDFText 使用pdftotext提取PDF文本，保留版面布局
+func ExtractPDFText(pdfPath string) (string, error) {
+	if _, err := os.Stat(pdfPath); os.IsNotExist(err) {
+		return "", ErrFileNotFound
+	}
+	if _, err := exec.LookPath("pdftotext"); err != nil {
+		return "", ErrCommandNotFound
+	}
+
+	out, err := runConverter("pdftotext", "-layout", "-enc", "UTF-8", pdfPath, "-")
+	if err != nil {
+		GetLogger().Error("提取PDF文本失败", zap.Error(err), zap.String("path", pdfPath))
+		if isConvertLimitError(err) {
+			return "", err
+		}
+		return "", ErrConvertPDFFailed
+	}
+	return string(out), nil
+}
+
+// DiffPDFPages 逐页渲染两个PDF并比较，变化区域高亮后以PNG写入outDir
+func DiffPDFPages(fromPDF, toPDF, outDir string) ([]PageDiff, error) {
+	fromDir := filepath.Join(outDir, "from")
+	toDir := filepath.Join(outDir, "to")
+	for _, dir := range []string{fromDir, toDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	// 渲染出的原始页面只在比较时使用
+	defer os.RemoveAll(fromDir)
+	defer os.RemoveAll(toDir)
+
+	fromPages, err := RenderPDFPages(fromPDF, fromDir)
+	if err != nil {
+		return nil, err
+	}
+	toPages, err := RenderPDFPages(toPDF, toDir)
+	if err != nil {
+		return nil, err
+	}
+
+	pageCount := len(fromPages)
+	if len(toPages) > pageCount {
+		pageCount = len(toPages)
+	}
+
+	diffs := make([]PageDiff, 0, pageCount)
+	for i := 0; i < pageCount; i++ {
+		var fromImg, toImg image.Image
+		if i < len(fromPages) {
+			if fromImg, err = decodeImageFile(fromPages[i]); err != nil {
+				return nil, err
+			}
+		}
+		if i < len(toPages) {
+			if toImg, err = decodeImageFile(toPages[i]); err != nil {
+				return nil, err
+			}
+		}
+
+		diff := PageDiff{Page: i + 1, Regions: []image.Rectangle{}}
+		var base image.Image
+		switch {
+		case fromImg == nil:
+			diff.Status = PageAdded
+			diff.Regions = append(diff.Regions, toImg.Bounds())
+			base = toImg
+		case toImg == nil:
+			diff.Status = PageRemoved
+			diff.Regions = append(diff.Regions, fromImg.Bounds())
+			base = fromImg
+		default:
+			diff.Regions = DiffImages(fromImg, toImg)
+			diff.Status = PageChanged
+			if len(diff.Regions) == 0 {
+				diff.Status = PageUnchanged
+			}
+			base = toImg
+		}
+
+		if diff.Status != PageUnchanged {
+			diff.ImagePath = filepath.Join(outDir, fmt.Sprintf("page-%03d.png", i+1))
+			if err := writePNG(diff.ImagePath, HighlightRegions(base, diff.Regions)); err != nil {
+				return nil, err
+			}
+		}
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// DiffImages 按网格比较两张图片，返回合并后的变化区域
+func DiffImages(a, b image.Image) []image.Rectangle {
+	bounds := a.Bounds().Union(b.Bounds())
+	cols := (bounds.Dx() + diffCellSize - 1) / diffCellSize
+	rows := (bounds.Dy() + diffCellSize - 1) / diffCellSize
+
+	changed := make([][]bool, rows)
+	for row := 0; row < rows; row++ {
+		changed[row] = make([]bool, cols)
+		for col := 0; col < cols; col++ {
+			cell := image.Rect(
+				bounds.Min.X+col*diffCellSize,
+				bounds.Min.Y+row*diffCellSize,
+				bounds.Min.X+(col+1)*diffCellSize,
+				bounds.Min.Y+(row+1)*diffCellSize,
+			).Intersect(bounds)
+			changed[row][col] = cellChanged(a, b, cell)
+		}
+	}
+
+	return mergeCells(changed, bounds.Min)
+}
+
+// cellChanged 判断单元格内不同像素的占比是否超过阈值
+func cellChanged(a, b image.Image, cell image.Rectangle) bool {
+	diff := 0
+	limit := int(float64(cell.Dx()*cell.Dy()) * diffCellRatio)
+	for y := cell.Min.Y; y < cell.Max.Y; y++ {
+		for x := cell.Min.X; x < cell.Max.X; x++ {
+			if pixelDistance(a, b, x, y) > diffPixelThreshold {
+				diff++
+				if diff > limit {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// pixelDistance 计算两张图片同一位置像素的RGB差值之和，超出图片范围的像素视为白色
+func pixelDistance(a, b image.Image, x, y int) int {
+	ar, ag, ab := pixelRGB(a, x, y)
+	br, bg, bb := pixelRGB(b, x, y)
+	return absInt(ar-br) + absInt(ag-bg) + absInt(ab-bb)
+}
+
+// pixelRGB 获取像素的8位RGB值
+func pixelRGB(img image.Image, x, y int) (int, int, int) {
+	if !(image.Point{X: x, Y: y}).In(img.Bounds()) {
+		return 255, 255, 255
+	}
+	r, g, b, _ := img.At(x, y).RGBA()
+	return int(r >> 8), int(g >> 8), int(b >> 8)
+}
+
+// mergeCells 将相邻的变化单元格合并为矩形区域
+func mergeCells(changed [][]bool, origin image.Point) []image.Rectangle {
+	regions := []image.Rectangle{}
+	if len(changed) == 0 {
+		return regions
+	}
+
+	rows, cols := len(changed), len(changed[0])
+	visited := make([][]bool, rows)
+	for i := range visited {
+		visited[i] = make([]bool, cols)
+	}
+
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			if !changed[row][col] || visited[row][col] {
+				continue
+			}
+
+			// 广度优先遍历相连的单元格，取外接矩形
+			minRow, maxRow, minCol, maxCol := row, row, col, col
+			queue := []image.Point{{X: col, Y: row}}
+			visited[row][col] = true
+			for len(queue) > 0 {
+				p := queue[0]
+				queue = queue[1:]
+				minRow, maxRow = min(minRow, p.Y), max(maxRow, p.Y)
+				minCol, maxCol = min(minCol, p.X), max(maxCol, p.X)
+
+				for _, d := range []image.Point{{X: 1}, {X: -1}, {Y: 1}, {Y: -1}} {
+					n := p.Add(d)
+					if n.X < 0 || n.Y < 0 || n.X >= cols || n.Y >= rows {
+						continue
+					}
+					if changed[n.Y][n.X] && !visited[n.Y][n.X] {
+						visited[n.Y][n.X] = true
+						queue = append(queue, n)
+					}
+				}
+			}
+
+			regions = append(regions, image.Rect(
+				origin.X+minCol*diffCellSize,
+				origin.Y+minRow*diffCellSize,
+				origin.X+(maxCol+1)*diffCellSize,
+				origin.Y+(maxRow+1)*diffCellSize,
+			))
+		}
+	}
+	return regions
+}
+
+// HighlightRegions 在图片上以半透明底色和边框标出变化区域
+func HighlightRegions(img image.Image, regions []image.Rectangle) *image.RGBA {
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+
+	fill := image.NewUniform(color.NRGBA{R: highlightColor.R, G: highlightColor.G, B: highlightColor.B, A: 64})
+	border := image.NewUniform(highlightColor)
+	for _, r := range regions {
+		r = r.Intersect(bounds)
+		draw.Draw(out, r, fill, image.Point{}, draw.Over)
+
+		// 2像素边框
+		for _, edge := range []image.Rectangle{
+			image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+2),
+			image.Rect(r.Min.X, r.Max.Y-2, r.Max.X, r.Max.Y),
+			image.Rect(r.Min.X, r.Min.Y, r.Min.X+2, r.Max.Y),
+			image.Rect(r.Max.X-2, r.Min.Y, r.Max.X, r.Max.Y),
+		} {
+			draw.Draw(out, edge.Intersect(r), border, image.Point{}, draw.Src)
+		}
+	}
+	return out
+}
+
+// UnifiedDiff 生成两段文本的统一格式差异，context为变化行前后保留的上下文行数
+func UnifiedDiff(fromName, toName, from, to string, context int) string {
+	a := splitLines(from)
+	b := splitLines(to)
+	ops := diffLines(a, b)
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", fromName, toName)
+
+	for start := 0; start < len(ops); {
+		// 找到下一处变化
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start >= len(ops) {
+			break
+		}
+
+		// 向后扩展，直到连续相同的行超过两倍上下文
+		end := start
+		for i := start; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*context {
+				break
+			}
+		}
+
+		hunkStart := max(start-context, 0)
+		hunkEnd := min(end+context, len(ops))
+		hunk := ops[hunkStart:hunkEnd]
+
+		aStart, bStart := hunk[0].aLine, hunk[0].bLine
+		aCount, bCount := 0, 0
+		for _, op := range hunk {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, op := range hunk {
+			buf.WriteByte(op.kind)
+			buf.WriteString(op.text)
+			buf.WriteByte('\n')
+		}
+
+		start = hunkEnd
+	}
+
+	return buf.String()
+}
+
+// diffOp 一行差异，kind为' '、'-'或'+'
+type diffOp struct {
+	kind  byte
+	text  string
+	aLine int // 该行在原文中的行号（从1开始），新增行为插入位置
+	bLine int // 该行在新文本中的行号（从1开始），删除行为插入位置
+}
+
+// diffLines 基于最长公共子序列计算逐行差异
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+
+	// 规模过大时不做精细比较，整体视为替换
+	if n*m > maxTextDiffCells {
+		ops := make([]diffOp, 0, n+m)
+		for i, line := range a {
+			ops = append(ops, diffOp{kind: '-', text: line, aLine: i + 1, bLine: 1})
+		}
+		for j, line := range b {
+			ops = append(ops, diffOp{kind: '+', text: line, aLine: n + 1, bLine: j + 1})
+		}
+		return ops
+	}
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', text: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', text: a[i], aLine: i + 1, bLine: j + 1})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', text: b[j], aLine: i + 1, bLine: j + 1})
+			j++
+		}
+	}
+	return ops
+}
+
+// hunkRange 格式化差异块的行范围
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines 按行拆分文本，去除行尾空白和分页符
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\f", "\n")
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return lines
+}
+
+// decodeImageFile 读取并解码图片文件
+func decodeImageFile(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("解码图片失败 %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// writePNG 将图片以PNG格式写入文件
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
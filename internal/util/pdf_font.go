@@ -0,0 +1,267 @@
+package util
+
+import (
+	"encoding/hex"
+	"strings"
+	"unicode/utf16"
+)
+
+// 纯Go渲染器使用的字体处理：解析字体字典得到字符宽度和Unicode映射，
+// 字形使用内置的5x8点阵字体绘制，只覆盖可打印ASCII字符，其他字符绘制为方框
+
+// bitmapGlyphs 可打印ASCII字符（0x20-0x7E）的点阵，每个字符8行，每行一个字节，低5位从左到右为像素，
+// 前7行位于基线之上，最后一行为下伸部分
+var bitmapGlyphs = func() [][8]byte {
+	const table = "" +
+		"0000000000000000 0404040404000400 0a0a000000000000 0a0a1f0a1f0a0a00 040f140e051e0400 1819020408130300 0c12140815120d00 0404000000000000 " +
+		"0204080808040200 0804020202040800 0004150e15040000 0004041f04040000 0000000000040408 0000001f00000000 00000000000c0c00 0001020408100000 " +
+		"0e11131519110e00 040c040404040e00 0e11010204081f00 1f02040201110e00 02060a121f020200 1f101e0101110e00 0608101e11110e00 1f01020408080800 " +
+		"0e11110e11110e00 0e11110f01020c00 000c0c000c0c0000 000c0c000c040800 0204081008040200 00001f001f000000 0804020102040800 0e11010204000400 " +
+		"0e11010d15150e00 0e11111f11111100 1e11111e11111e00 0e11101010110e00 1c12111111121c00 1f10101e10101f00 1f10101e10101000 0e11101711110f00 " +
+		"1111111f11111100 0e04040404040e00 0702020202120c00 1112141814121100 1010101010101f00 111b151511111100 1111191513111100 0e11111111110e00 " +
+		"1e11111e10101000 0e11111115120d00 1e11111e14121100 0f10100e01011e00 1f04040404040400 1111111111110e00 11111111110a0400 1111111515150a00 " +
+		"11110a040a111100 1111110a04040400 1f01020408101f00 0e08080808080e00 0010080402010000 0e02020202020e00 040a110000000000 0000000000001f00 " +
+		"0804000000000000 00000e010f110f00 1010161911111e00 00000e1010110e00 01010d1311110f00 00000e111f100e00 0609081c08080800 00000f11110f010e " +
+		"1010161911111100 04000c0404040e00 020006020202120c 1010121418141200 0c04040404040e00 00001a1515111100 0000161911111100 00000e1111110e00 " +
+		"00001e11111e1010 00000f11110f0101 0000161910101000 00000e100e011e00 08081c0808090600 0000111111130d00 00001111110a0400 0000111115150a00 " +
+		"0000110a040a1100 00001111110f010e 00001f0204081f00 0204040804040200 0404040404040400 0804040204040800 0000081502000000"
+
+	fields := strings.Fields(table)
+	glyphs := make([][8]byte, len(fields))
+	for i, f := range fields {
+		b, err := hex.DecodeString(f)
+		if err != nil || len(b) != 8 {
+			panic("invalid bitmap glyph: " + f)
+		}
+		copy(glyphs[i][:], b)
+	}
+	return glyphs
+}()
+
+// bitmapGlyph 返回字符的点阵，不在点阵字体中时返回false
+func bitmapGlyph(r rune) ([8]byte, bool) {
+	if r < 0x20 || int(r-0x20) >= len(bitmapGlyphs) {
+		return [8]byte{}, false
+	}
+	return bitmapGlyphs[r-0x20], true
+}
+
+// pdfGlyph 字符串中的一个字符
+type pdfGlyph struct {
+	code  int     // 字符编码
+	text  string  // 对应的Unicode文本，未知时为空
+	width float64 // 字宽，千分之一字号
+	space bool    // 单字节编码的空格，应用字间距Tw
+}
+
+// pdfFont 渲染需要的字体信息
+type pdfFont struct {
+	twoByte      bool // Type0字体使用双字节编码
+	firstChar    int
+	widths       []float64
+	cidWidths    map[int]float64
+	defaultWidth float64
+	toUnicode    map[int]string
+}
+
+// loadFont 解析字体字典
+func (d *pdfDocument) loadFont(obj interface{}) *pdfFont {
+	font := &pdfFont{defaultWidth: 500}
+	dict, err := d.resolveDict(obj)
+	if err != nil || dict == nil {
+		return font
+	}
+
+	if dict["Subtype"] == pdfName("Type0") {
+		font.twoByte = true
+		font.defaultWidth = 1000
+		if descendants, err := d.resolve(dict["DescendantFonts"]); err == nil {
+			if arr, ok := descendants.(pdfArray); ok && len(arr) > 0 {
+				if cid, err := d.resolveDict(arr[0]); err == nil && cid != nil {
+					d.loadCIDWidths(font, cid)
+				}
+			}
+		}
+	} else {
+		if base, ok := dict["BaseFont"].(pdfName); ok && strings.Contains(string(base), "Courier") {
+			font.defaultWidth = 600
+		}
+		font.firstChar = pdfIntDefault(dict["FirstChar"], 0)
+		if widths, err := d.resolve(dict["Widths"]); err == nil {
+			if arr, ok := widths.(pdfArray); ok {
+				for _, w := range arr {
+					w, _ = d.resolve(w)
+					v, _ := pdfNumber(w)
+					font.widths = append(font.widths, v)
+				}
+			}
+		}
+	}
+
+	if stream, err := d.resolve(dict["ToUnicode"]); err == nil {
+		if s, ok := stream.(*pdfStream); ok {
+			if data, err := d.decodeStream(s); err == nil {
+				font.toUnicode = parseToUnicode(data)
+			}
+		}
+	}
+	return font
+}
+
+// loadCIDWidths 解析CID字体的/DW和/W
+func (d *pdfDocument) loadCIDWidths(font *pdfFont, cid pdfDict) {
+	if dw, err := d.resolve(cid["DW"]); err == nil {
+		if v, ok := pdfNumber(dw); ok {
+			font.defaultWidth = v
+		}
+	}
+	obj, err := d.resolve(cid["W"])
+	if err != nil {
+		return
+	}
+	arr, ok := obj.(pdfArray)
+	if !ok {
+		return
+	}
+	font.cidWidths = make(map[int]float64)
+	for i := 0; i < len(arr); {
+		first, ok := pdfInt(arr[i])
+		if !ok || i+1 >= len(arr) {
+			return
+		}
+		next, _ := d.resolve(arr[i+1])
+		if list, ok := next.(pdfArray); ok {
+			// c [w1 w2 ...]
+			for j, w := range list {
+				w, _ = d.resolve(w)
+				if v, ok := pdfNumber(w); ok {
+					font.cidWidths[first+j] = v
+				}
+			}
+			i += 2
+			continue
+		}
+		// cfirst clast w
+		if i+2 >= len(arr) {
+			return
+		}
+		last, ok1 := pdfInt(next)
+		w, ok2 := pdfNumber(arr[i+2])
+		if !ok1 || !ok2 || last < first || last-first > 0xFFFF {
+			return
+		}
+		for c := first; c <= last; c++ {
+			font.cidWidths[c] = w
+		}
+		i += 3
+	}
+}
+
+// decode 将字符串拆分为字符
+func (f *pdfFont) decode(s pdfString) []pdfGlyph {
+	var glyphs []pdfGlyph
+	step := 1
+	if f.twoByte {
+		step = 2
+	}
+	for i := 0; i+step <= len(s); i += step {
+		code := int(s[i])
+		if f.twoByte {
+			code = code<<8 | int(s[i+1])
+		}
+		g := pdfGlyph{code: code, width: f.width(code), space: !f.twoByte && code == 32}
+		if text, ok := f.toUnicode[code]; ok {
+			g.text = text
+		} else if !f.twoByte {
+			// 没有ToUnicode时按Latin-1近似处理单字节编码
+			g.text = string(rune(code))
+		}
+		glyphs = append(glyphs, g)
+	}
+	return glyphs
+}
+
+// width 字符宽度，千分之一字号
+func (f *pdfFont) width(code int) float64 {
+	if f.twoByte {
+		if w, ok := f.cidWidths[code]; ok {
+			return w
+		}
+		return f.defaultWidth
+	}
+	if i := code - f.firstChar; i >= 0 && i < len(f.widths) && f.widths[i] > 0 {
+		return f.widths[i]
+	}
+	return f.defaultWidth
+}
+
+// parseToUnicode 解析ToUnicode CMap中的bfchar和bfrange
+func parseToUnicode(data []byte) map[int]string {
+	m := make(map[int]string)
+	r := &pdfContentReader{parser: pdfParser{lexer: &pdfLexer{data: data}}}
+	for {
+		op, operands, err := r.next()
+		if err != nil {
+			return m
+		}
+		switch op {
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].(pdfString)
+				dst, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					m[cmapCode(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 {
+					continue
+				}
+				first, last := cmapCode(lo), cmapCode(hi)
+				if last < first || last-first > 0xFFFF {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case pdfString:
+					// 目标为起始值，范围内依次递增最后一个字符
+					base := []rune(utf16BE(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for c := first; c <= last; c++ {
+						text := append([]rune(nil), base...)
+						text[len(text)-1] += rune(c - first)
+						m[c] = string(text)
+					}
+				case pdfArray:
+					for j, item := range dst {
+						if s, ok := item.(pdfString); ok && first+j <= last {
+							m[first+j] = utf16BE(s)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// cmapCode 将CMap中的源编码转换为整数
+func cmapCode(s pdfString) int {
+	code := 0
+	for i := 0; i < len(s) && i < 4; i++ {
+		code = code<<8 | int(s[i])
+	}
+	return code
+}
+
+// utf16BE 将UTF-16BE字符串转换为UTF-8
+func utf16BE(s pdfString) string {
+	units := make([]uint16, 0, len(s)/2)
+	for i := 0; i+1 < len(s); i += 2 {
+		units = append(units, uint16(s[i])<<8|uint16(s[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
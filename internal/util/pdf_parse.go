@@ -0,0 +1,1264 @@
+package util
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// 纯Go实现的PDF结构解析，用于在交给外部转换工具之前检查上传的文件
+// 只解析文件结构（交叉引用表、对象、页面树），不执行文件中的任何内容
+
+// PDF解析限制，防止构造的文件耗尽资源
+const (
+	pdfMaxNesting       = 64               // 数组、字典最大嵌套层数
+	pdfMaxRefChain      = 32               // 间接引用最大链长
+	pdfMaxPageTreeDepth = 32               // 页面树最大深度
+	pdfMaxDecodedStream = 64 * 1024 * 1024 // 单个流解压后的最大字节数
+	pdfHeaderSearch     = 1024             // 文件头%PDF-允许出现的范围
+)
+
+// errPDFSyntax PDF语法错误
+var errPDFSyntax = errors.New("PDF语法错误")
+
+// pdfName PDF名称对象，如 /Type
+type pdfName string
+
+// pdfString PDF字符串对象，内容为原始字节
+type pdfString string
+
+// pdfRef PDF间接引用，如 12 0 R
+type pdfRef struct {
+	Num int
+	Gen int
+}
+
+// pdfArray PDF数组对象
+type pdfArray []interface{}
+
+// pdfDict PDF字典对象
+type pdfDict map[pdfName]interface{}
+
+// pdfStream PDF流对象，Raw为未解码的数据
+type pdfStream struct {
+	Dict pdfDict
+	Raw  []byte
+}
+
+// pdfXref 交叉引用表项
+type pdfXref struct {
+	compressed bool // 是否保存在对象流中
+	offset     int  // 非压缩对象在文件中的偏移
+	stream     int  // 压缩对象所在对象流的编号
+	index      int  // 压缩对象在对象流中的序号
+}
+
+// pdfObjStm 已解析的对象流
+type pdfObjStm struct {
+	data    []byte
+	first   int
+	header  []int // 对象编号与偏移交替排列
+	offsets []int
+}
+
+// pdfRect PDF矩形，单位为pt
+type pdfRect struct {
+	X0, Y0, X1, Y1 float64
+}
+
+// Width 矩形宽度
+func (r pdfRect) Width() float64 { return r.X1 - r.X0 }
+
+// Height 矩形高度
+func (r pdfRect) Height() float64 { return r.Y1 - r.Y0 }
+
+// pdfPage PDF页面，继承的属性已合并
+type pdfPage struct {
+	Dict      pdfDict
+	MediaBox  pdfRect
+	CropBox   pdfRect // 页面可见区域，未设置时与MediaBox相同
+	Rotate    int
+	Resources pdfDict
+}
+
+// pdfDocument 已解析交叉引用表的PDF文件，对象按需解析
+type pdfDocument struct {
+	data    []byte
+	version string
+	xref    map[int]pdfXref
+	trailer pdfDict
+
+	objects   map[int]interface{}
+	objStms   map[int]*pdfObjStm
+	resolving map[int]bool
+}
+
+// parsePDF 解析PDF文件头和交叉引用表，交叉引用表损坏时扫描全文重建
+func parsePDF(data []byte) (*pdfDocument, error) {
+	head := data
+	if len(head) > pdfHeaderSearch {
+		head = head[:pdfHeaderSearch]
+	}
+	start := bytes.Index(head, []byte("%PDF-"))
+	if start < 0 {
+		return nil, ErrInvalidFileType
+	}
+
+	d := &pdfDocument{
+		data:      data,
+		objects:   make(map[int]interface{}),
+		objStms:   make(map[int]*pdfObjStm),
+		resolving: make(map[int]bool),
+	}
+	if v := pdfVersionPattern.FindSubmatch(data[start:]); v != nil {
+		d.version = string(v[1])
+	}
+
+	if err := d.readXref(); err != nil || d.trailer["Root"] == nil {
+		if repairErr := d.repairXref(); repairErr != nil {
+			if err == nil {
+				err = repairErr
+			}
+			return nil, fmt.Errorf("交叉引用表损坏: %w", err)
+		}
+	}
+	return d, nil
+}
+
+// pdfVersionPattern 文件头中的版本号
+var pdfVersionPattern = regexp.MustCompile(`^%PDF-(\d\.\d)`)
+
+// readXref 从startxref开始读取交叉引用表，依次合并/Prev指向的旧版本
+func (d *pdfDocument) readXref() error {
+	pos := bytes.LastIndex(d.data, []byte("startxref"))
+	if pos < 0 {
+		return fmt.Errorf("%w: 缺少startxref", errPDFSyntax)
+	}
+	l := &pdfLexer{data: d.data, pos: pos + len("startxref")}
+	offset, err := l.readInt()
+	if err != nil {
+		return err
+	}
+
+	d.xref = make(map[int]pdfXref)
+	d.trailer = nil
+	visited := make(map[int]bool)
+	for offset > 0 {
+		if visited[offset] || offset >= len(d.data) {
+			return fmt.Errorf("%w: 无效的交叉引用表偏移 %d", errPDFSyntax, offset)
+		}
+		visited[offset] = true
+
+		trailer, err := d.readXrefSection(offset)
+		if err != nil {
+			return err
+		}
+		if d.trailer == nil {
+			d.trailer = trailer
+		} else {
+			for k, v := range trailer {
+				if _, ok := d.trailer[k]; !ok {
+					d.trailer[k] = v
+				}
+			}
+		}
+
+		// 混合型文件中的交叉引用流
+		if stm, ok := pdfInt(trailer["XRefStm"]); ok && !visited[stm] {
+			visited[stm] = true
+			if _, err := d.readXrefSection(stm); err != nil {
+				return err
+			}
+		}
+
+		offset, _ = pdfInt(trailer["Prev"])
+	}
+	return nil
+}
+
+// readXrefSection 读取一段交叉引用表或交叉引用流，已存在的表项（更新的版本）不会被覆盖
+func (d *pdfDocument) readXrefSection(offset int) (pdfDict, error) {
+	l := &pdfLexer{data: d.data, pos: offset}
+	l.skipSpace()
+	if l.hasKeyword("xref") {
+		l.pos += len("xref")
+		return d.readXrefTable(l)
+	}
+
+	// 交叉引用流
+	_, _, obj, err := d.parseIndirect(offset)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := obj.(*pdfStream)
+	if !ok || stream.Dict["Type"] != pdfName("XRef") {
+		return nil, fmt.Errorf("%w: 偏移 %d 处不是交叉引用表", errPDFSyntax, offset)
+	}
+	if err := d.readXrefStream(stream); err != nil {
+		return nil, err
+	}
+	return stream.Dict, nil
+}
+
+// readXrefTable 读取传统交叉引用表及其后的trailer字典
+func (d *pdfDocument) readXrefTable(l *pdfLexer) (pdfDict, error) {
+	for {
+		l.skipSpace()
+		if l.hasKeyword("trailer") {
+			l.pos += len("trailer")
+			obj, err := (&pdfParser{lexer: l}).parseObject(0)
+			if err != nil {
+				return nil, err
+			}
+			trailer, ok := obj.(pdfDict)
+			if !ok {
+				return nil, fmt.Errorf("%w: trailer不是字典", errPDFSyntax)
+			}
+			return trailer, nil
+		}
+
+		first, err := l.readInt()
+		if err != nil {
+			return nil, err
+		}
+		count, err := l.readInt()
+		if err != nil {
+			return nil, err
+		}
+		// 每个表项20字节，数量不可能超过文件长度
+		if first < 0 || count < 0 || count > len(d.data)/20+1 {
+			return nil, fmt.Errorf("%w: 无效的交叉引用表项数量", errPDFSyntax)
+		}
+		for i := 0; i < count; i++ {
+			offset, err := l.readInt()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := l.readInt(); err != nil {
+				return nil, err
+			}
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return nil, io.ErrUnexpectedEOF
+			}
+			kind := l.data[l.pos]
+			l.pos++
+			if kind != 'n' && kind != 'f' {
+				return nil, fmt.Errorf("%w: 无效的交叉引用表项", errPDFSyntax)
+			}
+			if _, exists := d.xref[first+i]; !exists {
+				if kind == 'n' {
+					d.xref[first+i] = pdfXref{offset: offset}
+				} else {
+					d.xref[first+i] = pdfXref{offset: -1}
+				}
+			}
+		}
+	}
+}
+
+// readXrefStream 读取交叉引用流中的表项
+func (d *pdfDocument) readXrefStream(stream *pdfStream) error {
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return err
+	}
+
+	w, ok := stream.Dict["W"].(pdfArray)
+	if !ok || len(w) != 3 {
+		return fmt.Errorf("%w: 交叉引用流缺少/W", errPDFSyntax)
+	}
+	var widths [3]int
+	entryLen := 0
+	for i := range widths {
+		n, ok := pdfInt(w[i])
+		if !ok || n < 0 || n > 8 {
+			return fmt.Errorf("%w: 无效的交叉引用流/W", errPDFSyntax)
+		}
+		widths[i] = n
+		entryLen += n
+	}
+	if entryLen == 0 {
+		return fmt.Errorf("%w: 无效的交叉引用流/W", errPDFSyntax)
+	}
+
+	size, _ := pdfInt(stream.Dict["Size"])
+	index := pdfArray{0, size}
+	if arr, ok := stream.Dict["Index"].(pdfArray); ok {
+		index = arr
+	}
+
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		first, ok1 := pdfInt(index[i])
+		count, ok2 := pdfInt(index[i+1])
+		if !ok1 || !ok2 || first < 0 || count < 0 {
+			return fmt.Errorf("%w: 无效的交叉引用流/Index", errPDFSyntax)
+		}
+		for j := 0; j < count; j++ {
+			if pos+entryLen > len(data) {
+				return fmt.Errorf("%w: 交叉引用流数据不完整", errPDFSyntax)
+			}
+			var fields [3]int
+			for k, n := range widths {
+				for b := 0; b < n; b++ {
+					fields[k] = fields[k]<<8 | int(data[pos])
+					pos++
+				}
+			}
+			// 类型字段宽度为0时默认为1
+			if widths[0] == 0 {
+				fields[0] = 1
+			}
+
+			num := first + j
+			if _, exists := d.xref[num]; exists {
+				continue
+			}
+			switch fields[0] {
+			case 0:
+				d.xref[num] = pdfXref{offset: -1}
+			case 1:
+				d.xref[num] = pdfXref{offset: fields[1]}
+			case 2:
+				d.xref[num] = pdfXref{compressed: true, stream: fields[1], index: fields[2]}
+			}
+		}
+	}
+	return nil
+}
+
+// pdfObjectPattern 扫描重建交叉引用表时匹配对象开头
+var pdfObjectPattern = regexp.MustCompile(`(?:^|[\r\n\s])(\d{1,10})\s+(\d{1,5})\s+obj\b`)
+
+// repairXref 交叉引用表损坏时扫描全文重建，与常见阅读器的修复行为一致
+func (d *pdfDocument) repairXref() error {
+	d.xref = make(map[int]pdfXref)
+	d.trailer = nil
+	d.objects = make(map[int]interface{})
+	d.objStms = make(map[int]*pdfObjStm)
+
+	for _, m := range pdfObjectPattern.FindAllSubmatchIndex(d.data, -1) {
+		num, err := strconv.Atoi(string(d.data[m[2]:m[3]]))
+		if err != nil {
+			continue
+		}
+		// 同一对象出现多次时以最后一次（增量更新）为准
+		d.xref[num] = pdfXref{offset: m[2]}
+	}
+	if len(d.xref) == 0 {
+		return fmt.Errorf("%w: 未找到任何对象", errPDFSyntax)
+	}
+
+	// 最后一个trailer字典
+	if pos := bytes.LastIndex(d.data, []byte("trailer")); pos >= 0 {
+		l := &pdfLexer{data: d.data, pos: pos + len("trailer")}
+		if obj, err := (&pdfParser{lexer: l}).parseObject(0); err == nil {
+			if trailer, ok := obj.(pdfDict); ok {
+				d.trailer = trailer
+			}
+		}
+	}
+	if d.trailer == nil {
+		d.trailer = pdfDict{}
+	}
+
+	// 对象流中的对象，以及没有trailer时从交叉引用流或文档目录中找到根对象
+	nums := make([]int, 0, len(d.xref))
+	for num := range d.xref {
+		nums = append(nums, num)
+	}
+	for _, num := range nums {
+		obj, err := d.object(num)
+		if err != nil {
+			continue
+		}
+		switch o := obj.(type) {
+		case *pdfStream:
+			switch o.Dict["Type"] {
+			case pdfName("ObjStm"):
+				stm, err := d.objStm(num)
+				if err != nil {
+					continue
+				}
+				for i := range stm.offsets {
+					if _, exists := d.xref[stm.nums(i)]; !exists {
+						d.xref[stm.nums(i)] = pdfXref{compressed: true, stream: num, index: i}
+					}
+				}
+			case pdfName("XRef"):
+				if d.trailer["Root"] == nil && o.Dict["Root"] != nil {
+					d.trailer["Root"] = o.Dict["Root"]
+				}
+				if d.trailer["Encrypt"] == nil && o.Dict["Encrypt"] != nil {
+					d.trailer["Encrypt"] = o.Dict["Encrypt"]
+				}
+			}
+		}
+	}
+	if d.trailer["Root"] == nil {
+		for num := range d.xref {
+			obj, err := d.object(num)
+			if err != nil {
+				continue
+			}
+			if dict, ok := obj.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+				d.trailer["Root"] = pdfRef{Num: num}
+				break
+			}
+		}
+	}
+	if d.trailer["Root"] == nil {
+		return fmt.Errorf("%w: 未找到文档目录", errPDFSyntax)
+	}
+	return nil
+}
+
+// encrypted 文件是否加密
+func (d *pdfDocument) encrypted() bool {
+	v, ok := d.trailer["Encrypt"]
+	return ok && v != nil
+}
+
+// resolve 解析间接引用，非引用对象原样返回
+func (d *pdfDocument) resolve(obj interface{}) (interface{}, error) {
+	for i := 0; i < pdfMaxRefChain; i++ {
+		ref, ok := obj.(pdfRef)
+		if !ok {
+			return obj, nil
+		}
+		var err error
+		if obj, err = d.object(ref.Num); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w: 间接引用链过长", errPDFSyntax)
+}
+
+// resolveDict 解析间接引用并要求结果为字典，null返回nil
+func (d *pdfDocument) resolveDict(obj interface{}) (pdfDict, error) {
+	obj, err := d.resolve(obj)
+	if err != nil {
+		return nil, err
+	}
+	switch o := obj.(type) {
+	case nil:
+		return nil, nil
+	case pdfDict:
+		return o, nil
+	case *pdfStream:
+		return o.Dict, nil
+	}
+	return nil, fmt.Errorf("%w: 应为字典", errPDFSyntax)
+}
+
+// object 按对象编号读取对象，不存在的对象视为null
+func (d *pdfDocument) object(num int) (interface{}, error) {
+	if obj, ok := d.objects[num]; ok {
+		return obj, nil
+	}
+	entry, ok := d.xref[num]
+	if !ok || (!entry.compressed && entry.offset < 0) {
+		return nil, nil
+	}
+	if d.resolving[num] {
+		return nil, fmt.Errorf("%w: 对象 %d 循环引用", errPDFSyntax, num)
+	}
+	d.resolving[num] = true
+	defer delete(d.resolving, num)
+
+	var obj interface{}
+	if entry.compressed {
+		stm, err := d.objStm(entry.stream)
+		if err != nil {
+			return nil, err
+		}
+		if entry.index < 0 || entry.index >= len(stm.offsets) {
+			return nil, fmt.Errorf("%w: 对象 %d 不在对象流中", errPDFSyntax, num)
+		}
+		l := &pdfLexer{data: stm.data, pos: stm.first + stm.offsets[entry.index]}
+		if l.pos > len(stm.data) {
+			return nil, fmt.Errorf("%w: 对象流偏移越界", errPDFSyntax)
+		}
+		if obj, err = (&pdfParser{lexer: l}).parseObject(0); err != nil {
+			return nil, err
+		}
+	} else {
+		n, _, o, err := d.parseIndirect(entry.offset)
+		if err != nil {
+			return nil, err
+		}
+		if n != num {
+			return nil, fmt.Errorf("%w: 对象 %d 的偏移指向对象 %d", errPDFSyntax, num, n)
+		}
+		obj = o
+	}
+
+	d.objects[num] = obj
+	return obj, nil
+}
+
+// objStm 读取并解析对象流的头部
+func (d *pdfDocument) objStm(num int) (*pdfObjStm, error) {
+	if stm, ok := d.objStms[num]; ok {
+		return stm, nil
+	}
+	obj, err := d.object(num)
+	if err != nil {
+		return nil, err
+	}
+	stream, ok := obj.(*pdfStream)
+	if !ok || stream.Dict["Type"] != pdfName("ObjStm") {
+		return nil, fmt.Errorf("%w: 对象 %d 不是对象流", errPDFSyntax, num)
+	}
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	n, ok1 := pdfInt(stream.Dict["N"])
+	first, ok2 := pdfInt(stream.Dict["First"])
+	if !ok1 || !ok2 || n < 0 || first < 0 || first > len(data) || n > len(data) {
+		return nil, fmt.Errorf("%w: 无效的对象流", errPDFSyntax)
+	}
+
+	stm := &pdfObjStm{data: data, first: first, offsets: make([]int, n)}
+	header := make([]int, 0, 2*n)
+	l := &pdfLexer{data: data[:first]}
+	for i := 0; i < 2*n; i++ {
+		v, err := l.readInt()
+		if err != nil {
+			return nil, err
+		}
+		header = append(header, v)
+	}
+	stm.header = header
+	for i := 0; i < n; i++ {
+		stm.offsets[i] = header[2*i+1]
+	}
+	d.objStms[num] = stm
+	return stm, nil
+}
+
+// nums 对象流中第i个对象的编号
+func (s *pdfObjStm) nums(i int) int {
+	return s.header[2*i]
+}
+
+// parseIndirect 解析offset处的间接对象 "num gen obj ... endobj"
+func (d *pdfDocument) parseIndirect(offset int) (int, int, interface{}, error) {
+	if offset < 0 || offset >= len(d.data) {
+		return 0, 0, nil, fmt.Errorf("%w: 对象偏移越界", errPDFSyntax)
+	}
+	l := &pdfLexer{data: d.data, pos: offset}
+	num, err := l.readInt()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	gen, err := l.readInt()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	l.skipSpace()
+	if !l.hasKeyword("obj") {
+		return 0, 0, nil, fmt.Errorf("%w: 对象 %d 缺少obj", errPDFSyntax, num)
+	}
+	l.pos += len("obj")
+
+	obj, err := (&pdfParser{lexer: l}).parseObject(0)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	l.skipSpace()
+	if dict, ok := obj.(pdfDict); ok && l.hasKeyword("stream") {
+		l.pos += len("stream")
+		raw, err := d.streamData(l, dict)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		obj = &pdfStream{Dict: dict, Raw: raw}
+	}
+	return num, gen, obj, nil
+}
+
+// streamData 读取stream关键字之后的流数据，/Length不正确时查找endstream
+func (d *pdfDocument) streamData(l *pdfLexer, dict pdfDict) ([]byte, error) {
+	// stream关键字后为CRLF或LF
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+
+	if length, err := d.resolve(dict["Length"]); err == nil {
+		if n, ok := pdfInt(length); ok && n >= 0 && start+n <= len(l.data) {
+			end := &pdfLexer{data: l.data, pos: start + n}
+			end.skipSpace()
+			if end.hasKeyword("endstream") {
+				return l.data[start : start+n], nil
+			}
+		}
+	}
+
+	end := bytes.Index(l.data[start:], []byte("endstream"))
+	if end < 0 {
+		return nil, fmt.Errorf("%w: 流缺少endstream", errPDFSyntax)
+	}
+	raw := l.data[start : start+end]
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	return raw, nil
+}
+
+// decodeStream 按/Filter解码流数据，解码结果有大小上限
+func (d *pdfDocument) decodeStream(s *pdfStream) ([]byte, error) {
+	filterObj, err := d.resolve(s.Dict["Filter"])
+	if err != nil {
+		return nil, err
+	}
+	parmsObj, err := d.resolve(s.Dict["DecodeParms"])
+	if err != nil {
+		return nil, err
+	}
+
+	var filters pdfArray
+	var parms pdfArray
+	switch f := filterObj.(type) {
+	case nil:
+	case pdfName:
+		filters = pdfArray{f}
+		parms = pdfArray{parmsObj}
+	case pdfArray:
+		filters = f
+		if p, ok := parmsObj.(pdfArray); ok {
+			parms = p
+		}
+	default:
+		return nil, fmt.Errorf("%w: 无效的/Filter", errPDFSyntax)
+	}
+
+	data := s.Raw
+	for i, f := range filters {
+		name, ok := f.(pdfName)
+		if !ok {
+			return nil, fmt.Errorf("%w: 无效的/Filter", errPDFSyntax)
+		}
+		var parm pdfDict
+		if i < len(parms) {
+			parm, _ = d.resolveDict(parms[i])
+		}
+		if data, err = pdfDecode(name, data, parm); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// pdfDecode 使用单个过滤器解码
+func pdfDecode(filter pdfName, data []byte, parm pdfDict) ([]byte, error) {
+	switch filter {
+	case "FlateDecode", "Fl":
+		out, err := pdfInflate(data)
+		if err != nil {
+			return nil, err
+		}
+		return pdfUnpredict(out, parm)
+	case "ASCIIHexDecode", "AHx":
+		return pdfDecodeHex(data)
+	case "ASCII85Decode", "A85":
+		return pdfDecode85(data)
+	}
+	return nil, fmt.Errorf("%w: 不支持的过滤器 %s", errPDFSyntax, filter)
+}
+
+// pdfInflate 解压Flate数据，超过上限视为解压炸弹
+func pdfInflate(data []byte) ([]byte, error) {
+	var r io.ReadCloser
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		// 部分生成器省略zlib头
+		r = flate.NewReader(bytes.NewReader(data))
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(io.LimitReader(r, pdfMaxDecodedStream+1))
+	if len(out) > pdfMaxDecodedStream {
+		return nil, fmt.Errorf("%w: 流解压后超过 %d 字节", errPDFSyntax, pdfMaxDecodedStream)
+	}
+	// 末尾损坏的压缩流保留已解压的内容，与常见阅读器一致
+	if err != nil && len(out) == 0 {
+		return nil, fmt.Errorf("%w: 解压流失败: %v", errPDFSyntax, err)
+	}
+	return out, nil
+}
+
+// pdfUnpredict 还原Flate解码参数中的预测器
+func pdfUnpredict(data []byte, parm pdfDict) ([]byte, error) {
+	predictor, _ := pdfInt(parm["Predictor"])
+	if predictor <= 1 {
+		return data, nil
+	}
+	colors := pdfIntDefault(parm["Colors"], 1)
+	bpc := pdfIntDefault(parm["BitsPerComponent"], 8)
+	columns := pdfIntDefault(parm["Columns"], 1)
+	if colors < 1 || colors > 32 || bpc < 1 || bpc > 16 || columns < 1 || columns > 1<<20 {
+		return nil, fmt.Errorf("%w: 无效的预测器参数", errPDFSyntax)
+	}
+	bpp := (colors*bpc + 7) / 8
+	rowLen := (colors*bpc*columns + 7) / 8
+
+	if predictor == 2 {
+		// TIFF预测器，仅支持8位分量
+		if bpc != 8 {
+			return nil, fmt.Errorf("%w: 不支持的TIFF预测器参数", errPDFSyntax)
+		}
+		out := append([]byte(nil), data...)
+		for row := 0; row+rowLen <= len(out); row += rowLen {
+			for i := bpp; i < rowLen; i++ {
+				out[row+i] += out[row+i-bpp]
+			}
+		}
+		return out, nil
+	}
+
+	// PNG预测器，每行以过滤类型字节开头
+	out := make([]byte, 0, len(data)/(rowLen+1)*rowLen)
+	prev := make([]byte, rowLen)
+	for pos := 0; pos+1 <= len(data); pos += rowLen + 1 {
+		end := pos + 1 + rowLen
+		if end > len(data) {
+			end = len(data)
+		}
+		kind := data[pos]
+		row := make([]byte, rowLen)
+		copy(row, data[pos+1:end])
+		for i := 0; i < rowLen; i++ {
+			var left, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up := prev[i]
+			switch kind {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("%w: 无效的PNG预测器类型 %d", errPDFSyntax, kind)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+// paeth PNG的Paeth预测函数
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := absInt(p-int(a)), absInt(p-int(b)), absInt(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+	return c
+}
+
+// pdfDecodeHex 解码ASCIIHexDecode数据
+func pdfDecodeHex(data []byte) ([]byte, error) {
+	digits := make([]byte, 0, len(data))
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if !isPDFSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	if _, err := hex.Decode(out, digits); err != nil {
+		return nil, fmt.Errorf("%w: %v", errPDFSyntax, err)
+	}
+	return out, nil
+}
+
+// pdfDecode85 解码ASCII85Decode数据
+func pdfDecode85(data []byte) ([]byte, error) {
+	data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+	if end := bytes.Index(data, []byte("~>")); end >= 0 {
+		data = data[:end]
+	}
+	out := make([]byte, 4*len(data)/5+4)
+	n, _, err := ascii85.Decode(out, data, true)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errPDFSyntax, err)
+	}
+	return out[:n], nil
+}
+
+// pages 遍历页面树，返回所有页面；页数超过maxPages（大于0时）时提前返回ErrPDFTooManyPages
+func (d *pdfDocument) pages(maxPages int) ([]pdfPage, error) {
+	catalog, err := d.resolveDict(d.trailer["Root"])
+	if err != nil {
+		return nil, err
+	}
+	if catalog == nil {
+		return nil, fmt.Errorf("%w: 缺少文档目录", errPDFSyntax)
+	}
+
+	w := &pdfPageWalker{doc: d, maxPages: maxPages, visited: make(map[int]bool)}
+	inherited := pdfPage{MediaBox: pdfRect{X1: 612, Y1: 792}} // 缺少MediaBox时按Letter处理
+	if err := w.walk(catalog["Pages"], inherited, false, 0); err != nil {
+		return nil, err
+	}
+	if len(w.pages) == 0 {
+		return nil, fmt.Errorf("%w: 文档没有页面", errPDFSyntax)
+	}
+	return w.pages, nil
+}
+
+// pdfPageWalker 页面树遍历状态
+type pdfPageWalker struct {
+	doc      *pdfDocument
+	maxPages int
+	visited  map[int]bool
+	pages    []pdfPage
+}
+
+// walk 遍历页面树节点，合并可继承的属性
+func (w *pdfPageWalker) walk(node interface{}, inherited pdfPage, hasCropBox bool, depth int) error {
+	if depth > pdfMaxPageTreeDepth {
+		return fmt.Errorf("%w: 页面树过深", errPDFSyntax)
+	}
+	if ref, ok := node.(pdfRef); ok {
+		if w.visited[ref.Num] {
+			return fmt.Errorf("%w: 页面树存在循环", errPDFSyntax)
+		}
+		w.visited[ref.Num] = true
+	}
+	dict, err := w.doc.resolveDict(node)
+	if err != nil {
+		return err
+	}
+	if dict == nil {
+		return fmt.Errorf("%w: 页面树节点为空", errPDFSyntax)
+	}
+
+	attrs := inherited
+	if box, ok, err := w.doc.rect(dict["MediaBox"]); err != nil {
+		return err
+	} else if ok {
+		attrs.MediaBox = box
+	}
+	if box, ok, err := w.doc.rect(dict["CropBox"]); err != nil {
+		return err
+	} else if ok {
+		attrs.CropBox = box
+		hasCropBox = true
+	}
+	if rotate, err := w.doc.resolve(dict["Rotate"]); err == nil {
+		if r, ok := pdfInt(rotate); ok {
+			attrs.Rotate = ((r % 360) + 360) % 360
+		}
+	}
+	if res, err := w.doc.resolveDict(dict["Resources"]); err == nil && res != nil {
+		attrs.Resources = res
+	}
+
+	kidsObj, err := w.doc.resolve(dict["Kids"])
+	if err != nil {
+		return err
+	}
+	if dict["Type"] == pdfName("Pages") || kidsObj != nil {
+		kids, ok := kidsObj.(pdfArray)
+		if !ok {
+			return fmt.Errorf("%w: 页面树节点缺少/Kids", errPDFSyntax)
+		}
+		for _, kid := range kids {
+			if err := w.walk(kid, attrs, hasCropBox, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 页面节点
+	attrs.Dict = dict
+	if !hasCropBox {
+		attrs.CropBox = attrs.MediaBox
+	}
+	w.pages = append(w.pages, attrs)
+	if w.maxPages > 0 && len(w.pages) > w.maxPages {
+		return ErrPDFTooManyPages
+	}
+	return nil
+}
+
+// rect 解析矩形数组，坐标按大小规范化
+func (d *pdfDocument) rect(obj interface{}) (pdfRect, bool, error) {
+	obj, err := d.resolve(obj)
+	if err != nil || obj == nil {
+		return pdfRect{}, false, err
+	}
+	arr, ok := obj.(pdfArray)
+	if !ok || len(arr) != 4 {
+		return pdfRect{}, false, fmt.Errorf("%w: 无效的页面尺寸", errPDFSyntax)
+	}
+	var v [4]float64
+	for i := range arr {
+		item, err := d.resolve(arr[i])
+		if err != nil {
+			return pdfRect{}, false, err
+		}
+		if v[i], ok = pdfNumber(item); !ok {
+			return pdfRect{}, false, fmt.Errorf("%w: 无效的页面尺寸", errPDFSyntax)
+		}
+	}
+	return pdfRect{
+		X0: min(v[0], v[2]), Y0: min(v[1], v[3]),
+		X1: max(v[0], v[2]), Y1: max(v[1], v[3]),
+	}, true, nil
+}
+
+// pdfInt 将数字对象转换为整数
+func pdfInt(obj interface{}) (int, bool) {
+	switch v := obj.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// pdfIntDefault 将数字对象转换为整数，不是数字时返回默认值
+func pdfIntDefault(obj interface{}, def int) int {
+	if v, ok := pdfInt(obj); ok {
+		return v
+	}
+	return def
+}
+
+// pdfNumber 将数字对象转换为浮点数
+func pdfNumber(obj interface{}) (float64, bool) {
+	switch v := obj.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// isPDFSpace PDF空白字符
+func isPDFSpace(c byte) bool {
+	return c == 0 || c == '\t' || c == '\n' || c == '\f' || c == '\r' || c == ' '
+}
+
+// isPDFDelim PDF分隔字符
+func isPDFDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+// pdfLexer PDF词法扫描
+type pdfLexer struct {
+	data []byte
+	pos  int
+}
+
+// skipSpace 跳过空白和注释
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		if isPDFSpace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.data) && l.data[l.pos] != '\r' && l.data[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+// hasKeyword 当前位置是否为完整的关键字
+func (l *pdfLexer) hasKeyword(kw string) bool {
+	end := l.pos + len(kw)
+	if end > len(l.data) || string(l.data[l.pos:end]) != kw {
+		return false
+	}
+	return end == len(l.data) || isPDFSpace(l.data[end]) || isPDFDelim(l.data[end])
+}
+
+// regular 读取一个常规字符序列（数字或关键字）
+func (l *pdfLexer) regular() []byte {
+	start := l.pos
+	for l.pos < len(l.data) && !isPDFSpace(l.data[l.pos]) && !isPDFDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	return l.data[start:l.pos]
+}
+
+// readInt 跳过空白后读取一个非负整数
+func (l *pdfLexer) readInt() (int, error) {
+	l.skipSpace()
+	tok := l.regular()
+	if len(tok) == 0 || len(tok) > 10 {
+		return 0, fmt.Errorf("%w: 应为整数", errPDFSyntax)
+	}
+	n, err := strconv.Atoi(string(tok))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: 应为整数", errPDFSyntax)
+	}
+	return n, nil
+}
+
+// pdfParser PDF对象语法解析
+type pdfParser struct {
+	lexer *pdfLexer
+}
+
+// parseObject 解析一个直接对象，数字后跟 "gen R" 时解析为间接引用
+func (p *pdfParser) parseObject(depth int) (interface{}, error) {
+	if depth > pdfMaxNesting {
+		return nil, fmt.Errorf("%w: 嵌套过深", errPDFSyntax)
+	}
+	l := p.lexer
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		l.pos++
+		return p.parseName()
+	case c == '(':
+		l.pos++
+		return p.parseLiteralString()
+	case c == '<':
+		if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+			l.pos += 2
+			return p.parseDict(depth)
+		}
+		l.pos++
+		return p.parseHexString()
+	case c == '[':
+		l.pos++
+		return p.parseArray(depth)
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumberOrRef()
+	case isPDFDelim(c):
+		return nil, fmt.Errorf("%w: 意外的字符 %q", errPDFSyntax, c)
+	}
+
+	switch kw := string(l.regular()); kw {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: 意外的关键字 %q", errPDFSyntax, kw)
+	}
+}
+
+// parseName 解析名称，处理#xx转义
+func (p *pdfParser) parseName() (pdfName, error) {
+	raw := p.lexer.regular()
+	if bytes.IndexByte(raw, '#') < 0 {
+		return pdfName(raw), nil
+	}
+	out := make([]byte, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			var b [1]byte
+			if _, err := hex.Decode(b[:], raw[i+1:i+3]); err == nil {
+				out = append(out, b[0])
+				i += 2
+				continue
+			}
+		}
+		out = append(out, raw[i])
+	}
+	return pdfName(out), nil
+}
+
+// parseLiteralString 解析括号字符串，处理嵌套括号和转义
+func (p *pdfParser) parseLiteralString() (pdfString, error) {
+	l := p.lexer
+	var out []byte
+	nesting := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			nesting++
+		case ')':
+			nesting--
+			if nesting == 0 {
+				return pdfString(out), nil
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return "", io.ErrUnexpectedEOF
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// 续行
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return "", io.ErrUnexpectedEOF
+}
+
+// parseHexString 解析十六进制字符串
+func (p *pdfParser) parseHexString() (pdfString, error) {
+	l := p.lexer
+	end := bytes.IndexByte(l.data[l.pos:], '>')
+	if end < 0 {
+		return "", io.ErrUnexpectedEOF
+	}
+	out, err := pdfDecodeHex(l.data[l.pos : l.pos+end])
+	if err != nil {
+		return "", err
+	}
+	l.pos += end + 1
+	return pdfString(out), nil
+}
+
+// parseArray 解析数组
+func (p *pdfParser) parseArray(depth int) (pdfArray, error) {
+	l := p.lexer
+	arr := pdfArray{}
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if l.data[l.pos] == ']' {
+			l.pos++
+			return arr, nil
+		}
+		obj, err := p.parseObject(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, obj)
+	}
+}
+
+// parseDict 解析字典，值为null的键视为不存在
+func (p *pdfParser) parseDict(depth int) (pdfDict, error) {
+	l := p.lexer
+	dict := pdfDict{}
+	for {
+		l.skipSpace()
+		if l.pos+1 >= len(l.data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if l.data[l.pos] == '>' && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			return dict, nil
+		}
+		if l.data[l.pos] != '/' {
+			return nil, fmt.Errorf("%w: 字典的键应为名称", errPDFSyntax)
+		}
+		l.pos++
+		key, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.parseObject(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			dict[key] = value
+		}
+	}
+}
+
+// parseNumberOrRef 解析数字，整数后跟 "gen R" 时解析为间接引用
+func (p *pdfParser) parseNumberOrRef() (interface{}, error) {
+	l := p.lexer
+	tok := string(l.regular())
+	if n, err := strconv.Atoi(tok); err == nil {
+		// 向后查看是否为间接引用
+		save := l.pos
+		if gen, err := l.readInt(); err == nil {
+			l.skipSpace()
+			if l.hasKeyword("R") {
+				l.pos++
+				return pdfRef{Num: n, Gen: gen}, nil
+			}
+		}
+		l.pos = save
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(tok, 64)
+	if err != nil {
+		// 兼容 "--5" 之类的错误写法
+		return 0, nil
+	}
+	return f, nil
+}
@@ -0,0 +1,381 @@
+package util
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// RenderOptions PDF渲染参数
+type RenderOptions struct {
+	DPI       int // 渲染分辨率
+	Quality   int // JPEG质量 (1-100)
+	FirstPage int // 起始页码，从1开始，0表示第一页
+	LastPage  int // 结束页码，0表示最后一页
+}
+
+// PDFRenderer 将PDF逐页渲染为图片的工具
+type PDFRenderer interface {
+	// Name 渲染器名称
+	Name() string
+	// Command 渲染器依赖的外部命令，纯Go实现返回空字符串
+	Command() string
+	// RenderPages 将PDF逐页渲染为JPEG图片，输出到outDir，返回按页码排序的图片路径
+	RenderPages(pdfPath, outDir string, opts RenderOptions) ([]string, error)
+}
+
+// PDFRendererStatus 渲染器启动探测结果
+type PDFRendererStatus struct {
+	Name      string `json:"name"`
+	Command   string `json:"command,omitempty"`
+	Available bool   `json:"available"`
+	Error     string `json:"error,omitempty"`
+}
+
+// PDFRendererReport 渲染器探测报告，在健康检查中返回
+type PDFRendererReport struct {
+	Active    string              `json:"active"` // 优先使用的渲染器
+	Renderers []PDFRendererStatus `json:"renderers"`
+}
+
+// pdfRenderers 按优先级排列的渲染器，纯Go渲染器放在最后作为兜底
+var pdfRenderers = []PDFRenderer{
+	pdftoppmRenderer{},
+	ghostscriptRenderer{},
+	imageMagickRenderer{},
+	goRenderer{},
+}
+
+var (
+	rendererMu        sync.RWMutex
+	availableRenderer []PDFRenderer
+	rendererReport    PDFRendererReport
+	rendererProbed    bool
+)
+
+// ProbePDFRenderers 用内置的测试PDF逐个试用渲染器，记录可用的渲染器
+// 启动时调用一次，结果通过PDFRenderers在健康检查中返回
+func ProbePDFRenderers() PDFRendererReport {
+	var available []PDFRenderer
+	report := PDFRendererReport{}
+
+	workDir, err := os.MkdirTemp("", "codefolio-probe-")
+	if err != nil {
+		GetLogger().Error("创建渲染器探测目录失败", zap.Error(err))
+	} else {
+		defer os.RemoveAll(workDir)
+	}
+	probePath := filepath.Join(workDir, "probe.pdf")
+	if err == nil {
+		err = os.WriteFile(probePath, probePDF(), 0644)
+	}
+
+	for _, r := range pdfRenderers {
+		status := PDFRendererStatus{Name: r.Name(), Command: r.Command()}
+		probeErr := err
+		if probeErr == nil {
+			probeErr = probeRenderer(r, probePath, workDir)
+		}
+		if probeErr != nil {
+			status.Error = probeErr.Error()
+		} else {
+			status.Available = true
+			available = append(available, r)
+			if report.Active == "" {
+				report.Active = r.Name()
+			}
+		}
+		report.Renderers = append(report.Renderers, status)
+	}
+
+	// 纯Go渲染器不依赖任何外部环境，探测失败时仍然保留
+	if len(available) == 0 {
+		available = []PDFRenderer{goRenderer{}}
+		report.Active = goRenderer{}.Name()
+	}
+
+	rendererMu.Lock()
+	availableRenderer = available
+	rendererReport = report
+	rendererProbed = true
+	rendererMu.Unlock()
+
+	GetLogger().Info("PDF渲染器探测完成", zap.String("active", report.Active), zap.Any("renderers", report.Renderers))
+	return report
+}
+
+// PDFRenderers 返回启动时的渲染器探测报告
+func PDFRenderers() PDFRendererReport {
+	rendererMu.RLock()
+	probed := rendererProbed
+	report := rendererReport
+	rendererMu.RUnlock()
+	if !probed {
+		return ProbePDFRenderers()
+	}
+	return report
+}
+
+// activeRenderers 可用的渲染器，尚未探测时先进行探测
+func activeRenderers() []PDFRenderer {
+	rendererMu.RLock()
+	probed := rendererProbed
+	renderers := availableRenderer
+	rendererMu.RUnlock()
+	if !probed {
+		ProbePDFRenderers()
+		rendererMu.RLock()
+		renderers = availableRenderer
+		rendererMu.RUnlock()
+	}
+	return renderers
+}
+
+// probeRenderer 渲染测试PDF，确认渲染器能输出图片
+func probeRenderer(r PDFRenderer, probePath, workDir string) error {
+	if cmd := r.Command(); cmd != "" {
+		if _, err := exec.LookPath(cmd); err != nil {
+			return ErrCommandNotFound
+		}
+	}
+	outDir, err := os.MkdirTemp(workDir, r.Name()+"-")
+	if err != nil {
+		return err
+	}
+	pages, err := r.RenderPages(probePath, outDir, RenderOptions{DPI: 36, Quality: DefaultImageQuality})
+	if err != nil {
+		return err
+	}
+	if len(pages) != 1 {
+		return fmt.Errorf("测试PDF应渲染出1页，实际为%d页", len(pages))
+	}
+	if _, err := decodeImageFile(pages[0]); err != nil {
+		return err
+	}
+	return nil
+}
+
+// probePDF 渲染器探测使用的单页PDF
+func probePDF() []byte {
+	content := "0 0 0 rg 18 18 36 36 re f BT /F1 12 Tf 10 60 Td (ok) Tj ET"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 72 72] /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return []byte(b.String())
+}
+
+// RenderPDFPages 将PDF逐页渲染为JPEG图片，输出到outDir，返回按页码排序的图片路径
+func RenderPDFPages(pdfPath, outDir string) ([]string, error) {
+	return renderPDF(pdfPath, outDir, RenderOptions{DPI: renderProfile.DPI, Quality: renderProfile.Quality})
+}
+
+// renderPDF 按优先级使用可用的渲染器，渲染失败时换用下一个
+// 超时或超出资源限制说明文件本身有问题，不再换用其他渲染器
+func renderPDF(pdfPath, outDir string, opts RenderOptions) ([]string, error) {
+	if _, err := os.Stat(pdfPath); os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+
+	var lastErr error = ErrCommandNotFound
+	for _, r := range activeRenderers() {
+		// 每个渲染器使用单独的目录，避免失败时残留的图片混入结果
+		dir, err := os.MkdirTemp(outDir, r.Name()+"-")
+		if err != nil {
+			return nil, err
+		}
+		pages, err := r.RenderPages(pdfPath, dir, opts)
+		if err == nil && len(pages) > 0 {
+			return pages, nil
+		}
+		if err == nil {
+			err = ErrConvertPDFFailed
+		}
+		if isConvertLimitError(err) {
+			return nil, err
+		}
+		GetLogger().Warn("渲染PDF失败，尝试下一个渲染器", zap.String("renderer", r.Name()), zap.Error(err))
+		lastErr = err
+	}
+	if lastErr == ErrCommandNotFound {
+		GetLogger().Error("未找到合适的PDF转图片工具")
+	}
+	return nil, lastErr
+}
+
+// listPageImages 列出渲染器输出的图片，按文件名（页码）排序
+func listPageImages(outDir string) ([]string, error) {
+	files, err := os.ReadDir(outDir)
+	if err != nil {
+		return nil, err
+	}
+	var pages []string
+	for _, file := range files {
+		if !file.IsDir() {
+			pages = append(pages, filepath.Join(outDir, file.Name()))
+		}
+	}
+	sort.Strings(pages)
+	return pages, nil
+}
+
+// renderLimitError 外部渲染命令失败时的错误，保留超时和资源限制错误
+func renderLimitError(name string, err error) error {
+	if isConvertLimitError(err) {
+		return err
+	}
+	GetLogger().Error("分页转换PDF失败 ("+name+")", zap.Error(err))
+	return ErrConvertPDFFailed
+}
+
+// pdftoppmRenderer 使用Poppler的pdftoppm渲染
+type pdftoppmRenderer struct{}
+
+func (pdftoppmRenderer) Name() string    { return "pdftoppm" }
+func (pdftoppmRenderer) Command() string { return "pdftoppm" }
+
+func (r pdftoppmRenderer) RenderPages(pdfPath, outDir string, opts RenderOptions) ([]string, error) {
+	args := []string{
+		"-jpeg",                           // 输出JPEG格式
+		"-r", fmt.Sprintf("%d", opts.DPI), // 设置DPI
+		"-jpegopt", fmt.Sprintf("quality=%d", opts.Quality), // 设置JPEG质量
+	}
+	if opts.FirstPage > 0 {
+		args = append(args, "-f", fmt.Sprintf("%d", opts.FirstPage))
+	}
+	if opts.LastPage > 0 {
+		args = append(args, "-l", fmt.Sprintf("%d", opts.LastPage))
+	}
+	args = append(args, pdfPath, filepath.Join(outDir, "page"))
+
+	if _, err := runConverter(r.Command(), args...); err != nil {
+		return nil, renderLimitError(r.Name(), err)
+	}
+	return listPageImages(outDir)
+}
+
+// ghostscriptRenderer 使用Ghostscript渲染
+type ghostscriptRenderer struct{}
+
+func (ghostscriptRenderer) Name() string    { return "ghostscript" }
+func (ghostscriptRenderer) Command() string { return "gs" }
+
+func (r ghostscriptRenderer) RenderPages(pdfPath, outDir string, opts RenderOptions) ([]string, error) {
+	args := []string{
+		"-dSAFER",
+		"-dBATCH",
+		"-dNOPAUSE",
+		"-dQUIET",
+		"-sDEVICE=jpeg",
+		fmt.Sprintf("-dJPEGQ=%d", opts.Quality),
+		fmt.Sprintf("-r%d", opts.DPI),
+	}
+	if opts.FirstPage > 0 {
+		args = append(args, fmt.Sprintf("-dFirstPage=%d", opts.FirstPage))
+	}
+	if opts.LastPage > 0 {
+		args = append(args, fmt.Sprintf("-dLastPage=%d", opts.LastPage))
+	}
+	args = append(args, "-sOutputFile="+filepath.Join(outDir, "page-%03d.jpg"), pdfPath)
+
+	if _, err := runConverter(r.Command(), args...); err != nil {
+		return nil, renderLimitError(r.Name(), err)
+	}
+	return listPageImages(outDir)
+}
+
+// imageMagickRenderer 使用ImageMagick渲染，ImageMagick读取PDF时依赖Ghostscript
+type imageMagickRenderer struct{}
+
+func (imageMagickRenderer) Name() string    { return "imagemagick" }
+func (imageMagickRenderer) Command() string { return "convert" }
+
+func (r imageMagickRenderer) RenderPages(pdfPath, outDir string, opts RenderOptions) ([]string, error) {
+	input := pdfPath
+	if opts.FirstPage > 0 || opts.LastPage > 0 {
+		// ImageMagick的页码从0开始
+		first, last := max(opts.FirstPage, 1)-1, ""
+		if opts.LastPage > 0 {
+			last = fmt.Sprintf("%d", opts.LastPage-1)
+		} else {
+			last = "999999"
+		}
+		input = fmt.Sprintf("%s[%d-%s]", pdfPath, first, last)
+	}
+
+	_, err := runConverter(
+		r.Command(),
+		"-density", fmt.Sprintf("%d", opts.DPI),
+		input,
+		"-background", "white",
+		"-alpha", "remove",
+		"-quality", fmt.Sprintf("%d", opts.Quality),
+		filepath.Join(outDir, "page-%03d.jpg"),
+	)
+	if err != nil {
+		return nil, renderLimitError(r.Name(), err)
+	}
+	return listPageImages(outDir)
+}
+
+// stackedImage 将多页图片纵向拼接为一张图片，按需读取像素，不额外分配整张长图的内存
+type stackedImage struct {
+	pages   []image.Image
+	offsets []int // 每页在长图中的起始纵坐标
+	bounds  image.Rectangle
+}
+
+// newStackedImage 纵向拼接图片，宽度取最宽的一页，较窄的页面右侧留白
+func newStackedImage(pages []image.Image) *stackedImage {
+	s := &stackedImage{pages: pages}
+	width, height := 0, 0
+	for _, p := range pages {
+		s.offsets = append(s.offsets, height)
+		width = max(width, p.Bounds().Dx())
+		height += p.Bounds().Dy()
+	}
+	s.bounds = image.Rect(0, 0, width, height)
+	return s
+}
+
+func (s *stackedImage) ColorModel() color.Model { return color.RGBAModel }
+
+func (s *stackedImage) Bounds() image.Rectangle { return s.bounds }
+
+func (s *stackedImage) At(x, y int) color.Color {
+	i := sort.Search(len(s.offsets), func(i int) bool { return s.offsets[i] > y }) - 1
+	if i < 0 {
+		return color.White
+	}
+	b := s.pages[i].Bounds()
+	px, py := b.Min.X+x, b.Min.Y+y-s.offsets[i]
+	if px >= b.Max.X || py >= b.Max.Y {
+		return color.White
+	}
+	return s.pages[i].At(px, py)
+}
@@ -0,0 +1,1091 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 纯Go的PDF渲染器，在没有安装任何外部转换工具时使用（如开发环境和精简容器）
+// 支持路径填充和描边、矩形裁剪、JPEG和Flate编码的图片、表单XObject，
+// 文字按字体宽度排版并使用内置点阵字体绘制，不支持渐变、图案、透明度和嵌入字体的字形
+
+// goRenderer 纯Go渲染器
+type goRenderer struct{}
+
+func (goRenderer) Name() string    { return "go" }
+func (goRenderer) Command() string { return "" }
+
+// 纯Go渲染器的限制
+const (
+	goRenderMaxPixels   = 64 * 1024 * 1024 // 单页最大像素数
+	goRenderMaxOps      = 2_000_000        // 单页最多执行的操作数
+	goRenderMaxFormNest = 8                // 表单XObject最大嵌套层数
+	goRenderCurveSteps  = 12               // 贝塞尔曲线拆分的线段数
+)
+
+func (goRenderer) RenderPages(pdfPath, outDir string, opts RenderOptions) ([]string, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parsePDF(data)
+	if err != nil {
+		GetLogger().Warn("纯Go渲染器解析PDF失败", zap.Error(err))
+		return nil, ErrConvertPDFFailed
+	}
+	if doc.encrypted() {
+		return nil, ErrPDFEncrypted
+	}
+	pages, err := doc.pages(0)
+	if err != nil {
+		GetLogger().Warn("纯Go渲染器解析页面失败", zap.Error(err))
+		return nil, ErrConvertPDFFailed
+	}
+
+	first, last := 1, len(pages)
+	if opts.FirstPage > 0 {
+		first = opts.FirstPage
+	}
+	if opts.LastPage > 0 && opts.LastPage < last {
+		last = opts.LastPage
+	}
+
+	var deadline time.Time
+	if conversionLimits.Timeout > 0 {
+		deadline = time.Now().Add(conversionLimits.Timeout)
+	}
+
+	var paths []string
+	for n := first; n <= last; n++ {
+		img, err := doc.renderPage(pages[n-1], opts.DPI, deadline)
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(outDir, fmt.Sprintf("page-%03d.jpg", n))
+		if err := writeJPEG(path, img, opts.Quality); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// writeJPEG 将图片编码为JPEG文件
+func writeJPEG(path string, img image.Image, quality int) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if err := jpeg.Encode(out, img, &jpeg.Options{Quality: quality}); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// pdfMatrix PDF变换矩阵 [a b c d e f]，点按行向量右乘
+type pdfMatrix [6]float64
+
+// pdfIdentity 单位矩阵
+var pdfIdentity = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+// mul 返回 m×n，即先应用m再应用n
+func (m pdfMatrix) mul(n pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// apply 变换点
+func (m pdfMatrix) apply(x, y float64) (float64, float64) {
+	return x*m[0] + y*m[2] + m[4], x*m[1] + y*m[3] + m[5]
+}
+
+// invert 逆矩阵，不可逆时返回false
+func (m pdfMatrix) invert() (pdfMatrix, bool) {
+	det := m[0]*m[3] - m[1]*m[2]
+	if math.Abs(det) < 1e-12 {
+		return pdfMatrix{}, false
+	}
+	a, b, c, d := m[3]/det, -m[1]/det, -m[2]/det, m[0]/det
+	return pdfMatrix{a, b, c, d, -(m[4]*a + m[5]*c), -(m[4]*b + m[5]*d)}, true
+}
+
+// pdfPoint 设备坐标中的点
+type pdfPoint struct{ X, Y float64 }
+
+// pdfGraphicsState 图形状态
+type pdfGraphicsState struct {
+	ctm       pdfMatrix
+	fill      color.RGBA
+	stroke    color.RGBA
+	lineWidth float64
+	clip      image.Rectangle
+
+	font       *pdfFont
+	fontSize   float64
+	charSpace  float64
+	wordSpace  float64
+	hScale     float64
+	leading    float64
+	rise       float64
+	textRender int
+	textMatrix pdfMatrix
+	lineMatrix pdfMatrix
+}
+
+// pdfCanvas 单页渲染状态
+type pdfCanvas struct {
+	doc      *pdfDocument
+	img      *image.RGBA
+	gs       pdfGraphicsState
+	stack    []pdfGraphicsState
+	path     [][]pdfPoint // 当前路径，设备坐标
+	pendClip bool         // W/W*之后的路径绘制操作设置裁剪区域
+	ops      int
+	deadline time.Time
+	fonts    map[int]*pdfFont
+}
+
+// renderPage 渲染单页
+func (d *pdfDocument) renderPage(page pdfPage, dpi int, deadline time.Time) (image.Image, error) {
+	scale := float64(dpi) / 72
+	box := page.CropBox
+	width := int(math.Ceil(box.Width() * scale))
+	height := int(math.Ceil(box.Height() * scale))
+	if width <= 0 || height <= 0 {
+		return nil, ErrConvertPDFFailed
+	}
+	if width*height > goRenderMaxPixels {
+		return nil, ErrConvertResourceLimit
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xFF
+	}
+
+	c := &pdfCanvas{
+		doc:      d,
+		img:      img,
+		deadline: deadline,
+		fonts:    make(map[int]*pdfFont),
+	}
+	c.gs = pdfGraphicsState{
+		// PDF坐标原点在左下角，图片原点在左上角
+		ctm:       pdfMatrix{scale, 0, 0, -scale, -box.X0 * scale, box.Y1 * scale},
+		fill:      color.RGBA{A: 0xFF},
+		stroke:    color.RGBA{A: 0xFF},
+		lineWidth: 1,
+		clip:      img.Bounds(),
+		hScale:    1,
+	}
+
+	content, err := d.pageContent(page.Dict)
+	if err != nil {
+		GetLogger().Warn("读取页面内容失败", zap.Error(err))
+	}
+	if err := c.run(content, page.Resources, 0); err != nil {
+		return nil, err
+	}
+	return rotateImage(img, page.Rotate), nil
+}
+
+// pageContent 读取并拼接页面的内容流
+func (d *pdfDocument) pageContent(page pdfDict) ([]byte, error) {
+	obj, err := d.resolve(page["Contents"])
+	if err != nil {
+		return nil, err
+	}
+	var streams []interface{}
+	switch o := obj.(type) {
+	case *pdfStream:
+		streams = []interface{}{o}
+	case pdfArray:
+		streams = o
+	}
+
+	var content bytes.Buffer
+	for _, s := range streams {
+		s, err := d.resolve(s)
+		if err != nil {
+			return content.Bytes(), err
+		}
+		stream, ok := s.(*pdfStream)
+		if !ok {
+			continue
+		}
+		data, err := d.decodeStream(stream)
+		if err != nil {
+			return content.Bytes(), err
+		}
+		content.Write(data)
+		content.WriteByte('\n')
+	}
+	return content.Bytes(), nil
+}
+
+// run 执行内容流，不支持的操作忽略
+func (c *pdfCanvas) run(content []byte, resources pdfDict, depth int) error {
+	r := &pdfContentReader{parser: pdfParser{lexer: &pdfLexer{data: content}}}
+	for {
+		op, args, err := r.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// 内容流中的语法错误只影响之后的内容，与常见阅读器一致
+			GetLogger().Debug("内容流解析中止", zap.Error(err))
+			return nil
+		}
+
+		c.ops++
+		if c.ops > goRenderMaxOps {
+			return ErrConvertResourceLimit
+		}
+		if c.ops%4096 == 0 && !c.deadline.IsZero() && time.Now().After(c.deadline) {
+			return ErrConvertTimeout
+		}
+
+		if err := c.exec(op, args, resources, depth, r); err != nil {
+			return err
+		}
+	}
+}
+
+// exec 执行单个操作
+func (c *pdfCanvas) exec(op string, args []interface{}, resources pdfDict, depth int, r *pdfContentReader) error {
+	num := func(i int) float64 {
+		if i < len(args) {
+			v, _ := pdfNumber(args[i])
+			return v
+		}
+		return 0
+	}
+	gs := &c.gs
+
+	switch op {
+	// 图形状态
+	case "q":
+		c.stack = append(c.stack, c.gs)
+	case "Q":
+		if n := len(c.stack); n > 0 {
+			c.gs = c.stack[n-1]
+			c.stack = c.stack[:n-1]
+		}
+	case "cm":
+		if len(args) == 6 {
+			gs.ctm = pdfMatrix{num(0), num(1), num(2), num(3), num(4), num(5)}.mul(gs.ctm)
+		}
+	case "w":
+		gs.lineWidth = num(0)
+
+	// 颜色
+	case "g":
+		gs.fill = grayColor(num(0))
+	case "G":
+		gs.stroke = grayColor(num(0))
+	case "rg":
+		gs.fill = rgbColor(num(0), num(1), num(2))
+	case "RG":
+		gs.stroke = rgbColor(num(0), num(1), num(2))
+	case "k":
+		gs.fill = cmykColor(num(0), num(1), num(2), num(3))
+	case "K":
+		gs.stroke = cmykColor(num(0), num(1), num(2), num(3))
+	case "sc", "scn":
+		if col, ok := componentColor(args); ok {
+			gs.fill = col
+		}
+	case "SC", "SCN":
+		if col, ok := componentColor(args); ok {
+			gs.stroke = col
+		}
+	case "cs":
+		gs.fill = color.RGBA{A: 0xFF}
+	case "CS":
+		gs.stroke = color.RGBA{A: 0xFF}
+
+	// 路径
+	case "m":
+		c.path = append(c.path, []pdfPoint{c.point(num(0), num(1))})
+	case "l":
+		c.lineTo(c.point(num(0), num(1)))
+	case "c":
+		c.curveTo(c.point(num(0), num(1)), c.point(num(2), num(3)), c.point(num(4), num(5)))
+	case "v":
+		if p, ok := c.current(); ok {
+			c.curveTo(p, c.point(num(0), num(1)), c.point(num(2), num(3)))
+		}
+	case "y":
+		end := c.point(num(2), num(3))
+		c.curveTo(c.point(num(0), num(1)), end, end)
+	case "h":
+		if n := len(c.path); n > 0 && len(c.path[n-1]) > 0 {
+			c.path[n-1] = append(c.path[n-1], c.path[n-1][0])
+		}
+	case "re":
+		x, y, w, h := num(0), num(1), num(2), num(3)
+		c.path = append(c.path, []pdfPoint{
+			c.point(x, y), c.point(x+w, y), c.point(x+w, y+h), c.point(x, y+h), c.point(x, y),
+		})
+	case "W", "W*":
+		c.pendClip = true
+	case "f", "F", "f*":
+		c.fillPath(gs.fill, op == "f*")
+		c.endPath()
+	case "S", "s":
+		if op == "s" {
+			c.exec("h", nil, resources, depth, r)
+		}
+		c.strokePath()
+		c.endPath()
+	case "B", "B*", "b", "b*":
+		if op == "b" || op == "b*" {
+			c.exec("h", nil, resources, depth, r)
+		}
+		c.fillPath(gs.fill, op == "B*" || op == "b*")
+		c.strokePath()
+		c.endPath()
+	case "n":
+		c.endPath()
+
+	// 文字
+	case "BT":
+		gs.textMatrix = pdfIdentity
+		gs.lineMatrix = pdfIdentity
+	case "Tf":
+		if len(args) == 2 {
+			gs.font = c.font(resources, args[0])
+			gs.fontSize = num(1)
+		}
+	case "Tc":
+		gs.charSpace = num(0)
+	case "Tw":
+		gs.wordSpace = num(0)
+	case "Tz":
+		gs.hScale = num(0) / 100
+	case "TL":
+		gs.leading = num(0)
+	case "Ts":
+		gs.rise = num(0)
+	case "Tr":
+		gs.textRender = int(num(0))
+	case "Td":
+		c.moveText(num(0), num(1))
+	case "TD":
+		gs.leading = -num(1)
+		c.moveText(num(0), num(1))
+	case "Tm":
+		if len(args) == 6 {
+			gs.lineMatrix = pdfMatrix{num(0), num(1), num(2), num(3), num(4), num(5)}
+			gs.textMatrix = gs.lineMatrix
+		}
+	case "T*":
+		c.moveText(0, -gs.leading)
+	case "Tj":
+		if len(args) == 1 {
+			c.showText(args[0])
+		}
+	case "'":
+		c.moveText(0, -gs.leading)
+		if len(args) == 1 {
+			c.showText(args[0])
+		}
+	case "\"":
+		if len(args) == 3 {
+			gs.wordSpace, gs.charSpace = num(0), num(1)
+			c.moveText(0, -gs.leading)
+			c.showText(args[2])
+		}
+	case "TJ":
+		if len(args) == 1 {
+			if arr, ok := args[0].(pdfArray); ok {
+				for _, item := range arr {
+					if adjust, ok := pdfNumber(item); ok {
+						c.advance(-adjust / 1000 * gs.fontSize * gs.hScale)
+					} else {
+						c.showText(item)
+					}
+				}
+			}
+		}
+
+	// 外部对象
+	case "Do":
+		if len(args) == 1 {
+			return c.drawXObject(resources, args[0], depth)
+		}
+	case "BI":
+		return c.drawInlineImage(r)
+	}
+	return nil
+}
+
+// point 将用户坐标变换为设备坐标
+func (c *pdfCanvas) point(x, y float64) pdfPoint {
+	px, py := c.gs.ctm.apply(x, y)
+	return pdfPoint{px, py}
+}
+
+// current 当前点
+func (c *pdfCanvas) current() (pdfPoint, bool) {
+	if n := len(c.path); n > 0 && len(c.path[n-1]) > 0 {
+		sub := c.path[n-1]
+		return sub[len(sub)-1], true
+	}
+	return pdfPoint{}, false
+}
+
+// lineTo 添加直线段
+func (c *pdfCanvas) lineTo(p pdfPoint) {
+	if len(c.path) == 0 {
+		c.path = append(c.path, []pdfPoint{p})
+		return
+	}
+	c.path[len(c.path)-1] = append(c.path[len(c.path)-1], p)
+}
+
+// curveTo 添加三次贝塞尔曲线，拆分为线段
+func (c *pdfCanvas) curveTo(p1, p2, p3 pdfPoint) {
+	p0, ok := c.current()
+	if !ok {
+		c.lineTo(p3)
+		return
+	}
+	for i := 1; i <= goRenderCurveSteps; i++ {
+		t := float64(i) / goRenderCurveSteps
+		u := 1 - t
+		c.lineTo(pdfPoint{
+			X: u*u*u*p0.X + 3*u*u*t*p1.X + 3*u*t*t*p2.X + t*t*t*p3.X,
+			Y: u*u*u*p0.Y + 3*u*u*t*p1.Y + 3*u*t*t*p2.Y + t*t*t*p3.Y,
+		})
+	}
+}
+
+// endPath 结束路径，W/W*设置的裁剪按路径外接矩形处理
+func (c *pdfCanvas) endPath() {
+	if c.pendClip {
+		c.gs.clip = c.gs.clip.Intersect(pathBounds(c.path))
+		c.pendClip = false
+	}
+	c.path = nil
+}
+
+// pathBounds 路径的外接矩形
+func pathBounds(path [][]pdfPoint) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, sub := range path {
+		for _, p := range sub {
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+			maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+		}
+	}
+	if minX > maxX {
+		return image.Rectangle{}
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
+
+// fillPath 按扫描线填充路径
+func (c *pdfCanvas) fillPath(col color.RGBA, evenOdd bool) {
+	fillPolygons(c.img, c.gs.clip, c.path, col, evenOdd)
+}
+
+// strokePath 描边路径，每条线段绘制为矩形，线宽至少1像素
+func (c *pdfCanvas) strokePath() {
+	sx, sy := c.gs.ctm.apply(c.gs.lineWidth, 0)
+	ox, oy := c.gs.ctm.apply(0, 0)
+	half := math.Max(math.Hypot(sx-ox, sy-oy), 1) / 2
+
+	var quads [][]pdfPoint
+	for _, sub := range c.path {
+		for i := 1; i < len(sub); i++ {
+			a, b := sub[i-1], sub[i]
+			dx, dy := b.X-a.X, b.Y-a.Y
+			length := math.Hypot(dx, dy)
+			if length == 0 {
+				continue
+			}
+			nx, ny := -dy/length*half, dx/length*half
+			quads = append(quads, []pdfPoint{
+				{a.X + nx, a.Y + ny}, {b.X + nx, b.Y + ny},
+				{b.X - nx, b.Y - ny}, {a.X - nx, a.Y - ny}, {a.X + nx, a.Y + ny},
+			})
+		}
+	}
+	fillPolygons(c.img, c.gs.clip, quads, c.gs.stroke, false)
+}
+
+// pdfEdge 填充使用的边
+type pdfEdge struct {
+	x0, y0, x1, y1 float64
+	dir            int
+}
+
+// fillPolygons 扫描线填充多边形，采样像素中心，不做抗锯齿
+func fillPolygons(img *image.RGBA, clip image.Rectangle, polygons [][]pdfPoint, col color.RGBA, evenOdd bool) {
+	var edges []pdfEdge
+	bounds := pathBounds(polygons).Intersect(clip).Intersect(img.Bounds())
+	if bounds.Empty() {
+		return
+	}
+	for _, poly := range polygons {
+		for i := range poly {
+			a, b := poly[i], poly[(i+1)%len(poly)]
+			if a.Y == b.Y {
+				continue
+			}
+			if a.Y < b.Y {
+				edges = append(edges, pdfEdge{a.X, a.Y, b.X, b.Y, 1})
+			} else {
+				edges = append(edges, pdfEdge{b.X, b.Y, a.X, a.Y, -1})
+			}
+		}
+	}
+
+	type crossing struct {
+		x   float64
+		dir int
+	}
+	var xs []crossing
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		cy := float64(y) + 0.5
+		xs = xs[:0]
+		for _, e := range edges {
+			if cy >= e.y0 && cy < e.y1 {
+				xs = append(xs, crossing{e.x0 + (cy-e.y0)/(e.y1-e.y0)*(e.x1-e.x0), e.dir})
+			}
+		}
+		sort.Slice(xs, func(i, j int) bool { return xs[i].x < xs[j].x })
+
+		winding := 0
+		for i := 0; i+1 < len(xs); i++ {
+			if evenOdd {
+				winding ^= 1
+			} else {
+				winding += xs[i].dir
+			}
+			if winding == 0 {
+				continue
+			}
+			x0 := max(int(math.Ceil(xs[i].x-0.5)), bounds.Min.X)
+			x1 := min(int(math.Ceil(xs[i+1].x-0.5)), bounds.Max.X)
+			for x := x0; x < x1; x++ {
+				img.SetRGBA(x, y, col)
+			}
+		}
+	}
+}
+
+// font 按资源名称读取字体，同一字体对象只解析一次
+func (c *pdfCanvas) font(resources pdfDict, name interface{}) *pdfFont {
+	fonts, _ := c.doc.resolveDict(resources["Font"])
+	key, _ := name.(pdfName)
+	obj := fonts[key]
+	if ref, ok := obj.(pdfRef); ok {
+		if f, ok := c.fonts[ref.Num]; ok {
+			return f
+		}
+		f := c.doc.loadFont(ref)
+		c.fonts[ref.Num] = f
+		return f
+	}
+	return c.doc.loadFont(obj)
+}
+
+// moveText 移动到下一行的起点
+func (c *pdfCanvas) moveText(tx, ty float64) {
+	c.gs.lineMatrix = pdfMatrix{1, 0, 0, 1, tx, ty}.mul(c.gs.lineMatrix)
+	c.gs.textMatrix = c.gs.lineMatrix
+}
+
+// advance 沿文字方向移动
+func (c *pdfCanvas) advance(tx float64) {
+	c.gs.textMatrix = pdfMatrix{1, 0, 0, 1, tx, 0}.mul(c.gs.textMatrix)
+}
+
+// showText 绘制字符串并移动文字位置
+func (c *pdfCanvas) showText(obj interface{}) {
+	s, ok := obj.(pdfString)
+	gs := &c.gs
+	if !ok || gs.font == nil {
+		return
+	}
+	for _, g := range gs.font.decode(s) {
+		width := g.width / 1000 * gs.fontSize
+		// 渲染模式3为不可见文字，常见于扫描件的文字层
+		if gs.textRender != 3 && gs.textRender != 7 {
+			trm := pdfMatrix{gs.fontSize * gs.hScale, 0, 0, gs.fontSize, 0, gs.rise}.mul(gs.textMatrix).mul(gs.ctm)
+			c.drawGlyph(g, trm, g.width/1000)
+		}
+		tx := width + gs.charSpace
+		if g.space {
+			tx += gs.wordSpace
+		}
+		c.advance(tx * gs.hScale)
+	}
+}
+
+// drawGlyph 在文字空间中绘制字形，坐标单位为字号
+// 可打印ASCII字符使用点阵字体，其他可见字符绘制为方框
+func (c *pdfCanvas) drawGlyph(g pdfGlyph, trm pdfMatrix, advance float64) {
+	runes := []rune(g.text)
+	if len(runes) == 0 || runes[0] == ' ' || advance <= 0 {
+		return
+	}
+	quad := func(x0, y0, x1, y1 float64) []pdfPoint {
+		p := func(x, y float64) pdfPoint {
+			px, py := trm.apply(x, y)
+			return pdfPoint{px, py}
+		}
+		return []pdfPoint{p(x0, y0), p(x1, y0), p(x1, y1), p(x0, y1), p(x0, y0)}
+	}
+
+	glyph, ok := bitmapGlyph(runes[0])
+	if !ok {
+		// 方框占字宽的80%、字号的70%
+		outer := quad(advance*0.1, 0, advance*0.9, 0.7)
+		inner := quad(advance*0.2, 0.08, advance*0.8, 0.62)
+		fillPolygons(c.img, c.gs.clip, [][]pdfPoint{outer, inner}, c.gs.fill, true)
+		return
+	}
+
+	// 点阵每列占字宽的18%，每行占字号的10%，前7行在基线之上
+	var cells [][]pdfPoint
+	for row, bits := range glyph {
+		for col := 0; col < 5; col++ {
+			if bits&(0x10>>col) == 0 {
+				continue
+			}
+			x0 := advance * (0.05 + 0.18*float64(col))
+			y1 := 0.7 - 0.1*float64(row)
+			cells = append(cells, quad(x0, y1-0.1, x0+advance*0.18, y1))
+		}
+	}
+	fillPolygons(c.img, c.gs.clip, cells, c.gs.fill, false)
+}
+
+// drawXObject 绘制图片或表单XObject
+func (c *pdfCanvas) drawXObject(resources pdfDict, name interface{}, depth int) error {
+	xobjects, _ := c.doc.resolveDict(resources["XObject"])
+	key, _ := name.(pdfName)
+	obj, err := c.doc.resolve(xobjects[key])
+	if err != nil {
+		return nil
+	}
+	stream, ok := obj.(*pdfStream)
+	if !ok {
+		return nil
+	}
+
+	switch stream.Dict["Subtype"] {
+	case pdfName("Image"):
+		img, mask := c.doc.decodeImage(stream.Dict, stream.Raw)
+		c.drawImage(img, mask)
+	case pdfName("Form"):
+		if depth >= goRenderMaxFormNest {
+			return nil
+		}
+		data, err := c.doc.decodeStream(stream)
+		if err != nil {
+			return nil
+		}
+		formResources, _ := c.doc.resolveDict(stream.Dict["Resources"])
+		if formResources == nil {
+			formResources = resources
+		}
+
+		saved, savedStack := c.gs, len(c.stack)
+		if m, ok := stream.Dict["Matrix"].(pdfArray); ok && len(m) == 6 {
+			var matrix pdfMatrix
+			for i := range matrix {
+				matrix[i], _ = pdfNumber(m[i])
+			}
+			c.gs.ctm = matrix.mul(c.gs.ctm)
+		}
+		err = c.run(data, formResources, depth+1)
+		c.gs, c.stack = saved, c.stack[:savedStack]
+		return err
+	}
+	return nil
+}
+
+// drawInlineImage 绘制内联图片 BI ... ID data EI
+func (c *pdfCanvas) drawInlineImage(r *pdfContentReader) error {
+	dict, data, err := r.inlineImage()
+	if err != nil {
+		return nil
+	}
+	img, mask := c.doc.decodeImage(dict, data)
+	c.drawImage(img, mask)
+	return nil
+}
+
+// drawImage 将图片绘制到CTM对应的单位正方形，按最近邻采样
+// mask为true时图片为模板，灰度为0的像素使用填充色
+func (c *pdfCanvas) drawImage(img image.Image, mask bool) {
+	if img == nil {
+		return
+	}
+	ctm := c.gs.ctm
+	inverse, ok := ctm.invert()
+	if !ok {
+		return
+	}
+	corners := []pdfPoint{c.point(0, 0), c.point(1, 0), c.point(1, 1), c.point(0, 1)}
+	bounds := pathBounds([][]pdfPoint{corners}).Intersect(c.gs.clip).Intersect(c.img.Bounds())
+
+	src := img.Bounds()
+	w, h := float64(src.Dx()), float64(src.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			u, v := inverse.apply(float64(x)+0.5, float64(y)+0.5)
+			if u < 0 || u >= 1 || v < 0 || v >= 1 {
+				continue
+			}
+			// 图片第一行对应单位正方形的顶边
+			sx := src.Min.X + int(u*w)
+			sy := src.Min.Y + int((1-v)*h)
+			if sy >= src.Max.Y {
+				sy = src.Max.Y - 1
+			}
+			pixel := color.RGBAModel.Convert(img.At(sx, sy)).(color.RGBA)
+			if mask {
+				if pixel.R != 0 {
+					continue
+				}
+				pixel = c.gs.fill
+			}
+			c.img.SetRGBA(x, y, pixel)
+		}
+	}
+}
+
+// decodeImage 解码图片XObject，支持JPEG和8位灰度、RGB、CMYK及索引色的未压缩像素
+func (d *pdfDocument) decodeImage(dict pdfDict, raw []byte) (image.Image, bool) {
+	imageMask, _ := d.resolve(pdfDictGet(dict, "ImageMask", "IM"))
+	mask, _ := imageMask.(bool)
+	width := pdfIntDefault(pdfDictGet(dict, "Width", "W"), 0)
+	height := pdfIntDefault(pdfDictGet(dict, "Height", "H"), 0)
+	if width <= 0 || height <= 0 || width*height > goRenderMaxPixels {
+		return nil, false
+	}
+
+	filters := pdfDictGet(dict, "Filter", "F")
+	if name, ok := filters.(pdfName); ok {
+		filters = pdfArray{name}
+	}
+	list, _ := filters.(pdfArray)
+	if n := len(list); n > 0 && (list[n-1] == pdfName("DCTDecode") || list[n-1] == pdfName("DCT")) {
+		data, err := d.decodeStream(&pdfStream{Dict: pdfDict{"Filter": list[:n-1]}, Raw: raw})
+		if err != nil {
+			return nil, false
+		}
+		img, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, false
+		}
+		return img, false
+	}
+
+	data, err := d.decodeStream(&pdfStream{Dict: pdfDict{
+		"Filter":      filters,
+		"DecodeParms": pdfDictGet(dict, "DecodeParms", "DP"),
+	}, Raw: raw})
+	if err != nil {
+		return nil, false
+	}
+
+	bpc := pdfIntDefault(pdfDictGet(dict, "BitsPerComponent", "BPC"), 8)
+	if mask {
+		bpc = 1
+	}
+	components, palette := d.imageColorSpace(pdfDictGet(dict, "ColorSpace", "CS"))
+	if mask {
+		components, palette = 1, nil
+	}
+	if bpc != 8 && bpc != 1 {
+		return nil, false
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	rowBits := width * components * bpc
+	rowLen := (rowBits + 7) / 8
+	sample := func(row []byte, i int) int {
+		if bpc == 8 {
+			return int(row[i])
+		}
+		if row[i/8]&(0x80>>(i%8)) != 0 {
+			return 255
+		}
+		return 0
+	}
+	for y := 0; y < height && (y+1)*rowLen <= len(data); y++ {
+		row := data[y*rowLen : (y+1)*rowLen]
+		for x := 0; x < width; x++ {
+			i := x * components
+			var col color.RGBA
+			switch {
+			case palette != nil:
+				index := sample(row, i)
+				if bpc == 1 {
+					index /= 255
+				}
+				col = palette(index)
+			case components == 1:
+				v := uint8(sample(row, i))
+				col = color.RGBA{v, v, v, 0xFF}
+			case components == 3:
+				col = color.RGBA{uint8(sample(row, i)), uint8(sample(row, i+1)), uint8(sample(row, i+2)), 0xFF}
+			case components == 4:
+				col = cmykColor(float64(sample(row, i))/255, float64(sample(row, i+1))/255,
+					float64(sample(row, i+2))/255, float64(sample(row, i+3))/255)
+			}
+			img.SetRGBA(x, y, col)
+		}
+	}
+	return img, mask
+}
+
+// imageColorSpace 返回颜色空间的分量数，索引色同时返回查色函数
+func (d *pdfDocument) imageColorSpace(obj interface{}) (int, func(int) color.RGBA) {
+	obj, _ = d.resolve(obj)
+	switch cs := obj.(type) {
+	case pdfName:
+		switch cs {
+		case "DeviceRGB", "RGB", "CalRGB":
+			return 3, nil
+		case "DeviceCMYK", "CMYK":
+			return 4, nil
+		}
+		return 1, nil
+	case pdfArray:
+		if len(cs) == 0 {
+			return 1, nil
+		}
+		switch cs[0] {
+		case pdfName("ICCBased"):
+			if len(cs) > 1 {
+				if stream, _ := d.resolve(cs[1]); stream != nil {
+					if s, ok := stream.(*pdfStream); ok {
+						return pdfIntDefault(s.Dict["N"], 3), nil
+					}
+				}
+			}
+			return 3, nil
+		case pdfName("CalRGB"), pdfName("Lab"):
+			return 3, nil
+		case pdfName("Indexed"), pdfName("I"):
+			if len(cs) < 4 {
+				return 1, nil
+			}
+			base, _ := d.imageColorSpace(cs[1])
+			lookup, _ := d.resolve(cs[3])
+			var table []byte
+			switch l := lookup.(type) {
+			case pdfString:
+				table = []byte(l)
+			case *pdfStream:
+				table, _ = d.decodeStream(l)
+			}
+			return 1, func(i int) color.RGBA {
+				off := i * base
+				if off+base > len(table) {
+					return color.RGBA{A: 0xFF}
+				}
+				switch base {
+				case 3:
+					return color.RGBA{table[off], table[off+1], table[off+2], 0xFF}
+				case 4:
+					return cmykColor(float64(table[off])/255, float64(table[off+1])/255,
+						float64(table[off+2])/255, float64(table[off+3])/255)
+				}
+				return color.RGBA{table[off], table[off], table[off], 0xFF}
+			}
+		}
+	}
+	return 1, nil
+}
+
+// pdfDictGet 读取字典项，内联图片使用缩写的键名
+func pdfDictGet(dict pdfDict, key, abbr pdfName) interface{} {
+	if v, ok := dict[key]; ok {
+		return v
+	}
+	return dict[abbr]
+}
+
+// rotateImage 按页面的/Rotate顺时针旋转图片
+func rotateImage(img *image.RGBA, rotate int) image.Image {
+	if rotate%90 != 0 || rotate%360 == 0 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	var out *image.RGBA
+	switch rotate % 360 {
+	case 90:
+		out = image.NewRGBA(image.Rect(0, 0, h, w))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				out.SetRGBA(h-1-y, x, img.RGBAAt(x, y))
+			}
+		}
+	case 180:
+		out = image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				out.SetRGBA(w-1-x, h-1-y, img.RGBAAt(x, y))
+			}
+		}
+	case 270:
+		out = image.NewRGBA(image.Rect(0, 0, h, w))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				out.SetRGBA(y, w-1-x, img.RGBAAt(x, y))
+			}
+		}
+	}
+	return out
+}
+
+// clampUnit 将颜色分量限制在0到1之间
+func clampUnit(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func grayColor(g float64) color.RGBA {
+	v := uint8(clampUnit(g) * 255)
+	return color.RGBA{v, v, v, 0xFF}
+}
+
+func rgbColor(r, g, b float64) color.RGBA {
+	return color.RGBA{uint8(clampUnit(r) * 255), uint8(clampUnit(g) * 255), uint8(clampUnit(b) * 255), 0xFF}
+}
+
+func cmykColor(c, m, y, k float64) color.RGBA {
+	k = clampUnit(k)
+	return rgbColor((1-clampUnit(c))*(1-k), (1-clampUnit(m))*(1-k), (1-clampUnit(y))*(1-k))
+}
+
+// componentColor 按分量个数解释sc/scn设置的颜色，图案等非数字参数忽略
+func componentColor(args []interface{}) (color.RGBA, bool) {
+	values := make([]float64, 0, len(args))
+	for _, a := range args {
+		v, ok := pdfNumber(a)
+		if !ok {
+			return color.RGBA{}, false
+		}
+		values = append(values, v)
+	}
+	switch len(values) {
+	case 1:
+		return grayColor(values[0]), true
+	case 3:
+		return rgbColor(values[0], values[1], values[2]), true
+	case 4:
+		return cmykColor(values[0], values[1], values[2], values[3]), true
+	}
+	return color.RGBA{}, false
+}
+
+// pdfContentReader 按操作读取内容流，返回操作符及其之前的操作数
+type pdfContentReader struct {
+	parser pdfParser
+}
+
+// next 读取下一个操作，内容结束时返回io.EOF
+func (r *pdfContentReader) next() (string, []interface{}, error) {
+	l := r.parser.lexer
+	var operands []interface{}
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.data) {
+			return "", nil, io.EOF
+		}
+		c := l.data[l.pos]
+		switch {
+		case c == '/' || c == '(' || c == '<' || c == '[' || c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+			obj, err := r.parser.parseObject(0)
+			if err != nil {
+				return "", nil, err
+			}
+			operands = append(operands, obj)
+			continue
+		case isPDFDelim(c):
+			// 多余的分隔符（如 ] > { }）跳过
+			l.pos++
+			continue
+		}
+
+		switch op := string(l.regular()); op {
+		case "true":
+			operands = append(operands, true)
+		case "false":
+			operands = append(operands, false)
+		case "null":
+			operands = append(operands, nil)
+		default:
+			return op, operands, nil
+		}
+	}
+}
+
+// inlineImage 读取BI之后的内联图片参数和数据
+func (r *pdfContentReader) inlineImage() (pdfDict, []byte, error) {
+	l := r.parser.lexer
+	dict := pdfDict{}
+	for {
+		l.skipSpace()
+		if l.hasKeyword("ID") {
+			l.pos += len("ID")
+			break
+		}
+		if l.pos >= len(l.data) || l.data[l.pos] != '/' {
+			return nil, nil, fmt.Errorf("%w: 无效的内联图片", errPDFSyntax)
+		}
+		l.pos++
+		key, err := r.parser.parseName()
+		if err != nil {
+			return nil, nil, err
+		}
+		value, err := r.parser.parseObject(0)
+		if err != nil {
+			return nil, nil, err
+		}
+		dict[key] = value
+	}
+
+	// ID之后紧跟一个空白字符，数据以空白字符加EI结束
+	if l.pos < len(l.data) && isPDFSpace(l.data[l.pos]) {
+		l.pos++
+	}
+	start := l.pos
+	for i := start; i+2 <= len(l.data); i++ {
+		if l.data[i] == 'E' && l.data[i+1] == 'I' && i > start && isPDFSpace(l.data[i-1]) &&
+			(i+2 == len(l.data) || isPDFSpace(l.data[i+2]) || isPDFDelim(l.data[i+2])) {
+			l.pos = i + 2
+			return dict, l.data[start : i-1], nil
+		}
+	}
+	l.pos = len(l.data)
+	return nil, nil, fmt.Errorf("%w: 内联图片缺少EI", errPDFSyntax)
+}
@@ -0,0 +1,110 @@
+package util
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// PDF校验和转换相关错误
+var (
+	ErrPDFMalformed         = errors.New("PDF文件已损坏或格式不正确")
+	ErrPDFEncrypted         = errors.New("不支持加密的PDF文件")
+	ErrPDFTooManyPages      = errors.New("PDF页数超过限制")
+	ErrPDFPageTooLarge      = errors.New("PDF页面尺寸超过限制")
+	ErrConvertTimeout       = errors.New("文件转换超时")
+	ErrConvertResourceLimit = errors.New("文件转换超出资源限制")
+)
+
+// ConversionLimits 上传文件校验和外部转换命令的限制
+type ConversionLimits struct {
+	MaxPages    int           // 最大页数，0表示不限制
+	MaxPageSize float64       // 页面宽高上限（pt），0表示不限制
+	Timeout     time.Duration // 单次转换命令的超时时间，0表示不限制
+	CPUTime     time.Duration // 单次转换命令的CPU时间上限，0表示不限制
+	Memory      int64         // 单次转换命令的虚拟内存上限（字节），0表示不限制
+}
+
+// conversionLimits 当前生效的限制
+var conversionLimits = ConversionLimits{
+	MaxPages:    10,
+	MaxPageSize: 2000,
+	Timeout:     time.Minute,
+	CPUTime:     30 * time.Second,
+	Memory:      1024 * 1024 * 1024,
+}
+
+// SetConversionLimits 设置上传文件校验和外部转换命令的限制
+func SetConversionLimits(limits ConversionLimits) {
+	conversionLimits = limits
+}
+
+// PDFInfo PDF校验结果
+type PDFInfo struct {
+	Version string  // 文件头中的PDF版本
+	Pages   int     // 页数
+	Width   float64 // 最大页面宽度（pt）
+	Height  float64 // 最大页面高度（pt）
+}
+
+// pdfEOFSearch 文件尾%%EOF允许出现的范围，部分生成器会在其后追加少量数据
+const pdfEOFSearch = 4096
+
+// ValidatePDF 在交给外部转换工具之前检查上传的PDF
+// 按文件头识别类型，拒绝加密、结构损坏、页数或页面尺寸超过限制的文件
+func ValidatePDF(path string) (*PDFInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := parsePDF(data)
+	if err == ErrInvalidFileType {
+		return nil, ErrInvalidFileType
+	}
+	if err != nil {
+		GetLogger().Warn("PDF结构解析失败", zap.Error(err))
+		return nil, ErrPDFMalformed
+	}
+
+	// 缺少文件尾通常说明上传不完整
+	tail := data
+	if len(tail) > pdfEOFSearch {
+		tail = tail[len(tail)-pdfEOFSearch:]
+	}
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		GetLogger().Warn("PDF缺少文件结束标记")
+		return nil, ErrPDFMalformed
+	}
+
+	if doc.encrypted() {
+		return nil, ErrPDFEncrypted
+	}
+
+	pages, err := doc.pages(conversionLimits.MaxPages)
+	if err == ErrPDFTooManyPages {
+		return nil, ErrPDFTooManyPages
+	}
+	if err != nil {
+		GetLogger().Warn("PDF页面树解析失败", zap.Error(err))
+		return nil, ErrPDFMalformed
+	}
+
+	info := &PDFInfo{Version: doc.version, Pages: len(pages)}
+	for _, page := range pages {
+		width, height := page.CropBox.Width(), page.CropBox.Height()
+		if width < 1 || height < 1 {
+			GetLogger().Warn("PDF页面尺寸无效", zap.Float64("width", width), zap.Float64("height", height))
+			return nil, ErrPDFMalformed
+		}
+		if limit := conversionLimits.MaxPageSize; limit > 0 && (width > limit || height > limit) {
+			return nil, ErrPDFPageTooLarge
+		}
+		info.Width = max(info.Width, width)
+		info.Height = max(info.Height, height)
+	}
+	return info, nil
+}
@@ -0,0 +1,144 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 感知哈希参数：缩放为32x32灰度图，取DCT低频部分的8x8系数
+const (
+	phashSize    = 32
+	phashLowFreq = 8
+)
+
+// PHashBands 感知哈希按字节划分的分段数
+// 汉明距离小于分段数的两个哈希至少有一段完全相同，可先按分段索引筛选候选再计算距离
+const PHashBands = 8
+
+// ErrInvalidPHash 感知哈希格式不正确
+var ErrInvalidPHash = errors.New("无效的感知哈希")
+
+// PerceptualHash 计算图片的感知哈希（pHash），返回16位十六进制字符串
+// 内容相近的图片（重新导出、轻微修改、不同分辨率）哈希的汉明距离较小
+func PerceptualHash(img image.Image) string {
+	pixels := grayscaleThumbnail(img, phashSize)
+
+	// 二维DCT，只计算低频部分
+	coeffs := make([]float64, 0, phashLowFreq*phashLowFreq)
+	for u := 0; u < phashLowFreq; u++ {
+		for v := 0; v < phashLowFreq; v++ {
+			var sum float64
+			for x := 0; x < phashSize; x++ {
+				cu := math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * phashSize))
+				for y := 0; y < phashSize; y++ {
+					sum += pixels[x*phashSize+y] * cu * math.Cos(float64(2*y+1)*float64(v)*math.Pi/(2*phashSize))
+				}
+			}
+			coeffs = append(coeffs, sum)
+		}
+	}
+
+	// 以除直流分量外系数的中位数为阈值
+	sorted := append([]float64(nil), coeffs[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+
+	var hash uint64
+	for i, c := range coeffs {
+		if c > median {
+			hash |= 1 << uint(len(coeffs)-1-i)
+		}
+	}
+	return fmt.Sprintf("%016x", hash)
+}
+
+// HammingDistance 计算两个感知哈希的汉明距离
+func HammingDistance(a, b string) (int, error) {
+	x, err := parsePHash(a)
+	if err != nil {
+		return 0, err
+	}
+	y, err := parsePHash(b)
+	if err != nil {
+		return 0, err
+	}
+	return bits.OnesCount64(x ^ y), nil
+}
+
+// parsePHash 解析16位十六进制的感知哈希
+func parsePHash(s string) (uint64, error) {
+	if len(s) != 16 {
+		return 0, ErrInvalidPHash
+	}
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, ErrInvalidPHash
+	}
+	return v, nil
+}
+
+// grayscaleThumbnail 将图片按区域平均缩放为size x size的灰度图，按行优先返回亮度
+func grayscaleThumbnail(img image.Image, size int) []float64 {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	pixels := make([]float64, size*size)
+	if w == 0 || h == 0 {
+		return pixels
+	}
+
+	for ty := 0; ty < size; ty++ {
+		y0 := b.Min.Y + ty*h/size
+		y1 := b.Min.Y + max((ty+1)*h/size, ty*h/size+1)
+		for tx := 0; tx < size; tx++ {
+			x0 := b.Min.X + tx*w/size
+			x1 := b.Min.X + max((tx+1)*w/size, tx*w/size+1)
+
+			var sum float64
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					r, g, bl, _ := img.At(x, y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+				}
+			}
+			pixels[ty*size+tx] = sum / float64((y1-y0)*(x1-x0)) / 0xffff * 255
+		}
+	}
+	return pixels
+}
+
+// PHashBandExpr 第band段对应的SQL表达式，与MigratePHashIndexes创建的表达式索引一致
+func PHashBandExpr(band int) string {
+	return fmt.Sprintf("substr(p_hash, %d, 2)", band*2+1)
+}
+
+// PHashBandValues 感知哈希各分段的值
+func PHashBandValues(hash string) ([]string, error) {
+	if _, err := parsePHash(hash); err != nil {
+		return nil, err
+	}
+	hash = strings.ToLower(hash)
+	values := make([]string, PHashBands)
+	for i := range values {
+		values[i] = hash[i*2 : i*2+2]
+	}
+	return values, nil
+}
+
+// MigratePHashIndexes 为简历感知哈希的每个分段创建表达式索引，可重复执行
+func MigratePHashIndexes(db *gorm.DB) {
+	for band := 0; band < PHashBands; band++ {
+		sql := fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_resumes_p_hash_band%d ON resumes (%s) WHERE p_hash <> ''", band, PHashBandExpr(band))
+		if err := db.Exec(sql).Error; err != nil {
+			GetLogger().Error("创建感知哈希索引失败", zap.Error(err), zap.Int("band", band))
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package util
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy 限流策略（令牌桶）
+// 桶容量为Limit，每Period补满Limit个令牌
+type RateLimitPolicy struct {
+	Name   string        // 策略名称，作为限流键的前缀
+	Limit  int           // 桶容量，即周期内允许的请求数
+	Period time.Duration // 补满令牌桶所需的时间
+}
+
+// Enabled 判断策略是否生效
+func (p RateLimitPolicy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// RateLimitResult 限流判定结果
+type RateLimitResult struct {
+	Allowed    bool          // 是否放行
+	Limit      int           // 桶容量
+	Remaining  int           // 剩余令牌数
+	ResetAfter time.Duration // 令牌桶补满所需时间
+	RetryAfter time.Duration // 被拒绝时建议的重试等待时间
+}
+
+// RateLimitStore 限流存储后端接口
+type RateLimitStore interface {
+	// Take 尝试从key对应的令牌桶中取出一个令牌
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (*RateLimitResult, error)
+}
+
+// TakeToken 根据上次状态计算本次取令牌的结果，返回新的令牌数
+// 各存储后端共用该算法，只负责状态的读写
+func TakeToken(tokens float64, lastRefill, now time.Time, policy RateLimitPolicy) (float64, *RateLimitResult) {
+	capacity := float64(policy.Limit)
+	rate := capacity / policy.Period.Seconds() // 每秒补充的令牌数
+
+	// 按流逝时间补充令牌
+	if elapsed := now.Sub(lastRefill).Seconds(); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*rate)
+	}
+
+	result := &RateLimitResult{Limit: policy.Limit}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	result.Remaining = int(math.Floor(tokens))
+	result.ResetAfter = secondsToDuration((capacity - tokens) / rate)
+	return tokens, result
+}
+
+// secondsToDuration 将秒数转换为时间间隔
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// memoryBucket 内存令牌桶
+type memoryBucket struct {
+	tokens     float64
+	lastRefill time.Time
+	period     time.Duration
+}
+
+// memoryRateLimitStore 进程内限流存储，适用于单实例部署
+type memoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+}
+
+// NewMemoryRateLimitStore 创建内存限流存储，并启动过期令牌桶清理goroutine
+func NewMemoryRateLimitStore() RateLimitStore {
+	s := &memoryRateLimitStore{
+		buckets: make(map[string]*memoryBucket),
+	}
+	go s.cleanup()
+	return s
+}
+
+// Take 尝试取出一个令牌
+func (s *memoryRateLimitStore) Take(_ context.Context, key string, policy RateLimitPolicy) (*RateLimitResult, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(policy.Limit), lastRefill: now}
+		s.buckets[key] = bucket
+	}
+
+	tokens, result := TakeToken(bucket.tokens, bucket.lastRefill, now, policy)
+	bucket.tokens = tokens
+	bucket.lastRefill = now
+	bucket.period = policy.Period
+
+	return result, nil
+}
+
+// cleanup 定期清理已补满的令牌桶（与新建桶等价，无需保留）
+func (s *memoryRateLimitStore) cleanup() {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now()
+		s.mu.Lock()
+		for key, bucket := range s.buckets {
+			if now.Sub(bucket.lastRefill) > bucket.period {
+				delete(s.buckets, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,373 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// 简历图片支持的输出格式
+const (
+	ImageFormatJPEG = "jpg"
+	ImageFormatPNG  = "png"
+	ImageFormatWebP = "webp"
+)
+
+// webpMaxDimension WebP格式的最大宽高
+const webpMaxDimension = 16383
+
+// imageFormatMIME 输出格式对应的MIME类型，用于Accept协商
+var imageFormatMIME = map[string]string{
+	ImageFormatJPEG: "image/jpeg",
+	ImageFormatPNG:  "image/png",
+	ImageFormatWebP: "image/webp",
+}
+
+// ErrWebPEncoderNotFound 未找到WebP编码工具
+var ErrWebPEncoderNotFound = errors.New("未找到WebP编码工具（cwebp或ImageMagick）")
+
+// RenderProfile 简历图片的渲染配置
+type RenderProfile struct {
+	Formats  []string // 输出格式，第一个为主格式，保存在简历记录中，其余格式作为备选版本按Accept协商返回
+	Quality  int      // 有损格式的质量 (1-100)
+	DPI      int      // 渲染分辨率
+	MaxWidth int      // 长图最大宽度（像素），超过时按比例缩小，0表示不限制
+}
+
+// renderProfile 当前生效的渲染配置
+var renderProfile = RenderProfile{
+	Formats:  []string{DefaultImageFormat},
+	Quality:  DefaultImageQuality,
+	DPI:      DefaultDPI,
+	MaxWidth: 0,
+}
+
+// SetRenderProfile 设置简历图片的渲染配置，未设置的项使用默认值
+// 主格式为WebP但没有编码工具时返回错误，备选格式不可用时只记录警告并忽略
+func SetRenderProfile(profile RenderProfile) error {
+	var formats []string
+	seen := make(map[string]bool)
+	for _, f := range profile.Formats {
+		f = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(f), "."))
+		if f == "jpeg" {
+			f = ImageFormatJPEG
+		}
+		if f == "" || seen[f] {
+			continue
+		}
+		if _, ok := imageFormatMIME[f]; !ok {
+			return fmt.Errorf("不支持的图片格式: %s", f)
+		}
+		if f == ImageFormatWebP && webpEncoder() == "" {
+			if len(formats) == 0 {
+				return ErrWebPEncoderNotFound
+			}
+			GetLogger().Warn("未找到WebP编码工具，不生成WebP版本")
+			continue
+		}
+		seen[f] = true
+		formats = append(formats, f)
+	}
+	if len(formats) == 0 {
+		formats = []string{DefaultImageFormat}
+	}
+	profile.Formats = formats
+
+	if profile.Quality <= 0 || profile.Quality > 100 {
+		profile.Quality = DefaultImageQuality
+	}
+	if profile.DPI <= 0 {
+		profile.DPI = DefaultDPI
+	}
+	if profile.MaxWidth < 0 {
+		profile.MaxWidth = 0
+	}
+	renderProfile = profile
+	return nil
+}
+
+// GetRenderProfile 获取当前的渲染配置
+func GetRenderProfile() RenderProfile {
+	return renderProfile
+}
+
+// Tag 影响图片内容的渲染参数，写入简历图片的存储键，例如 d150-q90-w2000
+// 修改渲染配置后相同内容的上传会生成新的图片，不再复用按旧配置渲染的图片
+func (p RenderProfile) Tag() string {
+	return fmt.Sprintf("d%d-q%d-w%d", p.DPI, p.Quality, p.MaxWidth)
+}
+
+// imageBlobKey 按当前渲染配置生成的主格式简历图片的存储键
+// 例如: blobs/3f/3fa2...e1-d150-q90-w2000.jpg，其他格式的版本只有扩展名不同
+func imageBlobKey(contentHash string) StorageKey {
+	return BlobKey(contentHash+"-"+renderProfile.Tag(), "."+primaryImageFormat())
+}
+
+// primaryImageFormat 简历记录中保存的图片格式
+func primaryImageFormat() string {
+	return renderProfile.Formats[0]
+}
+
+// RenditionKey 同一简历图片另一种格式的存储键，与原图片只有扩展名不同
+func RenditionKey(imageKey StorageKey, format string) StorageKey {
+	s := imageKey.String()
+	return StorageKey(strings.TrimSuffix(s, imageKey.Ext()) + "." + format)
+}
+
+// imageKeyFormat 简历图片存储键对应的格式，不是内容寻址的简历图片时返回空字符串
+// 只有内容寻址的图片有其他格式的版本
+func imageKeyFormat(key StorageKey) string {
+	if !IsBlobKey(key.String()) {
+		return ""
+	}
+	format := strings.TrimPrefix(strings.ToLower(key.Ext()), ".")
+	if _, ok := imageFormatMIME[format]; !ok {
+		return ""
+	}
+	return format
+}
+
+// renditionKeys 简历图片其他格式版本的存储键，包括当前配置之外的格式，用于删除
+func renditionKeys(key StorageKey) []StorageKey {
+	format := imageKeyFormat(key)
+	if format == "" {
+		return nil
+	}
+	var keys []StorageKey
+	for f := range imageFormatMIME {
+		if f != format {
+			keys = append(keys, RenditionKey(key, f))
+		}
+	}
+	return keys
+}
+
+// HasRenditions 文件是否可能有其他格式的版本，提供文件时需要按Accept协商
+func HasRenditions(key StorageKey) bool {
+	return imageKeyFormat(key) != ""
+}
+
+// NegotiateRendition 按Accept请求头选择简历图片的格式，返回实际提供的存储键
+// 客户端明确列出的格式优先于通配符匹配的格式，同等条件下使用原格式，备选格式不存在时同样使用原格式
+func NegotiateRendition(ctx context.Context, key StorageKey, accept string) StorageKey {
+	primary := imageKeyFormat(key)
+	if primary == "" || strings.TrimSpace(accept) == "" {
+		return key
+	}
+
+	best := key
+	bestQ, bestSpecific := acceptQuality(accept, imageFormatMIME[primary])
+	for _, format := range renderProfile.Formats {
+		if format == primary {
+			continue
+		}
+		q, specific := acceptQuality(accept, imageFormatMIME[format])
+		if q < bestQ || (q == bestQ && specific <= bestSpecific) {
+			continue
+		}
+		candidate := RenditionKey(key, format)
+		if exists, err := blobExists(ctx, candidate); err != nil || !exists {
+			continue
+		}
+		best, bestQ, bestSpecific = candidate, q, specific
+	}
+	return best
+}
+
+// acceptQuality 解析Accept请求头，返回媒体类型的q值和匹配的精确程度（2为完全匹配，1为image/*，0为*/*）
+func acceptQuality(accept, mediaType string) (float64, int) {
+	q, specific := 0.0, -1
+	mainType := strings.SplitN(mediaType, "/", 2)[0]
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		rangeType := strings.ToLower(strings.TrimSpace(params[0]))
+
+		level := -1
+		switch rangeType {
+		case mediaType:
+			level = 2
+		case mainType + "/*":
+			level = 1
+		case "*/*":
+			level = 0
+		}
+		if level < 0 || level < specific {
+			continue
+		}
+
+		value := 1.0
+		for _, p := range params[1:] {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && strings.EqualFold(k, "q") {
+				if f, err := strconv.ParseFloat(v, 64); err == nil {
+					value = f
+				}
+			}
+		}
+		// 更精确的匹配覆盖通配符的q值
+		q, specific = value, level
+	}
+	if q <= 0 {
+		return 0, -1
+	}
+	return q, specific
+}
+
+// encodeImage 按格式将图片编码到path
+func encodeImage(img image.Image, format, path string, quality int) error {
+	switch format {
+	case ImageFormatJPEG:
+		return writeJPEG(path, img, quality)
+	case ImageFormatPNG:
+		out, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		if err := png.Encode(out, img); err != nil {
+			return err
+		}
+		return out.Close()
+	case ImageFormatWebP:
+		return encodeWebP(img, path, quality)
+	}
+	return fmt.Errorf("不支持的图片格式: %s", format)
+}
+
+// webpEncoder 可用的WebP编码工具
+func webpEncoder() string {
+	for _, cmd := range []string{"cwebp", "convert"} {
+		if _, err := exec.LookPath(cmd); err == nil {
+			return cmd
+		}
+	}
+	return ""
+}
+
+// encodeWebP 先输出无损PNG再调用外部工具编码为WebP，标准库没有WebP编码器
+// 超过WebP尺寸上限的图片会先等比缩小
+func encodeWebP(img image.Image, path string, quality int) error {
+	// 多页简历拼接的长图可能超过WebP的尺寸上限，等比缩小到上限以内
+	if b := img.Bounds(); b.Dx() > webpMaxDimension || b.Dy() > webpMaxDimension {
+		img = resizeToWidth(img, max(b.Dx()*webpMaxDimension/max(b.Dx(), b.Dy()), 1))
+	}
+	encoder := webpEncoder()
+	if encoder == "" {
+		return ErrWebPEncoderNotFound
+	}
+
+	source := strings.TrimSuffix(path, filepath.Ext(path)) + ".source.png"
+	if err := encodeImage(img, ImageFormatPNG, source, quality); err != nil {
+		return err
+	}
+	defer os.Remove(source)
+
+	var err error
+	if encoder == "cwebp" {
+		_, err = runConverter("cwebp", "-quiet", "-q", strconv.Itoa(quality), source, "-o", path)
+	} else {
+		_, err = runConverter("convert", source, "-quality", strconv.Itoa(quality), path)
+	}
+	return err
+}
+
+// resizeToWidth 将宽度超过width的图片按比例缩小，按区域平均采样
+func resizeToWidth(img image.Image, width int) image.Image {
+	b := img.Bounds()
+	if width <= 0 || b.Dx() <= width {
+		return img
+	}
+	height := max(b.Dy()*width/b.Dx(), 1)
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(b.Min.X+(x+1)*b.Dx()/width, x0+1)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			out.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(bl / n >> 8), A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return out
+}
+
+// renderResumeImages 将PDF渲染并拼接为长图，按formats输出多种格式，返回格式到文件路径的映射
+// 主格式输出失败时返回错误，备选格式失败只记录警告
+func renderResumeImages(pdfPath string, formats []string) (map[string]string, error) {
+	dir := filepath.Dir(pdfPath)
+	base := strings.TrimSuffix(filepath.Base(pdfPath), filepath.Ext(pdfPath))
+
+	// 临时目录，用于存放单页图片
+	tmpDir := filepath.Join(dir, fmt.Sprintf("%s_tmp", base))
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		GetLogger().Error("创建临时目录失败", zap.Error(err))
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir) // 清理临时目录
+
+	// 1. 将PDF分页转换为图片
+	pagePaths, err := RenderPDFPages(pdfPath, tmpDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(pagePaths) == 0 {
+		GetLogger().Error("未能生成任何图片页面")
+		return nil, ErrConvertPDFFailed
+	}
+
+	// 2. 按最大宽度缩放后垂直拼接为长图
+	pages := make([]image.Image, 0, len(pagePaths))
+	for _, path := range pagePaths {
+		img, err := decodeImageFile(path)
+		if err != nil {
+			GetLogger().Error("读取页面图片失败", zap.Error(err))
+			return nil, ErrConvertPDFFailed
+		}
+		pages = append(pages, resizeToWidth(img, renderProfile.MaxWidth))
+	}
+	stacked := newStackedImage(pages)
+
+	// 3. 按格式编码
+	images := make(map[string]string, len(formats))
+	for _, format := range formats {
+		path := filepath.Join(dir, base+"."+format)
+		if err := encodeImage(stacked, format, path, renderProfile.Quality); err != nil {
+			if format == primaryImageFormat() {
+				GetLogger().Error("输出简历图片失败", zap.Error(err), zap.String("format", format))
+				if isConvertLimitError(err) {
+					return nil, err
+				}
+				return nil, ErrConvertPDFFailed
+			}
+			GetLogger().Warn("输出简历图片备选格式失败", zap.Error(err), zap.String("format", format))
+			continue
+		}
+		images[format] = path
+	}
+
+	GetLogger().Info("多页PDF转换为长图成功",
+		zap.Int("页数", len(pagePaths)),
+		zap.Strings("格式", formats))
+	return images, nil
+}
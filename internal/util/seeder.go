@@ -3322,3 +3322,35 @@ func SeedUniversities(db *gorm.DB) {
 	
 	GetLogger().Info("成功初始化大学数据", zap.Int("count", len(universities)))
 }
+
+// GrantAdmins 为已注册的用户授予管理员权限，返回不存在的用户名
+// 只处理执行时已存在的账户，不会为之后注册的同名账户保留权限
+func GrantAdmins(db *gorm.DB, usernames []string) ([]string, error) {
+	var existing []string
+	if err := db.Model(&domain.User{}).Where("username IN ?", usernames).Pluck("username", &existing).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		found[name] = true
+	}
+	var missing []string
+	for _, name := range usernames {
+		if !found[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(existing) == 0 {
+		return missing, nil
+	}
+
+	result := db.Model(&domain.User{}).
+		Where("username IN ? AND is_admin = ?", existing, false).
+		Update("is_admin", true)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	GetLogger().Info("已授予管理员权限", zap.Strings("usernames", existing), zap.Int64("promoted", result.RowsAffected))
+	return missing, nil
+}
@@ -0,0 +1,377 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrPresignNotSupported 存储不支持生成预签名URL，需由应用代为提供文件
+var ErrPresignNotSupported = errors.New("存储不支持预签名URL")
+
+// ErrInvalidStorageKey 存储键不合法，如包含..或为绝对路径
+var ErrInvalidStorageKey = errors.New("无效的存储键")
+
+// StorageObject 存储中的文件信息
+type StorageObject struct {
+	Key         StorageKey
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage 文件存储接口，实现需拒绝未通过StorageKey.Validate校验的键
+type Storage interface {
+	// Put 写入文件，size未知时传-1，已存在时覆盖
+	Put(ctx context.Context, key StorageKey, r io.Reader, size int64, contentType string) error
+	// Get 读取文件，文件不存在时返回ErrFileNotFound，调用方负责关闭返回的Reader
+	Get(ctx context.Context, key StorageKey) (io.ReadCloser, *StorageObject, error)
+	// Stat 获取文件信息，文件不存在时返回ErrFileNotFound
+	Stat(ctx context.Context, key StorageKey) (*StorageObject, error)
+	// Delete 删除文件，文件不存在时不返回错误
+	Delete(ctx context.Context, key StorageKey) error
+	// List 列出键以prefix开头的全部文件，prefix为键的字符串前缀，如 resumes/1/
+	List(ctx context.Context, prefix string) ([]StorageObject, error)
+	// PresignedURL 生成可直接访问文件的临时URL，不支持时返回ErrPresignNotSupported
+	PresignedURL(ctx context.Context, key StorageKey, expires time.Duration) (string, error)
+}
+
+// storageMover 支持直接移动文件的存储，未实现时通过复制再删除完成移动
+type storageMover interface {
+	Move(ctx context.Context, srcKey, dstKey StorageKey) error
+}
+
+var (
+	fileStorage   Storage
+	fileURLExpiry = 15 * time.Minute
+)
+
+// SetStorage 设置文件存储，urlExpiry为预签名URL的有效期
+func SetStorage(storage Storage, urlExpiry time.Duration) {
+	fileStorage = storage
+	if urlExpiry > 0 {
+		fileURLExpiry = urlExpiry
+	}
+}
+
+// GetStorage 获取文件存储，未设置时使用上传目录作为本地存储
+func GetStorage() Storage {
+	if fileStorage == nil {
+		fileStorage = NewLocalStorage(UploadDir)
+	}
+	return fileStorage
+}
+
+// OpenStorage 根据存储类型创建文件存储，local保存在上传目录中，s3使用s3cfg连接对象存储
+func OpenStorage(driver string, s3cfg S3Config) (Storage, error) {
+	switch driver {
+	case "local":
+		if err := os.MkdirAll(UploadDir, 0755); err != nil {
+			return nil, fmt.Errorf("创建上传目录失败: %w", err)
+		}
+		return NewLocalStorage(UploadDir), nil
+	case "s3":
+		return NewS3Storage(s3cfg)
+	default:
+		return nil, fmt.Errorf("不支持的存储类型: %s", driver)
+	}
+}
+
+// OpenFile 打开存储中的文件
+func OpenFile(ctx context.Context, key StorageKey) (io.ReadCloser, *StorageObject, error) {
+	return GetStorage().Get(ctx, key)
+}
+
+// ReadFile 读取存储中的文件内容
+func ReadFile(ctx context.Context, key StorageKey) ([]byte, error) {
+	r, _, err := OpenFile(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// WriteFile 将内容写入存储
+func WriteFile(ctx context.Context, key StorageKey, data []byte, contentType string) error {
+	return GetStorage().Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+}
+
+// StoreLocalFile 将本地文件写入存储
+func StoreLocalFile(ctx context.Context, localPath string, key StorageKey) error {
+	return putLocalFile(ctx, localPath, key)
+}
+
+// FetchToLocal 将存储中的文件下载到本地路径，供PDF转换、比较等需要本地文件的工具使用
+func FetchToLocal(ctx context.Context, key StorageKey, localPath string) error {
+	r, _, err := OpenFile(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	dst, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, r); err != nil {
+		return err
+	}
+	return dst.Sync()
+}
+
+// DeleteDir 删除dir下的全部文件
+func DeleteDir(ctx context.Context, dir StorageKey) error {
+	if err := dir.Validate(); err != nil {
+		return err
+	}
+
+	storage := GetStorage()
+	objects, err := storage.List(ctx, dir.String()+"/")
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := storage.Delete(ctx, obj.Key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putLocalFile 将本地文件写入存储
+func putLocalFile(ctx context.Context, localPath string, key StorageKey) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return GetStorage().Put(ctx, key, f, info.Size(), contentTypeByKey(key))
+}
+
+// moveObject 在存储内移动文件
+func moveObject(ctx context.Context, storage Storage, srcKey, dstKey StorageKey) error {
+	if mover, ok := storage.(storageMover); ok {
+		return mover.Move(ctx, srcKey, dstKey)
+	}
+
+	r, obj, err := storage.Get(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := storage.Put(ctx, dstKey, r, obj.Size, obj.ContentType); err != nil {
+		return err
+	}
+	return storage.Delete(ctx, srcKey)
+}
+
+// contentTypeByKey 根据扩展名推断文件类型
+func contentTypeByKey(key StorageKey) string {
+	if ct := mime.TypeByExtension(strings.ToLower(key.Ext())); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// localStorage 本地文件系统存储
+type localStorage struct {
+	root string
+}
+
+// NewLocalStorage 创建以root为根目录的本地文件系统存储
+func NewLocalStorage(root string) Storage {
+	return &localStorage{root: root}
+}
+
+// resolve 将存储键解析为根目录下的本地路径，解析结果不在根目录中时拒绝
+func (s *localStorage) resolve(key StorageKey) (string, error) {
+	if err := key.Validate(); err != nil {
+		return "", err
+	}
+	p := filepath.Join(s.root, filepath.FromSlash(key.String()))
+	rel, err := filepath.Rel(s.root, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidStorageKey
+	}
+	return p, nil
+}
+
+// Put 先写入同目录下的临时文件再重命名，避免读取到写了一半的文件
+func (s *localStorage) Put(_ context.Context, key StorageKey, r io.Reader, _ int64, _ string) error {
+	p, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	tmp := p + ".tmp-" + uuid.New().String()[:8]
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Get 打开本地文件
+func (s *localStorage) Get(_ context.Context, key StorageKey) (io.ReadCloser, *StorageObject, error) {
+	p, err := s.resolve(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, ErrFileNotFound
+		}
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, ErrFileNotFound
+	}
+	return f, s.object(key, info), nil
+}
+
+// Stat 获取本地文件信息
+func (s *localStorage) Stat(_ context.Context, key StorageKey) (*StorageObject, error) {
+	p, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrFileNotFound
+		}
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, ErrFileNotFound
+	}
+	return s.object(key, info), nil
+}
+
+// Delete 删除本地文件
+func (s *localStorage) Delete(_ context.Context, key StorageKey) error {
+	p, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List 遍历prefix所在目录，返回键以prefix开头的文件
+func (s *localStorage) List(_ context.Context, prefix string) ([]StorageObject, error) {
+	dir := s.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		p, err := s.resolve(StorageKey(prefix[:i]))
+		if err != nil {
+			return nil, err
+		}
+		dir = p
+	}
+
+	var objects []StorageObject
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.root, p)
+		if err != nil {
+			return err
+		}
+		key := StorageKey(filepath.ToSlash(rel))
+		if !strings.HasPrefix(key.String(), prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, *s.object(key, info))
+		return nil
+	})
+	return objects, err
+}
+
+// PresignedURL 本地存储无法生成预签名URL，由应用提供文件
+func (s *localStorage) PresignedURL(context.Context, StorageKey, time.Duration) (string, error) {
+	return "", ErrPresignNotSupported
+}
+
+// Move 通过重命名移动本地文件
+func (s *localStorage) Move(_ context.Context, srcKey, dstKey StorageKey) error {
+	src, err := s.resolve(srcKey)
+	if err != nil {
+		return err
+	}
+	dst, err := s.resolve(dstKey)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(src); errors.Is(err, fs.ErrNotExist) {
+		return ErrFileNotFound
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// object 构建本地文件信息
+func (s *localStorage) object(key StorageKey, info fs.FileInfo) *StorageObject {
+	return &StorageObject{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: contentTypeByKey(key),
+		ModTime:     info.ModTime(),
+	}
+}
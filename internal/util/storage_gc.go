@@ -0,0 +1,475 @@
+package util
+
+import (
+	"codefolio/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// storageGCBatch 存储检查每批读取的记录数
+const storageGCBatch = 500
+
+// storageGCPrefixes 存储检查遍历的目录，其他位置的文件不由应用管理
+var storageGCPrefixes = []string{ResumeDir + "/", AvatarDir + "/", BlobDir + "/", TrashDir + "/", ChunkDir + "/"}
+
+// staleTempDirPrefixes 进程异常退出后可能残留的本地临时目录
+// codefolio-magick-为运行中进程的ImageMagick策略目录，不在此列
+var staleTempDirPrefixes = []string{"codefolio-upload-", "codefolio-diff-", "codefolio-probe-"}
+
+// StorageGCOptions 存储一致性检查参数
+type StorageGCOptions struct {
+	Repair      bool          // 为false时只生成报告，不修改文件和数据库
+	GracePeriod time.Duration // 只处理早于该时长的文件、引用计数和临时目录，避免误删正在上传或转换的文件
+}
+
+// OrphanFile 没有任何记录引用的文件
+type OrphanFile struct {
+	Key     StorageKey `json:"key"`
+	Size    int64      `json:"size"`
+	ModTime time.Time  `json:"mod_time"`
+}
+
+// MissingFile 记录引用但在存储中不存在的文件
+type MissingFile struct {
+	Table  string     `json:"table"`
+	ID     uint       `json:"id"`
+	Column string     `json:"column"`
+	Key    StorageKey `json:"key"`
+}
+
+// BlobRefMismatch 与简历版本记录不一致的内容寻址文件引用计数
+type BlobRefMismatch struct {
+	Key      StorageKey `json:"key"`
+	RefCount int        `json:"ref_count"` // 引用计数表中的值，没有记录时为0
+	Expected int        `json:"expected"`  // 按持有文件的简历版本统计的值
+}
+
+// StorageGCReport 存储一致性检查结果
+type StorageGCReport struct {
+	Repair       bool      `json:"repair"`
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+	ScannedFiles int       `json:"scanned_files"`
+
+	Orphans        []OrphanFile      `json:"orphans"`
+	OrphanBytes    int64             `json:"orphan_bytes"`
+	Missing        []MissingFile     `json:"missing"`
+	BlobMismatches []BlobRefMismatch `json:"blob_mismatches"`
+	StaleTempDirs  []string          `json:"stale_temp_dirs"`
+
+	// 修复模式下的处理结果
+	DeletedFiles    int      `json:"deleted_files"`
+	FreedBytes      int64    `json:"freed_bytes"`
+	FixedBlobs      int      `json:"fixed_blobs"`
+	RemovedTempDirs int      `json:"removed_temp_dirs"`
+	Errors          []string `json:"errors,omitempty"`
+}
+
+// storageGCRow 存储检查时读取的记录
+type storageGCRow struct {
+	ID        uint
+	Value     string
+	UpdatedAt time.Time
+}
+
+// storageGC 单次存储检查的状态
+type storageGC struct {
+	ctx    context.Context
+	db     *gorm.DB
+	opts   StorageGCOptions
+	cutoff time.Time
+	report *StorageGCReport
+
+	objects    map[StorageKey]StorageObject
+	referenced map[StorageKey]bool
+	owned      map[StorageKey]int // 内容寻址文件被持有文件的简历版本引用的次数
+	resumes    map[uint]bool      // 全部简历ID（含已软删除），其版本对比缓存有效
+	uploads    map[string]bool    // 未完成的分片上传会话，其分片有效
+	blobs      map[StorageKey]domain.Blob
+	freedBlobs map[StorageKey]bool // 修复时已删除引用计数记录的文件
+}
+
+// RunStorageGC 对比存储中的文件和数据库记录，报告没有记录引用的文件、文件不存在的记录和错误的引用计数
+// 修复模式下删除无引用的文件和残留的本地临时目录，并按简历版本记录修正引用计数；文件缺失的记录只报告，需人工处理
+// 检查期间新写入的文件和记录可能尚未对应，GracePeriod内的文件和修改过的记录不做处理
+func RunStorageGC(ctx context.Context, db *gorm.DB, opts StorageGCOptions) (*StorageGCReport, error) {
+	now := time.Now()
+	gc := &storageGC{
+		ctx:    ctx,
+		db:     db.WithContext(ctx),
+		opts:   opts,
+		cutoff: now.Add(-opts.GracePeriod),
+		report: &StorageGCReport{
+			Repair:    opts.Repair,
+			StartedAt: now,
+		},
+		objects:    make(map[StorageKey]StorageObject),
+		referenced: make(map[StorageKey]bool),
+		owned:      make(map[StorageKey]int),
+		resumes:    make(map[uint]bool),
+		uploads:    make(map[string]bool),
+		blobs:      make(map[StorageKey]domain.Blob),
+		freedBlobs: make(map[StorageKey]bool),
+	}
+
+	// 先列出文件再读取记录，列出后新建的记录引用的文件不会被当作无引用文件
+	if err := gc.listObjects(); err != nil {
+		return nil, err
+	}
+	for _, col := range storageKeyColumns {
+		if err := gc.scanColumn(col); err != nil {
+			return nil, fmt.Errorf("读取%s.%s失败: %w", col.table, col.column, err)
+		}
+	}
+	if err := gc.loadOwners(); err != nil {
+		return nil, err
+	}
+
+	gc.checkBlobs()
+	gc.findOrphans()
+	gc.findStaleTempDirs()
+
+	if opts.Repair {
+		gc.repairBlobs()
+		gc.deleteOrphans()
+		gc.removeStaleTempDirs()
+	}
+
+	gc.report.FinishedAt = time.Now()
+	return gc.report, nil
+}
+
+// StartStorageGC 按interval定期执行存储检查，interval不大于0时不启动
+func StartStorageGC(db *gorm.DB, interval time.Duration, opts StorageGCOptions) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			report, err := RunStorageGC(context.Background(), db, opts)
+			if err != nil {
+				GetLogger().Error("存储一致性检查失败", zap.Error(err))
+				continue
+			}
+			GetLogger().Info("存储一致性检查完成",
+				zap.Bool("repair", report.Repair),
+				zap.Int("scanned", report.ScannedFiles),
+				zap.Int("orphans", len(report.Orphans)),
+				zap.Int64("orphanBytes", report.OrphanBytes),
+				zap.Int("missing", len(report.Missing)),
+				zap.Int("blobMismatches", len(report.BlobMismatches)),
+				zap.Int("staleTempDirs", len(report.StaleTempDirs)),
+				zap.Int("deleted", report.DeletedFiles),
+				zap.Int("fixedBlobs", report.FixedBlobs),
+				zap.Int("errors", len(report.Errors)))
+			for _, m := range report.Missing {
+				GetLogger().Warn("记录引用的文件不存在",
+					zap.String("table", m.Table),
+					zap.Uint("id", m.ID),
+					zap.String("column", m.Column),
+					zap.String("key", m.Key.String()))
+			}
+		}
+	}()
+}
+
+// fail 记录修复时的错误，继续处理其他项
+func (gc *storageGC) fail(msg string, err error, key string) {
+	gc.report.Errors = append(gc.report.Errors, fmt.Sprintf("%s %s: %v", msg, key, err))
+	GetLogger().Error(msg, zap.Error(err), zap.String("key", key))
+}
+
+// listObjects 列出应用管理的全部文件
+func (gc *storageGC) listObjects() error {
+	for _, prefix := range storageGCPrefixes {
+		objects, err := GetStorage().List(gc.ctx, prefix)
+		if err != nil {
+			return fmt.Errorf("列出%s失败: %w", prefix, err)
+		}
+		for _, obj := range objects {
+			gc.objects[obj.Key] = obj
+		}
+	}
+	gc.report.ScannedFiles = len(gc.objects)
+	return nil
+}
+
+// exists 文件是否存在，遍历范围外的文件单独查询
+func (gc *storageGC) exists(key StorageKey) bool {
+	for _, prefix := range storageGCPrefixes {
+		if strings.HasPrefix(key.String(), prefix) {
+			_, ok := gc.objects[key]
+			return ok
+		}
+	}
+	_, err := GetStorage().Stat(gc.ctx, key)
+	return err == nil
+}
+
+// reference 标记文件被引用，包括回收站中的文件和其他格式的简历图片
+func (gc *storageGC) reference(key StorageKey) {
+	gc.referenced[key] = true
+	gc.referenced[key.Trash()] = true
+	for _, k := range renditionKeys(key) {
+		gc.referenced[k] = true
+	}
+}
+
+// scanColumn 按主键顺序分批读取一个存储键字段，包括已软删除的记录
+func (gc *storageGC) scanColumn(col storageKeyColumn) error {
+	var lastID uint
+	for {
+		var rows []storageGCRow
+		err := gc.db.Unscoped().Model(col.model).
+			Select("id, "+col.column+" AS value, "+col.timestamp+" AS updated_at").
+			Where("id > ?", lastID).
+			Where(col.column + " <> ''").
+			Order("id ASC").
+			Limit(storageGCBatch).
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			// 第三方头像等外部URL不是存储键
+			key, err := ParseStorageKey(row.Value)
+			if err != nil {
+				continue
+			}
+			gc.reference(key)
+
+			// 软删除的简历文件可能已移入回收站
+			if row.UpdatedAt.Before(gc.report.StartedAt) && !gc.exists(key) && !gc.exists(key.Trash()) {
+				gc.report.Missing = append(gc.report.Missing, MissingFile{
+					Table:  col.table,
+					ID:     row.ID,
+					Column: col.column,
+					Key:    key,
+				})
+			}
+		}
+	}
+}
+
+// loadOwners 读取持有文件的简历版本、简历ID、未完成的上传会话和引用计数记录
+func (gc *storageGC) loadOwners() error {
+	var versions []domain.ResumeVersion
+	err := gc.db.Select("id, image_url, source_url").
+		Where("change_type IN ?", []string{domain.ResumeChangeCreate, domain.ResumeChangeFile}).
+		FindInBatches(&versions, storageGCBatch, func(*gorm.DB, int) error {
+			for _, v := range versions {
+				for _, stored := range []string{v.ImageURL, v.SourceURL} {
+					if key, err := ParseStorageKey(stored); err == nil && IsBlobKey(key.String()) {
+						gc.owned[key]++
+					}
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return fmt.Errorf("读取简历版本失败: %w", err)
+	}
+
+	var resumeIDs []uint
+	if err := gc.db.Unscoped().Model(&domain.Resume{}).Pluck("id", &resumeIDs).Error; err != nil {
+		return fmt.Errorf("读取简历失败: %w", err)
+	}
+	for _, id := range resumeIDs {
+		gc.resumes[id] = true
+	}
+
+	var uploadIDs []string
+	err = gc.db.Model(&domain.UploadSession{}).
+		Where("status <> ?", domain.UploadSessionCompleted).
+		Pluck("id", &uploadIDs).Error
+	if err != nil {
+		return fmt.Errorf("读取上传会话失败: %w", err)
+	}
+	for _, id := range uploadIDs {
+		gc.uploads[id] = true
+	}
+
+	var blobs []domain.Blob
+	if err := gc.db.Find(&blobs).Error; err != nil {
+		return fmt.Errorf("读取引用计数失败: %w", err)
+	}
+	for _, b := range blobs {
+		gc.blobs[StorageKey(b.Key)] = b
+	}
+	return nil
+}
+
+// checkBlobs 按持有文件的简历版本核对引用计数
+// 被简历引用但没有持有版本的文件至少保留一个引用；宽限期内修改过的计数可能属于尚未创建简历的临时上传，视为有效
+func (gc *storageGC) checkBlobs() {
+	for key, blob := range gc.blobs {
+		if blob.UpdatedAt.After(gc.cutoff) {
+			gc.reference(key)
+			continue
+		}
+		expected := gc.owned[key]
+		if expected == 0 && gc.referenced[key] {
+			expected = 1
+		}
+		if blob.RefCount != expected {
+			gc.report.BlobMismatches = append(gc.report.BlobMismatches, BlobRefMismatch{
+				Key:      key,
+				RefCount: blob.RefCount,
+				Expected: expected,
+			})
+		}
+	}
+	for key, count := range gc.owned {
+		if _, ok := gc.blobs[key]; !ok {
+			gc.report.BlobMismatches = append(gc.report.BlobMismatches, BlobRefMismatch{
+				Key:      key,
+				Expected: count,
+			})
+		}
+	}
+}
+
+// isReferenced 文件是否仍然有效
+func (gc *storageGC) isReferenced(key StorageKey) bool {
+	if gc.referenced[key] {
+		return true
+	}
+	segs := strings.Split(key.String(), "/")
+	switch {
+	// 版本对比缓存 resumes/<userID>/diffs/<resumeID>/...，简历永久删除前保留
+	case segs[0] == ResumeDir && len(segs) > 4 && segs[2] == "diffs":
+		id, err := strconv.ParseUint(segs[3], 10, 64)
+		return err == nil && gc.resumes[uint(id)]
+	// 分片 chunks/<uploadID>/<partNumber>，会话完成或过期删除前保留
+	case segs[0] == ChunkDir && len(segs) > 2:
+		return gc.uploads[segs[1]]
+	}
+	return false
+}
+
+// findOrphans 找出宽限期之前写入且没有记录引用的文件
+func (gc *storageGC) findOrphans() {
+	for key, obj := range gc.objects {
+		if obj.ModTime.After(gc.cutoff) || gc.isReferenced(key) {
+			continue
+		}
+		gc.report.Orphans = append(gc.report.Orphans, OrphanFile{
+			Key:     key,
+			Size:    obj.Size,
+			ModTime: obj.ModTime,
+		})
+		gc.report.OrphanBytes += obj.Size
+	}
+}
+
+// findStaleTempDirs 找出本机残留的临时目录，只能发现当前主机上的目录
+func (gc *storageGC) findStaleTempDirs() {
+	tmp := os.TempDir()
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		GetLogger().Warn("读取临时目录失败", zap.Error(err))
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !hasAnyPrefix(entry.Name(), staleTempDirPrefixes) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(gc.cutoff) {
+			continue
+		}
+		gc.report.StaleTempDirs = append(gc.report.StaleTempDirs, filepath.Join(tmp, entry.Name()))
+	}
+}
+
+// repairBlobs 修正引用计数，仅当计数在检查后未被修改时更新，期间有新引用的记录保持不变
+func (gc *storageGC) repairBlobs() {
+	for _, m := range gc.report.BlobMismatches {
+		blob, ok := gc.blobs[m.Key]
+		var result *gorm.DB
+		switch {
+		case !ok:
+			now := time.Now()
+			result = gc.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&domain.Blob{
+				Key:       m.Key.String(),
+				RefCount:  m.Expected,
+				CreatedAt: now,
+				UpdatedAt: now,
+			})
+		case m.Expected == 0:
+			result = gc.db.Where("key = ? AND ref_count = ? AND updated_at <= ?", m.Key.String(), blob.RefCount, gc.cutoff).
+				Delete(&domain.Blob{})
+		default:
+			result = gc.db.Model(&domain.Blob{}).
+				Where("key = ? AND ref_count = ? AND updated_at <= ?", m.Key.String(), blob.RefCount, gc.cutoff).
+				Updates(map[string]interface{}{
+					"ref_count":  m.Expected,
+					"updated_at": time.Now(),
+				})
+		}
+		if result.Error != nil {
+			gc.fail("修正引用计数失败", result.Error, m.Key.String())
+			continue
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		gc.report.FixedBlobs++
+		if m.Expected == 0 {
+			gc.freedBlobs[m.Key] = true
+		}
+	}
+}
+
+// deleteOrphans 删除无引用的文件，仍有引用计数记录的内容寻址文件只有在记录已删除时才删除
+func (gc *storageGC) deleteOrphans() {
+	for _, o := range gc.report.Orphans {
+		if _, ok := gc.blobs[o.Key]; ok && !gc.freedBlobs[o.Key] {
+			continue
+		}
+		if err := GetStorage().Delete(gc.ctx, o.Key); err != nil {
+			gc.fail("删除无引用文件失败", err, o.Key.String())
+			continue
+		}
+		gc.report.DeletedFiles++
+		gc.report.FreedBytes += o.Size
+	}
+}
+
+// removeStaleTempDirs 删除残留的本地临时目录
+func (gc *storageGC) removeStaleTempDirs() {
+	for _, dir := range gc.report.StaleTempDirs {
+		if err := os.RemoveAll(dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+			gc.fail("删除临时目录失败", err, dir)
+			continue
+		}
+		gc.report.RemovedTempDirs++
+	}
+}
+
+// hasAnyPrefix s是否以prefixes中任意一个开头
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
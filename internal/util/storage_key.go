@@ -0,0 +1,80 @@
+package util
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxStorageKeyLength 存储键最大长度，与S3对象键的限制一致
+const maxStorageKeyLength = 1024
+
+// StorageKey 文件在存储中的键，以/分隔、相对于存储根目录，如 resumes/1/2023_03/abc.jpg
+// 存储键不是本地文件路径也不是URL：本地路径由存储解析，对外访问地址由GetFileURL签发
+// 数据库中保存的文件字段（简历图片、原始文件、头像等）均为存储键
+type StorageKey string
+
+// ParseStorageKey 解析数据库中保存的文件字段
+// 兼容迁移前带上传目录前缀的旧格式，如 /uploads/resumes/1/2023_03/abc.jpg -> resumes/1/2023_03/abc.jpg
+func ParseStorageKey(stored string) (StorageKey, error) {
+	p := strings.TrimPrefix(stored, "/")
+	if root := legacyStoragePrefix(); strings.HasPrefix(p, root) {
+		p = strings.TrimPrefix(p, root)
+	}
+	key := StorageKey(p)
+	if err := key.Validate(); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// legacyStoragePrefix 旧格式文件路径中的上传目录前缀
+func legacyStoragePrefix() string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean(UploadDir)), "/") + "/"
+}
+
+// Validate 校验存储键
+// 存储键必须是规范形式：非空、不以/开头或结尾、不含\和控制字符，且不包含空段、.或..
+func (k StorageKey) Validate() error {
+	s := string(k)
+	if s == "" || len(s) > maxStorageKeyLength {
+		return ErrInvalidStorageKey
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < 0x20 || c == 0x7f || c == '\\' {
+			return ErrInvalidStorageKey
+		}
+	}
+	for _, seg := range strings.Split(s, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return ErrInvalidStorageKey
+		}
+	}
+	return nil
+}
+
+// Join 拼接存储键，结果在使用时由存储校验
+func (k StorageKey) Join(elem ...string) StorageKey {
+	return StorageKey(path.Join(append([]string{string(k)}, elem...)...))
+}
+
+// Trash 回收站中对应的存储键
+// 例如: resumes/1/2023_03/abc.jpg -> trash/resumes/1/2023_03/abc.jpg
+func (k StorageKey) Trash() StorageKey {
+	return StorageKey(TrashDir).Join(string(k))
+}
+
+// Ext 文件扩展名
+func (k StorageKey) Ext() string {
+	return path.Ext(string(k))
+}
+
+// Base 文件名
+func (k StorageKey) Base() string {
+	return path.Base(string(k))
+}
+
+// String 返回存储键，保存到数据库时使用
+func (k StorageKey) String() string {
+	return string(k)
+}
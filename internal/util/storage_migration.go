@@ -0,0 +1,93 @@
+package util
+
+import (
+	"codefolio/internal/domain"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// storageKeyMigrationBatch 每批迁移的记录数
+const storageKeyMigrationBatch = 500
+
+// storageKeyColumn 保存文件存储键的数据库字段
+type storageKeyColumn struct {
+	model     interface{}
+	table     string
+	column    string
+	timestamp string // 记录最后修改时间的字段，存储检查时跳过检查开始后修改的记录
+}
+
+// storageKeyColumns 全部保存文件存储键的字段
+var storageKeyColumns = []storageKeyColumn{
+	{&domain.Resume{}, "resumes", "image_url", "updated_at"},
+	{&domain.Resume{}, "resumes", "source_url", "updated_at"},
+	{&domain.ResumeVersion{}, "resume_versions", "image_url", "created_at"},
+	{&domain.ResumeVersion{}, "resume_versions", "source_url", "created_at"},
+	{&domain.User{}, "users", "avatar_url", "updated_at"},
+}
+
+// storageKeyRow 迁移时读取的记录
+type storageKeyRow struct {
+	ID    uint
+	Value string
+}
+
+// MigrateStorageKeys 将旧格式的文件路径（如 /uploads/resumes/1/2023_03/abc.jpg）统一为存储键
+// 包括已软删除的记录；无法解析的值保持不变并记录日志，可重复执行
+func MigrateStorageKeys(db *gorm.DB) {
+	legacy := legacyStoragePrefix()
+	for _, col := range storageKeyColumns {
+		migrated, err := migrateStorageKeyColumn(db, col, legacy)
+		if err != nil {
+			GetLogger().Error("迁移文件存储键失败", zap.Error(err), zap.String("column", col.column))
+			continue
+		}
+		if migrated > 0 {
+			GetLogger().Info("已迁移文件存储键", zap.String("column", col.column), zap.Int("count", migrated))
+		}
+	}
+}
+
+// migrateStorageKeyColumn 按主键顺序分批迁移单个字段，返回迁移的记录数
+func migrateStorageKeyColumn(db *gorm.DB, col storageKeyColumn, legacy string) (int, error) {
+	migrated := 0
+	var lastID uint
+	for {
+		var rows []storageKeyRow
+		err := db.Unscoped().Model(col.model).
+			Select("id, "+col.column+" AS value").
+			Where("id > ?", lastID).
+			Where(col.column+" LIKE ? OR "+col.column+" LIKE ?", "/%", legacy+"%").
+			Order("id").
+			Limit(storageKeyMigrationBatch).
+			Scan(&rows).Error
+		if err != nil {
+			return migrated, err
+		}
+		if len(rows) == 0 {
+			return migrated, nil
+		}
+
+		for _, row := range rows {
+			lastID = row.ID
+			key, err := ParseStorageKey(row.Value)
+			if err != nil {
+				GetLogger().Warn("无法解析的文件路径，跳过迁移",
+					zap.String("column", col.column),
+					zap.Uint("id", row.ID),
+					zap.String("value", row.Value))
+				continue
+			}
+			if key.String() == row.Value {
+				continue
+			}
+			// 仅修改该字段，不更新updated_at
+			err = db.Unscoped().Model(col.model).Where("id = ?", row.ID).UpdateColumn(col.column, key.String()).Error
+			if err != nil {
+				return migrated, err
+			}
+			migrated++
+		}
+	}
+}
@@ -0,0 +1,588 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testS3Bucket    = "codefolio-test"
+	testS3Region    = "ap-east-1"
+	testS3AccessKey = "AKIDEXAMPLE"
+	testS3SecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+// fakeS3Object 模拟存储中的对象
+type fakeS3Object struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+// fakeS3 本地模拟的S3兼容服务，独立校验SigV4签名，支持路径形式访问的对象读写、复制和分页列举
+type fakeS3 struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu       sync.Mutex
+	objects  map[string]fakeS3Object
+	pageSize int           // ListObjectsV2每页的最大数量
+	delay    time.Duration // 响应前的等待时间，用于测试超时
+}
+
+func newFakeS3(t *testing.T) *fakeS3 {
+	f := &fakeS3{t: t, objects: make(map[string]fakeS3Object), pageSize: 2}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// storage 创建连接到模拟服务的S3存储
+func (f *fakeS3) storage(t *testing.T, timeout time.Duration) *s3Storage {
+	t.Helper()
+	storage, err := NewS3Storage(S3Config{
+		Endpoint:  f.server.URL,
+		Region:    testS3Region,
+		Bucket:    testS3Bucket,
+		AccessKey: testS3AccessKey,
+		SecretKey: testS3SecretKey,
+		PathStyle: true,
+		Timeout:   timeout,
+	})
+	if err != nil {
+		t.Fatalf("创建S3存储失败: %v", err)
+	}
+	return storage.(*s3Storage)
+}
+
+func (f *fakeS3) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	delay := f.delay
+	f.mu.Unlock()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if err := f.verify(r); err != nil {
+		f.t.Logf("签名校验失败: %v", err)
+		writeFakeS3Error(w, http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+		return
+	}
+
+	bucketPath := "/" + testS3Bucket
+	if r.URL.Path == bucketPath || r.URL.Path == bucketPath+"/" {
+		if r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2" {
+			f.list(w, r)
+			return
+		}
+		writeFakeS3Error(w, http.StatusNotImplemented, "NotImplemented", r.Method+" "+r.URL.String())
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, bucketPath+"/") {
+		writeFakeS3Error(w, http.StatusNotFound, "NoSuchBucket", r.URL.Path)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, bucketPath+"/")
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+			srcPath, err := url.PathUnescape(src)
+			if err != nil {
+				writeFakeS3Error(w, http.StatusBadRequest, "InvalidArgument", src)
+				return
+			}
+			obj, ok := f.objects[strings.TrimPrefix(srcPath, bucketPath+"/")]
+			if !ok {
+				writeFakeS3Error(w, http.StatusNotFound, "NoSuchKey", srcPath)
+				return
+			}
+			obj.modTime = time.Now()
+			f.objects[key] = obj
+			_, _ = io.WriteString(w, "<CopyObjectResult></CopyObjectResult>")
+			return
+		}
+		// S3不接受分块上传的普通PUT请求，必须提供Content-Length
+		if len(r.TransferEncoding) > 0 || r.ContentLength < 0 {
+			writeFakeS3Error(w, http.StatusLengthRequired, "MissingContentLength", "")
+			return
+		}
+		data, _ := io.ReadAll(r.Body)
+		if int64(len(data)) != r.ContentLength {
+			writeFakeS3Error(w, http.StatusBadRequest, "IncompleteBody", "")
+			return
+		}
+		f.objects[key] = fakeS3Object{data: data, contentType: r.Header.Get("Content-Type"), modTime: time.Now()}
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			writeFakeS3Error(w, http.StatusNotFound, "NoSuchKey", key)
+			return
+		}
+		if obj.contentType != "" {
+			w.Header().Set("Content-Type", obj.contentType)
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+		w.Header().Set("Last-Modified", obj.modTime.UTC().Format(http.TimeFormat))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeFakeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed", r.Method)
+	}
+}
+
+// list 按键排序分页返回前缀下的对象，continuation-token为下一页的起始位置
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+	start, _ := strconv.Atoi(r.URL.Query().Get("continuation-token"))
+
+	f.mu.Lock()
+	var keys []string
+	for k := range f.objects {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	type content struct {
+		Key          string `xml:"Key"`
+		Size         int    `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	}
+	var result struct {
+		XMLName               xml.Name  `xml:"ListBucketResult"`
+		Contents              []content `xml:"Contents"`
+		IsTruncated           bool      `xml:"IsTruncated"`
+		NextContinuationToken string    `xml:"NextContinuationToken,omitempty"`
+	}
+	end := start + f.pageSize
+	if end >= len(keys) {
+		end = len(keys)
+	} else {
+		result.IsTruncated = true
+		result.NextContinuationToken = strconv.Itoa(end)
+	}
+	for _, k := range keys[start:end] {
+		obj := f.objects[k]
+		result.Contents = append(result.Contents, content{
+			Key:          k,
+			Size:         len(obj.data),
+			LastModified: obj.modTime.UTC().Format(time.RFC3339),
+		})
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+// verify 按SigV4规则从收到的请求重新计算签名，支持请求头签名和预签名URL
+func (f *fakeS3) verify(r *http.Request) error {
+	query := r.URL.Query()
+	presigned := query.Get("X-Amz-Signature") != ""
+
+	var credential, signedHeaders, signature, amzDate, payloadHash string
+	if presigned {
+		if r.Method != http.MethodGet {
+			return fmt.Errorf("预签名URL只允许GET")
+		}
+		credential = query.Get("X-Amz-Credential")
+		signedHeaders = query.Get("X-Amz-SignedHeaders")
+		signature = query.Get("X-Amz-Signature")
+		amzDate = query.Get("X-Amz-Date")
+		payloadHash = s3UnsignedPayload
+		if query.Get("X-Amz-Algorithm") != s3Algorithm {
+			return fmt.Errorf("签名算法 = %q", query.Get("X-Amz-Algorithm"))
+		}
+		query.Del("X-Amz-Signature")
+	} else {
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, s3Algorithm+" ") {
+			return fmt.Errorf("Authorization = %q", auth)
+		}
+		for _, part := range strings.Split(strings.TrimPrefix(auth, s3Algorithm+" "), ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch name {
+			case "Credential":
+				credential = value
+			case "SignedHeaders":
+				signedHeaders = value
+			case "Signature":
+				signature = value
+			}
+		}
+		amzDate = r.Header.Get("X-Amz-Date")
+		payloadHash = r.Header.Get("X-Amz-Content-Sha256")
+		if payloadHash == "" {
+			return fmt.Errorf("缺少X-Amz-Content-Sha256")
+		}
+	}
+
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil {
+		return fmt.Errorf("X-Amz-Date = %q", amzDate)
+	}
+	if presigned {
+		expires, err := strconv.Atoi(query.Get("X-Amz-Expires"))
+		if err != nil || time.Now().After(signedAt.Add(time.Duration(expires)*time.Second)) {
+			return fmt.Errorf("预签名URL已过期")
+		}
+	} else if d := time.Since(signedAt); d > 15*time.Minute || d < -15*time.Minute {
+		return fmt.Errorf("请求时间偏差过大: %s", d)
+	}
+
+	scope := amzDate[:8] + "/" + testS3Region + "/s3/aws4_request"
+	if credential != testS3AccessKey+"/"+scope {
+		return fmt.Errorf("Credential = %q", credential)
+	}
+
+	names := strings.Split(signedHeaders, ";")
+	if !sort.StringsAreSorted(names) || names[0] == "" {
+		return fmt.Errorf("SignedHeaders = %q", signedHeaders)
+	}
+	var canonicalHeaders strings.Builder
+	signsHost := false
+	for _, name := range names {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value, signsHost = r.Host, true
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	if !signsHost {
+		return fmt.Errorf("签名未包含host")
+	}
+	if !presigned {
+		// 以x-amz-开头的请求头必须参与签名
+		for name := range r.Header {
+			lower := strings.ToLower(name)
+			if strings.HasPrefix(lower, "x-amz-") && !containsName(names, lower) {
+				return fmt.Errorf("请求头%s未参与签名", name)
+			}
+		}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		awsURIEncode(r.URL.Path, false),
+		awsCanonicalQuery(query),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{s3Algorithm, amzDate, scope, hex.EncodeToString(hash[:])}, "\n")
+
+	key := []byte("AWS4" + testS3SecretKey)
+	for _, part := range []string{amzDate[:8], testS3Region, "s3", "aws4_request"} {
+		key = testHMAC(key, part)
+	}
+	if want := hex.EncodeToString(testHMAC(key, stringToSign)); !hmac.Equal([]byte(signature), []byte(want)) {
+		return fmt.Errorf("签名不一致，规范请求:\n%s", canonicalRequest)
+	}
+	return nil
+}
+
+// containsName 判断列表中是否包含name
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func testHMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEncode 按AWS文档的规则编码，非保留字符按UTF-8字节编码为大写十六进制
+func awsURIEncode(s string, encodeSlash bool) string {
+	const unreserved = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.~"
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if strings.IndexByte(unreserved, c) >= 0 || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+// awsCanonicalQuery 按编码后的参数名排序拼接查询参数
+func awsCanonicalQuery(query url.Values) string {
+	var pairs []string
+	for k, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, awsURIEncode(k, true)+"="+awsURIEncode(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func writeFakeS3Error(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, message)
+}
+
+func TestS3PutGetStatDelete(t *testing.T) {
+	f := newFakeS3(t)
+	s := f.storage(t, 0)
+	ctx := context.Background()
+
+	// 键中的空格、加号、括号和中文都需要按SigV4规则编码
+	key := StorageKey("resumes/1/2024_05/简历 (1)+final.pdf")
+	content := []byte("%PDF-1.4 test")
+	if err := s.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+		t.Fatalf("Put失败: %v", err)
+	}
+
+	// 未知大小的内容先读入内存再上传，空文件也需要正确的Content-Length
+	if err := s.Put(ctx, "resumes/1/unknown.txt", strings.NewReader("hello"), -1, ""); err != nil {
+		t.Fatalf("Put未知大小的内容失败: %v", err)
+	}
+	if err := s.Put(ctx, "resumes/1/empty.txt", bytes.NewReader(nil), 0, "text/plain"); err != nil {
+		t.Fatalf("Put空文件失败: %v", err)
+	}
+
+	r, obj, err := s.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get失败: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(data, content) {
+		t.Errorf("Get内容 = %q", data)
+	}
+	if obj.Key != key || obj.Size != int64(len(content)) || obj.ContentType != "application/pdf" || obj.ModTime.IsZero() {
+		t.Errorf("Get文件信息 = %+v", obj)
+	}
+
+	stat, err := s.Stat(ctx, "resumes/1/unknown.txt")
+	if err != nil {
+		t.Fatalf("Stat失败: %v", err)
+	}
+	if stat.Size != 5 || !strings.HasPrefix(stat.ContentType, "text/plain") {
+		t.Errorf("Stat文件信息 = %+v", stat)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete失败: %v", err)
+	}
+	if err := s.Delete(ctx, key); err != nil {
+		t.Errorf("删除不存在的文件返回错误: %v", err)
+	}
+	if _, _, err := s.Get(ctx, key); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("删除后Get的错误 = %v", err)
+	}
+	if _, err := s.Stat(ctx, key); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("删除后Stat的错误 = %v", err)
+	}
+
+	if err := s.Put(ctx, "../escape", strings.NewReader("x"), 1, ""); !errors.Is(err, ErrInvalidStorageKey) {
+		t.Errorf("非法存储键的错误 = %v", err)
+	}
+}
+
+func TestS3ListPaginates(t *testing.T) {
+	f := newFakeS3(t)
+	s := f.storage(t, 0)
+	ctx := context.Background()
+
+	want := []string{"resumes/1/a.jpg", "resumes/1/b c.jpg", "resumes/1/sub/d.pdf"}
+	for _, k := range append([]string{"resumes/10/other.jpg", "avatars/1/x.png"}, want...) {
+		if err := s.Put(ctx, StorageKey(k), strings.NewReader(k), int64(len(k)), ""); err != nil {
+			t.Fatalf("Put %s失败: %v", k, err)
+		}
+	}
+
+	objects, err := s.List(ctx, "resumes/1/")
+	if err != nil {
+		t.Fatalf("List失败: %v", err)
+	}
+	var got []string
+	for _, obj := range objects {
+		got = append(got, obj.Key.String())
+		if obj.Size != int64(len(obj.Key)) || obj.ModTime.IsZero() {
+			t.Errorf("List文件信息 = %+v", obj)
+		}
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("List = %v，期望 %v", got, want)
+	}
+}
+
+func TestS3Move(t *testing.T) {
+	f := newFakeS3(t)
+	s := f.storage(t, 0)
+	ctx := context.Background()
+
+	src, dst := StorageKey("resumes/1/简历.pdf"), StorageKey("resumes/1/简历.pdf").Trash()
+	if err := s.Put(ctx, src, strings.NewReader("pdf"), 3, "application/pdf"); err != nil {
+		t.Fatalf("Put失败: %v", err)
+	}
+	if err := s.Move(ctx, src, dst); err != nil {
+		t.Fatalf("Move失败: %v", err)
+	}
+	if _, err := s.Stat(ctx, src); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("移动后源文件仍存在: %v", err)
+	}
+	if obj, err := s.Stat(ctx, dst); err != nil || obj.Size != 3 {
+		t.Errorf("移动后的目标文件 = %+v, %v", obj, err)
+	}
+	if err := s.Move(ctx, src, dst); !errors.Is(err, ErrFileNotFound) {
+		t.Errorf("移动不存在的文件的错误 = %v", err)
+	}
+}
+
+func TestS3PresignedURL(t *testing.T) {
+	f := newFakeS3(t)
+	s := f.storage(t, 0)
+	ctx := context.Background()
+
+	key := StorageKey("resumes/1/预览 (1).jpg")
+	if err := s.Put(ctx, key, strings.NewReader("jpeg"), 4, "image/jpeg"); err != nil {
+		t.Fatalf("Put失败: %v", err)
+	}
+
+	signed, err := s.PresignedURL(ctx, key, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("PresignedURL失败: %v", err)
+	}
+	u, _ := url.Parse(signed)
+	if got := u.Query().Get("X-Amz-Expires"); got != "600" {
+		t.Errorf("X-Amz-Expires = %q", got)
+	}
+
+	// 预签名URL无需其他认证即可访问
+	resp, err := http.Get(signed)
+	if err != nil {
+		t.Fatalf("访问预签名URL失败: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "jpeg" {
+		t.Fatalf("预签名URL响应 = %d %q", resp.StatusCode, body)
+	}
+
+	// 修改对象路径后签名失效
+	tampered := *u
+	tampered.Path = "/" + testS3Bucket + "/resumes/2/other.jpg"
+	tampered.RawPath = ""
+	if resp, err := http.Get(tampered.String()); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("篡改路径后的响应 = %v, %v", resp, err)
+	} else {
+		resp.Body.Close()
+	}
+
+	// 过期的预签名URL被拒绝
+	s.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	expired, _ := s.PresignedURL(ctx, key, time.Minute)
+	if resp, err := http.Get(expired); err != nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("过期URL的响应 = %v, %v", resp, err)
+	} else {
+		resp.Body.Close()
+	}
+
+	// 超过SigV4上限的有效期按上限签发
+	s.now = time.Now
+	capped, _ := s.PresignedURL(ctx, key, 30*24*time.Hour)
+	if u, _ := url.Parse(capped); u.Query().Get("X-Amz-Expires") != strconv.Itoa(int(s3MaxPresignExpiry.Seconds())) {
+		t.Errorf("超长有效期的X-Amz-Expires = %q", u.Query().Get("X-Amz-Expires"))
+	}
+}
+
+func TestS3RejectsWrongCredentials(t *testing.T) {
+	f := newFakeS3(t)
+	s := f.storage(t, 0)
+	s.cfg.SecretKey = "wrong"
+
+	err := s.Put(context.Background(), "resumes/1/a.txt", strings.NewReader("a"), 1, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Fatalf("错误的密钥上传结果 = %v", err)
+	}
+}
+
+func TestS3RequestTimeout(t *testing.T) {
+	f := newFakeS3(t)
+	f.mu.Lock()
+	f.delay = time.Second
+	f.mu.Unlock()
+
+	// 客户端超时在调用方未设置截止时间时生效
+	s := f.storage(t, 50*time.Millisecond)
+	start := time.Now()
+	if _, err := s.Stat(context.Background(), "resumes/1/a.txt"); err == nil {
+		t.Fatal("服务无响应时Stat未返回错误")
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("超时后%s才返回", d)
+	}
+
+	// 调用方的ctx取消时请求立即结束
+	s = f.storage(t, time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if _, err := s.List(ctx, "resumes/"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ctx超时后List的错误 = %v", err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("ctx超时后%s才返回", d)
+	}
+}
+
+func TestS3VirtualHostedURL(t *testing.T) {
+	storage, err := NewS3Storage(S3Config{
+		Endpoint: "https://s3.example.com/",
+		Bucket:   testS3Bucket,
+	})
+	if err != nil {
+		t.Fatalf("创建S3存储失败: %v", err)
+	}
+	s := storage.(*s3Storage)
+	if s.client.Timeout != s3DefaultTimeout {
+		t.Errorf("默认超时 = %s", s.client.Timeout)
+	}
+
+	u := s.objectURL("resumes/1/a b.pdf")
+	if u.Host != testS3Bucket+".s3.example.com" || u.EscapedPath() != "/resumes/1/a%20b.pdf" {
+		t.Errorf("对象地址 = %s", u)
+	}
+	if u := s.objectURL(""); u.String() != "https://"+testS3Bucket+".s3.example.com/" {
+		t.Errorf("存储桶地址 = %s", u)
+	}
+}
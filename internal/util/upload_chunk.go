@@ -0,0 +1,99 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// ErrChecksumMismatch 上传内容与客户端声明的SHA-256不一致
+var ErrChecksumMismatch = errors.New("文件校验和不匹配")
+
+// UploadPartKey 分片的存储键，分片保存在存储中，多实例部署时可以由不同实例接收
+func UploadPartKey(uploadID string, partNumber int) StorageKey {
+	return StorageKey(ChunkDir).Join(uploadID, fmt.Sprintf("%06d", partNumber))
+}
+
+// SaveUploadPart 保存分片并返回其SHA-256，expectedChecksum不为空时先校验内容
+func SaveUploadPart(ctx context.Context, uploadID string, partNumber int, data []byte, expectedChecksum string) (string, error) {
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+	if expectedChecksum != "" && !strings.EqualFold(expectedChecksum, checksum) {
+		return "", ErrChecksumMismatch
+	}
+
+	key := UploadPartKey(uploadID, partNumber)
+	if err := GetStorage().Put(ctx, key, bytes.NewReader(data), int64(len(data)), "application/octet-stream"); err != nil {
+		GetLogger().Error("保存上传分片失败", zap.Error(err), zap.Stringer("key", key))
+		return "", ErrSaveFileFailed
+	}
+	return checksum, nil
+}
+
+// DeleteUploadParts 删除上传会话的全部分片
+func DeleteUploadParts(ctx context.Context, uploadID string) error {
+	return DeleteDir(ctx, StorageKey(ChunkDir).Join(uploadID))
+}
+
+// SaveChunkedUpload 按顺序合并分片并校验完整文件的SHA-256，之后与普通上传走相同的识别、校验和转换流程
+func SaveChunkedUpload(ctx context.Context, uploadID string, totalParts int, fileName string, fileSize int64, checksum string) (*UploadFileResult, error) {
+	// 创建临时工作目录
+	workDir, err := os.MkdirTemp("", "codefolio-upload-")
+	if err != nil {
+		GetLogger().Error("创建临时目录失败", zap.Error(err))
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	uploadPath := filepath.Join(workDir, "upload")
+	contentHash, written, err := assembleParts(ctx, uploadID, totalParts, uploadPath)
+	if err != nil {
+		return nil, err
+	}
+	if written != fileSize || !strings.EqualFold(contentHash, checksum) {
+		GetLogger().Warn("合并后的文件与声明不一致",
+			zap.String("uploadID", uploadID),
+			zap.Int64("size", written),
+			zap.String("hash", contentHash))
+		return nil, ErrChecksumMismatch
+	}
+	return saveResumeFile(ctx, workDir, uploadPath, contentHash, fileName, fileSize)
+}
+
+// assembleParts 将分片依次写入本地文件，返回内容哈希和总大小
+func assembleParts(ctx context.Context, uploadID string, totalParts int, path string) (string, int64, error) {
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer dst.Close()
+
+	hasher := sha256.New()
+	var written int64
+	for part := 1; part <= totalParts; part++ {
+		r, _, err := OpenFile(ctx, UploadPartKey(uploadID, part))
+		if err != nil {
+			GetLogger().Error("读取上传分片失败", zap.Error(err), zap.String("uploadID", uploadID), zap.Int("part", part))
+			return "", 0, err
+		}
+		n, err := io.Copy(io.MultiWriter(dst, hasher), r)
+		r.Close()
+		if err != nil {
+			return "", 0, err
+		}
+		written += n
+	}
+	if err := dst.Sync(); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), written, nil
+}
@@ -0,0 +1,112 @@
+package util
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// UploadType 简历上传支持的文件类型，按文件内容识别
+type UploadType string
+
+// 简历上传支持的文件类型
+const (
+	UploadTypePDF  UploadType = "pdf"
+	UploadTypeDOCX UploadType = "docx"
+	UploadTypePNG  UploadType = "png"
+	UploadTypeJPEG UploadType = "jpeg"
+)
+
+// uploadTypeAliases UPLOAD_ALLOWED_TYPES中可以使用的写法，支持扩展名和MIME类型
+var uploadTypeAliases = map[string]UploadType{
+	"pdf":             UploadTypePDF,
+	"application/pdf": UploadTypePDF,
+	"docx":            UploadTypeDOCX,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": UploadTypeDOCX,
+	"png":        UploadTypePNG,
+	"image/png":  UploadTypePNG,
+	"jpg":        UploadTypeJPEG,
+	"jpeg":       UploadTypeJPEG,
+	"image/jpeg": UploadTypeJPEG,
+}
+
+// allowedUploadTypes 允许上传的简历文件类型
+var allowedUploadTypes = map[UploadType]bool{UploadTypePDF: true}
+
+// ParseUploadTypes 解析以逗号分隔的允许上传类型，如".pdf,.docx,image/png"
+func ParseUploadTypes(value string) (map[UploadType]bool, error) {
+	types := make(map[UploadType]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(item), "."))
+		if item == "" {
+			continue
+		}
+		t, ok := uploadTypeAliases[item]
+		if !ok {
+			return nil, fmt.Errorf("不支持的上传文件类型: %s", item)
+		}
+		types[t] = true
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("未配置允许上传的文件类型")
+	}
+	return types, nil
+}
+
+// AllowedUploadTypes 当前允许上传的简历文件类型
+func AllowedUploadTypes() []UploadType {
+	types := make([]UploadType, 0, len(allowedUploadTypes))
+	for t := range allowedUploadTypes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// docxMainPart DOCX文件中必须存在的正文部件
+const docxMainPart = "word/document.xml"
+
+// DetectUploadType 按文件内容识别上传文件的类型，不信任扩展名和客户端提供的Content-Type
+func DetectUploadType(path string) (UploadType, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	head := make([]byte, 1024)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", ErrInvalidFileType
+	}
+	head = head[:n]
+
+	switch {
+	case bytes.Contains(head, []byte("%PDF-")):
+		return UploadTypePDF, nil
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return UploadTypePNG, nil
+	case bytes.HasPrefix(head, []byte("\xff\xd8\xff")):
+		return UploadTypeJPEG, nil
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		// DOCX是ZIP包，只读取目录确认包含Word正文，不解压内容
+		info, err := f.Stat()
+		if err != nil {
+			return "", err
+		}
+		archive, err := zip.NewReader(f, info.Size())
+		if err != nil {
+			return "", ErrInvalidFileType
+		}
+		for _, entry := range archive.File {
+			if entry.Name == docxMainPart {
+				return UploadTypeDOCX, nil
+			}
+		}
+	}
+	return "", ErrInvalidFileType
+}